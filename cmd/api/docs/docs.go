@@ -28,9 +28,12 @@ var doc = `{
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Get all users",
+                "description": "Get a page of the users, sorted and filtered by name and surname.\nThe total number of matching users is returned in the X-Total-Count header,\nand the first, prev, next and last pages in the Link header.\nThe cursor of the next page is returned in the X-Next-Cursor header. The pages after a cursor stay\nstable while users are created or deleted, and only link to the first and next pages.\nThe page has an ETag, and is answered with 304 Not Modified when it is the one of If-None-Match.\nThe deleted users are listed as well with include=deleted.",
                 "produces": [
                     "application/json"
                 ],
@@ -39,6 +42,80 @@ var doc = `{
                 ],
                 "summary": "Get all users",
                 "operationId": "FindAll",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of users, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of users to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page to continue after, as returned in X-Next-Cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by (id, name or surname), prefixed by - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefix of the name",
+                        "name": "name_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of the name",
+                        "name": "name_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact surname",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefix of the surname",
+                        "name": "surname_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of the surname",
+                        "name": "surname_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "deleted to list the deleted users as well",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETags of the cached pages",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -48,16 +125,28 @@ var doc = `{
                                 "$ref": "#/definitions/handler.Response"
                             }
                         }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Modify a user",
+                "description": "Create a user. The name and surname are trimmed and normalized to Unicode NFC, and must be made of\n1 to 100 letters, spaces, apostrophes, hyphens and periods. Its version is returned in the ETag header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -67,8 +156,8 @@ var doc = `{
                 "tags": [
                     "users"
                 ],
-                "summary": "Modify a user",
-                "operationId": "Modify",
+                "summary": "Create a user",
+                "operationId": "Create",
                 "parameters": [
                     {
                         "description": "entity.User",
@@ -86,16 +175,268 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/handler.Response"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stream the events of the users as Server-Sent Events, whose id is the ID of the event and whose event\nis its type. A client resumes the stream by giving the ID of the last event it received as\nLast-Event-ID, from which the latest events are replayed. The stream ends once the credentials of the\nclient expire, and is resumed with new ones.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Stream the changes of the users",
+                "operationId": "StreamUserEvents",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UserEventDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            }
+        },
+        "/api/users/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search users by partial name or surname, ignoring accents and case, sorted by relevance.\nEvery word of the query must match the start or a part of a word of the name or surname.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Search users",
+                "operationId": "Search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Words to search for",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of users, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.Response"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/api/users/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrade the connection to a WebSocket, on which the client sends subscribe messages\n{\"type\":\"subscribe\",\"id\":\"...\",\"user_ids\":[...],\"event_types\":[...]} and unsubscribe messages\n{\"type\":\"unsubscribe\",\"id\":\"...\"}, and receives the events matching its subscriptions as messages\n{\"type\":\"event\",\"subscriptions\":[...],\"event\":{...}}. The access token can be given as access_token\nby the clients which cannot set headers. A client reconnects from the last event it received, for\ninstance when it is disconnected with the code 1013 after falling behind, and the events after it are\nreplayed once the client first subscribes.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Subscribe to the changes of the users through a WebSocket",
+                "operationId": "SubscribeUserEvents",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "426": {
+                        "description": "Upgrade Required"
+                    }
+                }
+            }
+        },
+        "/api/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a user by ID. Its version is returned as the entity tag of the ETag header, and its modification\ntime in the Last-Modified header. The user is answered with 304 Not Modified when its ETag is one of\nIf-None-Match or, without If-None-Match, when it was not modified since If-Modified-Since.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user by ID",
+                "operationId": "FindByID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETags of the cached versions of the user",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modification time of the cached user",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
                     }
                 }
             },
-            "post": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a user, which is kept until it is purged and can be restored meanwhile, or purge it, deleted\nor not, which only the admins may do. When If-Match is given, the user is only deleted if it is still\nat that version.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete a user",
+                "operationId": "Delete",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the user permanently",
+                        "name": "purge",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version of the user to delete",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
+                    }
+                }
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Create a user",
+                "description": "Replace every field of a user. The ID of the body, if any, must be the ID of the path.\nWhen If-Match is given, the user is only modified if it is still at that version.",
                 "consumes": [
                     "application/json"
                 ],
@@ -105,9 +446,22 @@ var doc = `{
                 "tags": [
                     "users"
                 ],
-                "summary": "Create a user",
-                "operationId": "Create",
+                "summary": "Modify a user",
+                "operationId": "Modify",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version of the user to modify",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "entity.User",
                         "name": "user",
@@ -124,26 +478,123 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/handler.Response"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Partially modify a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document,\nas told by the Content-Type header. The fields missing from a merge patch are kept.\nWhen If-Match is given, the user is only patched if it is still at that version.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Patch a user",
+                "operationId": "Patch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version of the user to modify",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "JSON Merge Patch or JSON Patch document",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
                     }
                 }
             }
         },
-        "/api/users/{id}": {
-            "get": {
+        "/api/users/{id}/restore": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Get a user by ID",
+                "description": "Restore a deleted user. When If-Match is given, the user is only restored if it is still at that\nversion.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Get a user by ID",
-                "operationId": "FindByID",
+                "summary": "Restore a user",
+                "operationId": "Restore",
                 "parameters": [
                     {
                         "type": "integer",
@@ -151,6 +602,12 @@ var doc = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version of the deleted user to restore",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -159,21 +616,41 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/handler.Response"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/users/{id}/history": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Delete a user",
+                "description": "Get the changes of a user in the order they occurred, with the caller and the request making them and\nthe user before and after them. The history is kept after the user is purged.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Delete a user",
-                "operationId": "Delete",
+                "summary": "Get the history of a user",
+                "operationId": "FindUserHistory",
                 "parameters": [
                     {
                         "type": "integer",
@@ -187,13 +664,36 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.UserAuditEntryDTO"
+                            }
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
                     }
                 }
             }
         }
     },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key of a machine client, created by the admins through /api/api-keys.\nIt can also be given as \"Authorization: ApiKey \u003ckey\u003e\".",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT access token given as \"Bearer \u003ctoken\u003e\", as returned by /login.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    },
     "definitions": {
         "entity.User": {
             "type": "object",
@@ -222,6 +722,149 @@ var doc = `{
                     "type": "string"
                 }
             }
+        },
+        "handler.UserAuditEntryDTO": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "description": "Actor is the subject of the caller making the change, and ActorID the ID of its user if any",
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "after": {
+                    "$ref": "#/definitions/handler.UserSnapshotDTO"
+                },
+                "before": {
+                    "description": "Before is not given for a creation, and After for a purge",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.UserSnapshotDTO"
+                        }
+                    ]
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.UserDTO": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "description": "DeletedAt is only given for the deleted users",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.UserEventDTO": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/handler.UserDTO"
+                }
+            }
+        },
+        "handler.UserSnapshotDTO": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "description": "DeletedAt is only given for the deleted users",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "surname": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is the version of the user, as given by its ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "problem.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "problem.Problem": {
+            "type": "object",
+            "properties": {
+                "correlation_id": {
+                    "description": "CorrelationID is the ID of the request, as given or returned in the X-Request-ID header",
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors are the invariants broken by the fields of the request, for the validation problems",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/problem.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
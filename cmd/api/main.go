@@ -1,11 +1,26 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/app"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := app.Migrate(ctx, os.Args[2:], os.Stdout)
+		stop()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	err := app.Start()
 	if err != nil {
 		log.Fatal(err)
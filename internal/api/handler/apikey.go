@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+)
+
+// APIKeyAPI encapsulates the API key use cases.
+type APIKeyAPI struct {
+	finderAll usecase.APIKeyFinderAll
+	creator   usecase.APIKeyCreator
+	revoker   usecase.APIKeyRevoker
+}
+
+type CreateAPIKeyDTO struct {
+	Name      string     `json:"name"`
+	OwnerID   uint       `json:"owner_id"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	OwnerID    uint       `json:"owner_id"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type IssuedAPIKeyDTO struct {
+	APIKeyDTO
+	// Key is the plain API key, which is only returned on creation
+	Key string `json:"key"`
+}
+
+// toEntityAPIKey converts a CreateAPIKeyDTO to an entity.APIKey
+func (k CreateAPIKeyDTO) toEntityAPIKey() entity.APIKey {
+	return entity.APIKey{
+		Name:      k.Name,
+		OwnerID:   k.OwnerID,
+		Scopes:    k.Scopes,
+		ExpiresAt: k.ExpiresAt,
+	}
+}
+
+// toAPIKeyDTO converts an entity.APIKey to APIKeyDTO
+func toAPIKeyDTO(k entity.APIKey) APIKeyDTO {
+	scopes := k.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return APIKeyDTO{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		OwnerID:    k.OwnerID,
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+// NewAPIKeyAPI creates a new APIKeyAPI.
+func NewAPIKeyAPI(
+	finderAll usecase.APIKeyFinderAll,
+	creator usecase.APIKeyCreator,
+	revoker usecase.APIKeyRevoker,
+) *APIKeyAPI {
+	return &APIKeyAPI{
+		finderAll: finderAll,
+		creator:   creator,
+		revoker:   revoker,
+	}
+}
+
+// FindAll godoc
+// @summary Get all API keys
+// @description Get all API keys, without their plain values
+// @tags api-keys
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id FindAllAPIKeys
+// @produce json
+// @Router /api/api-keys [get]
+// @response 200 {object} []APIKeyDTO "OK"
+// @response 403 "Forbidden"
+func (h *APIKeyAPI) FindAll(c *fiber.Ctx) error {
+	keys, err := h.finderAll.Find(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]APIKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKeyDTO(key))
+	}
+	return c.JSON(response)
+}
+
+// Create godoc
+// @summary Create an API key
+// @description Create an API key owned by the given user, or by the caller. The plain key is only returned once.
+// @tags api-keys
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id CreateAPIKey
+// @accept json
+// @produce json
+// @param key body CreateAPIKeyDTO true "CreateAPIKeyDTO"
+// @Router /api/api-keys [post]
+// @response 201 {object} IssuedAPIKeyDTO "Created"
+// @response 400 "Bad Request"
+// @response 403 "Forbidden"
+// @response 404 "Owner not found"
+func (h *APIKeyAPI) Create(c *fiber.Ctx) error {
+	var keyDTO CreateAPIKeyDTO
+
+	if err := c.BodyParser(&keyDTO); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if keyDTO.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name is required")
+	}
+
+	issued, err := h.creator.Create(c.UserContext(), keyDTO.toEntityAPIKey())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(IssuedAPIKeyDTO{
+		APIKeyDTO: toAPIKeyDTO(issued.APIKey),
+		Key:       issued.Key,
+	})
+}
+
+// Revoke godoc
+// @summary Revoke an API key
+// @description Revoke an API key, which is rejected from then on
+// @tags api-keys
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id RevokeAPIKey
+// @param id path int true "API key ID"
+// @Router /api/api-keys/{id} [delete]
+// @response 204 "No Content"
+// @response 403 "Forbidden"
+// @response 404 "Not Found"
+func (h *APIKeyAPI) Revoke(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
+
+	if err = h.revoker.Revoke(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
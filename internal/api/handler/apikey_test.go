@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ApiKeysEndpoint = "/api/api-keys"
+)
+
+func TestAPIKeyAPI_FindAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should find all API keys",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockAPIKeyFinderAll := usecase.NewMockAPIKeyFinderAll()
+				mockAPIKeyFinderAll.On("Find", c.UserContext()).Return([]entity.APIKey{
+					{ID: 1, Name: "batch", Prefix: "0123456789ab", KeyHash: "hash", OwnerID: 1},
+				}, nil)
+				api := NewAPIKeyAPI(mockAPIKeyFinderAll, nil, nil)
+
+				a.Get(ApiKeysEndpoint, api.FindAll)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiKeysEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NotContains(t, string(body), "hash")
+
+				var keys []APIKeyDTO
+				err = json.Unmarshal(body, &keys)
+				assert.NoError(t, err)
+				assert.Equal(t, []APIKeyDTO{{ID: 1, Name: "batch", Prefix: "0123456789ab", OwnerID: 1, Scopes: []string{}}}, keys)
+			},
+		},
+		{
+			name: "should fail finding API keys",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockAPIKeyFinderAll := usecase.NewMockAPIKeyFinderAll()
+				mockAPIKeyFinderAll.On("Find", c.UserContext()).Return([]entity.APIKey{}, errors.New("error"))
+				api := NewAPIKeyAPI(mockAPIKeyFinderAll, nil, nil)
+
+				a.Get(ApiKeysEndpoint, api.FindAll)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiKeysEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestAPIKeyAPI_Create(t *testing.T) {
+	expiresAt := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		body  string
+		given func() *fiber.App
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should create an API key",
+			body: `{"name":"batch","owner_id":2,"scopes":["users:read"],"expires_at":"2027-01-01T00:00:00Z"}`,
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockAPIKeyCreator := usecase.NewMockAPIKeyCreator()
+				mockAPIKeyCreator.On("Create", c.UserContext(), entity.APIKey{
+					Name:      "batch",
+					OwnerID:   2,
+					Scopes:    []string{"users:read"},
+					ExpiresAt: &expiresAt,
+				}).Return(entity.IssuedAPIKey{
+					APIKey: entity.APIKey{ID: 1, Name: "batch", Prefix: "0123456789ab", OwnerID: 2, Scopes: []string{"users:read"}},
+					Key:    "0123456789ab.secret",
+				}, nil)
+				api := NewAPIKeyAPI(nil, mockAPIKeyCreator, nil)
+
+				a.Post(ApiKeysEndpoint, api.Create)
+				return a
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var issued IssuedAPIKeyDTO
+				err = json.Unmarshal(body, &issued)
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), issued.ID)
+				assert.Equal(t, "0123456789ab", issued.Prefix)
+				assert.Equal(t, "0123456789ab.secret", issued.Key)
+			},
+		},
+		{
+			name: "should not create an API key without name",
+			body: `{"owner_id":2}`,
+			given: func() *fiber.App {
+				a := testutils.App()
+				api := NewAPIKeyAPI(nil, usecase.NewMockAPIKeyCreator(), nil)
+
+				a.Post(ApiKeysEndpoint, api.Create)
+				return a
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not create an already expired API key",
+			body: `{"name":"batch","owner_id":2}`,
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockAPIKeyCreator := usecase.NewMockAPIKeyCreator()
+				mockAPIKeyCreator.On("Create", c.UserContext(), entity.APIKey{Name: "batch", OwnerID: 2}).
+					Return(entity.IssuedAPIKey{}, domerrors.ErrInvalidAPIKeyExpiry)
+				api := NewAPIKeyAPI(nil, mockAPIKeyCreator, nil)
+
+				a.Post(ApiKeysEndpoint, api.Create)
+				return a
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not create an API key of an unknown owner",
+			body: `{"name":"batch","owner_id":9}`,
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockAPIKeyCreator := usecase.NewMockAPIKeyCreator()
+				mockAPIKeyCreator.On("Create", c.UserContext(), entity.APIKey{Name: "batch", OwnerID: 9}).
+					Return(entity.IssuedAPIKey{}, domerrors.ErrUserNotFound)
+				api := NewAPIKeyAPI(nil, mockAPIKeyCreator, nil)
+
+				a.Post(ApiKeysEndpoint, api.Create)
+				return a
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			name: "should fail creating an API key",
+			body: `{"name":"batch","owner_id":2}`,
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockAPIKeyCreator := usecase.NewMockAPIKeyCreator()
+				mockAPIKeyCreator.On("Create", c.UserContext(), entity.APIKey{Name: "batch", OwnerID: 2}).
+					Return(entity.IssuedAPIKey{}, errors.New("error"))
+				api := NewAPIKeyAPI(nil, mockAPIKeyCreator, nil)
+
+				a.Post(ApiKeysEndpoint, api.Create)
+				return a
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			req := httptest.NewRequest(http.MethodPost, ApiKeysEndpoint, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestAPIKeyAPI_Revoke(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "should revoke an API key", status: http.StatusNoContent},
+		{name: "should not revoke an unknown API key", err: domerrors.ErrAPIKeyNotFound, status: http.StatusNotFound},
+		{name: "should fail revoking an API key", err: errors.New("error"), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			c := testutils.AcquireFiberCtx(a)
+			mockAPIKeyRevoker := usecase.NewMockAPIKeyRevoker()
+			mockAPIKeyRevoker.On("Revoke", c.UserContext(), uint(1)).Return(tt.err)
+			a.Delete(ApiKeysEndpoint+"/:id", NewAPIKeyAPI(nil, nil, mockAPIKeyRevoker).Revoke)
+
+			// When
+			req := httptest.NewRequest(http.MethodDelete, ApiKeysEndpoint+"/1", nil)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
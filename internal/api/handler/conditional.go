@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notModified sets the given validators of the representation answered to a GET, and reports whether the request
+// is conditional and the representation cached by the client is still the current one, which is then answered with
+// 304 Not Modified (RFC 9110). If-None-Match takes precedence over If-Modified-Since, which is only evaluated when
+// there is no If-None-Match and the representation has a modification time.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch); ifNoneMatch != "" {
+		return noneMatchFails(ifNoneMatch, etag)
+	}
+	if ifModifiedSince := c.Get(fiber.HeaderIfModifiedSince); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		// an invalid date is ignored, and the dates only have a precision of seconds
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// noneMatchFails reports whether the given If-None-Match header lists the given entity tag, or is "*". The entity
+// tags are compared weakly, as If-None-Match requires.
+func noneMatchFails(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == ifMatchAny {
+		return true
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -2,11 +2,69 @@ create table users (
     id serial primary key,
     name text not null,
     surname text not null,
+    roles text,
     created_at timestamp with time zone default now(),
     updated_at timestamp with time zone default now(),
     deleted_at timestamp with time zone
 );
 
-insert into users(name, surname) values ('John', 'Doe');
+create index idx_users_name_id on users(name, id);
+create index idx_users_surname_id on users(surname, id);
+
+create table user_credentials (
+    id serial primary key,
+    user_id integer not null references users(id),
+    username text not null unique,
+    password_hash text not null,
+    created_at timestamp with time zone default now(),
+    updated_at timestamp with time zone default now(),
+    deleted_at timestamp with time zone
+);
+
+create table user_identities (
+    id serial primary key,
+    user_id integer not null references users(id),
+    issuer text not null,
+    subject text not null,
+    created_at timestamp with time zone default now(),
+    updated_at timestamp with time zone default now(),
+    deleted_at timestamp with time zone,
+    unique (issuer, subject)
+);
+
+create index idx_user_identities_user_id on user_identities(user_id);
+
+create table refresh_tokens (
+    id serial primary key,
+    user_id integer not null references users(id),
+    family_id text not null,
+    token_hash text not null unique,
+    expires_at timestamp with time zone not null,
+    created_at timestamp with time zone default now(),
+    revoked_at timestamp with time zone
+);
+
+create index idx_refresh_tokens_user_id on refresh_tokens(user_id);
+create index idx_refresh_tokens_family_id on refresh_tokens(family_id);
+
+create table api_keys (
+    id serial primary key,
+    name text not null,
+    prefix text not null unique,
+    key_hash text not null,
+    owner_id integer not null references users(id),
+    scopes text,
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    created_at timestamp with time zone default now(),
+    revoked_at timestamp with time zone
+);
+
+create index idx_api_keys_owner_id on api_keys(owner_id);
+
+insert into users(name, surname, roles) values ('John', 'Doe', '["admin"]');
 insert into users(name, surname) values ('Jane', 'Doe');
-insert into users(name, surname) values ('Alice', 'Smith');
\ No newline at end of file
+insert into users(name, surname) values ('Alice', 'Smith');
+
+-- the password of john is "lark"
+insert into user_credentials(user_id, username, password_hash) values (1, 'john', '$2a$10$jbRdr4jyTjRRHM6iiXlCO.LSrrqujwL3MjupTLU4MA/paEiS/AJiG');
\ No newline at end of file
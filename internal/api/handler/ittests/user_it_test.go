@@ -10,15 +10,23 @@ import (
 	"io"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	json "github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/handler"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/problem"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/app"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/db"
+	infrarepo "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository/repositorytest"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -68,8 +76,9 @@ func (st *UserAPITestITSuite) SetupSuite() {
 }
 
 func (st *UserAPITestITSuite) doLogin() {
-	req, err := http.NewRequest(http.MethodPost, LoginEndpoint, nil)
+	req, err := http.NewRequest(http.MethodPost, LoginEndpoint, strings.NewReader(`{"username": "john", "password": "lark"}`))
 	assert.NoError(st.T(), err)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 
 	client := &http.Client{}
 
@@ -136,6 +145,8 @@ func (st *UserAPITestITSuite) TestApiUsersFindAll() {
 	}(resp.Body)
 
 	assert.Equal(st.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(st.T(), "3", resp.Header.Get(handler.HeaderTotalCount))
+	assert.Contains(st.T(), resp.Header.Get(fiber.HeaderLink), `rel="first"`)
 
 	body, err := io.ReadAll(resp.Body)
 	assert.NoError(st.T(), err)
@@ -152,6 +163,59 @@ func (st *UserAPITestITSuite) TestApiUsersFindAll() {
 	assert.Equal(st.T(), "Smith", userResponses[2].Surname)
 }
 
+func (st *UserAPITestITSuite) TestApiUsersFindAllByCursor() {
+	client := &http.Client{}
+
+	var names []string
+	url := UsersEndpoint + "?limit=2&sort=name"
+	for url != "" {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(st.T(), err)
+		req.Header.Set(fiber.HeaderAuthorization, BearerToken+st.token)
+
+		resp, err := client.Do(req)
+		require.NoError(st.T(), err)
+		require.Equal(st.T(), http.StatusOK, resp.StatusCode)
+
+		var userResponses []handler.UserDTO
+		err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&userResponses)
+		assert.NoError(st.T(), err)
+		assert.NoError(st.T(), resp.Body.Close())
+		for _, u := range userResponses {
+			names = append(names, u.Name)
+		}
+
+		url = ""
+		if cursor := resp.Header.Get(handler.HeaderNextCursor); cursor != "" {
+			url = UsersEndpoint + "?limit=2&sort=name&cursor=" + cursor
+		}
+	}
+
+	assert.Equal(st.T(), []string{"Alice", "Jane", "John"}, names)
+}
+
+func (st *UserAPITestITSuite) TestApiUsersSearch() {
+	req, err := http.NewRequest(http.MethodGet, UsersEndpoint+"/search?q=DO%C3%A9", nil)
+	assert.NoError(st.T(), err)
+	req.Header.Set(fiber.HeaderAuthorization, BearerToken+st.token)
+
+	resp, err := (&http.Client{}).Do(req)
+	require.NoError(st.T(), err)
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		assert.NoError(st.T(), err)
+	}(resp.Body)
+
+	assert.Equal(st.T(), http.StatusOK, resp.StatusCode)
+
+	var userResponses []handler.UserDTO
+	err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&userResponses)
+	assert.NoError(st.T(), err)
+	require.Len(st.T(), userResponses, 2)
+	assert.Equal(st.T(), "John", userResponses[0].Name)
+	assert.Equal(st.T(), "Jane", userResponses[1].Name)
+}
+
 func (st *UserAPITestITSuite) TestApiUsersFindById() {
 	req, err := http.NewRequest(http.MethodGet, UsersEndpoint+"/1", nil)
 	assert.NoError(st.T(), err)
@@ -191,6 +255,35 @@ func (st *UserAPITestITSuite) TestApiUsersFindByIdNotFound() {
 	assert.Equal(st.T(), http.StatusNotFound, resp.StatusCode)
 }
 
+func (st *UserAPITestITSuite) TestApiUsersCreateInvalid() {
+	req, err := http.NewRequest(http.MethodPost, UsersEndpoint, strings.NewReader(`{"name": "  ", "surname": "Doe 2"}`))
+	assert.NoError(st.T(), err)
+	req.Header.Add(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	req.Header.Set(fiber.HeaderAuthorization, BearerToken+st.token)
+
+	client := &http.Client{}
+
+	resp, err := client.Do(req)
+	assert.NoError(st.T(), err)
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		assert.NoError(st.T(), err)
+	}(resp.Body)
+
+	assert.Equal(st.T(), http.StatusUnprocessableEntity, resp.StatusCode)
+
+	assert.Equal(st.T(), problem.MIMEApplicationProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+
+	var response problem.Problem
+	err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&response)
+	assert.NoError(st.T(), err)
+	assert.Equal(st.T(), problem.TypePrefix+"invalid-user", response.Type)
+	assert.Equal(st.T(), []problem.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "surname", Message: "must only contain letters, spaces, apostrophes, hyphens and periods"},
+	}, response.Errors)
+}
+
 func (st *UserAPITestITSuite) TestApiUsersCreateModifyAndDelete() {
 	// Create a new user
 	user := entity.User{
@@ -252,6 +345,43 @@ func (st *UserAPITestITSuite) TestApiUsersCreateModifyAndDelete() {
 	err = resp.Body.Close()
 	assert.NoError(st.T(), err)
 
+	// Patch the surname of the user, keeping its name
+	req, err = http.NewRequest(http.MethodPatch, UsersEndpoint+"/4", strings.NewReader(`{"surname": "Doe Patched"}`))
+	assert.NoError(st.T(), err)
+	req.Header.Add(fiber.HeaderContentType, handler.MIMEApplicationMergePatchJSON)
+	req.Header.Set(fiber.HeaderAuthorization, BearerToken+st.token)
+
+	resp, err = client.Do(req)
+	assert.NoError(st.T(), err)
+	assert.Equal(st.T(), http.StatusOK, resp.StatusCode)
+
+	err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&userResponses)
+	assert.NoError(st.T(), err)
+	assert.Equal(st.T(), "John Modified", userResponses.Name)
+	assert.Equal(st.T(), "Doe Patched", userResponses.Surname)
+
+	err = resp.Body.Close()
+	assert.NoError(st.T(), err)
+
+	// Patch the name of the user only if it was not modified meanwhile
+	req, err = http.NewRequest(http.MethodPatch, UsersEndpoint+"/4", strings.NewReader(
+		`[{"op": "test", "path": "/name", "value": "John Modified"}, {"op": "replace", "path": "/name", "value": "John Patched"}]`))
+	assert.NoError(st.T(), err)
+	req.Header.Add(fiber.HeaderContentType, handler.MIMEApplicationJSONPatchJSON)
+	req.Header.Set(fiber.HeaderAuthorization, BearerToken+st.token)
+
+	resp, err = client.Do(req)
+	assert.NoError(st.T(), err)
+	assert.Equal(st.T(), http.StatusOK, resp.StatusCode)
+
+	err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&userResponses)
+	assert.NoError(st.T(), err)
+	assert.Equal(st.T(), "John Patched", userResponses.Name)
+	assert.Equal(st.T(), "Doe Patched", userResponses.Surname)
+
+	err = resp.Body.Close()
+	assert.NoError(st.T(), err)
+
 	// Delete the user
 	req, err = http.NewRequest(http.MethodDelete, UsersEndpoint+"/4", nil)
 	assert.NoError(st.T(), err)
@@ -269,4 +399,69 @@ func (st *UserAPITestITSuite) TestApiUsersCreateModifyAndDelete() {
 	resp, err = client.Do(req)
 	assert.NoError(st.T(), err)
 	assert.Equal(st.T(), http.StatusNotFound, resp.StatusCode)
+
+	// Check that every change of the user has been audited
+	req, err = http.NewRequest(http.MethodGet, UsersEndpoint+"/4/history", nil)
+	assert.NoError(st.T(), err)
+	req.Header.Set(fiber.HeaderAuthorization, BearerToken+st.token)
+
+	resp, err = client.Do(req)
+	assert.NoError(st.T(), err)
+	assert.Equal(st.T(), http.StatusOK, resp.StatusCode)
+
+	var history []handler.UserAuditEntryDTO
+	err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&history)
+	assert.NoError(st.T(), err)
+	actions := make([]string, 0, len(history))
+	for _, entry := range history {
+		actions = append(actions, entry.Action)
+		assert.NotEmpty(st.T(), entry.Actor)
+		assert.NotEmpty(st.T(), entry.RequestID)
+	}
+	assert.Equal(st.T(), []string{"create", "modify", "patch", "patch", "delete"}, actions)
+	if assert.Len(st.T(), history, 5) {
+		assert.Equal(st.T(), "John", history[1].Before.Name)
+		assert.Equal(st.T(), "John Modified", history[1].After.Name)
+	}
+
+	err = resp.Body.Close()
+	assert.NoError(st.T(), err)
+}
+
+// TestUserDBContract runs the contract of the user repositories against the database of the application
+func (st *UserAPITestITSuite) TestUserDBContract() {
+	conn, err := db.ConnectDatabase(config.DB{Type: config.PostgresDB, Host: "localhost", Port: "5432", User: "postgres", Password: "postgres"})
+	require.NoError(st.T(), err)
+
+	suite.Run(st.T(), &repositorytest.UserSuite{NewRepository: func(t *testing.T) repository.User {
+		return infrarepo.NewUserDB(conn)
+	}})
+}
+
+// TestUserOutboxDBTransaction checks that the events of the users are written in the transaction of the users
+func (st *UserAPITestITSuite) TestUserOutboxDBTransaction() {
+	conn, err := db.ConnectDatabase(config.DB{Type: config.PostgresDB, Host: "localhost", Port: "5432", User: "postgres", Password: "postgres"})
+	require.NoError(st.T(), err)
+	users, outbox := infrarepo.NewUserDB(conn), infrarepo.NewUserOutboxDB(conn)
+	rollback := errors.New("rolled back")
+
+	err = infrarepo.NewTransactorDB(conn).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		user, err := users.Create(ctx, entity.User{ID: 9001, Name: "Outbox", Surname: "Rollback"})
+		require.NoError(st.T(), err)
+		event, err := outbox.Append(ctx, entity.NewUserEvent(entity.UserCreated, user))
+		require.NoError(st.T(), err)
+
+		pending, err := outbox.FindPending(ctx, 1000)
+		require.NoError(st.T(), err)
+		assert.Contains(st.T(), pending, event)
+		return rollback
+	})
+	assert.ErrorIs(st.T(), err, rollback)
+
+	// neither the user nor its event are written
+	_, err = users.FindByID(context.Background(), 9001)
+	assert.ErrorIs(st.T(), err, domerrors.ErrUserNotFound)
+	var count int64
+	require.NoError(st.T(), conn.Model(&infrarepo.UserOutboxDBEntity{}).Where("user_id = ?", 9001).Count(&count).Error)
+	assert.Zero(st.T(), count)
 }
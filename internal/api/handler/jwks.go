@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// jwksCacheControl lets the verifiers cache the published keys for 5 minutes
+const jwksCacheControl = "public, max-age=300"
+
+// JWKSAPI publishes the public keys verifying the access tokens.
+type JWKSAPI struct {
+	keys service.PublicKeySet
+}
+
+// JWKSDTO is a JSON Web Key Set, as defined by RFC 7517
+type JWKSDTO struct {
+	Keys []JWKDTO `json:"keys"`
+}
+
+// JWKDTO is a public JSON Web Key, as defined by RFC 7517 and RFC 7518
+type JWKDTO struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// toJWKDTO converts an entity.PublicKey to JWKDTO
+func toJWKDTO(k entity.PublicKey) (JWKDTO, error) {
+	jwk := JWKDTO{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		public, err := key.ECDH()
+		if err != nil {
+			return JWKDTO{}, errors.Wrapf(err, "cannot encode key %q", k.ID)
+		}
+		// the uncompressed point is 0x04 followed by the X and Y coordinates of the same size
+		point := public.Bytes()[1:]
+		size := len(point) / 2
+		jwk.KeyType = "EC"
+		jwk.Curve = key.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[:size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[size:])
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return JWKDTO{}, errors.Errorf("unsupported type %T of key %q", k.Key, k.ID)
+	}
+
+	return jwk, nil
+}
+
+// NewJWKSAPI creates a new JWKSAPI.
+func NewJWKSAPI(keys service.PublicKeySet) *JWKSAPI {
+	return &JWKSAPI{
+		keys: keys,
+	}
+}
+
+// JWKS godoc
+// @summary Get the public keys
+// @description Get the JSON Web Key Set verifying the access tokens
+// @tags auth
+// @id JWKS
+// @produce json
+// @Router /.well-known/jwks.json [get]
+// @response 200 {object} JWKSDTO "OK"
+func (h *JWKSAPI) JWKS(c *fiber.Ctx) error {
+	keys, err := h.keys.PublicKeys(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	jwks := JWKSDTO{Keys: make([]JWKDTO, 0, len(keys))}
+	for _, k := range keys {
+		jwk, err := toJWKDTO(k)
+		if err != nil {
+			return err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	c.Set(fiber.HeaderCacheControl, jwksCacheControl)
+	return c.JSON(jwks)
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	JWKSEndpoint = "/.well-known/jwks.json"
+)
+
+func TestJWKSAPI_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should publish the public keys",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockPublicKeySet := security.NewMockPublicKeySet()
+				mockPublicKeySet.On("PublicKeys", c.UserContext()).
+					Return([]entity.PublicKey{
+						{ID: "rsa", Algorithm: "RS256", Key: &rsaKey.PublicKey},
+						{ID: "ec", Algorithm: "ES384", Key: &ecKey.PublicKey},
+						{ID: "ed", Algorithm: "EdDSA", Key: edPublic},
+					}, nil)
+				api := NewJWKSAPI(mockPublicKeySet)
+
+				a.Get(JWKSEndpoint, api.JWKS)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, JWKSEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "public, max-age=300", resp.Header.Get(fiber.HeaderCacheControl))
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var jwks JWKSDTO
+				err = json.Unmarshal(body, &jwks)
+				assert.NoError(t, err)
+				require.Len(t, jwks.Keys, 3)
+
+				n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+				assert.NoError(t, err)
+				assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+				assert.Equal(t, "rsa", jwks.Keys[0].KeyID)
+				assert.Equal(t, "RS256", jwks.Keys[0].Algorithm)
+				assert.Equal(t, "sig", jwks.Keys[0].Use)
+				assert.Equal(t, "AQAB", jwks.Keys[0].E)
+				assert.Equal(t, rsaKey.N, new(big.Int).SetBytes(n))
+
+				x, err := base64.RawURLEncoding.DecodeString(jwks.Keys[1].X)
+				assert.NoError(t, err)
+				y, err := base64.RawURLEncoding.DecodeString(jwks.Keys[1].Y)
+				assert.NoError(t, err)
+				assert.Equal(t, "EC", jwks.Keys[1].KeyType)
+				assert.Equal(t, "P-384", jwks.Keys[1].Curve)
+				assert.Len(t, x, 48)
+				assert.Len(t, y, 48)
+				assert.Equal(t, ecKey.X, new(big.Int).SetBytes(x))
+				assert.Equal(t, ecKey.Y, new(big.Int).SetBytes(y))
+
+				assert.Equal(t, "OKP", jwks.Keys[2].KeyType)
+				assert.Equal(t, "Ed25519", jwks.Keys[2].Curve)
+				assert.Equal(t, base64.RawURLEncoding.EncodeToString(edPublic), jwks.Keys[2].X)
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should publish an empty key set",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockPublicKeySet := security.NewMockPublicKeySet()
+				mockPublicKeySet.On("PublicKeys", c.UserContext()).
+					Return([]entity.PublicKey{}, nil)
+				api := NewJWKSAPI(mockPublicKeySet)
+
+				a.Get(JWKSEndpoint, api.JWKS)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, JWKSEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `{"keys": []}`, string(body))
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should not publish an unsupported key",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockPublicKeySet := security.NewMockPublicKeySet()
+				mockPublicKeySet.On("PublicKeys", c.UserContext()).
+					Return([]entity.PublicKey{{ID: "hmac", Algorithm: "HS256", Key: []byte("secret")}}, nil)
+				api := NewJWKSAPI(mockPublicKeySet)
+
+				a.Get(JWKSEndpoint, api.JWKS)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, JWKSEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not publish the public keys when they cannot be got",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockPublicKeySet := security.NewMockPublicKeySet()
+				mockPublicKeySet.On("PublicKeys", c.UserContext()).
+					Return([]entity.PublicKey{}, errors.New("cannot get keys"))
+				api := NewJWKSAPI(mockPublicKeySet)
+
+				a.Get(JWKSEndpoint, api.JWKS)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, JWKSEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
@@ -4,37 +4,135 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
 )
 
-func Login(c *fiber.Ctx) error {
-	// implement login logic here
-	// user := c.Query("user")
-	// pass := c.Query("pass")
-
-	// // Throws Unauthorized error
-	// if user != "john" || pass != "lark" {
-	// 	return c.AbortWithStatus(http.StatusUnauthorized)
-	// }
-
-	// Create the Claims
-	// claims := jwt.MapClaims{
-	// 	"name":  "John Lark",
-	// 	"admin": true,
-	// 	"exp":   time.Now().Add(time.Hour * 72).Unix(),
-	// }
-
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(time.Hour * 72)},
-	})
-
-	ss, err := token.SignedString([]byte("secret"))
+// LoginAPI encapsulates the authentication use cases.
+type LoginAPI struct {
+	authenticator usecase.UserAuthenticator
+	granter       usecase.TokenGranter
+	refresher     usecase.TokenRefresher
+	revoker       usecase.TokenRevoker
+}
+
+type LoginDTO struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenDTO struct {
+	Token            string `json:"token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// toTokenDTO converts an entity.TokenPair to TokenDTO
+func toTokenDTO(p entity.TokenPair) TokenDTO {
+	return TokenDTO{
+		Token:            p.AccessToken.Token,
+		ExpiresIn:        int64(time.Until(p.AccessToken.ExpiresAt).Seconds()),
+		RefreshToken:     p.RefreshToken,
+		RefreshExpiresIn: int64(time.Until(p.RefreshTokenExpiresAt).Seconds()),
+	}
+}
+
+// NewLoginAPI creates a new LoginAPI.
+func NewLoginAPI(
+	authenticator usecase.UserAuthenticator,
+	granter usecase.TokenGranter,
+	refresher usecase.TokenRefresher,
+	revoker usecase.TokenRevoker,
+) *LoginAPI {
+	return &LoginAPI{
+		authenticator: authenticator,
+		granter:       granter,
+		refresher:     refresher,
+		revoker:       revoker,
+	}
+}
+
+// Login godoc
+// @summary Log in
+// @description Check the user credentials and return an access token and a refresh token
+// @tags auth
+// @id Login
+// @accept json
+// @produce json
+// @param credentials body LoginDTO true "LoginDTO"
+// @Router /login [post]
+// @response 200 {object} TokenDTO "OK"
+// @response 401 "Unauthorized"
+func (h *LoginAPI) Login(c *fiber.Ctx) error {
+	var loginDTO LoginDTO
+
+	if err := c.BodyParser(&loginDTO); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	user, err := h.authenticator.Authenticate(c.UserContext(), loginDTO.Username, loginDTO.Password)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.NewError(fiber.StatusInternalServerError, err.Error()))
+		return err
+	}
+
+	tokens, err := h.granter.Grant(c.UserContext(), user)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toTokenDTO(tokens))
+}
+
+// Refresh godoc
+// @summary Refresh the tokens
+// @description Rotate a refresh token and return a new access token and refresh token
+// @tags auth
+// @id Refresh
+// @accept json
+// @produce json
+// @param token body RefreshTokenDTO true "RefreshTokenDTO"
+// @Router /auth/refresh [post]
+// @response 200 {object} TokenDTO "OK"
+// @response 401 "Unauthorized"
+func (h *LoginAPI) Refresh(c *fiber.Ctx) error {
+	var refreshTokenDTO RefreshTokenDTO
+
+	if err := c.BodyParser(&refreshTokenDTO); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	tokens, err := h.refresher.Refresh(c.UserContext(), refreshTokenDTO.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toTokenDTO(tokens))
+}
+
+// Logout godoc
+// @summary Log out
+// @description Revoke a refresh token and every token rotated from the same login
+// @tags auth
+// @id Logout
+// @accept json
+// @param token body RefreshTokenDTO true "RefreshTokenDTO"
+// @Router /auth/logout [post]
+// @response 204 "No Content"
+func (h *LoginAPI) Logout(c *fiber.Ctx) error {
+	var refreshTokenDTO RefreshTokenDTO
+
+	if err := c.BodyParser(&refreshTokenDTO); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.revoker.Revoke(c.UserContext(), refreshTokenDTO.RefreshToken); err != nil {
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"token": ss,
-	})
+	return c.SendStatus(fiber.StatusNoContent)
 }
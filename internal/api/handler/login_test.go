@@ -0,0 +1,435 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	LoginEndpoint   = "/login"
+	RefreshEndpoint = "/auth/refresh"
+	LogoutEndpoint  = "/auth/logout"
+)
+
+func tokenPair() entity.TokenPair {
+	return entity.TokenPair{
+		AccessToken:           entity.AccessToken{Token: "token", ExpiresAt: time.Now().Add(15 * time.Minute)},
+		RefreshToken:          "refresh-token",
+		RefreshTokenExpiresAt: time.Now().Add(30 * 24 * time.Hour),
+	}
+}
+
+func TestLoginAPI_Login(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should log in",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserAuthenticator := usecase.NewMockUserAuthenticator()
+				mockUserAuthenticator.On("Authenticate", c.UserContext(), "john", "lark").
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				mockTokenGranter := usecase.NewMockTokenGranter()
+				mockTokenGranter.On("Grant", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).
+					Return(tokenPair(), nil)
+				api := NewLoginAPI(mockUserAuthenticator, mockTokenGranter, usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(LoginEndpoint, api.Login)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LoginEndpoint, strings.NewReader(`{"username": "john", "password": "lark"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var tokenResponse TokenDTO
+				err = json.Unmarshal(body, &tokenResponse)
+				assert.NoError(t, err)
+				assert.Equal(t, "token", tokenResponse.Token)
+				assert.InDelta(t, 15*60, tokenResponse.ExpiresIn, 5)
+				assert.Equal(t, "refresh-token", tokenResponse.RefreshToken)
+				assert.InDelta(t, 30*24*60*60, tokenResponse.RefreshExpiresIn, 5)
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should not log in with invalid credentials",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserAuthenticator := usecase.NewMockUserAuthenticator()
+				mockUserAuthenticator.On("Authenticate", c.UserContext(), "john", "wrong").
+					Return(entity.User{}, domerrors.ErrInvalidCredentials)
+				api := NewLoginAPI(mockUserAuthenticator, usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(LoginEndpoint, api.Login)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LoginEndpoint, strings.NewReader(`{"username": "john", "password": "wrong"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not log in when authentication fails",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserAuthenticator := usecase.NewMockUserAuthenticator()
+				mockUserAuthenticator.On("Authenticate", c.UserContext(), "john", "lark").
+					Return(entity.User{}, errors.New("connection lost"))
+				api := NewLoginAPI(mockUserAuthenticator, usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(LoginEndpoint, api.Login)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LoginEndpoint, strings.NewReader(`{"username": "john", "password": "lark"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not log in when the token cannot be issued",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserAuthenticator := usecase.NewMockUserAuthenticator()
+				mockUserAuthenticator.On("Authenticate", c.UserContext(), "john", "lark").
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				mockTokenGranter := usecase.NewMockTokenGranter()
+				mockTokenGranter.On("Grant", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).
+					Return(entity.TokenPair{}, errors.New("cannot sign token"))
+				api := NewLoginAPI(mockUserAuthenticator, mockTokenGranter, usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(LoginEndpoint, api.Login)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LoginEndpoint, strings.NewReader(`{"username": "john", "password": "lark"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not log in with invalid data",
+			given: func() *fiber.App {
+				a := testutils.App()
+
+				mockUserAuthenticator := usecase.NewMockUserAuthenticator()
+				api := NewLoginAPI(mockUserAuthenticator, usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(LoginEndpoint, api.Login)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LoginEndpoint, strings.NewReader(`{"username": "john"`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestLoginAPI_Refresh(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should refresh tokens",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockTokenRefresher := usecase.NewMockTokenRefresher()
+				mockTokenRefresher.On("Refresh", c.UserContext(), "refresh-token").
+					Return(tokenPair(), nil)
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), mockTokenRefresher, usecase.NewMockTokenRevoker())
+
+				a.Post(RefreshEndpoint, api.Refresh)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, RefreshEndpoint, strings.NewReader(`{"refresh_token": "refresh-token"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var tokenResponse TokenDTO
+				err = json.Unmarshal(body, &tokenResponse)
+				assert.NoError(t, err)
+				assert.Equal(t, "token", tokenResponse.Token)
+				assert.Equal(t, "refresh-token", tokenResponse.RefreshToken)
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should not refresh an invalid refresh token",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockTokenRefresher := usecase.NewMockTokenRefresher()
+				mockTokenRefresher.On("Refresh", c.UserContext(), "refresh-token").
+					Return(entity.TokenPair{}, domerrors.ErrInvalidRefreshToken)
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), mockTokenRefresher, usecase.NewMockTokenRevoker())
+
+				a.Post(RefreshEndpoint, api.Refresh)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, RefreshEndpoint, strings.NewReader(`{"refresh_token": "refresh-token"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not refresh a reused refresh token",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockTokenRefresher := usecase.NewMockTokenRefresher()
+				mockTokenRefresher.On("Refresh", c.UserContext(), "refresh-token").
+					Return(entity.TokenPair{}, domerrors.ErrRefreshTokenReused)
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), mockTokenRefresher, usecase.NewMockTokenRevoker())
+
+				a.Post(RefreshEndpoint, api.Refresh)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, RefreshEndpoint, strings.NewReader(`{"refresh_token": "refresh-token"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not refresh when refreshing fails",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockTokenRefresher := usecase.NewMockTokenRefresher()
+				mockTokenRefresher.On("Refresh", c.UserContext(), "refresh-token").
+					Return(entity.TokenPair{}, errors.New("connection lost"))
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), mockTokenRefresher, usecase.NewMockTokenRevoker())
+
+				a.Post(RefreshEndpoint, api.Refresh)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, RefreshEndpoint, strings.NewReader(`{"refresh_token": "refresh-token"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not refresh with invalid data",
+			given: func() *fiber.App {
+				a := testutils.App()
+
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(RefreshEndpoint, api.Refresh)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, RefreshEndpoint, strings.NewReader(`{"refresh_token": `))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestLoginAPI_Logout(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should log out",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockTokenRevoker := usecase.NewMockTokenRevoker()
+				mockTokenRevoker.On("Revoke", c.UserContext(), "refresh-token").
+					Return(nil)
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), mockTokenRevoker)
+
+				a.Post(LogoutEndpoint, api.Logout)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LogoutEndpoint, strings.NewReader(`{"refresh_token": "refresh-token"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not log out when revoking fails",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockTokenRevoker := usecase.NewMockTokenRevoker()
+				mockTokenRevoker.On("Revoke", c.UserContext(), "refresh-token").
+					Return(errors.New("connection lost"))
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), mockTokenRevoker)
+
+				a.Post(LogoutEndpoint, api.Logout)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LogoutEndpoint, strings.NewReader(`{"refresh_token": "refresh-token"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not log out with invalid data",
+			given: func() *fiber.App {
+				a := testutils.App()
+
+				api := NewLoginAPI(usecase.NewMockUserAuthenticator(), usecase.NewMockTokenGranter(), usecase.NewMockTokenRefresher(), usecase.NewMockTokenRevoker())
+
+				a.Post(LogoutEndpoint, api.Logout)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, LogoutEndpoint, strings.NewReader(`{"refresh_token": `))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
@@ -1,11 +1,23 @@
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a machine client, created by the admins through /api/api-keys.
+// @description It can also be given as "Authorization: ApiKey <key>".
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT access token given as "Bearer <token>", as returned by /login.
+
 package handler
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
 	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
-	"github.com/pkg/errors"
 )
 
 const errBuildResponseTxt = "cannot build response"
@@ -13,16 +25,22 @@ const errBuildResponseTxt = "cannot build response"
 // UserAPI encapsulates the user use cases.
 type UserAPI struct {
 	finderAll  usecase.UserFinderAll
+	searcher   usecase.UserSearcher
 	finderByID usecase.UserFinderByID
 	creator    usecase.UserCreator
 	modifier   usecase.UserModifier
+	patcher    usecase.UserPatcher
 	deleter    usecase.UserDeleter
+	restorer   usecase.UserRestorer
 }
 
 type UserDTO struct {
-	ID      uint   `json:"id"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
+	ID      uint     `json:"id"`
+	Name    string   `json:"name"`
+	Surname string   `json:"surname"`
+	Roles   []string `json:"roles,omitempty"`
+	// DeletedAt is only given for the deleted users
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // toEntityUser converts a UserDTO to an entity.User
@@ -31,176 +49,386 @@ func (u UserDTO) toEntityUser() entity.User {
 		ID:      u.ID,
 		Name:    u.Name,
 		Surname: u.Surname,
+		Roles:   u.Roles,
 	}
 }
 
 // toUserDTO concerts entity.User to UserDTO
 func toUserDTO(u entity.User) UserDTO {
-	return UserDTO{
+	dto := UserDTO{
 		ID:      u.ID,
 		Name:    u.Name,
 		Surname: u.Surname,
+		Roles:   u.Roles,
 	}
+	if u.Deleted() {
+		dto.DeletedAt = &u.DeletedAt
+	}
+	return dto
 }
 
 // NewUserAPI creates a new UserAPI.
 func NewUserAPI(
 	finderAll usecase.UserFinderAll,
+	searcher usecase.UserSearcher,
 	finderByID usecase.UserFinderByID,
 	creator usecase.UserCreator,
 	modifier usecase.UserModifier,
+	patcher usecase.UserPatcher,
 	deleter usecase.UserDeleter,
+	restorer usecase.UserRestorer,
 ) *UserAPI {
 	return &UserAPI{
 		finderAll:  finderAll,
+		searcher:   searcher,
 		finderByID: finderByID,
 		creator:    creator,
 		modifier:   modifier,
+		patcher:    patcher,
 		deleter:    deleter,
+		restorer:   restorer,
 	}
 }
 
 // FindAll godoc
 // @summary Get all users
-// @description Get all users
+// @description Get a page of the users, sorted and filtered by name and surname.
+// @description The total number of matching users is returned in the X-Total-Count header,
+// @description and the first, prev, next and last pages in the Link header.
+// @description The cursor of the next page is returned in the X-Next-Cursor header. The pages after a cursor stay
+// @description stable while users are created or deleted, and only link to the first and next pages.
+// @description The page has an ETag, and is answered with 304 Not Modified when it is the one of If-None-Match.
+// @description The deleted users are listed as well with include=deleted.
 // @tags users
 // @security ApiKeyAuth
+// @security BearerAuth
 // @id FindAll
 // @produce json
+// @param limit query int false "Maximum number of users, 20 by default and 100 at most"
+// @param offset query int false "Number of users to skip"
+// @param cursor query string false "Cursor of the page to continue after, as returned in X-Next-Cursor"
+// @param sort query string false "Field to sort by (id, name or surname), prefixed by - for descending order"
+// @param name query string false "Exact name"
+// @param name_prefix query string false "Prefix of the name"
+// @param name_contains query string false "Part of the name"
+// @param surname query string false "Exact surname"
+// @param surname_prefix query string false "Prefix of the surname"
+// @param surname_contains query string false "Part of the surname"
+// @param include query string false "deleted to list the deleted users as well"
+// @param If-None-Match header string false "ETags of the cached pages"
 // @Router /api/users [get]
 // @response 200 {object} []UserDTO "OK"
+// @response 304 "Not Modified"
+// @response 400 "Bad Request"
+// @response 403 "Forbidden"
 func (h *UserAPI) FindAll(c *fiber.Ctx) error {
-	users, err := h.finderAll.Find(c.UserContext())
+	query, err := parseUserQuery(c)
+	if err != nil {
+		return err
+	}
 
+	page, err := h.finderAll.Find(c.UserContext(), query)
 	if err != nil {
-		return c.SendStatus(fiber.StatusNotFound)
-	} else {
-		response := make([]UserDTO, 0, len(users))
-		for _, user := range users {
-			response = append(response, toUserDTO(user))
-		}
-		return c.JSON(response)
+		return err
+	}
+
+	setPageHeaders(c, query.Normalized(), page)
+	if notModified(c, pageETag(page), time.Time{}) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	response := make([]UserDTO, 0, len(page.Users))
+	for _, user := range page.Users {
+		response = append(response, toUserDTO(user))
+	}
+	return c.JSON(response)
+}
+
+// Search godoc
+// @summary Search users
+// @description Search users by partial name or surname, ignoring accents and case, sorted by relevance.
+// @description Every word of the query must match the start or a part of a word of the name or surname.
+// @tags users
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id Search
+// @produce json
+// @param q query string true "Words to search for"
+// @param limit query int false "Maximum number of users, 20 by default and 100 at most"
+// @Router /api/users/search [get]
+// @response 200 {object} []UserDTO "OK"
+// @response 400 "Bad Request"
+func (h *UserAPI) Search(c *fiber.Ctx) error {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return err
+	}
+
+	users, err := h.searcher.Search(c.UserContext(), c.Query("q"), limit)
+	if err != nil {
+		return err
 	}
+
+	response := make([]UserDTO, 0, len(users))
+	for _, user := range users {
+		response = append(response, toUserDTO(user))
+	}
+	return c.JSON(response)
 }
 
 // FindByID godoc
 // @summary Get a user by ID
-// @description Get a user by ID
+// @description Get a user by ID. Its version is returned as the entity tag of the ETag header, and its modification
+// @description time in the Last-Modified header. The user is answered with 304 Not Modified when its ETag is one of
+// @description If-None-Match or, without If-None-Match, when it was not modified since If-Modified-Since.
 // @tags users
 // @security ApiKeyAuth
+// @security BearerAuth
 // @id FindByID
 // @produce json
 // @param id path int true "User ID"
+// @param If-None-Match header string false "ETags of the cached versions of the user"
+// @param If-Modified-Since header string false "Modification time of the cached user"
 // @Router /api/users/{id} [get]
 // @response 200 {object} UserDTO "OK"
+// @response 304 "Not Modified"
 func (h *UserAPI) FindByID(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
-
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).
-			JSON(fiber.NewError(fiber.StatusInternalServerError, "cannot parse id"))
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
 	}
 
 	user, err := h.finderByID.Find(c.UserContext(), uint(id))
-
 	if err != nil {
-		return c.SendStatus(fiber.StatusNotFound)
-	} else {
-		return c.JSON(toUserDTO(user))
+		return err
+	}
+
+	if notModified(c, etag(user.Version), user.UpdatedAt) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
+	return c.JSON(toUserDTO(user))
 }
 
 // Create godoc
 // @summary Create a user
-// @description Create a user
+// @description Create a user. The name and surname are trimmed and normalized to Unicode NFC, and must be made of
+// @description 1 to 100 letters, spaces, apostrophes, hyphens and periods. Its version is returned in the ETag header.
 // @tags users
 // @security ApiKeyAuth
+// @security BearerAuth
 // @id Create
 // @accept json
 // @produce json
 // @param user body entity.User true "entity.User"
 // @Router /api/users [post]
 // @response 200 {object} UserDTO "OK"
+// @response 403 "Forbidden"
+// @response 409 "Conflict"
+// @response 422 {object} problem.Problem "Unprocessable Entity"
 func (h *UserAPI) Create(c *fiber.Ctx) error {
 	var userDTO UserDTO
 
 	if err := c.BodyParser(&userDTO); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.NewError(fiber.StatusBadRequest, err.Error()))
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
 	user, err := h.creator.Create(c.UserContext(), userDTO.toEntityUser())
-
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).
-			JSON(fiber.NewError(fiber.StatusInternalServerError, "Cannot create user: "+err.Error()))
-	} else {
-		return c.Status(fiber.StatusCreated).JSON(toUserDTO(user))
+		return err
 	}
+
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	return c.Status(fiber.StatusCreated).JSON(toUserDTO(user))
 }
 
 // Modify godoc
 // @summary Modify a user
-// @description Modify a user
+// @description Replace every field of a user. The ID of the body, if any, must be the ID of the path.
+// @description When If-Match is given, the user is only modified if it is still at that version.
 // @tags users
 // @security ApiKeyAuth
+// @security BearerAuth
 // @id Modify
 // @accept json
 // @produce json
+// @param id path int true "User ID"
+// @param If-Match header string false "ETag of the version of the user to modify"
 // @param user body entity.User true "entity.User"
-// @Router /api/users [put]
+// @Router /api/users/{id} [put]
 // @response 200 {object} UserDTO "OK"
+// @response 400 "Bad Request"
+// @response 403 "Forbidden"
+// @response 412 "Precondition Failed"
+// @response 422 {object} problem.Problem "Unprocessable Entity"
 func (h *UserAPI) Modify(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
+
 	var userDTO UserDTO
 
 	if err := c.BodyParser(&userDTO); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.NewError(fiber.StatusBadRequest, err.Error()))
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	user, err := h.modifier.Modify(c.UserContext(), userDTO.toEntityUser())
+	if userDTO.ID != 0 && userDTO.ID != uint(id) {
+		return fiber.NewError(fiber.StatusBadRequest, "id of the body does not match the id of the path")
+	}
+	userDTO.ID = uint(id)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	user := userDTO.toEntityUser()
+	user.Version = version
 
+	user, err = h.modifier.Modify(c.UserContext(), user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).
-			JSON(fiber.NewError(fiber.StatusInternalServerError, "Cannot modify user: "+err.Error()))
-	} else {
-		return c.JSON(toUserDTO(user))
+		return ifMatchMissing(c, err)
 	}
+
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	return c.JSON(toUserDTO(user))
+}
+
+// Patch godoc
+// @summary Patch a user
+// @description Partially modify a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document,
+// @description as told by the Content-Type header. The fields missing from a merge patch are kept.
+// @description When If-Match is given, the user is only patched if it is still at that version.
+// @tags users
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id Patch
+// @accept application/merge-patch+json
+// @accept application/json-patch+json
+// @produce json
+// @param id path int true "User ID"
+// @param If-Match header string false "ETag of the version of the user to modify"
+// @param patch body object true "JSON Merge Patch or JSON Patch document"
+// @Router /api/users/{id} [patch]
+// @response 200 {object} UserDTO "OK"
+// @response 400 "Bad Request"
+// @response 403 "Forbidden"
+// @response 404 "Not Found"
+// @response 409 "Conflict"
+// @response 412 "Precondition Failed"
+// @response 415 "Unsupported Media Type"
+// @response 422 {object} problem.Problem "Unprocessable Entity"
+func (h *UserAPI) Patch(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
+
+	format, ok := patchFormat(c.Get(fiber.HeaderContentType))
+	if !ok {
+		c.Set(HeaderAcceptPatch, MIMEApplicationMergePatchJSON+", "+MIMEApplicationJSONPatchJSON)
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "unsupported patch format")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.patcher.Patch(c.UserContext(), uint(id), entity.UserPatch{Format: format, Document: c.Body(), Version: version})
+	if err != nil {
+		return ifMatchMissing(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	return c.JSON(toUserDTO(user))
 }
 
 // Delete godoc
 // @summary Delete a user
-// @description Delete a user
+// @description Delete a user, which is kept until it is purged and can be restored meanwhile, or purge it, deleted
+// @description or not, which only the admins may do. When If-Match is given, the user is only deleted if it is still
+// @description at that version.
 // @tags users
 // @security ApiKeyAuth
+// @security BearerAuth
 // @id Delete
 // @param id path int true "User ID"
+// @param purge query bool false "Delete the user permanently"
+// @param If-Match header string false "ETag of the version of the user to delete"
 // @Router /api/users/{id} [delete]
 // @response 200 {object} UserDTO "OK"
+// @response 403 "Forbidden"
+// @response 412 "Precondition Failed"
 func (h *UserAPI) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
 
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).
-			JSON(fiber.NewError(fiber.StatusInternalServerError, "cannot parse id"))
+		return err
+	}
+
+	if c.QueryBool("purge") {
+		if err = h.deleter.Purge(c.UserContext(), uint(id), version); err != nil {
+			return ifMatchMissing(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
 	}
 
 	user, err := h.finderByID.Find(c.UserContext(), uint(id))
+	if err != nil {
+		return ifMatchMissing(c, err)
+	}
+
+	if user.ID == 0 {
+		return ifMatchMissing(c, domerrors.ErrUserNotFound)
+	}
+
+	if version != 0 {
+		user.Version = version
+	}
+
+	if err = h.deleter.Delete(c.UserContext(), user); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
 
+// Restore godoc
+// @summary Restore a user
+// @description Restore a deleted user. When If-Match is given, the user is only restored if it is still at that
+// @description version.
+// @tags users
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id Restore
+// @produce json
+// @param id path int true "User ID"
+// @param If-Match header string false "ETag of the version of the deleted user to restore"
+// @Router /api/users/{id}/restore [post]
+// @response 200 {object} UserDTO "OK"
+// @response 403 "Forbidden"
+// @response 404 "Not Found"
+// @response 409 "Conflict"
+// @response 412 "Precondition Failed"
+func (h *UserAPI) Restore(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
 	if err != nil {
-		if errors.Is(err, domerrors.ErrUserNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.NewError(fiber.StatusNotFound, "User not found"))
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.NewError(fiber.StatusInternalServerError, err.Error()))
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
 	}
 
-	if user == (entity.User{}) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.NewError(fiber.StatusNotFound, "User not found"))
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
 
-	err = h.deleter.Delete(c.UserContext(), user)
+	user, err := h.restorer.Restore(c.UserContext(), uint(id), version)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).
-			JSON(fiber.NewError(fiber.StatusInternalServerError, "Cannot delete user: "+err.Error()))
+		return ifMatchMissing(c, err)
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	c.Set(fiber.HeaderETag, etag(user.Version))
+	return c.JSON(toUserDTO(user))
 }
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+// ifMatchAny is the If-Match header matching any current version of a resource
+const ifMatchAny = "*"
+
+// etag returns the strong entity tag of the given version of a user (RFC 9110)
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// pageETag returns the strong entity tag of the given page of users, derived from the IDs and versions of its users
+// along with its total and whether more users follow, so that it changes whenever one of its users is saved or
+// deleted, or a user enters or leaves the page
+func pageETag(page entity.UserPage) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(page.Total, 10) + ";" + strconv.FormatBool(page.Next != nil)))
+	for _, user := range page.Users {
+		h.Write([]byte(";" + strconv.FormatUint(uint64(user.ID), 10) + ":" + strconv.FormatUint(uint64(user.Version), 10)))
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// ifMatchVersion returns the version of the user given by the If-Match header of the request, or 0 when the header
+// is missing or matches any version. The entity tags are compared strongly, so that a weak entity tag, or one which
+// is not of a version, never matches and returns errors.ErrConcurrentModification.
+func ifMatchVersion(c *fiber.Ctx) (uint, error) {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if ifMatch == "" || ifMatch == ifMatchAny {
+		return 0, nil
+	}
+	if strings.Contains(ifMatch, ",") {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "If-Match must be * or a single entity tag")
+	}
+
+	weak := strings.HasPrefix(ifMatch, "W/")
+	opaque := strings.TrimPrefix(ifMatch, "W/")
+	if len(opaque) < 2 || !strings.HasPrefix(opaque, `"`) || !strings.HasSuffix(opaque, `"`) {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "malformed If-Match")
+	}
+	if weak {
+		return 0, errors.Wrapf(domerrors.ErrConcurrentModification, "weak entity tag %s never matches", ifMatch)
+	}
+
+	version, err := strconv.ParseUint(opaque[1:len(opaque)-1], 10, strconv.IntSize)
+	if err != nil || version == 0 {
+		return 0, errors.Wrapf(domerrors.ErrConcurrentModification, "entity tag %s does not match", ifMatch)
+	}
+	return uint(version), nil
+}
+
+// ifMatchMissing returns errors.ErrConcurrentModification in place of errors.ErrUserNotFound when the request has an
+// If-Match header, which no current version of a missing user matches, even * (RFC 9110), or else the given error
+func ifMatchMissing(c *fiber.Ctx, err error) error {
+	if errors.Is(err, domerrors.ErrUserNotFound) && strings.TrimSpace(c.Get(fiber.HeaderIfMatch)) != "" {
+		return errors.Wrap(domerrors.ErrConcurrentModification, "If-Match does not match a missing user")
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+)
+
+const (
+	// lastEventIDHeader is the header of the ID of the last event received by a client resuming the stream of events
+	lastEventIDHeader = "Last-Event-ID"
+	// userEventsRetry is the delay, in milliseconds, the clients wait before resuming the stream of events once it ends
+	userEventsRetry = 3000
+	// DefaultUserEventsHeartbeat is the interval of the comments sent on the idle streams of events, keeping the proxies
+	// from closing them and detecting the clients gone
+	DefaultUserEventsHeartbeat = 15 * time.Second
+)
+
+// UserEventAPI streams the events of the users to the clients as Server-Sent Events.
+type UserEventAPI struct {
+	subscriber service.UserEventSubscriber
+	heartbeat  time.Duration
+	now        func() time.Time
+}
+
+type UserEventDTO struct {
+	ID         uint      `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	User       UserDTO   `json:"user"`
+}
+
+// toUserEventDTO converts an entity.UserEvent to UserEventDTO
+func toUserEventDTO(e entity.UserEvent) UserEventDTO {
+	return UserEventDTO{
+		ID:         e.ID,
+		Type:       string(e.Type),
+		OccurredAt: e.OccurredAt,
+		User:       toUserDTO(e.User),
+	}
+}
+
+// NewUserEventAPI creates a new UserEventAPI.
+func NewUserEventAPI(subscriber service.UserEventSubscriber) *UserEventAPI {
+	return &UserEventAPI{
+		subscriber: subscriber,
+		heartbeat:  DefaultUserEventsHeartbeat,
+		now:        time.Now,
+	}
+}
+
+// Stream godoc
+// @summary Stream the changes of the users
+// @description Stream the events of the users as Server-Sent Events, whose id is the ID of the event and whose event
+// @description is its type. A client resumes the stream by giving the ID of the last event it received as
+// @description Last-Event-ID, from which the latest events are replayed. The stream ends once the credentials of the
+// @description client expire, and is resumed with new ones.
+// @tags users
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id StreamUserEvents
+// @produce text/event-stream
+// @param Last-Event-ID header int false "ID of the last event received"
+// @Router /api/users/events [get]
+// @response 200 {object} UserEventDTO "OK"
+// @response 400 "Bad Request"
+// @response 401 "Unauthorized"
+func (h *UserEventAPI) Stream(c *fiber.Ctx) error {
+	var lastEventID uint64
+	if value := c.Get(lastEventIDHeader); value != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(value, 10, 0); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "cannot parse "+lastEventIDHeader)
+		}
+	}
+
+	principal, _ := entity.PrincipalFromContext(c.UserContext())
+	events, cancel := h.subscriber.Subscribe(uint(lastEventID))
+	heartbeat, now := h.heartbeat, h.now
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// disables the buffering of the reverse proxies
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		var expired <-chan time.Time
+		if !principal.ExpiresAt.IsZero() {
+			timer := time.NewTimer(principal.ExpiresAt.Sub(now()))
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		_, _ = fmt.Fprintf(w, "retry: %d\n\n", userEventsRetry)
+		// the stream ends once the client is gone, which is detected by the failure of the next write
+		for w.Flush() == nil {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// the subscriber fell behind or the server is shutting down, and the client resumes the stream
+					return
+				}
+				if err := writeUserEvent(w, event); err != nil {
+					return
+				}
+			case <-ticker.C:
+				_, _ = w.WriteString(": heartbeat\n\n")
+			case <-expired:
+				// the client resumes the stream with new credentials
+				_, _ = w.WriteString(": credentials expired\n\n")
+				_ = w.Flush()
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeUserEvent writes the given event as a Server-Sent Event
+func writeUserEvent(w *bufio.Writer, event entity.UserEvent) error {
+	data, err := json.Marshal(toUserEventDTO(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/event"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	UserEventsEndpoint = "/api/users/events"
+)
+
+func TestUserEventAPI_Stream(t *testing.T) {
+	occurredAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	created := entity.UserEvent{ID: 43, Type: entity.UserCreated, UserID: 1, OccurredAt: occurredAt,
+		User: entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"admin"}}}
+	deleted := entity.UserEvent{ID: 44, Type: entity.UserDeleted, UserID: 2, OccurredAt: occurredAt,
+		User: entity.User{ID: 2, Name: "Jane", Surname: "Doe", DeletedAt: occurredAt}}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		given       func(*event.MockUserEventSubscriber, *atomic.Bool)
+		then        func(t *testing.T, resp *http.Response, body string)
+	}{
+		{
+			name: "should stream the events of the users",
+			given: func(m *event.MockUserEventSubscriber, cancelled *atomic.Bool) {
+				events := make(chan entity.UserEvent, 2)
+				events <- created
+				events <- deleted
+				close(events)
+				m.On("Subscribe", uint(0)).Return((<-chan entity.UserEvent)(events), func() { cancelled.Store(true) })
+			},
+			then: func(t *testing.T, resp *http.Response, body string) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+				assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+				assert.Equal(t, "retry: 3000\n\n"+
+					"id: 43\nevent: user.created\n"+
+					`data: {"id":43,"type":"user.created","occurred_at":"2024-03-01T12:00:00Z","user":{"id":1,"name":"John","surname":"Doe","roles":["admin"]}}`+"\n\n"+
+					"id: 44\nevent: user.deleted\n"+
+					`data: {"id":44,"type":"user.deleted","occurred_at":"2024-03-01T12:00:00Z","user":{"id":2,"name":"Jane","surname":"Doe","deleted_at":"2024-03-01T12:00:00Z"}}`+"\n\n",
+					body)
+			},
+		},
+		{
+			name:        "should resume the stream after the last event received",
+			lastEventID: "42",
+			given: func(m *event.MockUserEventSubscriber, cancelled *atomic.Bool) {
+				events := make(chan entity.UserEvent, 1)
+				events <- created
+				close(events)
+				m.On("Subscribe", uint(42)).Return((<-chan entity.UserEvent)(events), func() { cancelled.Store(true) })
+			},
+			then: func(t *testing.T, resp *http.Response, body string) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Contains(t, body, "id: 43\n")
+			},
+		},
+		{
+			name:        "should not stream the events after an invalid last event",
+			lastEventID: "last",
+			given: func(m *event.MockUserEventSubscriber, cancelled *atomic.Bool) {
+				// not subscribed, so not cancelled either
+				cancelled.Store(true)
+			},
+			then: func(t *testing.T, resp *http.Response, body string) {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			mockSubscriber := event.NewMockUserEventSubscriber()
+			var cancelled atomic.Bool
+			tt.given(mockSubscriber, &cancelled)
+			a.Get(UserEventsEndpoint, NewUserEventAPI(mockSubscriber).Stream)
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, UserEventsEndpoint, nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			tt.then(t, resp, string(body))
+			assert.True(t, cancelled.Load())
+			mockSubscriber.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserEventAPI_Stream_Heartbeat(t *testing.T) {
+	// Given
+	a := testutils.App()
+	broker := event.NewBroker(10, 10)
+	api := NewUserEventAPI(broker)
+	api.heartbeat = 10 * time.Millisecond
+	a.Get(UserEventsEndpoint, api.Stream)
+	// the stream ends once the broker is closed
+	time.AfterFunc(100*time.Millisecond, broker.Close)
+
+	// When
+	resp, err := a.Test(httptest.NewRequest(http.MethodGet, UserEventsEndpoint, nil), -1)
+
+	// Then
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), ": heartbeat\n\n")
+}
+
+func TestUserEventAPI_Stream_Expiry(t *testing.T) {
+	// Given
+	a := testutils.App()
+	broker := event.NewBroker(10, 10)
+	defer broker.Close()
+	a.Get(UserEventsEndpoint, func(c *fiber.Ctx) error {
+		c.SetUserContext(entity.ContextWithPrincipal(c.UserContext(),
+			entity.Principal{Subject: "1", UserID: 1, ExpiresAt: time.Now().Add(50 * time.Millisecond)}))
+		return c.Next()
+	}, NewUserEventAPI(broker).Stream)
+
+	// When
+	resp, err := a.Test(httptest.NewRequest(http.MethodGet, UserEventsEndpoint, nil), int(time.Second.Milliseconds()))
+
+	// Then the stream ends although the broker is still open
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "retry: 3000\n\n: credentials expired\n\n", string(body))
+}
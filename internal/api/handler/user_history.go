@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+)
+
+// UserHistoryAPI encapsulates the user audit trail use cases.
+type UserHistoryAPI struct {
+	finder usecase.UserHistoryFinder
+}
+
+type UserAuditEntryDTO struct {
+	Action string `json:"action"`
+	// Actor is the subject of the caller making the change, and ActorID the ID of its user if any
+	Actor   string `json:"actor,omitempty"`
+	ActorID uint   `json:"actor_id,omitempty"`
+	// Before is not given for a creation, and After for a purge
+	Before     *UserSnapshotDTO `json:"before,omitempty"`
+	After      *UserSnapshotDTO `json:"after,omitempty"`
+	RequestID  string           `json:"request_id,omitempty"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+type UserSnapshotDTO struct {
+	UserDTO
+	// Version is the version of the user, as given by its ETag
+	Version uint `json:"version"`
+}
+
+// toUserAuditEntryDTO converts an entity.UserAuditEntry to UserAuditEntryDTO
+func toUserAuditEntryDTO(a entity.UserAuditEntry) UserAuditEntryDTO {
+	return UserAuditEntryDTO{
+		Action:     string(a.Action),
+		Actor:      a.Actor,
+		ActorID:    a.ActorID,
+		Before:     toUserSnapshotDTO(a.Before),
+		After:      toUserSnapshotDTO(a.After),
+		RequestID:  a.RequestID,
+		OccurredAt: a.OccurredAt,
+	}
+}
+
+// toUserSnapshotDTO converts an entity.User to UserSnapshotDTO, or nil to nil
+func toUserSnapshotDTO(u *entity.User) *UserSnapshotDTO {
+	if u == nil {
+		return nil
+	}
+	return &UserSnapshotDTO{UserDTO: toUserDTO(*u), Version: u.Version}
+}
+
+// NewUserHistoryAPI creates a new UserHistoryAPI.
+func NewUserHistoryAPI(finder usecase.UserHistoryFinder) *UserHistoryAPI {
+	return &UserHistoryAPI{
+		finder: finder,
+	}
+}
+
+// FindByUserID godoc
+// @summary Get the history of a user
+// @description Get the changes of a user in the order they occurred, with the caller and the request making them and
+// @description the user before and after them. The history is kept after the user is purged.
+// @tags users
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id FindUserHistory
+// @produce json
+// @param id path int true "User ID"
+// @Router /api/users/{id}/history [get]
+// @response 200 {object} []UserAuditEntryDTO "OK"
+// @response 403 "Forbidden"
+// @response 404 "Not Found"
+func (h *UserHistoryAPI) FindByUserID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
+
+	entries, err := h.finder.Find(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := make([]UserAuditEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, toUserAuditEntryDTO(entry))
+	}
+	return c.JSON(response)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserHistoryAPI_FindByUserID(t *testing.T) {
+	occurredAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should find the history of a user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserHistoryFinder := usecase.NewMockUserHistoryFinder()
+				mockUserHistoryFinder.On("Find", c.UserContext(), uint(1)).Return([]entity.UserAuditEntry{
+					{
+						ID:         1,
+						UserID:     1,
+						Action:     entity.UserAuditCreate,
+						Actor:      "1",
+						ActorID:    1,
+						After:      &entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1},
+						RequestID:  "3f5c1a7e-request",
+						OccurredAt: occurredAt,
+					},
+					{
+						ID:         2,
+						UserID:     1,
+						Action:     entity.UserAuditPurge,
+						Actor:      "https://accounts.example.com|jane",
+						Before:     &entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1},
+						OccurredAt: occurredAt.Add(time.Hour),
+					},
+				}, nil)
+
+				a.Get(ApiUsersEndpoint+"/:id/history", NewUserHistoryAPI(mockUserHistoryFinder).FindByUserID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1/history", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `[
+					{
+						"action": "create",
+						"actor": "1",
+						"actor_id": 1,
+						"after": {"id": 1, "name": "John", "surname": "Doe", "version": 1},
+						"request_id": "3f5c1a7e-request",
+						"occurred_at": "2024-03-01T12:00:00Z"
+					},
+					{
+						"action": "purge",
+						"actor": "https://accounts.example.com|jane",
+						"before": {"id": 1, "name": "John", "surname": "Doe", "version": 1},
+						"occurred_at": "2024-03-01T13:00:00Z"
+					}
+				]`, string(body))
+			},
+		},
+		{
+			name: "should not find the history of a missing user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserHistoryFinder := usecase.NewMockUserHistoryFinder()
+				mockUserHistoryFinder.On("Find", c.UserContext(), uint(1)).
+					Return([]entity.UserAuditEntry(nil), domerrors.ErrUserNotFound)
+
+				a.Get(ApiUsersEndpoint+"/:id/history", NewUserHistoryAPI(mockUserHistoryFinder).FindByUserID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1/history", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not find the history of an invalid ID",
+			given: func() *fiber.App {
+				a := testutils.App()
+				a.Get(ApiUsersEndpoint+"/:id/history", NewUserHistoryAPI(usecase.NewMockUserHistoryFinder()).FindByUserID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/one/history", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
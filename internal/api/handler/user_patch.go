@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"mime"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+const (
+	// HeaderAcceptPatch is the header with the patch formats accepted by a resource (RFC 5789)
+	HeaderAcceptPatch = "Accept-Patch"
+	// MIMEApplicationMergePatchJSON is the media type of the JSON Merge Patch documents (RFC 7396)
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+	// MIMEApplicationJSONPatchJSON is the media type of the JSON Patch documents (RFC 6902)
+	MIMEApplicationJSONPatchJSON = "application/json-patch+json"
+)
+
+// patchFormat returns the patch format of the given content type, and whether it is a supported one
+func patchFormat(contentType string) (entity.PatchFormat, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case MIMEApplicationMergePatchJSON:
+		return entity.PatchMerge, true
+	case MIMEApplicationJSONPatchJSON:
+		return entity.PatchJSON, true
+	default:
+		return "", false
+	}
+}
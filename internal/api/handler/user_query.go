@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderTotalCount is the header with the total number of items matching a query
+	HeaderTotalCount = "X-Total-Count"
+	// HeaderNextCursor is the header with the cursor of the next page
+	HeaderNextCursor = "X-Next-Cursor"
+	// sortDescPrefix marks a sort field as descending, as in "-name"
+	sortDescPrefix = "-"
+	// includeDeleted is the value of the include query parameter selecting the deleted users too
+	includeDeleted = "deleted"
+)
+
+// userFilterParams maps the query parameters to the filters of the users
+var userFilterParams = []struct {
+	param  string
+	filter entity.UserFilter
+}{
+	{param: "name", filter: entity.UserFilter{Field: entity.UserFieldName, Mode: entity.MatchExact}},
+	{param: "name_prefix", filter: entity.UserFilter{Field: entity.UserFieldName, Mode: entity.MatchPrefix}},
+	{param: "name_contains", filter: entity.UserFilter{Field: entity.UserFieldName, Mode: entity.MatchContains}},
+	{param: "surname", filter: entity.UserFilter{Field: entity.UserFieldSurname, Mode: entity.MatchExact}},
+	{param: "surname_prefix", filter: entity.UserFilter{Field: entity.UserFieldSurname, Mode: entity.MatchPrefix}},
+	{param: "surname_contains", filter: entity.UserFilter{Field: entity.UserFieldSurname, Mode: entity.MatchContains}},
+}
+
+// parseUserQuery parses the query parameters of the request into an entity.UserQuery
+func parseUserQuery(c *fiber.Ctx) (entity.UserQuery, error) {
+	var query entity.UserQuery
+
+	var err error
+	if query.Limit, err = queryInt(c, "limit"); err != nil {
+		return entity.UserQuery{}, err
+	}
+	if query.Offset, err = queryInt(c, "offset"); err != nil {
+		return entity.UserQuery{}, err
+	}
+	query.Cursor = c.Query("cursor")
+
+	if sort := c.Query("sort"); sort != "" {
+		query.Direction = entity.SortAsc
+		if strings.HasPrefix(sort, sortDescPrefix) {
+			query.Direction = entity.SortDesc
+			sort = strings.TrimPrefix(sort, sortDescPrefix)
+		}
+		query.SortBy = entity.UserField(sort)
+	}
+
+	for _, p := range userFilterParams {
+		if value := c.Query(p.param); value != "" {
+			filter := p.filter
+			filter.Value = value
+			query.Filters = append(query.Filters, filter)
+		}
+	}
+
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		switch include {
+		case "":
+		case includeDeleted:
+			query.IncludeDeleted = true
+		default:
+			return entity.UserQuery{}, errors.Wrapf(domerrors.ErrInvalidUserQuery, "cannot include %q", include)
+		}
+	}
+
+	return query, nil
+}
+
+// queryInt returns the given integer query parameter, or 0 if it is not present
+func queryInt(c *fiber.Ctx, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrapf(domerrors.ErrInvalidUserQuery, "cannot parse %s", key)
+	}
+	return i, nil
+}
+
+// setPageHeaders sets the pagination headers of the given page of the normalized query:
+// the total count and the offset links of the offset pages, or the cursor links of the pages after a cursor,
+// and the cursor of the next page, if any, for both of them
+func setPageHeaders(c *fiber.Ctx, query entity.UserQuery, page entity.UserPage) {
+	if page.NextCursor != "" {
+		c.Set(HeaderNextCursor, page.NextCursor)
+	}
+
+	params, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	params.Set("limit", strconv.Itoa(query.Limit))
+	params.Del("cursor")
+	link := func(offset int, rel string) string {
+		params.Set("offset", strconv.Itoa(offset))
+		return fmt.Sprintf(`<%s%s?%s>; rel="%s"`, c.BaseURL(), c.Path(), params.Encode(), rel)
+	}
+
+	if query.Cursor != "" {
+		links := []string{link(0, "first")}
+		if page.NextCursor != "" {
+			params.Del("offset")
+			params.Set("cursor", page.NextCursor)
+			links = append(links, fmt.Sprintf(`<%s%s?%s>; rel="next"`, c.BaseURL(), c.Path(), params.Encode()))
+		}
+		c.Set(fiber.HeaderLink, strings.Join(links, ", "))
+		return
+	}
+
+	c.Set(HeaderTotalCount, strconv.FormatInt(page.Total, 10))
+
+	last := 0
+	if page.Total > 0 {
+		last = int((page.Total - 1) / int64(query.Limit) * int64(query.Limit))
+	}
+
+	links := []string{link(0, "first")}
+	if query.Offset > 0 {
+		links = append(links, link(max(query.Offset-query.Limit, 0), "prev"))
+	}
+	if int64(query.Offset+query.Limit) < page.Total {
+		links = append(links, link(query.Offset+query.Limit, "next"))
+	}
+	links = append(links, link(last, "last"))
+
+	c.Set(fiber.HeaderLink, strings.Join(links, ", "))
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+const (
+	// lastEventIDQuery is the query parameter of the ID of the last event received by a client reconnecting its socket
+	lastEventIDQuery = "last_event_id"
+	// MaxUserSocketSubscriptions is the maximum number of subscriptions of a socket
+	MaxUserSocketSubscriptions = 32
+	// userSocketMaxMessageSize is the maximum size, in bytes, of the messages sent by the clients
+	userSocketMaxMessageSize = 4096
+	// userSocketWriteWait is the time allowed to write a frame to a client before giving it up
+	userSocketWriteWait = 10 * time.Second
+	// userSocketRepliesSize is the number of replies to the messages of a client waiting to be written
+	userSocketRepliesSize = 16
+	// userSocketLastEventIDKey and userSocketExpiresAtKey are the keys of the fiber.Ctx locals carried to the socket
+	userSocketLastEventIDKey = "user_socket_last_event_id"
+	userSocketExpiresAtKey   = "user_socket_expires_at"
+)
+
+// types of the messages of the sockets of the events of the users
+const (
+	userSocketSubscribe    = "subscribe"
+	userSocketUnsubscribe  = "unsubscribe"
+	userSocketSubscribed   = "subscribed"
+	userSocketUnsubscribed = "unsubscribed"
+	userSocketEvent        = "event"
+	userSocketError        = "error"
+)
+
+var (
+	errUserSocketMessage              = errors.New("malformed message")
+	errUserSocketMessageType          = errors.New("unknown message type")
+	errUserSocketSubscriptionID       = errors.New("subscription id is required")
+	errUserSocketSubscriptionExists   = errors.New("subscription already exists")
+	errUserSocketSubscriptionNotFound = errors.New("subscription not found")
+	errUserSocketSubscriptionLimit    = errors.New("too many subscriptions")
+)
+
+// UserSocketAPI notifies the changes of the users to the clients subscribed through a WebSocket to the events of
+// some users, or of some types.
+type UserSocketAPI struct {
+	subscriber service.UserEventSubscriber
+	heartbeat  time.Duration
+	now        func() time.Time
+	upgrade    fiber.Handler
+}
+
+// UserSocketRequestDTO is a message sent by a client, subscribing to the events matching the given user IDs and
+// event types under the given ID, or cancelling the subscription of the given ID
+type UserSocketRequestDTO struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	UserIDs    []uint   `json:"user_ids,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// UserSocketMessageDTO is a message sent to a client, replying to one of its messages or notifying an event along
+// with the IDs of the subscriptions it matches
+type UserSocketMessageDTO struct {
+	Type          string        `json:"type"`
+	ID            string        `json:"id,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	Subscriptions []string      `json:"subscriptions,omitempty"`
+	Event         *UserEventDTO `json:"event,omitempty"`
+}
+
+// NewUserSocketAPI creates a new UserSocketAPI.
+func NewUserSocketAPI(subscriber service.UserEventSubscriber) *UserSocketAPI {
+	h := &UserSocketAPI{
+		subscriber: subscriber,
+		heartbeat:  DefaultUserEventsHeartbeat,
+		now:        time.Now,
+	}
+	h.upgrade = websocket.New(h.serve, websocket.Config{HandshakeTimeout: userSocketWriteWait})
+	return h
+}
+
+// Subscribe godoc
+// @summary Subscribe to the changes of the users through a WebSocket
+// @description Upgrade the connection to a WebSocket, on which the client sends subscribe messages
+// @description {"type":"subscribe","id":"...","user_ids":[...],"event_types":[...]} and unsubscribe messages
+// @description {"type":"unsubscribe","id":"..."}, and receives the events matching its subscriptions as messages
+// @description {"type":"event","subscriptions":[...],"event":{...}}. The access token can be given as access_token
+// @description by the clients which cannot set headers. A client reconnects from the last event it received, for
+// @description instance when it is disconnected with the code 1013 after falling behind, and the events after it are
+// @description replayed once the client first subscribes.
+// @tags users
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id SubscribeUserEvents
+// @param last_event_id query int false "ID of the last event received"
+// @param access_token query string false "Access token"
+// @Router /api/users/ws [get]
+// @response 101 "Switching Protocols"
+// @response 400 "Bad Request"
+// @response 401 "Unauthorized"
+// @response 426 "Upgrade Required"
+func (h *UserSocketAPI) Subscribe(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	var lastEventID uint64
+	if value := c.Query(lastEventIDQuery); value != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(value, 10, 0); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "cannot parse "+lastEventIDQuery)
+		}
+	}
+
+	// the user context is not carried to the socket, unlike the locals
+	principal, _ := entity.PrincipalFromContext(c.UserContext())
+	c.Locals(userSocketLastEventIDKey, uint(lastEventID))
+	c.Locals(userSocketExpiresAtKey, principal.ExpiresAt)
+
+	return h.upgrade(c)
+}
+
+// serve notifies the events matching the subscriptions of the client until it goes away, falls behind, or its
+// credentials expire. The messages of the client are read by another goroutine, while every frame is written by this
+// one, so that a slow client only holds up its own socket.
+func (h *UserSocketAPI) serve(conn *websocket.Conn) {
+	lastEventID, _ := conn.Locals(userSocketLastEventIDKey).(uint)
+	expiresAt, _ := conn.Locals(userSocketExpiresAtKey).(time.Time)
+
+	// subscribed to the broker once the client first subscribes, which the replayed events would not match before
+	var events <-chan entity.UserEvent
+	cancel := func() {}
+	defer func() { cancel() }()
+
+	subscriptions := newUserSocketSubscriptions()
+	replies := make(chan UserSocketMessageDTO, userSocketRepliesSize)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.read(conn, subscriptions, replies, quit)
+	}()
+	defer func() {
+		// the connection is released once serve returns, so the reader must be done with it
+		close(quit)
+		_ = conn.Close()
+		<-done
+	}()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	var expired <-chan time.Time
+	if !expiresAt.IsZero() {
+		timer := time.NewTimer(expiresAt.Sub(h.now()))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		var err error
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// the client fell behind or the server is shutting down, and the client reconnects
+				writeUserSocketClose(conn, websocket.CloseTryAgainLater, "reconnect from the last event received")
+				return
+			}
+			if ids := subscriptions.matching(event); len(ids) > 0 {
+				dto := toUserEventDTO(event)
+				err = writeUserSocketMessage(conn, UserSocketMessageDTO{Type: userSocketEvent, Subscriptions: ids, Event: &dto})
+			}
+		case reply := <-replies:
+			if reply.Type == userSocketSubscribed && events == nil {
+				events, cancel = h.subscriber.Subscribe(lastEventID)
+			}
+			err = writeUserSocketMessage(conn, reply)
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(userSocketWriteWait))
+		case <-expired:
+			writeUserSocketClose(conn, websocket.ClosePolicyViolation, "credentials expired")
+			return
+		case <-done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// read handles the messages of the client until it goes away or misses a heartbeat, sending the replies to the writer
+func (h *UserSocketAPI) read(conn *websocket.Conn, subscriptions *userSocketSubscriptions,
+	replies chan<- UserSocketMessageDTO, quit <-chan struct{}) {
+	readWait := 2 * h.heartbeat
+	conn.SetReadLimit(userSocketMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(readWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(readWait))
+
+		select {
+		case replies <- handleUserSocketMessage(subscriptions, data):
+		case <-quit:
+			return
+		}
+	}
+}
+
+// handleUserSocketMessage applies the given message of a client to its subscriptions and returns the reply
+func handleUserSocketMessage(subscriptions *userSocketSubscriptions, data []byte) UserSocketMessageDTO {
+	var request UserSocketRequestDTO
+	if err := json.Unmarshal(data, &request); err != nil {
+		return UserSocketMessageDTO{Type: userSocketError, Error: errUserSocketMessage.Error()}
+	}
+
+	var err error
+	reply := UserSocketMessageDTO{ID: request.ID}
+	switch {
+	case request.Type != userSocketSubscribe && request.Type != userSocketUnsubscribe:
+		err = errUserSocketMessageType
+	case request.ID == "":
+		err = errUserSocketSubscriptionID
+	case request.Type == userSocketSubscribe:
+		filter := entity.UserEventFilter{UserIDs: request.UserIDs}
+		for _, eventType := range request.EventTypes {
+			filter.EventTypes = append(filter.EventTypes, entity.UserEventType(eventType))
+		}
+		err = subscriptions.add(request.ID, filter)
+		reply.Type = userSocketSubscribed
+	default:
+		err = subscriptions.remove(request.ID)
+		reply.Type = userSocketUnsubscribed
+	}
+	if err != nil {
+		return UserSocketMessageDTO{Type: userSocketError, ID: request.ID, Error: err.Error()}
+	}
+	return reply
+}
+
+// writeUserSocketMessage writes the given message to the client as a JSON text frame
+func writeUserSocketMessage(conn *websocket.Conn, message UserSocketMessageDTO) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(userSocketWriteWait)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(message)
+}
+
+// writeUserSocketClose tells the client why its socket is closed, ignoring the failures of a client already gone
+func writeUserSocketClose(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(userSocketWriteWait))
+}
+
+// userSocketSubscriptions are the filters of the events subscribed by a client, by the ID of their subscription
+type userSocketSubscriptions struct {
+	mu      sync.Mutex
+	filters map[string]entity.UserEventFilter
+}
+
+// newUserSocketSubscriptions creates the subscriptions of a client, which has none yet
+func newUserSocketSubscriptions() *userSocketSubscriptions {
+	return &userSocketSubscriptions{filters: make(map[string]entity.UserEventFilter)}
+}
+
+// add subscribes to the events matching the given filter under the given ID
+func (s *userSocketSubscriptions) add(id string, filter entity.UserEventFilter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.filters[id]; ok {
+		return errUserSocketSubscriptionExists
+	}
+	if len(s.filters) >= MaxUserSocketSubscriptions {
+		return errUserSocketSubscriptionLimit
+	}
+	s.filters[id] = filter
+	return nil
+}
+
+// remove cancels the subscription of the given ID
+func (s *userSocketSubscriptions) remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.filters[id]; !ok {
+		return errUserSocketSubscriptionNotFound
+	}
+	delete(s.filters, id)
+	return nil
+}
+
+// matching returns the sorted IDs of the subscriptions whose filter matches the given event
+func (s *userSocketSubscriptions) matching(event entity.UserEvent) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, filter := range s.filters {
+		if filter.Matches(event) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/event"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	UserSocketEndpoint = "/api/users/ws"
+)
+
+// serveUserSocket serves the sockets of the given API to the callers of the given principal, and returns their URL
+func serveUserSocket(t *testing.T, api *UserSocketAPI, principal entity.Principal) string {
+	a := testutils.App()
+	a.Get(UserSocketEndpoint, func(c *fiber.Ctx) error {
+		c.SetUserContext(entity.ContextWithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}, api.Subscribe)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = a.Listener(ln) }()
+	t.Cleanup(func() { testutils.Shutdown(a) })
+
+	return "ws://" + ln.Addr().String() + UserSocketEndpoint
+}
+
+// dialUserSocket opens a socket to the given URL, closed at the end of the test
+func dialUserSocket(t *testing.T, url string) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+// exchange sends the given message on the socket and returns the reply
+func exchange(t *testing.T, conn *websocket.Conn, message string) UserSocketMessageDTO {
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+	return receive(t, conn)
+}
+
+// receive returns the next message of the socket
+func receive(t *testing.T, conn *websocket.Conn) UserSocketMessageDTO {
+	var message UserSocketMessageDTO
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestUserSocketAPI_Subscribe(t *testing.T) {
+	occurredAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	newEvent := func(id uint, eventType entity.UserEventType, userID uint) entity.UserEvent {
+		return entity.UserEvent{ID: id, Type: eventType, UserID: userID, OccurredAt: occurredAt, User: entity.User{ID: userID}}
+	}
+
+	tests := []struct {
+		name string
+		then func(t *testing.T, broker *event.Broker, conn *websocket.Conn)
+	}{
+		{
+			name: "should notify the events matching the subscriptions",
+			then: func(t *testing.T, broker *event.Broker, conn *websocket.Conn) {
+				assert.Equal(t, UserSocketMessageDTO{Type: "subscribed", ID: "john"},
+					exchange(t, conn, `{"type":"subscribe","id":"john","user_ids":[1]}`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "subscribed", ID: "deletions"},
+					exchange(t, conn, `{"type":"subscribe","id":"deletions","event_types":["user.deleted"]}`))
+
+				_ = broker.Publish(context.Background(), newEvent(1, entity.UserModified, 2))
+				_ = broker.Publish(context.Background(), newEvent(2, entity.UserModified, 1))
+				_ = broker.Publish(context.Background(), newEvent(3, entity.UserDeleted, 1))
+
+				dto := toUserEventDTO(newEvent(2, entity.UserModified, 1))
+				assert.Equal(t, UserSocketMessageDTO{Type: "event", Subscriptions: []string{"john"}, Event: &dto}, receive(t, conn))
+				dto = toUserEventDTO(newEvent(3, entity.UserDeleted, 1))
+				assert.Equal(t, UserSocketMessageDTO{Type: "event", Subscriptions: []string{"deletions", "john"}, Event: &dto}, receive(t, conn))
+
+				assert.Equal(t, UserSocketMessageDTO{Type: "unsubscribed", ID: "john"},
+					exchange(t, conn, `{"type":"unsubscribe","id":"john"}`))
+				_ = broker.Publish(context.Background(), newEvent(4, entity.UserModified, 1))
+				_ = broker.Publish(context.Background(), newEvent(5, entity.UserDeleted, 2))
+
+				dto = toUserEventDTO(newEvent(5, entity.UserDeleted, 2))
+				assert.Equal(t, UserSocketMessageDTO{Type: "event", Subscriptions: []string{"deletions"}, Event: &dto}, receive(t, conn))
+			},
+		},
+		{
+			name: "should reject the invalid messages",
+			then: func(t *testing.T, broker *event.Broker, conn *websocket.Conn) {
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", Error: "malformed message"},
+					exchange(t, conn, `subscribe`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", ID: "john", Error: "unknown message type"},
+					exchange(t, conn, `{"type":"publish","id":"john"}`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", Error: "subscription id is required"},
+					exchange(t, conn, `{"type":"subscribe"}`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", ID: "john",
+					Error: "invalid user event filter: user_ids[0] must be positive, event_types[0] must be one of " +
+						"[user.created user.modified user.deleted user.restored user.purged]"},
+					exchange(t, conn, `{"type":"subscribe","id":"john","user_ids":[0],"event_types":["user.renamed"]}`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", ID: "john", Error: "subscription not found"},
+					exchange(t, conn, `{"type":"unsubscribe","id":"john"}`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "subscribed", ID: "john"},
+					exchange(t, conn, `{"type":"subscribe","id":"john"}`))
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", ID: "john", Error: "subscription already exists"},
+					exchange(t, conn, `{"type":"subscribe","id":"john"}`))
+			},
+		},
+		{
+			name: "should limit the number of subscriptions",
+			then: func(t *testing.T, broker *event.Broker, conn *websocket.Conn) {
+				for i := 0; i < MaxUserSocketSubscriptions; i++ {
+					assert.Equal(t, "subscribed", exchange(t, conn, `{"type":"subscribe","id":"`+strconv.Itoa(i)+`"}`).Type)
+				}
+				assert.Equal(t, UserSocketMessageDTO{Type: "error", ID: "too-many", Error: "too many subscriptions"},
+					exchange(t, conn, `{"type":"subscribe","id":"too-many"}`))
+			},
+		},
+		{
+			name: "should close the socket of a client to reconnect once the broker closes its subscription",
+			then: func(t *testing.T, broker *event.Broker, conn *websocket.Conn) {
+				assert.Equal(t, "subscribed", exchange(t, conn, `{"type":"subscribe","id":"all"}`).Type)
+
+				broker.Close()
+
+				_, _, err := conn.ReadMessage()
+				assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			broker := event.NewBroker(10, 10)
+			url := serveUserSocket(t, NewUserSocketAPI(broker), entity.Principal{Subject: "1", UserID: 1})
+
+			// When
+			conn := dialUserSocket(t, url)
+
+			// Then
+			tt.then(t, broker, conn)
+		})
+	}
+}
+
+func TestUserSocketAPI_Subscribe_Replay(t *testing.T) {
+	// Given
+	broker := event.NewBroker(10, 10)
+	for id := uint(1); id <= 3; id++ {
+		_ = broker.Publish(context.Background(), entity.UserEvent{ID: id, Type: entity.UserModified, UserID: id})
+	}
+	url := serveUserSocket(t, NewUserSocketAPI(broker), entity.Principal{Subject: "1", UserID: 1})
+
+	// When
+	conn := dialUserSocket(t, url+"?last_event_id=1")
+
+	// Then
+	assert.Equal(t, "subscribed", exchange(t, conn, `{"type":"subscribe","id":"all"}`).Type)
+	assert.Equal(t, uint(2), receive(t, conn).Event.ID)
+	assert.Equal(t, uint(3), receive(t, conn).Event.ID)
+}
+
+func TestUserSocketAPI_Subscribe_Heartbeat(t *testing.T) {
+	// Given
+	api := NewUserSocketAPI(event.NewBroker(10, 10))
+	api.heartbeat = 10 * time.Millisecond
+	url := serveUserSocket(t, api, entity.Principal{Subject: "1", UserID: 1})
+	conn := dialUserSocket(t, url)
+	pings := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	// When
+	go func() { _, _, _ = conn.ReadMessage() }()
+
+	// Then
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		assert.Fail(t, "no heartbeat")
+	}
+}
+
+func TestUserSocketAPI_Subscribe_Expiry(t *testing.T) {
+	// Given
+	url := serveUserSocket(t, NewUserSocketAPI(event.NewBroker(10, 10)),
+		entity.Principal{Subject: "1", UserID: 1, ExpiresAt: time.Now().Add(50 * time.Millisecond)})
+
+	// When
+	conn := dialUserSocket(t, url)
+
+	// Then
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+}
+
+func TestUserSocketAPI_Subscribe_Upgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		upgrade bool
+		status  int
+	}{
+		{name: "should require a WebSocket upgrade", status: http.StatusUpgradeRequired},
+		{name: "should reject an invalid last event", query: "?last_event_id=last", upgrade: true, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Get(UserSocketEndpoint, NewUserSocketAPI(event.NewMockUserEventSubscriber()).Subscribe)
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, UserSocketEndpoint+tt.query, nil)
+			if tt.upgrade {
+				req.Header.Set(fiber.HeaderConnection, "Upgrade")
+				req.Header.Set(fiber.HeaderUpgrade, "websocket")
+			}
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	json "github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/problem"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
 	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
@@ -22,6 +25,8 @@ const (
 )
 
 func TestUserAPI_FindAll(t *testing.T) {
+	page := entity.UserPage{Users: []entity.User{{ID: 1, Name: "John", Surname: "Doe", Version: 2}, {ID: 2, Name: "Jane", Surname: "Doe", Version: 1}}, Total: 2}
+
 	tests := []struct {
 		name  string
 		given func() *fiber.App
@@ -35,16 +40,22 @@ func TestUserAPI_FindAll(t *testing.T) {
 				c := testutils.AcquireFiberCtx(a)
 
 				mockUserFinderAll := usecase.NewMockUserFinderAll()
-				mockUserFinderAll.On("Find", c.UserContext()).Return([]entity.User{
-					{ID: 1, Name: "John", Surname: "Doe"},
-					{ID: 2, Name: "Jane", Surname: "Doe"},
-					{ID: 3, Name: "Alice", Surname: "Smith"},
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{}).Return(entity.UserPage{
+					Users: []entity.User{
+						{ID: 1, Name: "John", Surname: "Doe"},
+						{ID: 2, Name: "Jane", Surname: "Doe"},
+						{ID: 3, Name: "Alice", Surname: "Smith"},
+					},
+					Total: 3,
 				}, nil)
 				api := NewUserAPI(
 					mockUserFinderAll,
 					nil,
 					nil,
 					nil,
+					nil,
+					nil,
+					nil,
 					nil)
 
 				a.Get(ApiUsersEndpoint, api.FindAll)
@@ -72,81 +83,51 @@ func TestUserAPI_FindAll(t *testing.T) {
 				assert.Equal(t, "Doe", userResponses[1].Surname)
 				assert.Equal(t, "Alice", userResponses[2].Name)
 				assert.Equal(t, "Smith", userResponses[2].Surname)
+				assert.Equal(t, "3", resp.Header.Get(HeaderTotalCount))
+				assert.Equal(t,
+					`<http://example.com/api/users?limit=20&offset=0>; rel="first", `+
+						`<http://example.com/api/users?limit=20&offset=0>; rel="last"`,
+					resp.Header.Get(fiber.HeaderLink))
 
 				err = resp.Body.Close()
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "should not find users",
+			name: "should find a page of users",
 			given: func() *fiber.App {
 				a := testutils.App()
 				c := testutils.AcquireFiberCtx(a)
 
 				mockUserFinderAll := usecase.NewMockUserFinderAll()
-				mockUserFinderAll.On("Find", c.UserContext()).Return([]entity.User{}, errors.New("not found"))
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{
+					Limit:     2,
+					Offset:    2,
+					SortBy:    entity.UserFieldName,
+					Direction: entity.SortDesc,
+					Filters: []entity.UserFilter{
+						{Field: entity.UserFieldName, Mode: entity.MatchPrefix, Value: "J"},
+						{Field: entity.UserFieldSurname, Mode: entity.MatchExact, Value: "Doe"},
+					},
+				}).Return(entity.UserPage{
+					Users: []entity.User{{ID: 5, Name: "Jim", Surname: "Doe"}, {ID: 4, Name: "Jill", Surname: "Doe"}},
+					Total: 7,
+				}, nil)
 				api := NewUserAPI(
 					mockUserFinderAll,
 					nil,
 					nil,
 					nil,
-					nil)
-
-				a.Get(ApiUsersEndpoint, api.FindAll)
-				return a
-			},
-			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint, nil)
-				return a.Test(req, -1)
-			},
-			then: func(t *testing.T, resp *http.Response, err error) {
-				assert.NoError(t, err)
-				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
-			},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Given
-			a := tt.given()
-
-			// When
-			resp, err := tt.when(a)
-
-			// Then
-			tt.then(t, resp, err)
-		})
-	}
-}
-
-func TestUserAPI_FindByID(t *testing.T) {
-	tests := []struct {
-		name  string
-		given func() *fiber.App
-		when  func(a *fiber.App) (*http.Response, error)
-		then  func(t *testing.T, resp *http.Response, err error)
-	}{
-		{
-			name: "should find user by ID",
-			given: func() *fiber.App {
-				a := testutils.App()
-				c := testutils.AcquireFiberCtx(a)
-
-				mockUserFinderByID := usecase.NewMockUserFinderByID()
-				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
-				api := NewUserAPI(
 					nil,
-					mockUserFinderByID,
 					nil,
 					nil,
 					nil)
 
-				a.Get("/api/users/:id", api.FindByID)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1", nil)
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?limit=2&offset=2&sort=-name&name_prefix=J&surname=Doe", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
@@ -156,158 +137,142 @@ func TestUserAPI_FindByID(t *testing.T) {
 				body, err := io.ReadAll(resp.Body)
 				assert.NoError(t, err)
 
-				var userResponses UserDTO
+				var userResponses []UserDTO
 				err = json.Unmarshal(body, &userResponses)
 				assert.NoError(t, err)
-
-				assert.Equal(t, uint(1), userResponses.ID)
-				assert.Equal(t, "John", userResponses.Name)
-				assert.Equal(t, "Doe", userResponses.Surname)
-
-				err = resp.Body.Close()
-				assert.NoError(t, err)
+				assert.Equal(t, []UserDTO{{ID: 5, Name: "Jim", Surname: "Doe"}, {ID: 4, Name: "Jill", Surname: "Doe"}}, userResponses)
+
+				assert.Equal(t, "7", resp.Header.Get(HeaderTotalCount))
+				link := func(offset, rel string) string {
+					return `<http://example.com/api/users?limit=2&name_prefix=J&offset=` + offset +
+						`&sort=-name&surname=Doe>; rel="` + rel + `"`
+				}
+				assert.Equal(t,
+					link("0", "first")+", "+link("0", "prev")+", "+link("4", "next")+", "+link("6", "last"),
+					resp.Header.Get(fiber.HeaderLink))
 			},
 		},
 		{
-			name: "should not find user by ID",
+			name: "should find the page after a cursor",
 			given: func() *fiber.App {
 				a := testutils.App()
 				c := testutils.AcquireFiberCtx(a)
 
-				mockUserFinderByID := usecase.NewMockUserFinderByID()
-				mockUserFinderByID.On("Find", c.UserContext(), uint(999)).Return(entity.User{}, errors.New("not found"))
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{Limit: 2, SortBy: entity.UserFieldName, Direction: entity.SortAsc, Cursor: "abc.def"}).
+					Return(entity.UserPage{
+						Users:      []entity.User{{ID: 2, Name: "Jane", Surname: "Doe"}, {ID: 1, Name: "John", Surname: "Doe"}},
+						NextCursor: "ghi.jkl",
+					}, nil)
 				api := NewUserAPI(
+					mockUserFinderAll,
+					nil,
+					nil,
+					nil,
 					nil,
-					mockUserFinderByID,
 					nil,
 					nil,
 					nil)
 
-				a.Get("/api/users/:id", api.FindByID)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/999", nil)
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?limit=2&sort=name&cursor=abc.def", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
 				assert.NoError(t, err)
-				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Empty(t, resp.Header.Get(HeaderTotalCount))
+				assert.Equal(t, "ghi.jkl", resp.Header.Get(HeaderNextCursor))
+				assert.Equal(t,
+					`<http://example.com/api/users?limit=2&offset=0&sort=name>; rel="first", `+
+						`<http://example.com/api/users?cursor=ghi.jkl&limit=2&sort=name>; rel="next"`,
+					resp.Header.Get(fiber.HeaderLink))
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Given
-			a := tt.given()
-
-			// When
-			resp, err := tt.when(a)
-
-			// Then
-			tt.then(t, resp, err)
-		})
-	}
-}
-
-func TestUserAPI_Create(t *testing.T) {
-	tests := []struct {
-		name  string
-		given func() *fiber.App
-		when  func(a *fiber.App) (*http.Response, error)
-		then  func(t *testing.T, resp *http.Response, err error)
-	}{
 		{
-			name: "should create a new user",
+			name: "should not find users after an invalid cursor",
 			given: func() *fiber.App {
 				a := testutils.App()
 				c := testutils.AcquireFiberCtx(a)
 
-				mockUserCreator := usecase.NewMockUserCreator()
-				mockUserCreator.On("Create", c.UserContext(), entity.User{Name: "John", Surname: "Doe"}).
-					Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{Cursor: "forged"}).
+					Return(entity.UserPage{}, domerrors.ErrInvalidCursor)
 				api := NewUserAPI(
+					mockUserFinderAll,
+					nil,
+					nil,
+					nil,
 					nil,
 					nil,
-					mockUserCreator,
 					nil,
 					nil)
 
-				a.Post(ApiUsersEndpoint, api.Create)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"name": "John", "surname": "Doe"}`))
-				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?cursor=forged", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
 				assert.NoError(t, err)
-				assert.Equal(t, http.StatusCreated, resp.StatusCode)
-
-				body, err := io.ReadAll(resp.Body)
-				assert.NoError(t, err)
-
-				var userResponses UserDTO
-				err = json.Unmarshal(body, &userResponses)
-				assert.NoError(t, err)
-
-				assert.Equal(t, uint(1), userResponses.ID)
-				assert.Equal(t, "John", userResponses.Name)
-				assert.Equal(t, "Doe", userResponses.Surname)
-
-				err = resp.Body.Close()
-				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 			},
 		},
 		{
-			name: "should not create a new user",
+			name: "should not find users with an invalid limit",
 			given: func() *fiber.App {
 				a := testutils.App()
-				c := testutils.AcquireFiberCtx(a)
-
-				mockUserCreator := usecase.NewMockUserCreator()
-				mockUserCreator.On("Create", c.UserContext(), entity.User{Name: "John", Surname: "Doe"}).
-					Return(entity.User{}, errors.New("error creating user"))
 				api := NewUserAPI(
+					usecase.NewMockUserFinderAll(),
+					nil,
+					nil,
+					nil,
 					nil,
 					nil,
-					mockUserCreator,
 					nil,
 					nil)
 
-				a.Post(ApiUsersEndpoint, api.Create)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"name": "John", "surname": "Doe"}`))
-				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?limit=ten", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
 				assert.NoError(t, err)
-				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 			},
 		},
 		{
-			name: "should not create a new user with invalid data",
+			name: "should not find users with an invalid query",
 			given: func() *fiber.App {
 				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
 
-				mockUserCreator := usecase.NewMockUserCreator()
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{SortBy: "password", Direction: entity.SortAsc}).
+					Return(entity.UserPage{}, domerrors.ErrInvalidUserQuery)
 				api := NewUserAPI(
+					mockUserFinderAll,
+					nil,
+					nil,
+					nil,
 					nil,
 					nil,
-					mockUserCreator,
 					nil,
 					nil)
 
-				a.Post(ApiUsersEndpoint, api.Create)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"other": "message"}`))
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?sort=password", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
@@ -315,50 +280,35 @@ func TestUserAPI_Create(t *testing.T) {
 				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Given
-			a := tt.given()
-
-			// When
-			resp, err := tt.when(a)
-
-			// Then
-			tt.then(t, resp, err)
-		})
-	}
-}
-
-func TestUserAPI_Modify(t *testing.T) {
-	tests := []struct {
-		name  string
-		given func() *fiber.App
-		when  func(a *fiber.App) (*http.Response, error)
-		then  func(t *testing.T, resp *http.Response, err error)
-	}{
 		{
-			name: "should modify a user",
+			name: "should find all users including the deleted ones",
 			given: func() *fiber.App {
 				a := testutils.App()
 				c := testutils.AcquireFiberCtx(a)
 
-				mockUserModifier := usecase.NewMockUserModifier()
-				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John Modified", Surname: "Doe Modified"}).
-					Return(entity.User{ID: 1, Name: "John Modified", Surname: "Doe Modified"}, nil)
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{IncludeDeleted: true}).Return(entity.UserPage{
+					Users: []entity.User{
+						{ID: 1, Name: "John", Surname: "Doe"},
+						{ID: 2, Name: "Jane", Surname: "Doe", DeletedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+					},
+					Total: 2,
+				}, nil)
 				api := NewUserAPI(
+					mockUserFinderAll,
+					nil,
+					nil,
+					nil,
 					nil,
 					nil,
 					nil,
-					mockUserModifier,
 					nil)
 
-				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/:id", strings.NewReader(`{"id": 1, "name": "John Modified", "surname": "Doe Modified"}`))
-				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?include=deleted", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
@@ -367,95 +317,1259 @@ func TestUserAPI_Modify(t *testing.T) {
 
 				body, err := io.ReadAll(resp.Body)
 				assert.NoError(t, err)
-
-				var userResponses UserDTO
-				err = json.Unmarshal(body, &userResponses)
-				assert.NoError(t, err)
-
-				assert.Equal(t, uint(1), userResponses.ID)
-				assert.Equal(t, "John Modified", userResponses.Name)
-				assert.Equal(t, "Doe Modified", userResponses.Surname)
-
-				err = resp.Body.Close()
-				assert.NoError(t, err)
+				assert.JSONEq(t, `[
+					{"id": 1, "name": "John", "surname": "Doe"},
+					{"id": 2, "name": "Jane", "surname": "Doe", "deleted_at": "2024-05-01T12:00:00Z"}
+				]`, string(body))
+				assert.Contains(t, resp.Header.Get(fiber.HeaderLink), "include=deleted")
 			},
 		},
 		{
-			name: "should not modify a user",
+			name: "should not find users including something else than the deleted ones",
 			given: func() *fiber.App {
 				a := testutils.App()
-				c := testutils.AcquireFiberCtx(a)
-
-				mockUserModifier := usecase.NewMockUserModifier()
-				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John Modified", Surname: "Doe Modified"}).
-					Return(entity.User{}, errors.New("error modifying user"))
 				api := NewUserAPI(
+					usecase.NewMockUserFinderAll(),
+					nil,
+					nil,
+					nil,
 					nil,
 					nil,
 					nil,
-					mockUserModifier,
 					nil)
 
-				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				a.Get(ApiUsersEndpoint, api.FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/:id", strings.NewReader(`{"id": 1, "name": "John Modified", "surname": "Doe Modified"}`))
-				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"?include=credentials", nil)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
 				assert.NoError(t, err)
-				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 			},
 		},
 		{
-			name: "should not modify a user with invalid data",
+			name: "should not answer a page whose ETag is the one of If-None-Match",
 			given: func() *fiber.App {
 				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
 
-				mockUserModifier := usecase.NewMockUserModifier()
-				api := NewUserAPI(
-					nil,
-					nil,
-					nil,
-					mockUserModifier,
-					nil)
-
-				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{}).Return(page, nil)
+				a.Get(ApiUsersEndpoint, NewUserAPI(mockUserFinderAll, nil, nil, nil, nil, nil, nil, nil).FindAll)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
-				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/:id", strings.NewReader(`{"other": "message"}`))
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint, nil)
+				req.Header.Set(fiber.HeaderIfNoneMatch, pageETag(page))
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
 				assert.NoError(t, err)
-				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+				assert.Equal(t, pageETag(page), resp.Header.Get(fiber.HeaderETag))
+				assert.Equal(t, "2", resp.Header.Get(HeaderTotalCount))
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Given
-			a := tt.given()
-
-			// When
-			resp, err := tt.when(a)
-
-			// Then
-			tt.then(t, resp, err)
-		})
-	}
-}
-
-func TestUserAPI_Delete(t *testing.T) {
-	tests := []struct {
-		name  string
-		given func() *fiber.App
-		when  func(a *fiber.App) (*http.Response, error)
-		then  func(t *testing.T, resp *http.Response, err error)
-	}{
+		{
+			name: "should answer a page whose ETag changed since If-None-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				modified := entity.UserPage{Users: []entity.User{{ID: 1, Name: "John", Surname: "Smith", Version: 3}, page.Users[1]}, Total: 2}
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{}).Return(modified, nil)
+				a.Get(ApiUsersEndpoint, NewUserAPI(mockUserFinderAll, nil, nil, nil, nil, nil, nil, nil).FindAll)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint, nil)
+				req.Header.Set(fiber.HeaderIfNoneMatch, pageETag(page))
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.NotEqual(t, pageETag(page), resp.Header.Get(fiber.HeaderETag))
+			},
+		},
+		{
+			name: "should fail finding users",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderAll := usecase.NewMockUserFinderAll()
+				mockUserFinderAll.On("Find", c.UserContext(), entity.UserQuery{}).Return(entity.UserPage{}, errors.New("connection lost"))
+				api := NewUserAPI(
+					mockUserFinderAll,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Get(ApiUsersEndpoint, api.FindAll)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint, nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+				var response problem.Problem
+				assert.NoError(t, json.ConfigDefault.NewDecoder(resp.Body).Decode(&response))
+				assert.Equal(t, problem.TypeBlank, response.Type)
+				assert.Empty(t, response.Detail)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestUserAPI_Search(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		given  func(c *fiber.Ctx) *usecase.MockUserSearcher
+		then   func(t *testing.T, resp *http.Response)
+	}{
+		{
+			name:   "should search users",
+			target: ApiUsersEndpoint + "/search?q=Jos%C3%A9%20doe&limit=5",
+			given: func(c *fiber.Ctx) *usecase.MockUserSearcher {
+				m := usecase.NewMockUserSearcher()
+				m.On("Search", c.UserContext(), "José doe", 5).
+					Return([]entity.User{{ID: 4, Name: "José", Surname: "Doe"}}, nil)
+				return m
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var userResponses []UserDTO
+				err = json.Unmarshal(body, &userResponses)
+				assert.NoError(t, err)
+				assert.Equal(t, []UserDTO{{ID: 4, Name: "José", Surname: "Doe"}}, userResponses)
+			},
+		},
+		{
+			name:   "should not search users without query",
+			target: ApiUsersEndpoint + "/search",
+			given: func(c *fiber.Ctx) *usecase.MockUserSearcher {
+				m := usecase.NewMockUserSearcher()
+				m.On("Search", c.UserContext(), "", 0).
+					Return([]entity.User(nil), errors.Wrap(domerrors.ErrInvalidUserQuery, "search query is required"))
+				return m
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name:   "should not search users with an invalid limit",
+			target: ApiUsersEndpoint + "/search?q=doe&limit=ten",
+			given: func(c *fiber.Ctx) *usecase.MockUserSearcher {
+				return usecase.NewMockUserSearcher()
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name:   "should fail searching users",
+			target: ApiUsersEndpoint + "/search?q=doe",
+			given: func(c *fiber.Ctx) *usecase.MockUserSearcher {
+				m := usecase.NewMockUserSearcher()
+				m.On("Search", c.UserContext(), "doe", 0).Return([]entity.User(nil), errors.New("error"))
+				return m
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			c := testutils.AcquireFiberCtx(a)
+			mockUserSearcher := tt.given(c)
+			a.Get(ApiUsersEndpoint+"/search", NewUserAPI(nil, mockUserSearcher, nil, nil, nil, nil, nil, nil).Search)
+
+			// When
+			resp, err := a.Test(httptest.NewRequest(http.MethodGet, tt.target, nil), -1)
+
+			// Then
+			assert.NoError(t, err)
+			tt.then(t, resp)
+			mockUserSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserAPI_FindByID(t *testing.T) {
+	updatedAt := time.Date(2024, time.March, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should find user by ID",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}, nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					mockUserFinderByID,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Get("/api/users/:id", api.FindByID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var userResponses UserDTO
+				err = json.Unmarshal(body, &userResponses)
+				assert.NoError(t, err)
+
+				assert.Equal(t, uint(1), userResponses.ID)
+				assert.Equal(t, "John", userResponses.Name)
+				assert.Equal(t, "Doe", userResponses.Surname)
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should not answer a user whose ETag is the one of If-None-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3, UpdatedAt: updatedAt}, nil)
+				a.Get("/api/users/:id", NewUserAPI(nil, nil, mockUserFinderByID, nil, nil, nil, nil, nil).FindByID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1", nil)
+				req.Header.Set(fiber.HeaderIfNoneMatch, `W/"2", "3"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+				assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", resp.Header.Get(fiber.HeaderLastModified))
+			},
+		},
+		{
+			name: "should answer a user whose ETag is not the one of If-None-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3, UpdatedAt: updatedAt}, nil)
+				a.Get("/api/users/:id", NewUserAPI(nil, nil, mockUserFinderByID, nil, nil, nil, nil, nil).FindByID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1", nil)
+				req.Header.Set(fiber.HeaderIfNoneMatch, `"2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+				assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", resp.Header.Get(fiber.HeaderLastModified))
+			},
+		},
+		{
+			name: "should not answer a user not modified since If-Modified-Since",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3, UpdatedAt: updatedAt}, nil)
+				a.Get("/api/users/:id", NewUserAPI(nil, nil, mockUserFinderByID, nil, nil, nil, nil, nil).FindByID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1", nil)
+				req.Header.Set(fiber.HeaderIfModifiedSince, "Fri, 01 Mar 2024 12:00:00 GMT")
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+				assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", resp.Header.Get(fiber.HeaderLastModified))
+			},
+		},
+		{
+			name: "should answer a user modified since If-Modified-Since",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3, UpdatedAt: updatedAt}, nil)
+				a.Get("/api/users/:id", NewUserAPI(nil, nil, mockUserFinderByID, nil, nil, nil, nil, nil).FindByID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/1", nil)
+				req.Header.Set(fiber.HeaderIfModifiedSince, "Fri, 01 Mar 2024 11:59:59 GMT")
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+				assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", resp.Header.Get(fiber.HeaderLastModified))
+			},
+		},
+		{
+			name: "should not find user by ID",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(999)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				api := NewUserAPI(
+					nil,
+					nil,
+					mockUserFinderByID,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Get("/api/users/:id", api.FindByID)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodGet, ApiUsersEndpoint+"/999", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+				var response problem.Problem
+				assert.NoError(t, json.ConfigDefault.NewDecoder(resp.Body).Decode(&response))
+				assert.Equal(t, problem.TypePrefix+"user-not-found", response.Type)
+				assert.Equal(t, ApiUsersEndpoint+"/999", response.Instance)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestUserAPI_Create(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should create a new user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserCreator := usecase.NewMockUserCreator()
+				mockUserCreator.On("Create", c.UserContext(), entity.User{Name: "John", Surname: "Doe"}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					mockUserCreator,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Post(ApiUsersEndpoint, api.Create)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var userResponses UserDTO
+				err = json.Unmarshal(body, &userResponses)
+				assert.NoError(t, err)
+
+				assert.Equal(t, uint(1), userResponses.ID)
+				assert.Equal(t, "John", userResponses.Name)
+				assert.Equal(t, "Doe", userResponses.Surname)
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should not create an invalid user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserCreator := usecase.NewMockUserCreator()
+				mockUserCreator.On("Create", c.UserContext(), entity.User{Name: "", Surname: "Doe2"}).
+					Return(entity.User{}, &domerrors.ValidationError{Err: domerrors.ErrInvalidUser, Violations: []domerrors.FieldViolation{
+						{Field: "name", Message: "is required"},
+						{Field: "surname", Message: "must only contain letters"},
+					}})
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					mockUserCreator,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Post(ApiUsersEndpoint, api.Create)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"name": "", "surname": "Doe2"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				assert.Equal(t, problem.MIMEApplicationProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+
+				var response problem.Problem
+				err = json.Unmarshal(body, &response)
+				assert.NoError(t, err)
+
+				assert.Equal(t, problem.Problem{
+					Type:     problem.TypePrefix + "invalid-user",
+					Title:    "Invalid user",
+					Status:   http.StatusUnprocessableEntity,
+					Detail:   "invalid user: name is required, surname must only contain letters",
+					Instance: ApiUsersEndpoint,
+					Errors: []problem.FieldError{
+						{Field: "name", Message: "is required"},
+						{Field: "surname", Message: "must only contain letters"},
+					},
+				}, response)
+			},
+		},
+		{
+			name: "should not create a new user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserCreator := usecase.NewMockUserCreator()
+				mockUserCreator.On("Create", c.UserContext(), entity.User{Name: "John", Surname: "Doe"}).
+					Return(entity.User{}, errors.New("error creating user"))
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					mockUserCreator,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Post(ApiUsersEndpoint, api.Create)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not create a new user with roles when the caller is not an admin",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserCreator := usecase.NewMockUserCreator()
+				mockUserCreator.On("Create", c.UserContext(), entity.User{Name: "John", Surname: "Doe", Roles: []string{"admin"}}).
+					Return(entity.User{}, domerrors.ErrForbidden)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					mockUserCreator,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Post(ApiUsersEndpoint, api.Create)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"name": "John", "surname": "Doe", "roles": ["admin"]}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not create a new user with invalid data",
+			given: func() *fiber.App {
+				a := testutils.App()
+
+				mockUserCreator := usecase.NewMockUserCreator()
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					mockUserCreator,
+					nil,
+					nil,
+					nil,
+					nil)
+
+				a.Post(ApiUsersEndpoint, api.Create)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint, strings.NewReader(`{"other": "message"}`))
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestUserAPI_Modify(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should modify a user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John Modified", Surname: "Doe Modified"}).
+					Return(entity.User{ID: 1, Name: "John Modified", Surname: "Doe Modified"}, nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"id": 1, "name": "John Modified", "surname": "Doe Modified"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+
+				var userResponses UserDTO
+				err = json.Unmarshal(body, &userResponses)
+				assert.NoError(t, err)
+
+				assert.Equal(t, uint(1), userResponses.ID)
+				assert.Equal(t, "John Modified", userResponses.Name)
+				assert.Equal(t, "Doe Modified", userResponses.Surname)
+
+				err = resp.Body.Close()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should modify the version of a user given by If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}, nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+			},
+		},
+		{
+			name: "should not modify a user modified concurrently",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}).
+					Return(entity.User{}, domerrors.ErrConcurrentModification)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+				var p problem.Problem
+				assert.NoError(t, json.ConfigDefault.NewDecoder(resp.Body).Decode(&p))
+				assert.Equal(t, problem.TypePrefix+"concurrent-modification", p.Type)
+			},
+		},
+		{
+			name: "should not modify a missing user given If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).
+					Return(entity.User{}, domerrors.ErrUserNotFound)
+				a.Put(ApiUsersEndpoint+"/:id", NewUserAPI(nil, nil, nil, nil, mockUserModifier, nil, nil, nil).Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req.Header.Set(fiber.HeaderIfMatch, "*")
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify a user with a weak If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				a.Put(ApiUsersEndpoint+"/:id", NewUserAPI(nil, nil, nil, nil, usecase.NewMockUserModifier(), nil, nil, nil).Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req.Header.Set(fiber.HeaderIfMatch, `W/"2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify a user with a malformed If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				a.Put(ApiUsersEndpoint+"/:id", NewUserAPI(nil, nil, nil, nil, usecase.NewMockUserModifier(), nil, nil, nil).Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				req.Header.Set(fiber.HeaderIfMatch, `"1", "2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify a user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John Modified", Surname: "Doe Modified"}).
+					Return(entity.User{}, errors.New("error modifying user"))
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"id": 1, "name": "John Modified", "surname": "Doe Modified"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify the roles of a user when the caller is not an admin",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"admin"}}).
+					Return(entity.User{}, domerrors.ErrForbidden)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"id": 1, "name": "John", "surname": "Doe", "roles": ["admin"]}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify a user not found",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).
+					Return(entity.User{}, domerrors.ErrUserNotFound)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"id": 1, "name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			name: "should modify the user of the path when the body has no ID",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 2, Name: "Jane", Surname: "Doe"}).
+					Return(entity.User{ID: 2, Name: "Jane", Surname: "Doe"}, nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/2", strings.NewReader(`{"name": "Jane", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify an invalid user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				mockUserModifier.On("Modify", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: ""}).
+					Return(entity.User{}, errors.WithMessage(&domerrors.ValidationError{Err: domerrors.ErrInvalidUser, Violations: []domerrors.FieldViolation{
+						{Field: "surname", Message: "is required"},
+					}}, "cannot modify user"))
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"name": "John", "surname": ""}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+				var response problem.Problem
+				err = json.ConfigDefault.NewDecoder(resp.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Equal(t, []problem.FieldError{{Field: "surname", Message: "is required"}}, response.Errors)
+			},
+		},
+		{
+			name: "should not modify a user when the ID of the body is not the ID of the path",
+			given: func() *fiber.App {
+				a := testutils.App()
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/2", strings.NewReader(`{"id": 1, "name": "John", "surname": "Doe"}`))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not modify a user with invalid data",
+			given: func() *fiber.App {
+				a := testutils.App()
+
+				mockUserModifier := usecase.NewMockUserModifier()
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserModifier,
+					nil,
+					nil,
+					nil)
+
+				a.Put(ApiUsersEndpoint+"/:id", api.Modify)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPut, ApiUsersEndpoint+"/1", strings.NewReader(`{"other": "message"}`))
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
+
+func TestUserAPI_Patch(t *testing.T) {
+	mergePatch := entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`)}
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		given       func(m *usecase.MockUserPatcher, ctx context.Context)
+		then        func(t *testing.T, resp *http.Response)
+	}{
+		{
+			name:        "should patch a user with a merge patch",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{ID: 1, Name: "John", Surname: "Smith"}, nil)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				var user UserDTO
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NoError(t, json.Unmarshal(body, &user))
+				assert.Equal(t, UserDTO{ID: 1, Name: "John", Surname: "Smith"}, user)
+			},
+		},
+		{
+			name:        "should patch the version of a user given by If-Match",
+			contentType: MIMEApplicationMergePatchJSON,
+			ifMatch:     `"4"`,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`), Version: 4}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 5}, nil)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `"5"`, resp.Header.Get(fiber.HeaderETag))
+			},
+		},
+		{
+			name:        "should not patch a user modified concurrently",
+			contentType: MIMEApplicationMergePatchJSON,
+			ifMatch:     `"4"`,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`), Version: 4}).
+					Return(entity.User{}, domerrors.ErrConcurrentModification)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should patch a user with a json patch",
+			contentType: MIMEApplicationJSONPatchJSON + "; charset=utf-8",
+			body:        `[{"op": "replace", "path": "/surname", "value": "Smith"}]`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), entity.UserPatch{Format: entity.PatchJSON, Document: []byte(`[{"op": "replace", "path": "/surname", "value": "Smith"}]`)}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Smith"}, nil)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user with an unsupported patch format",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"surname": "Smith"}`,
+			given:       func(m *usecase.MockUserPatcher, ctx context.Context) {},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+				assert.Equal(t, MIMEApplicationMergePatchJSON+", "+MIMEApplicationJSONPatchJSON, resp.Header.Get(HeaderAcceptPatch))
+			},
+		},
+		{
+			name:        "should not patch a user not found",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, domerrors.ErrUserNotFound)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user not found given If-Match",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			ifMatch:     "*",
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, domerrors.ErrUserNotFound)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user with a malformed patch",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, domerrors.ErrInvalidPatch)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user when a test fails",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, domerrors.ErrPatchConflict)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusConflict, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user when the patch cannot be applied",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, domerrors.ErrUnprocessablePatch)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user into an invalid one",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, &domerrors.ValidationError{Err: domerrors.ErrInvalidUser, Violations: []domerrors.FieldViolation{
+					{Field: "surname", Message: "must be at most 100 characters"},
+				}})
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+				var response problem.Problem
+				assert.NoError(t, json.ConfigDefault.NewDecoder(resp.Body).Decode(&response))
+				assert.Equal(t, []problem.FieldError{{Field: "surname", Message: "must be at most 100 characters"}}, response.Errors)
+			},
+		},
+		{
+			name:        "should not patch the roles of a user when the caller is not an admin",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, domerrors.ErrForbidden)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			},
+		},
+		{
+			name:        "should not patch a user when something goes wrong",
+			contentType: MIMEApplicationMergePatchJSON,
+			body:        `{"surname": "Smith"}`,
+			given: func(m *usecase.MockUserPatcher, ctx context.Context) {
+				m.On("Patch", ctx, uint(1), mergePatch).Return(entity.User{}, errors.New("error patching user"))
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			c := testutils.AcquireFiberCtx(a)
+			mockUserPatcher := usecase.NewMockUserPatcher()
+			tt.given(mockUserPatcher, c.UserContext())
+			a.Patch(ApiUsersEndpoint+"/:id", NewUserAPI(nil, nil, nil, nil, nil, mockUserPatcher, nil, nil).Patch)
+
+			// When
+			req := httptest.NewRequest(http.MethodPatch, ApiUsersEndpoint+"/1", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			tt.then(t, resp)
+			mockUserPatcher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserAPI_Delete(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
 		{
 			name: "should delete a user",
 			given: func() *fiber.App {
@@ -467,17 +1581,85 @@ func TestUserAPI_Delete(t *testing.T) {
 				mockUserDeleter := usecase.NewMockUserDeleter()
 				mockUserDeleter.On("Delete", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).Return(nil)
 				api := NewUserAPI(
+					nil,
+					nil,
+					mockUserFinderByID,
+					nil,
+					nil,
+					nil,
+					mockUserDeleter,
+					nil)
+
+				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodDelete, ApiUsersEndpoint+"/1", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			},
+		},
+		{
+			name: "should delete the version of a user given by If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}, nil)
+				mockUserDeleter := usecase.NewMockUserDeleter()
+				mockUserDeleter.On("Delete", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}).
+					Return(domerrors.ErrConcurrentModification)
+				api := NewUserAPI(
+					nil,
 					nil,
 					mockUserFinderByID,
 					nil,
 					nil,
-					mockUserDeleter)
+					nil,
+					mockUserDeleter,
+					nil)
 
 				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
 				return a
 			},
 			when: func(a *fiber.App) (*http.Response, error) {
 				req := httptest.NewRequest(http.MethodDelete, ApiUsersEndpoint+"/1", nil)
+				req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
+		{
+			name: "should purge a user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserDeleter := usecase.NewMockUserDeleter()
+				mockUserDeleter.On("Purge", c.UserContext(), uint(1), uint(2)).Return(nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					usecase.NewMockUserFinderByID(),
+					nil,
+					nil,
+					nil,
+					mockUserDeleter,
+					nil)
+
+				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodDelete, ApiUsersEndpoint+"/1?purge=true", nil)
+				req.Header.Set(fiber.HeaderIfMatch, `"2"`)
 				return a.Test(req, -1)
 			},
 			then: func(t *testing.T, resp *http.Response, err error) {
@@ -485,6 +1667,78 @@ func TestUserAPI_Delete(t *testing.T) {
 				assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 			},
 		},
+		{
+			name: "should not purge a missing user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserDeleter := usecase.NewMockUserDeleter()
+				mockUserDeleter.On("Purge", c.UserContext(), uint(1), uint(0)).Return(domerrors.ErrUserNotFound)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserDeleter,
+					nil)
+
+				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodDelete, ApiUsersEndpoint+"/1?purge=true", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not purge a missing user given If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserDeleter := usecase.NewMockUserDeleter()
+				mockUserDeleter.On("Purge", c.UserContext(), uint(1), uint(0)).Return(domerrors.ErrUserNotFound)
+				a.Delete(ApiUsersEndpoint+"/:id", NewUserAPI(nil, nil, nil, nil, nil, nil, mockUserDeleter, nil).Delete)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodDelete, ApiUsersEndpoint+"/1?purge=true", nil)
+				req.Header.Set(fiber.HeaderIfMatch, "*")
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not delete a missing user given If-Match",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserFinderByID := usecase.NewMockUserFinderByID()
+				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				a.Delete(ApiUsersEndpoint+"/:id", NewUserAPI(nil, nil, mockUserFinderByID, nil, nil, nil, usecase.NewMockUserDeleter(), nil).Delete)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodDelete, ApiUsersEndpoint+"/1", nil)
+				req.Header.Set(fiber.HeaderIfMatch, "*")
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+			},
+		},
 		{
 			name: "should not delete a user",
 			given: func() *fiber.App {
@@ -497,11 +1751,14 @@ func TestUserAPI_Delete(t *testing.T) {
 				mockUserDeleter.On("Delete", c.UserContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).
 					Return(errors.New("error deleting user"))
 				api := NewUserAPI(
+					nil,
 					nil,
 					mockUserFinderByID,
 					nil,
 					nil,
-					mockUserDeleter)
+					nil,
+					mockUserDeleter,
+					nil)
 
 				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
 				return a
@@ -525,11 +1782,14 @@ func TestUserAPI_Delete(t *testing.T) {
 				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
 				mockUserDeleter := usecase.NewMockUserDeleter()
 				api := NewUserAPI(
+					nil,
 					nil,
 					mockUserFinderByID,
 					nil,
 					nil,
-					mockUserDeleter)
+					nil,
+					mockUserDeleter,
+					nil)
 
 				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
 				return a
@@ -553,11 +1813,14 @@ func TestUserAPI_Delete(t *testing.T) {
 				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{}, nil)
 				mockUserDeleter := usecase.NewMockUserDeleter()
 				api := NewUserAPI(
+					nil,
 					nil,
 					mockUserFinderByID,
 					nil,
 					nil,
-					mockUserDeleter)
+					nil,
+					mockUserDeleter,
+					nil)
 
 				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
 				return a
@@ -581,11 +1844,14 @@ func TestUserAPI_Delete(t *testing.T) {
 				mockUserFinderByID.On("Find", c.UserContext(), uint(1)).Return(entity.User{}, errors.New("error finding user"))
 				mockUserDeleter := usecase.NewMockUserDeleter()
 				api := NewUserAPI(
+					nil,
 					nil,
 					mockUserFinderByID,
 					nil,
 					nil,
-					mockUserDeleter)
+					nil,
+					mockUserDeleter,
+					nil)
 
 				a.Delete(ApiUsersEndpoint+"/:id", api.Delete)
 				return a
@@ -613,3 +1879,122 @@ func TestUserAPI_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserAPI_Restore(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *fiber.App
+		when  func(a *fiber.App) (*http.Response, error)
+		then  func(t *testing.T, resp *http.Response, err error)
+	}{
+		{
+			name: "should restore a user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserRestorer := usecase.NewMockUserRestorer()
+				mockUserRestorer.On("Restore", c.UserContext(), uint(1), uint(2)).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}, nil)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserRestorer)
+
+				a.Post(ApiUsersEndpoint+"/:id/restore", api.Restore)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint+"/1/restore", nil)
+				req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `{"id": 1, "name": "John", "surname": "Doe"}`, string(body))
+			},
+		},
+		{
+			name: "should not restore a user which is not deleted",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserRestorer := usecase.NewMockUserRestorer()
+				mockUserRestorer.On("Restore", c.UserContext(), uint(1), uint(0)).Return(entity.User{}, domerrors.ErrUserNotDeleted)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserRestorer)
+
+				a.Post(ApiUsersEndpoint+"/:id/restore", api.Restore)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint+"/1/restore", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusConflict, resp.StatusCode)
+			},
+		},
+		{
+			name: "should not restore a missing user",
+			given: func() *fiber.App {
+				a := testutils.App()
+				c := testutils.AcquireFiberCtx(a)
+
+				mockUserRestorer := usecase.NewMockUserRestorer()
+				mockUserRestorer.On("Restore", c.UserContext(), uint(1), uint(0)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				api := NewUserAPI(
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					mockUserRestorer)
+
+				a.Post(ApiUsersEndpoint+"/:id/restore", api.Restore)
+				return a
+			},
+			when: func(a *fiber.App) (*http.Response, error) {
+				req := httptest.NewRequest(http.MethodPost, ApiUsersEndpoint+"/1/restore", nil)
+				return a.Test(req, -1)
+			},
+			then: func(t *testing.T, resp *http.Response, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := tt.given()
+
+			// When
+			resp, err := tt.when(a)
+
+			// Then
+			tt.then(t, resp, err)
+		})
+	}
+}
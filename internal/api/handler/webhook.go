@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+)
+
+// WebhookAPI encapsulates the webhook use cases.
+type WebhookAPI struct {
+	finderAll      usecase.WebhookFinderAll
+	creator        usecase.WebhookCreator
+	deleter        usecase.WebhookDeleter
+	deliveryFinder usecase.WebhookDeliveryFinder
+}
+
+type CreateWebhookDTO struct {
+	URL string `json:"url"`
+	// Secret signs the requests sent to the webhook, and is generated when not given
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookDTO struct {
+	ID  uint   `json:"id"`
+	URL string `json:"url"`
+	// EventTypes are the types of the events sent to the webhook, every type when empty
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreatedWebhookDTO struct {
+	WebhookDTO
+	// Secret is the secret signing the requests sent to the webhook, which is only returned on creation
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID        uint   `json:"id"`
+	EventID   uint   `json:"event_id"`
+	EventType string `json:"event_type"`
+	UserID    uint   `json:"user_id"`
+	// Status is one of pending, delivered or dead
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// toEntityWebhook converts a CreateWebhookDTO to an entity.Webhook
+func (w CreateWebhookDTO) toEntityWebhook() entity.Webhook {
+	webhook := entity.Webhook{
+		URL:    w.URL,
+		Secret: w.Secret,
+	}
+	for _, eventType := range w.EventTypes {
+		webhook.EventTypes = append(webhook.EventTypes, entity.UserEventType(eventType))
+	}
+	return webhook
+}
+
+// toWebhookDTO converts an entity.Webhook to WebhookDTO
+func toWebhookDTO(w entity.Webhook) WebhookDTO {
+	eventTypes := make([]string, 0, len(w.EventTypes))
+	for _, eventType := range w.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	return WebhookDTO{
+		ID:         w.ID,
+		URL:        w.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+// toWebhookDeliveryDTO converts an entity.WebhookDelivery to WebhookDeliveryDTO
+func toWebhookDeliveryDTO(d entity.WebhookDelivery) WebhookDeliveryDTO {
+	delivery := WebhookDeliveryDTO{
+		ID:             d.ID,
+		EventID:        d.Event.ID,
+		EventType:      string(d.Event.Type),
+		UserID:         d.Event.UserID,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+	// only the pending deliveries have a next attempt
+	if d.Status == entity.WebhookDeliveryPending {
+		delivery.NextAttemptAt = &d.NextAttemptAt
+	}
+	return delivery
+}
+
+// NewWebhookAPI creates a new WebhookAPI.
+func NewWebhookAPI(
+	finderAll usecase.WebhookFinderAll,
+	creator usecase.WebhookCreator,
+	deleter usecase.WebhookDeleter,
+	deliveryFinder usecase.WebhookDeliveryFinder,
+) *WebhookAPI {
+	return &WebhookAPI{
+		finderAll:      finderAll,
+		creator:        creator,
+		deleter:        deleter,
+		deliveryFinder: deliveryFinder,
+	}
+}
+
+// FindAll godoc
+// @summary Get all webhooks
+// @description Get all webhooks, without their secrets
+// @tags webhooks
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id FindAllWebhooks
+// @produce json
+// @Router /api/webhooks [get]
+// @response 200 {object} []WebhookDTO "OK"
+// @response 403 "Forbidden"
+func (h *WebhookAPI) FindAll(c *fiber.Ctx) error {
+	webhooks, err := h.finderAll.Find(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]WebhookDTO, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, toWebhookDTO(webhook))
+	}
+	return c.JSON(response)
+}
+
+// Create godoc
+// @summary Create a webhook
+// @description Subscribe a URL to the events of the users of the given types, or of every type. The secret signing
+// @description the requests is generated when not given, and only returned once.
+// @tags webhooks
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id CreateWebhook
+// @accept json
+// @produce json
+// @param webhook body CreateWebhookDTO true "CreateWebhookDTO"
+// @Router /api/webhooks [post]
+// @response 201 {object} CreatedWebhookDTO "Created"
+// @response 400 "Bad Request"
+// @response 403 "Forbidden"
+// @response 422 {object} problem.Problem "Unprocessable Entity"
+func (h *WebhookAPI) Create(c *fiber.Ctx) error {
+	var webhookDTO CreateWebhookDTO
+
+	if err := c.BodyParser(&webhookDTO); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	created, err := h.creator.Create(c.UserContext(), webhookDTO.toEntityWebhook())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(CreatedWebhookDTO{
+		WebhookDTO: toWebhookDTO(created),
+		Secret:     created.Secret,
+	})
+}
+
+// Delete godoc
+// @summary Delete a webhook
+// @description Delete a webhook along with its delivery log, cancelling its pending deliveries
+// @tags webhooks
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id DeleteWebhook
+// @param id path int true "Webhook ID"
+// @Router /api/webhooks/{id} [delete]
+// @response 204 "No Content"
+// @response 403 "Forbidden"
+// @response 404 "Not Found"
+func (h *WebhookAPI) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
+
+	if err = h.deleter.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// FindDeliveries godoc
+// @summary Get the delivery log of a webhook
+// @description Get the latest deliveries of the events to a webhook, latest first, with the outcome of their last
+// @description attempt. The dead deliveries were given up after their last attempt failed.
+// @tags webhooks
+// @security ApiKeyAuth
+// @security BearerAuth
+// @id FindWebhookDeliveries
+// @produce json
+// @param id path int true "Webhook ID"
+// @Router /api/webhooks/{id}/deliveries [get]
+// @response 200 {object} []WebhookDeliveryDTO "OK"
+// @response 403 "Forbidden"
+// @response 404 "Not Found"
+func (h *WebhookAPI) FindDeliveries(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot parse id")
+	}
+
+	deliveries, err := h.deliveryFinder.Find(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := make([]WebhookDeliveryDTO, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, toWebhookDeliveryDTO(delivery))
+	}
+	return c.JSON(response)
+}
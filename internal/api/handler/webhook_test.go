@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	WebhooksEndpoint = "/api/webhooks"
+)
+
+func TestWebhookAPI_FindAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func(*usecase.MockWebhookFinderAll, *fiber.Ctx)
+		then  func(t *testing.T, resp *http.Response)
+	}{
+		{
+			name: "should find all webhooks without their secrets",
+			given: func(m *usecase.MockWebhookFinderAll, c *fiber.Ctx) {
+				m.On("Find", c.UserContext()).Return([]entity.Webhook{
+					{ID: 1, URL: "https://example.com/a", Secret: "0123456789abcdef"},
+					{ID: 2, URL: "https://example.com/b", Secret: "0123456789abcdef",
+						EventTypes: []entity.UserEventType{entity.UserCreated}},
+				}, nil)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NotContains(t, string(body), "0123456789abcdef")
+
+				var webhooks []WebhookDTO
+				err = json.Unmarshal(body, &webhooks)
+				assert.NoError(t, err)
+				assert.Equal(t, []WebhookDTO{
+					{ID: 1, URL: "https://example.com/a", EventTypes: []string{}},
+					{ID: 2, URL: "https://example.com/b", EventTypes: []string{"user.created"}},
+				}, webhooks)
+			},
+		},
+		{
+			name: "should fail finding webhooks",
+			given: func(m *usecase.MockWebhookFinderAll, c *fiber.Ctx) {
+				m.On("Find", c.UserContext()).Return([]entity.Webhook{}, errors.New("error"))
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			mockWebhookFinderAll := usecase.NewMockWebhookFinderAll()
+			tt.given(mockWebhookFinderAll, testutils.AcquireFiberCtx(a))
+			a.Get(WebhooksEndpoint, NewWebhookAPI(mockWebhookFinderAll, nil, nil, nil).FindAll)
+
+			// When
+			resp, err := a.Test(httptest.NewRequest(http.MethodGet, WebhooksEndpoint, nil), -1)
+
+			// Then
+			assert.NoError(t, err)
+			tt.then(t, resp)
+		})
+	}
+}
+
+func TestWebhookAPI_Create(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*usecase.MockWebhookCreator, *fiber.Ctx)
+		then  func(t *testing.T, resp *http.Response)
+	}{
+		{
+			name: "should create a webhook and return its secret",
+			body: `{"url":"https://example.com/a","event_types":["user.created"]}`,
+			given: func(m *usecase.MockWebhookCreator, c *fiber.Ctx) {
+				m.On("Create", c.UserContext(), entity.Webhook{URL: "https://example.com/a",
+					EventTypes: []entity.UserEventType{entity.UserCreated}}).
+					Return(entity.Webhook{ID: 1, URL: "https://example.com/a", Secret: "generated-secret-0123",
+						EventTypes: []entity.UserEventType{entity.UserCreated}}, nil)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+				var created CreatedWebhookDTO
+				err := json.ConfigDefault.NewDecoder(resp.Body).Decode(&created)
+				assert.NoError(t, err)
+				assert.Equal(t, CreatedWebhookDTO{
+					WebhookDTO: WebhookDTO{ID: 1, URL: "https://example.com/a", EventTypes: []string{"user.created"}},
+					Secret:     "generated-secret-0123",
+				}, created)
+			},
+		},
+		{
+			name: "should not create an invalid webhook",
+			body: `{"url":"ftp://example.com"}`,
+			given: func(m *usecase.MockWebhookCreator, c *fiber.Ctx) {
+				m.On("Create", c.UserContext(), entity.Webhook{URL: "ftp://example.com"}).
+					Return(entity.Webhook{}, &domerrors.ValidationError{Err: domerrors.ErrInvalidWebhook,
+						Violations: []domerrors.FieldViolation{{Field: "url", Message: "must be an absolute http or https URL"}}})
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.Contains(t, string(body), "invalid-webhook")
+				assert.Contains(t, string(body), "must be an absolute http or https URL")
+			},
+		},
+		{
+			name:  "should not create a webhook from a malformed body",
+			body:  `{"url":`,
+			given: func(*usecase.MockWebhookCreator, *fiber.Ctx) {},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			},
+		},
+		{
+			name: "should fail creating a webhook",
+			body: `{"url":"https://example.com/a"}`,
+			given: func(m *usecase.MockWebhookCreator, c *fiber.Ctx) {
+				m.On("Create", c.UserContext(), entity.Webhook{URL: "https://example.com/a"}).
+					Return(entity.Webhook{}, errors.New("error"))
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			mockWebhookCreator := usecase.NewMockWebhookCreator()
+			tt.given(mockWebhookCreator, testutils.AcquireFiberCtx(a))
+			a.Post(WebhooksEndpoint, NewWebhookAPI(nil, mockWebhookCreator, nil, nil).Create)
+
+			// When
+			req := httptest.NewRequest(http.MethodPost, WebhooksEndpoint, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			tt.then(t, resp)
+		})
+	}
+}
+
+func TestWebhookAPI_Delete(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "should delete a webhook", status: http.StatusNoContent},
+		{name: "should not delete an unknown webhook", err: domerrors.ErrWebhookNotFound, status: http.StatusNotFound},
+		{name: "should fail deleting a webhook", err: errors.New("error"), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			c := testutils.AcquireFiberCtx(a)
+			mockWebhookDeleter := usecase.NewMockWebhookDeleter()
+			mockWebhookDeleter.On("Delete", c.UserContext(), uint(1)).Return(tt.err)
+			a.Delete(WebhooksEndpoint+"/:id", NewWebhookAPI(nil, nil, mockWebhookDeleter, nil).Delete)
+
+			// When
+			req := httptest.NewRequest(http.MethodDelete, WebhooksEndpoint+"/1", nil)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestWebhookAPI_FindDeliveries(t *testing.T) {
+	nextAttemptAt := time.Date(2024, time.March, 1, 12, 0, 10, 0, time.UTC)
+	event := entity.UserEvent{ID: 3, Type: entity.UserCreated, UserID: 1}
+
+	tests := []struct {
+		name  string
+		given func(*usecase.MockWebhookDeliveryFinder, *fiber.Ctx)
+		then  func(t *testing.T, resp *http.Response)
+	}{
+		{
+			name: "should find the delivery log of a webhook",
+			given: func(m *usecase.MockWebhookDeliveryFinder, c *fiber.Ctx) {
+				m.On("Find", c.UserContext(), uint(1)).Return([]entity.WebhookDelivery{
+					{ID: 2, WebhookID: 1, Event: event, Status: entity.WebhookDeliveryPending, Attempts: 1,
+						NextAttemptAt: nextAttemptAt, LastStatusCode: 503, LastError: "unexpected status 503"},
+					{ID: 1, WebhookID: 1, Event: event, Status: entity.WebhookDeliveryDelivered, Attempts: 1,
+						NextAttemptAt: nextAttemptAt, LastStatusCode: 204},
+				}, nil)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				var deliveries []WebhookDeliveryDTO
+				err := json.ConfigDefault.NewDecoder(resp.Body).Decode(&deliveries)
+				assert.NoError(t, err)
+				assert.Equal(t, []WebhookDeliveryDTO{
+					{ID: 2, EventID: 3, EventType: "user.created", UserID: 1, Status: "pending", Attempts: 1,
+						NextAttemptAt: &nextAttemptAt, LastStatusCode: 503, LastError: "unexpected status 503"},
+					{ID: 1, EventID: 3, EventType: "user.created", UserID: 1, Status: "delivered", Attempts: 1,
+						LastStatusCode: 204},
+				}, deliveries)
+			},
+		},
+		{
+			name: "should not find the delivery log of an unknown webhook",
+			given: func(m *usecase.MockWebhookDeliveryFinder, c *fiber.Ctx) {
+				m.On("Find", c.UserContext(), uint(1)).Return([]entity.WebhookDelivery{}, domerrors.ErrWebhookNotFound)
+			},
+			then: func(t *testing.T, resp *http.Response) {
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			mockWebhookDeliveryFinder := usecase.NewMockWebhookDeliveryFinder()
+			tt.given(mockWebhookDeliveryFinder, testutils.AcquireFiberCtx(a))
+			a.Get(WebhooksEndpoint+"/:id/deliveries", NewWebhookAPI(nil, nil, nil, mockWebhookDeliveryFinder).FindDeliveries)
+
+			// When
+			resp, err := a.Test(httptest.NewRequest(http.MethodGet, WebhooksEndpoint+"/1/deliveries", nil), -1)
+
+			// Then
+			assert.NoError(t, err)
+			tt.then(t, resp)
+		})
+	}
+}
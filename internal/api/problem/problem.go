@@ -0,0 +1,93 @@
+package problem
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MIMEApplicationProblemJSON is the media type of the problem details (RFC 7807)
+	MIMEApplicationProblemJSON = "application/problem+json"
+	// TypePrefix prefixes the stable URIs of the types of the problems of the domain errors
+	TypePrefix = "urn:problem-type:"
+	// TypeBlank is the type of the problems with no other semantics than their HTTP status code
+	TypeBlank = "about:blank"
+)
+
+// Problem is the details of an error of the API, as defined by RFC 7807
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// CorrelationID is the ID of the request, as given or returned in the X-Request-ID header
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// Errors are the invariants broken by the fields of the request, for the validation problems
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is an invariant broken by a field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrorHandler is the fiber.ErrorHandler sending the errors returned by the handlers as application/problem+json
+// responses, along with the path and the correlation ID of the request.
+// The details of the unexpected errors are logged rather than sent, and the unauthorized requests are challenged to
+// authenticate with a bearer token.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	p := New(err)
+	p.Instance = c.Path()
+	p.CorrelationID = c.GetRespHeader(fiber.HeaderXRequestID)
+
+	if errors.Is(err, domerrors.ErrUnauthorized) {
+		c.Set(fiber.HeaderWWWAuthenticate, bearerChallenge(c))
+	}
+
+	if p.Status == fiber.StatusInternalServerError {
+		log.Errorf("%s %s failed [correlation_id=%s]: %v", c.Method(), c.Path(), p.CorrelationID, err)
+	}
+
+	return c.Status(p.Status).JSON(p, MIMEApplicationProblemJSON)
+}
+
+// New returns the problem of the given error: the problem of the type of its domain error if any, a blank problem
+// of the status of a *fiber.Error, or else an internal server error without details
+func New(err error) Problem {
+	for _, t := range types {
+		if errors.Is(err, t.err) {
+			return t.problem(err)
+		}
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		p := Problem{Type: TypeBlank, Title: http.StatusText(fiberErr.Code), Status: fiberErr.Code}
+		if fiberErr.Message != p.Title {
+			p.Detail = fiberErr.Message
+		}
+		return p
+	}
+
+	return Problem{
+		Type:   TypeBlank,
+		Title:  http.StatusText(fiber.StatusInternalServerError),
+		Status: fiber.StatusInternalServerError,
+	}
+}
+
+// bearerChallenge returns the challenge of the bearer tokens (RFC 6750) answering the given unauthorized request,
+// telling that its token is not valid when it has one
+func bearerChallenge(c *fiber.Ctx) string {
+	if strings.HasPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ") {
+		return `Bearer error="invalid_token"`
+	}
+	return "Bearer"
+}
@@ -0,0 +1,130 @@
+package problem
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	endpoint      = "/api/users/1"
+	correlationID = "3f5c1a7e-request"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		err           error
+		want          Problem
+		wantChallenge string
+	}{
+		{
+			name: "should answer the problem of a domain error",
+			err:  domerrors.ErrUserNotFound,
+			want: Problem{Type: TypePrefix + "user-not-found", Title: "User not found", Status: http.StatusNotFound},
+		},
+		{
+			name: "should detail the problem of a wrapped domain error",
+			err:  errors.Wrap(domerrors.ErrForbidden, "only admins can assign roles"),
+			want: Problem{
+				Type:   TypePrefix + "forbidden",
+				Title:  "Forbidden",
+				Status: http.StatusForbidden,
+				Detail: "only admins can assign roles: forbidden",
+			},
+		},
+		{
+			name: "should list the invalid fields of a validation error",
+			err: &domerrors.ValidationError{Err: domerrors.ErrInvalidUser, Violations: []domerrors.FieldViolation{
+				{Field: "name", Message: "is required"},
+			}},
+			want: Problem{
+				Type:   TypePrefix + "invalid-user",
+				Title:  "Invalid user",
+				Status: http.StatusUnprocessableEntity,
+				Detail: "invalid user: name is required",
+				Errors: []FieldError{{Field: "name", Message: "is required"}},
+			},
+		},
+		{
+			name: "should answer a conflict",
+			err:  domerrors.ErrUserAlreadyExists,
+			want: Problem{Type: TypePrefix + "user-already-exists", Title: "User already exists", Status: http.StatusConflict},
+		},
+		{
+			name:          "should answer an unauthorized request with an invalid token",
+			authorization: "Bearer expired",
+			err:           errors.Wrap(domerrors.ErrUnauthorized, "invalid token"),
+			want: Problem{
+				Type:   TypePrefix + "unauthorized",
+				Title:  "Unauthorized",
+				Status: http.StatusUnauthorized,
+				Detail: "invalid token: unauthorized",
+			},
+			wantChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name: "should answer an unauthorized request without token",
+			err:  domerrors.ErrUnauthorized,
+			want: Problem{
+				Type:   TypePrefix + "unauthorized",
+				Title:  "Unauthorized",
+				Status: http.StatusUnauthorized,
+			},
+			wantChallenge: "Bearer",
+		},
+		{
+			name: "should answer a blank problem of a fiber error",
+			err:  fiber.NewError(http.StatusBadRequest, "cannot parse id"),
+			want: Problem{Type: TypeBlank, Title: "Bad Request", Status: http.StatusBadRequest, Detail: "cannot parse id"},
+		},
+		{
+			name: "should not detail a fiber error without message",
+			err:  fiber.ErrMethodNotAllowed,
+			want: Problem{Type: TypeBlank, Title: "Method Not Allowed", Status: http.StatusMethodNotAllowed},
+		},
+		{
+			name: "should hide the details of an unexpected error",
+			err:  errors.New("connection refused"),
+			want: Problem{Type: TypeBlank, Title: "Internal Server Error", Status: http.StatusInternalServerError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			a.Use(requestid.New())
+			a.Get(endpoint, func(c *fiber.Ctx) error {
+				return tt.err
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, endpoint, nil)
+			req.Header.Set(fiber.HeaderXRequestID, correlationID)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Status, resp.StatusCode)
+			assert.Equal(t, MIMEApplicationProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+			assert.Equal(t, tt.wantChallenge, resp.Header.Get(fiber.HeaderWWWAuthenticate))
+
+			var p Problem
+			assert.NoError(t, json.ConfigDefault.NewDecoder(resp.Body).Decode(&p))
+			tt.want.Instance = endpoint
+			tt.want.CorrelationID = correlationID
+			assert.Equal(t, tt.want, p)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package problem
+
+import (
+	"github.com/gofiber/fiber/v2"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+// problemType is the type of the problems of a domain error
+type problemType struct {
+	err    error
+	name   string
+	title  string
+	status int
+}
+
+// types are the types of the problems of the domain errors. Their names are part of the API: a type must not be
+// renamed once released, and a new type must be added for a new domain error rather than reusing another one.
+var types = []problemType{
+	{err: domerrors.ErrUserNotFound, name: "user-not-found", title: "User not found", status: fiber.StatusNotFound},
+	{err: domerrors.ErrUserAlreadyExists, name: "user-already-exists", title: "User already exists", status: fiber.StatusConflict},
+	{err: domerrors.ErrUserNotDeleted, name: "user-not-deleted", title: "User not deleted", status: fiber.StatusConflict},
+	{err: domerrors.ErrInvalidUser, name: "invalid-user", title: "Invalid user", status: fiber.StatusUnprocessableEntity},
+	{err: domerrors.ErrInvalidUserQuery, name: "invalid-user-query", title: "Invalid user query", status: fiber.StatusBadRequest},
+	{err: domerrors.ErrInvalidCursor, name: "invalid-cursor", title: "Invalid cursor", status: fiber.StatusBadRequest},
+	{err: domerrors.ErrInvalidPatch, name: "invalid-patch", title: "Invalid patch", status: fiber.StatusBadRequest},
+	{err: domerrors.ErrUnprocessablePatch, name: "unprocessable-patch", title: "Unprocessable patch", status: fiber.StatusUnprocessableEntity},
+	{err: domerrors.ErrPatchConflict, name: "patch-conflict", title: "Patch test failed", status: fiber.StatusConflict},
+	{err: domerrors.ErrConcurrentModification, name: "concurrent-modification", title: "Concurrent modification", status: fiber.StatusPreconditionFailed},
+	{err: domerrors.ErrUnauthorized, name: "unauthorized", title: "Unauthorized", status: fiber.StatusUnauthorized},
+	{err: domerrors.ErrInvalidCredentials, name: "invalid-credentials", title: "Invalid credentials", status: fiber.StatusUnauthorized},
+	{err: domerrors.ErrInvalidRefreshToken, name: "invalid-refresh-token", title: "Invalid refresh token", status: fiber.StatusUnauthorized},
+	{err: domerrors.ErrRefreshTokenReused, name: "invalid-refresh-token", title: "Invalid refresh token", status: fiber.StatusUnauthorized},
+	{err: domerrors.ErrInvalidAPIKey, name: "invalid-api-key", title: "Invalid API key", status: fiber.StatusUnauthorized},
+	{err: domerrors.ErrForbidden, name: "forbidden", title: "Forbidden", status: fiber.StatusForbidden},
+	{err: domerrors.ErrAPIKeyNotFound, name: "api-key-not-found", title: "API key not found", status: fiber.StatusNotFound},
+	{err: domerrors.ErrInvalidAPIKeyExpiry, name: "invalid-api-key-expiry", title: "Invalid API key expiry", status: fiber.StatusBadRequest},
+	{err: domerrors.ErrWebhookNotFound, name: "webhook-not-found", title: "Webhook not found", status: fiber.StatusNotFound},
+	{err: domerrors.ErrInvalidWebhook, name: "invalid-webhook", title: "Invalid webhook", status: fiber.StatusUnprocessableEntity},
+}
+
+// problem returns the problem of the given error of the type, detailed by its message when it tells more than the
+// domain error, and listing the broken invariants of a *errors.ValidationError
+func (t problemType) problem(err error) Problem {
+	p := Problem{Type: TypePrefix + t.name, Title: t.title, Status: t.status}
+	if err.Error() != t.err.Error() {
+		p.Detail = err.Error()
+	}
+
+	var validationErr *domerrors.ValidationError
+	if errors.As(err, &validationErr) {
+		p.Errors = make([]FieldError, 0, len(validationErr.Violations))
+		for _, v := range validationErr.Violations {
+			p.Errors = append(p.Errors, FieldError{Field: v.Field, Message: v.Message})
+		}
+	}
+	return p
+}
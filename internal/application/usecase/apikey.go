@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+const (
+	// apiKeyPrefixSize is the number of random bytes of the public prefix of the API keys
+	apiKeyPrefixSize = 6
+	// apiKeySecretSize is the number of random bytes of the secret part of the API keys
+	apiKeySecretSize = 32
+	// apiKeySeparator separates the prefix from the secret in the API keys
+	apiKeySeparator = "."
+	// apiKeyTouchInterval limits how often the last use of an API key is recorded
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyFinderAll use case
+type APIKeyFinderAll struct {
+	keys repository.APIKey
+}
+
+// NewAPIKeyFinderAll creates a new usecase.APIKeyFinderAll instance
+func NewAPIKeyFinderAll(keys repository.APIKey) usecase.APIKeyFinderAll {
+	return &APIKeyFinderAll{
+		keys: keys,
+	}
+}
+
+// Find returns all API keys or an error if something goes wrong
+func (u *APIKeyFinderAll) Find(ctx context.Context) ([]entity.APIKey, error) {
+	return u.keys.FindAll(ctx)
+}
+
+// APIKeyCreator use case
+type APIKeyCreator struct {
+	keys repository.APIKey
+	user repository.User
+	now  func() time.Time
+}
+
+// NewAPIKeyCreator creates a new usecase.APIKeyCreator instance
+func NewAPIKeyCreator(keys repository.APIKey, user repository.User) usecase.APIKeyCreator {
+	return &APIKeyCreator{
+		keys: keys,
+		user: user,
+		now:  time.Now,
+	}
+}
+
+// Create creates an API key and returns it along with its plain value, or an error if something goes wrong.
+// The key is owned by the caller unless another owner is given, and the owner must exist.
+func (u *APIKeyCreator) Create(ctx context.Context, key entity.APIKey) (entity.IssuedAPIKey, error) {
+	if key.OwnerID == 0 {
+		p, _ := entity.PrincipalFromContext(ctx)
+		key.OwnerID = p.UserID
+	}
+	if key.OwnerID == 0 {
+		return entity.IssuedAPIKey{}, errors.Wrap(domerrors.ErrUserNotFound, "owner of the api key")
+	}
+	if key.IsExpired(u.now()) {
+		return entity.IssuedAPIKey{}, domerrors.ErrInvalidAPIKeyExpiry
+	}
+
+	if _, err := u.user.FindByID(ctx, key.OwnerID); err != nil {
+		return entity.IssuedAPIKey{}, errors.WithMessage(err, "owner of the api key")
+	}
+
+	prefix, err := randomAPIKeyPrefix()
+	if err != nil {
+		return entity.IssuedAPIKey{}, err
+	}
+	secret, err := randomToken(apiKeySecretSize)
+	if err != nil {
+		return entity.IssuedAPIKey{}, err
+	}
+	plain := prefix + apiKeySeparator + secret
+
+	key.Prefix = prefix
+	key.KeyHash = hashToken(plain)
+	key.LastUsedAt = nil
+	key.RevokedAt = nil
+	stored, err := u.keys.Create(ctx, key)
+	if err != nil {
+		return entity.IssuedAPIKey{}, err
+	}
+
+	return entity.IssuedAPIKey{APIKey: stored, Key: plain}, nil
+}
+
+// APIKeyRevoker use case
+type APIKeyRevoker struct {
+	keys repository.APIKey
+}
+
+// NewAPIKeyRevoker creates a new usecase.APIKeyRevoker instance
+func NewAPIKeyRevoker(keys repository.APIKey) usecase.APIKeyRevoker {
+	return &APIKeyRevoker{
+		keys: keys,
+	}
+}
+
+// Revoke revokes the API key with the given ID or returns errors.ErrAPIKeyNotFound
+func (u *APIKeyRevoker) Revoke(ctx context.Context, id uint) error {
+	return u.keys.Revoke(ctx, id)
+}
+
+// APIKeyAuthenticator use case
+type APIKeyAuthenticator struct {
+	keys repository.APIKey
+	user repository.User
+	now  func() time.Time
+}
+
+// NewAPIKeyAuthenticator creates a new usecase.APIKeyAuthenticator instance
+func NewAPIKeyAuthenticator(keys repository.APIKey, user repository.User) usecase.APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		keys: keys,
+		user: user,
+		now:  time.Now,
+	}
+}
+
+// Authenticate returns the principal of the given API key or errors.ErrInvalidAPIKey if it is not valid.
+// The principal acts as the owner of the key, with its roles, limited to what the scopes of the key grant.
+func (u *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (entity.Principal, error) {
+	prefix, _, ok := strings.Cut(key, apiKeySeparator)
+	if !ok || prefix == "" {
+		return entity.Principal{}, domerrors.ErrInvalidAPIKey
+	}
+
+	stored, err := u.keys.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, domerrors.ErrAPIKeyNotFound) {
+			return entity.Principal{}, domerrors.ErrInvalidAPIKey
+		}
+		return entity.Principal{}, err
+	}
+
+	now := u.now()
+	if subtle.ConstantTimeCompare([]byte(hashToken(key)), []byte(stored.KeyHash)) != 1 ||
+		stored.IsRevoked() || stored.IsExpired(now) {
+		return entity.Principal{}, domerrors.ErrInvalidAPIKey
+	}
+
+	owner, err := u.user.FindByID(ctx, stored.OwnerID)
+	if err != nil {
+		if errors.Is(err, domerrors.ErrUserNotFound) {
+			return entity.Principal{}, domerrors.ErrInvalidAPIKey
+		}
+		return entity.Principal{}, err
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= apiKeyTouchInterval {
+		if err = u.keys.Touch(ctx, stored.ID, now); err != nil {
+			return entity.Principal{}, err
+		}
+	}
+
+	principal := entity.Principal{
+		Subject: "apikey:" + stored.Prefix,
+		UserID:  owner.ID,
+		Roles:   owner.Roles,
+		Scopes:  stored.Scopes,
+		Scoped:  true,
+	}
+	if stored.ExpiresAt != nil {
+		principal.ExpiresAt = *stored.ExpiresAt
+	}
+	return principal, nil
+}
+
+// randomAPIKeyPrefix returns a random prefix of an API key
+func randomAPIKeyPrefix() (string, error) {
+	b := make([]byte, apiKeyPrefixSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate api key prefix")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyFinderAll struct {
+	mock.Mock
+}
+
+func NewMockAPIKeyFinderAll() *MockAPIKeyFinderAll {
+	return &MockAPIKeyFinderAll{}
+}
+
+func (m *MockAPIKeyFinderAll) Find(ctx context.Context) ([]entity.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+type MockAPIKeyCreator struct {
+	mock.Mock
+}
+
+func NewMockAPIKeyCreator() *MockAPIKeyCreator {
+	return &MockAPIKeyCreator{}
+}
+
+func (m *MockAPIKeyCreator) Create(ctx context.Context, key entity.APIKey) (entity.IssuedAPIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(entity.IssuedAPIKey), args.Error(1)
+}
+
+type MockAPIKeyRevoker struct {
+	mock.Mock
+}
+
+func NewMockAPIKeyRevoker() *MockAPIKeyRevoker {
+	return &MockAPIKeyRevoker{}
+}
+
+func (m *MockAPIKeyRevoker) Revoke(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockAPIKeyAuthenticator struct {
+	mock.Mock
+}
+
+func NewMockAPIKeyAuthenticator() *MockAPIKeyAuthenticator {
+	return &MockAPIKeyAuthenticator{}
+}
+
+func (m *MockAPIKeyAuthenticator) Authenticate(ctx context.Context, key string) (entity.Principal, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(entity.Principal), args.Error(1)
+}
@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyCreator_Create(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	owner := entity.User{ID: 2, Name: "Jane"}
+	callerContext := entity.ContextWithPrincipal(context.Background(), entity.Principal{Subject: "1", UserID: 1})
+
+	tests := []struct {
+		name  string
+		given func() (*repository.MockAPIKey, *repository.MockUser)
+		when  func(*repository.MockAPIKey, *repository.MockUser) (entity.IssuedAPIKey, error)
+		then  func(entity.IssuedAPIKey, error)
+	}{
+		{
+			name: "should create an API key of the given owner",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("Create", context.Background(), mock.MatchedBy(func(key entity.APIKey) bool {
+					return key.Name == "batch" && key.OwnerID == 2 && len(key.Prefix) == 2*apiKeyPrefixSize &&
+						len(key.KeyHash) == 64
+				})).Return(entity.APIKey{ID: 1, Name: "batch", Prefix: "0123456789ab", OwnerID: 2}, nil)
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(2)).Return(owner, nil)
+				return k, u
+			},
+			when: func(k *repository.MockAPIKey, u *repository.MockUser) (entity.IssuedAPIKey, error) {
+				c := &APIKeyCreator{keys: k, user: u, now: func() time.Time { return now }}
+				return c.Create(context.Background(), entity.APIKey{Name: "batch", OwnerID: 2, Scopes: []string{"users:read"}})
+			},
+			then: func(issued entity.IssuedAPIKey, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), issued.APIKey.ID)
+				prefix, secret, ok := strings.Cut(issued.Key, apiKeySeparator)
+				assert.True(t, ok)
+				assert.Len(t, prefix, 2*apiKeyPrefixSize)
+				assert.NotEmpty(t, secret)
+			},
+		},
+		{
+			name: "should create an API key of the caller",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("Create", callerContext, mock.MatchedBy(func(key entity.APIKey) bool {
+					return key.OwnerID == 1
+				})).Return(entity.APIKey{ID: 1, OwnerID: 1}, nil)
+				u := repository.NewMockUser()
+				u.On("FindByID", callerContext, uint(1)).Return(entity.User{ID: 1}, nil)
+				return k, u
+			},
+			when: func(k *repository.MockAPIKey, u *repository.MockUser) (entity.IssuedAPIKey, error) {
+				return NewAPIKeyCreator(k, u).Create(callerContext, entity.APIKey{Name: "batch"})
+			},
+			then: func(issued entity.IssuedAPIKey, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), issued.APIKey.OwnerID)
+			},
+		},
+		{
+			name: "should not create an API key without owner",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				return repository.NewMockAPIKey(), repository.NewMockUser()
+			},
+			when: func(k *repository.MockAPIKey, u *repository.MockUser) (entity.IssuedAPIKey, error) {
+				return NewAPIKeyCreator(k, u).Create(context.Background(), entity.APIKey{Name: "batch"})
+			},
+			then: func(issued entity.IssuedAPIKey, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, issued)
+			},
+		},
+		{
+			name: "should not create an API key of an unknown owner",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(9)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				return repository.NewMockAPIKey(), u
+			},
+			when: func(k *repository.MockAPIKey, u *repository.MockUser) (entity.IssuedAPIKey, error) {
+				return NewAPIKeyCreator(k, u).Create(context.Background(), entity.APIKey{Name: "batch", OwnerID: 9})
+			},
+			then: func(issued entity.IssuedAPIKey, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, issued)
+			},
+		},
+		{
+			name: "should not create an already expired API key",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				return repository.NewMockAPIKey(), repository.NewMockUser()
+			},
+			when: func(k *repository.MockAPIKey, u *repository.MockUser) (entity.IssuedAPIKey, error) {
+				c := &APIKeyCreator{keys: k, user: u, now: func() time.Time { return now }}
+				return c.Create(context.Background(), entity.APIKey{Name: "batch", OwnerID: 2, ExpiresAt: &past})
+			},
+			then: func(issued entity.IssuedAPIKey, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKeyExpiry)
+				assert.Empty(t, issued)
+			},
+		},
+		{
+			name: "should fail when the API key cannot be stored",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("Create", context.Background(), mock.Anything).Return(entity.APIKey{}, errors.New("error"))
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(2)).Return(owner, nil)
+				return k, u
+			},
+			when: func(k *repository.MockAPIKey, u *repository.MockUser) (entity.IssuedAPIKey, error) {
+				return NewAPIKeyCreator(k, u).Create(context.Background(), entity.APIKey{Name: "batch", OwnerID: 2})
+			},
+			then: func(issued entity.IssuedAPIKey, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, issued)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			k, u := tt.given()
+
+			// When
+			issued, err := tt.when(k, u)
+
+			// Then
+			tt.then(issued, err)
+			k.AssertExpectations(t)
+			u.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyAuthenticator_Authenticate(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	recently := now.Add(-time.Second)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	plain := "0123456789ab.secret"
+	stored := entity.APIKey{
+		ID:      1,
+		Prefix:  "0123456789ab",
+		KeyHash: hashToken(plain),
+		OwnerID: 2,
+		Scopes:  []string{"users:read"},
+	}
+	owner := entity.User{ID: 2, Roles: []string{entity.RoleAdmin}}
+
+	tests := []struct {
+		name  string
+		key   string
+		given func() (*repository.MockAPIKey, *repository.MockUser)
+		then  func(entity.Principal, error)
+	}{
+		{
+			name: "should authenticate the owner of the key with the scopes of the key",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(stored, nil)
+				k.On("Touch", context.Background(), uint(1), now).Return(nil)
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(2)).Return(owner, nil)
+				return k, u
+			},
+			then: func(p entity.Principal, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.Principal{
+					Subject: "apikey:0123456789ab",
+					UserID:  2,
+					Roles:   []string{entity.RoleAdmin},
+					Scopes:  []string{"users:read"},
+					Scoped:  true,
+				}, p)
+			},
+		},
+		{
+			name: "should let the principal expire with the key",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				expiring := stored
+				expiring.ExpiresAt = &future
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(expiring, nil)
+				k.On("Touch", context.Background(), uint(1), now).Return(nil)
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(2)).Return(owner, nil)
+				return k, u
+			},
+			then: func(p entity.Principal, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, future, p.ExpiresAt)
+			},
+		},
+		{
+			name: "should not record the use of a key used recently",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				used := stored
+				used.LastUsedAt = &recently
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(used, nil)
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(2)).Return(owner, nil)
+				return k, u
+			},
+			then: func(p entity.Principal, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(2), p.UserID)
+			},
+		},
+		{
+			name: "should reject a malformed key",
+			key:  "malformed",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				return repository.NewMockAPIKey(), repository.NewMockUser()
+			},
+			then: func(p entity.Principal, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "should reject an unknown key",
+			key:  "unknown.secret",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "unknown").Return(entity.APIKey{}, domerrors.ErrAPIKeyNotFound)
+				return k, repository.NewMockUser()
+			},
+			then: func(p entity.Principal, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "should reject a key with a wrong secret",
+			key:  "0123456789ab.wrong",
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(stored, nil)
+				return k, repository.NewMockUser()
+			},
+			then: func(p entity.Principal, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "should reject a revoked key",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				revoked := stored
+				revoked.RevokedAt = &past
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(revoked, nil)
+				return k, repository.NewMockUser()
+			},
+			then: func(p entity.Principal, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "should reject an expired key",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				expired := stored
+				expired.ExpiresAt = &past
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(expired, nil)
+				return k, repository.NewMockUser()
+			},
+			then: func(p entity.Principal, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "should reject a key whose owner no longer exists",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(stored, nil)
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(2)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				return k, u
+			},
+			then: func(p entity.Principal, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "should fail when the key cannot be found",
+			key:  plain,
+			given: func() (*repository.MockAPIKey, *repository.MockUser) {
+				k := repository.NewMockAPIKey()
+				k.On("FindByPrefix", context.Background(), "0123456789ab").Return(entity.APIKey{}, errors.New("error"))
+				return k, repository.NewMockUser()
+			},
+			then: func(p entity.Principal, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrInvalidAPIKey)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			k, u := tt.given()
+			a := &APIKeyAuthenticator{keys: k, user: u, now: func() time.Time { return now }}
+
+			// When
+			p, err := a.Authenticate(context.Background(), tt.key)
+
+			// Then
+			tt.then(p, err)
+			k.AssertExpectations(t)
+			u.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyRevoker_Revoke(t *testing.T) {
+	k := repository.NewMockAPIKey()
+	k.On("Revoke", context.Background(), uint(1)).Return(domerrors.ErrAPIKeyNotFound)
+
+	err := NewAPIKeyRevoker(k).Revoke(context.Background(), 1)
+
+	assert.ErrorIs(t, err, domerrors.ErrAPIKeyNotFound)
+	k.AssertExpectations(t)
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+// UserAuthenticator use case
+type UserAuthenticator struct {
+	user        repository.User
+	credentials repository.UserCredentials
+	hasher      service.PasswordHasher
+
+	// dummyHash is verified when the username is unknown, so that both failures take a similar time
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+// NewUserAuthenticator creates a new usecase.UserAuthenticator instance
+func NewUserAuthenticator(
+	user repository.User,
+	credentials repository.UserCredentials,
+	hasher service.PasswordHasher,
+) usecase.UserAuthenticator {
+	return &UserAuthenticator{
+		user:        user,
+		credentials: credentials,
+		hasher:      hasher,
+	}
+}
+
+// Authenticate returns the user owning the given credentials or errors.ErrInvalidCredentials if they do not match
+func (u *UserAuthenticator) Authenticate(ctx context.Context, username, password string) (entity.User, error) {
+	credentials, err := u.credentials.FindCredentialsByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, domerrors.ErrUserNotFound) {
+			u.verifyDummy(password)
+			return entity.User{}, domerrors.ErrInvalidCredentials
+		}
+		return entity.User{}, err
+	}
+
+	ok, err := u.hasher.Verify(credentials.PasswordHash, password)
+	if err != nil {
+		return entity.User{}, err
+	}
+	if !ok {
+		return entity.User{}, domerrors.ErrInvalidCredentials
+	}
+
+	user, err := u.user.FindByID(ctx, credentials.UserID)
+	if err != nil {
+		if errors.Is(err, domerrors.ErrUserNotFound) {
+			return entity.User{}, domerrors.ErrInvalidCredentials
+		}
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// verifyDummy spends the same effort as a real verification against a hash of no account
+func (u *UserAuthenticator) verifyDummy(password string) {
+	u.dummyHashOnce.Do(func() {
+		u.dummyHash, _ = u.hasher.Hash("dummy password")
+	})
+	_, _ = u.hasher.Verify(u.dummyHash, password)
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserAuthenticator struct {
+	mock.Mock
+}
+
+func NewMockUserAuthenticator() *MockUserAuthenticator {
+	return &MockUserAuthenticator{}
+}
+
+func (m *MockUserAuthenticator) Authenticate(ctx context.Context, username, password string) (entity.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+type MockUserProvisioner struct {
+	mock.Mock
+}
+
+func NewMockUserProvisioner() *MockUserProvisioner {
+	return &MockUserProvisioner{}
+}
+
+func (m *MockUserProvisioner) Provision(ctx context.Context, identity entity.ExternalIdentity) (entity.User, error) {
+	args := m.Called(ctx, identity)
+	return args.Get(0).(entity.User), args.Error(1)
+}
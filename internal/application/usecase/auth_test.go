@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserAuthenticator_Authenticate(t *testing.T) {
+	credentials := entity.Credentials{UserID: 1, Username: "john", PasswordHash: "hash"}
+
+	tests := []struct {
+		name  string
+		given func() (*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher)
+		when  func(*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher) (entity.User, error)
+		then  func(entity.User, error)
+	}{
+		{
+			name: "should authenticate user",
+			given: func() (*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher) {
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				c := repository.NewMockUserCredentials()
+				c.On("FindCredentialsByUsername", context.Background(), "john").Return(credentials, nil)
+				h := security.NewMockPasswordHasher()
+				h.On("Verify", "hash", "lark").Return(true, nil)
+				return u, c, h
+			},
+			when: func(u *repository.MockUser, c *repository.MockUserCredentials, h *security.MockPasswordHasher) (entity.User, error) {
+				return NewUserAuthenticator(u, c, h).Authenticate(context.Background(), "john", "lark")
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), user.ID)
+				assert.Equal(t, "John", user.Name)
+				assert.Equal(t, "Doe", user.Surname)
+			},
+		},
+		{
+			name: "should not authenticate user with wrong password",
+			given: func() (*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher) {
+				u := repository.NewMockUser()
+				c := repository.NewMockUserCredentials()
+				c.On("FindCredentialsByUsername", context.Background(), "john").Return(credentials, nil)
+				h := security.NewMockPasswordHasher()
+				h.On("Verify", "hash", "wrong").Return(false, nil)
+				return u, c, h
+			},
+			when: func(u *repository.MockUser, c *repository.MockUserCredentials, h *security.MockPasswordHasher) (entity.User, error) {
+				return NewUserAuthenticator(u, c, h).Authenticate(context.Background(), "john", "wrong")
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidCredentials)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should not authenticate unknown user",
+			given: func() (*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher) {
+				u := repository.NewMockUser()
+				c := repository.NewMockUserCredentials()
+				c.On("FindCredentialsByUsername", context.Background(), "unknown").Return(entity.Credentials{}, domerrors.ErrUserNotFound)
+				h := security.NewMockPasswordHasher()
+				h.On("Hash", mock.Anything).Return("dummy", nil)
+				h.On("Verify", "dummy", "lark").Return(false, nil)
+				return u, c, h
+			},
+			when: func(u *repository.MockUser, c *repository.MockUserCredentials, h *security.MockPasswordHasher) (entity.User, error) {
+				return NewUserAuthenticator(u, c, h).Authenticate(context.Background(), "unknown", "lark")
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidCredentials)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should not authenticate when the user of the credentials does not exist",
+			given: func() (*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher) {
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				c := repository.NewMockUserCredentials()
+				c.On("FindCredentialsByUsername", context.Background(), "john").Return(credentials, nil)
+				h := security.NewMockPasswordHasher()
+				h.On("Verify", "hash", "lark").Return(true, nil)
+				return u, c, h
+			},
+			when: func(u *repository.MockUser, c *repository.MockUserCredentials, h *security.MockPasswordHasher) (entity.User, error) {
+				return NewUserAuthenticator(u, c, h).Authenticate(context.Background(), "john", "lark")
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidCredentials)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should fail when credentials cannot be read",
+			given: func() (*repository.MockUser, *repository.MockUserCredentials, *security.MockPasswordHasher) {
+				u := repository.NewMockUser()
+				c := repository.NewMockUserCredentials()
+				c.On("FindCredentialsByUsername", context.Background(), "john").Return(entity.Credentials{}, errors.New("connection lost"))
+				h := security.NewMockPasswordHasher()
+				return u, c, h
+			},
+			when: func(u *repository.MockUser, c *repository.MockUserCredentials, h *security.MockPasswordHasher) (entity.User, error) {
+				return NewUserAuthenticator(u, c, h).Authenticate(context.Background(), "john", "lark")
+			},
+			then: func(user entity.User, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrInvalidCredentials)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser, mockCredentials, mockHasher := tt.given()
+
+			// When
+			user, err := tt.when(mockUser, mockCredentials, mockHasher)
+
+			// Then
+			tt.then(user, err)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+// UserProvisioner use case
+type UserProvisioner struct {
+	userEventRaiser
+	identities repository.UserIdentity
+}
+
+// NewUserProvisioner creates a new usecase.UserProvisioner instance, raising its events in the given outbox
+func NewUserProvisioner(
+	identities repository.UserIdentity,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+) usecase.UserProvisioner {
+	return &UserProvisioner{
+		userEventRaiser: userEventRaiser{tx: tx, outbox: outbox},
+		identities:      identities,
+	}
+}
+
+// Provision returns the user linked to the given identity, creating it without roles the first time the identity is
+// seen, in which case an entity.UserCreated event is raised along with the user
+func (u *UserProvisioner) Provision(ctx context.Context, identity entity.ExternalIdentity) (entity.User, error) {
+	user, err := u.identities.FindByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, domerrors.ErrUserNotFound) {
+		return entity.User{}, err
+	}
+
+	provisioned, err := newProvisionedUser(identity)
+	if err != nil {
+		return entity.User{}, err
+	}
+	user, err = u.change(ctx, entity.UserCreated, func(ctx context.Context) (entity.User, error) {
+		return u.identities.CreateWithIdentity(ctx, provisioned, identity)
+	})
+	if errors.Is(err, domerrors.ErrUserAlreadyExists) {
+		// a concurrent request of the same identity provisioned the user first
+		return u.identities.FindByIdentity(ctx, identity.Issuer, identity.Subject)
+	}
+
+	return user, err
+}
+
+// provisionedUserFallbackName is the name, or surname, of the users provisioned for an identity without any valid one
+const provisionedUserFallbackName = "Unknown"
+
+// newProvisionedUser returns the normalized user to create for the given identity, named after the first of its names,
+// email user and subject left valid by entity.SanitizedName, and surnamed after its family name, falling back to
+// provisionedUserFallbackName for either
+func newProvisionedUser(identity entity.ExternalIdentity) (entity.User, error) {
+	emailUser, _, _ := strings.Cut(identity.Email, "@")
+	user := entity.User{
+		Name:    provisionedName(identity.GivenName, identity.Name, emailUser, identity.Subject),
+		Surname: provisionedName(identity.FamilyName),
+	}.Normalized()
+	if err := user.Validate(); err != nil {
+		return entity.User{}, errors.Wrapf(err, "cannot provision a valid user for the identity %q of %s",
+			identity.Subject, identity.Issuer)
+	}
+	return user, nil
+}
+
+// provisionedName returns the first of the given names which is valid once sanitized, or else
+// provisionedUserFallbackName
+func provisionedName(names ...string) string {
+	for _, name := range names {
+		if name = entity.SanitizedName(name); name != "" {
+			return name
+		}
+	}
+	return provisionedUserFallbackName
+}
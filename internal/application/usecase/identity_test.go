@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserProvisioner_Provision(t *testing.T) {
+	identity := entity.ExternalIdentity{
+		Issuer:     "https://issuer.example.com",
+		Subject:    "external-subject",
+		Email:      "john@example.com",
+		GivenName:  "John",
+		FamilyName: "Doe",
+	}
+	provisioned := entity.User{Name: "John", Surname: "Doe"}
+
+	tests := []struct {
+		name  string
+		given func() *repository.MockUserIdentity
+		when  func(*repository.MockUserIdentity) (entity.User, error)
+		then  func(entity.User, error)
+	}{
+		{
+			name: "should return the user linked to the identity",
+			given: func() *repository.MockUserIdentity {
+				r := repository.NewMockUserIdentity()
+				r.On("FindByIdentity", context.Background(), identity.Issuer, identity.Subject).
+					Return(entity.User{ID: 1, Name: "John", Roles: []string{entity.RoleAdmin}}, nil)
+				return r
+			},
+			when: func(r *repository.MockUserIdentity) (entity.User, error) {
+				return NewUserProvisioner(r, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Provision(context.Background(), identity)
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.User{ID: 1, Name: "John", Roles: []string{entity.RoleAdmin}}, user)
+			},
+		},
+		{
+			name: "should provision a user without roles the first time the identity is seen",
+			given: func() *repository.MockUserIdentity {
+				r := repository.NewMockUserIdentity()
+				r.On("FindByIdentity", context.Background(), identity.Issuer, identity.Subject).
+					Return(entity.User{}, domerrors.ErrUserNotFound)
+				r.On("CreateWithIdentity", context.Background(), provisioned, identity).
+					Return(entity.User{ID: 2, Name: "John", Surname: "Doe"}, nil)
+				return r
+			},
+			when: func(r *repository.MockUserIdentity) (entity.User, error) {
+				return NewUserProvisioner(r, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Provision(context.Background(), identity)
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.User{ID: 2, Name: "John", Surname: "Doe"}, user)
+			},
+		},
+		{
+			name: "should return the user provisioned by a concurrent request",
+			given: func() *repository.MockUserIdentity {
+				r := repository.NewMockUserIdentity()
+				r.On("FindByIdentity", context.Background(), identity.Issuer, identity.Subject).
+					Return(entity.User{}, domerrors.ErrUserNotFound).Once()
+				r.On("CreateWithIdentity", context.Background(), provisioned, identity).
+					Return(entity.User{}, domerrors.ErrUserAlreadyExists)
+				r.On("FindByIdentity", context.Background(), identity.Issuer, identity.Subject).
+					Return(entity.User{ID: 3, Name: "John", Surname: "Doe"}, nil).Once()
+				return r
+			},
+			when: func(r *repository.MockUserIdentity) (entity.User, error) {
+				return NewUserProvisioner(r, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Provision(context.Background(), identity)
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(3), user.ID)
+			},
+		},
+		{
+			name: "should fail when the identity cannot be found",
+			given: func() *repository.MockUserIdentity {
+				r := repository.NewMockUserIdentity()
+				r.On("FindByIdentity", context.Background(), identity.Issuer, identity.Subject).
+					Return(entity.User{}, errors.New("error"))
+				return r
+			},
+			when: func(r *repository.MockUserIdentity) (entity.User, error) {
+				return NewUserProvisioner(r, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Provision(context.Background(), identity)
+			},
+			then: func(user entity.User, err error) {
+				assert.Error(t, err)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should fail when the user cannot be provisioned",
+			given: func() *repository.MockUserIdentity {
+				r := repository.NewMockUserIdentity()
+				r.On("FindByIdentity", context.Background(), identity.Issuer, identity.Subject).
+					Return(entity.User{}, domerrors.ErrUserNotFound)
+				r.On("CreateWithIdentity", context.Background(), provisioned, identity).
+					Return(entity.User{}, errors.New("error"))
+				return r
+			},
+			when: func(r *repository.MockUserIdentity) (entity.User, error) {
+				return NewUserProvisioner(r, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Provision(context.Background(), identity)
+			},
+			then: func(user entity.User, err error) {
+				assert.Error(t, err)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			r := tt.given()
+
+			// When
+			user, err := tt.when(r)
+
+			// Then
+			tt.then(user, err)
+			r.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewProvisionedUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity entity.ExternalIdentity
+		want     entity.User
+	}{
+		{
+			name:     "should use the given and family names",
+			identity: entity.ExternalIdentity{Subject: "sub", Email: "john@example.com", Name: "John Doe", GivenName: "John", FamilyName: "Doe"},
+			want:     entity.User{Name: "John", Surname: "Doe"},
+		},
+		{
+			name:     "should normalize the names",
+			identity: entity.ExternalIdentity{Subject: "sub", GivenName: "  Jose\u0301  Mari\u0301a ", FamilyName: "de   la Cruz"},
+			want:     entity.User{Name: "José María", Surname: "de la Cruz"},
+		},
+		{
+			name:     "should fall back to the full name",
+			identity: entity.ExternalIdentity{Subject: "sub", Email: "john@example.com", Name: "John Doe"},
+			want:     entity.User{Name: "John Doe", Surname: provisionedUserFallbackName},
+		},
+		{
+			name:     "should fall back to the user of the email without its invalid characters",
+			identity: entity.ExternalIdentity{Subject: "sub", Email: "john_doe+1@example.com"},
+			want:     entity.User{Name: "john doe", Surname: provisionedUserFallbackName},
+		},
+		{
+			name:     "should fall back to the subject",
+			identity: entity.ExternalIdentity{Subject: "sub"},
+			want:     entity.User{Name: "sub", Surname: provisionedUserFallbackName},
+		},
+		{
+			name:     "should skip the names without valid characters",
+			identity: entity.ExternalIdentity{Subject: "248289761001", GivenName: "🙂", FamilyName: "42"},
+			want:     entity.User{Name: provisionedUserFallbackName, Surname: provisionedUserFallbackName},
+		},
+		{
+			name:     "should truncate the names too long",
+			identity: entity.ExternalIdentity{Subject: "sub", GivenName: strings.Repeat("a", entity.MaxUserNameLength) + " b", FamilyName: "Doe"},
+			want:     entity.User{Name: strings.Repeat("a", entity.MaxUserNameLength), Surname: "Doe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := newProvisionedUser(tt.identity)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, user)
+			assert.NoError(t, user.Validate())
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+// RefreshTokenTTL is the lifetime of the refresh tokens
+type RefreshTokenTTL time.Duration
+
+// refreshTokenSize is the number of random bytes of the opaque refresh tokens
+const refreshTokenSize = 32
+
+// tokenPairs issues the token pairs shared by the token use cases
+type tokenPairs struct {
+	tokens repository.RefreshToken
+	issuer service.TokenIssuer
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// issue returns a new access token and a new refresh token of the given family
+func (p tokenPairs) issue(ctx context.Context, user entity.User, familyID string) (entity.TokenPair, error) {
+	accessToken, err := p.issuer.Issue(ctx, user)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	refreshToken, err := randomToken(refreshTokenSize)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	stored, err := p.tokens.Create(ctx, entity.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: p.now().Add(p.ttl),
+	})
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	return entity.TokenPair{
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// TokenGranter use case
+type TokenGranter struct {
+	pairs tokenPairs
+}
+
+// NewTokenGranter creates a new usecase.TokenGranter instance
+func NewTokenGranter(tokens repository.RefreshToken, issuer service.TokenIssuer, ttl RefreshTokenTTL) usecase.TokenGranter {
+	return &TokenGranter{
+		pairs: tokenPairs{tokens: tokens, issuer: issuer, ttl: time.Duration(ttl), now: time.Now},
+	}
+}
+
+// Grant returns a new access token and a refresh token of a new token family
+func (u *TokenGranter) Grant(ctx context.Context, user entity.User) (entity.TokenPair, error) {
+	familyID, err := randomFamilyID()
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	return u.pairs.issue(ctx, user, familyID)
+}
+
+// TokenRefresher use case
+type TokenRefresher struct {
+	user  repository.User
+	pairs tokenPairs
+}
+
+// NewTokenRefresher creates a new usecase.TokenRefresher instance
+func NewTokenRefresher(
+	user repository.User,
+	tokens repository.RefreshToken,
+	issuer service.TokenIssuer,
+	ttl RefreshTokenTTL,
+) usecase.TokenRefresher {
+	return &TokenRefresher{
+		user:  user,
+		pairs: tokenPairs{tokens: tokens, issuer: issuer, ttl: time.Duration(ttl), now: time.Now},
+	}
+}
+
+// Refresh revokes the given refresh token and returns a new access token and refresh token of the same family
+func (u *TokenRefresher) Refresh(ctx context.Context, refreshToken string) (entity.TokenPair, error) {
+	stored, err := u.pairs.tokens.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, domerrors.ErrRefreshTokenNotFound) {
+			return entity.TokenPair{}, domerrors.ErrInvalidRefreshToken
+		}
+		return entity.TokenPair{}, err
+	}
+
+	if stored.IsRevoked() {
+		return entity.TokenPair{}, u.revokeReusedFamily(ctx, stored)
+	}
+	if stored.IsExpired(u.pairs.now()) {
+		return entity.TokenPair{}, domerrors.ErrInvalidRefreshToken
+	}
+
+	// revoking is conditional, so only one of two concurrent refreshes of the same token wins
+	if err = u.pairs.tokens.Revoke(ctx, stored); err != nil {
+		if errors.Is(err, domerrors.ErrRefreshTokenRevoked) {
+			return entity.TokenPair{}, u.revokeReusedFamily(ctx, stored)
+		}
+		return entity.TokenPair{}, err
+	}
+
+	user, err := u.user.FindByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, domerrors.ErrUserNotFound) {
+			return entity.TokenPair{}, domerrors.ErrInvalidRefreshToken
+		}
+		return entity.TokenPair{}, err
+	}
+
+	return u.pairs.issue(ctx, user, stored.FamilyID)
+}
+
+// revokeReusedFamily revokes the family of a reused refresh token and returns errors.ErrRefreshTokenReused
+func (u *TokenRefresher) revokeReusedFamily(ctx context.Context, token entity.RefreshToken) error {
+	if err := u.pairs.tokens.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	return domerrors.ErrRefreshTokenReused
+}
+
+// TokenRevoker use case
+type TokenRevoker struct {
+	tokens repository.RefreshToken
+}
+
+// NewTokenRevoker creates a new usecase.TokenRevoker instance
+func NewTokenRevoker(tokens repository.RefreshToken) usecase.TokenRevoker {
+	return &TokenRevoker{
+		tokens: tokens,
+	}
+}
+
+// Revoke revokes the whole family of the given refresh token. Unknown tokens are ignored.
+func (u *TokenRevoker) Revoke(ctx context.Context, refreshToken string) error {
+	stored, err := u.tokens.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, domerrors.ErrRefreshTokenNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return u.tokens.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// randomToken returns a URL safe random token of the given number of bytes
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// randomFamilyID returns a random identifier of a refresh token family
+func randomFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate token family")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the hex encoded SHA-256 of the given token.
+// A plain hash is enough, since the tokens are long random values and not passwords.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTokenGranter struct {
+	mock.Mock
+}
+
+func NewMockTokenGranter() *MockTokenGranter {
+	return &MockTokenGranter{}
+}
+
+func (m *MockTokenGranter) Grant(ctx context.Context, user entity.User) (entity.TokenPair, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(entity.TokenPair), args.Error(1)
+}
+
+type MockTokenRefresher struct {
+	mock.Mock
+}
+
+func NewMockTokenRefresher() *MockTokenRefresher {
+	return &MockTokenRefresher{}
+}
+
+func (m *MockTokenRefresher) Refresh(ctx context.Context, refreshToken string) (entity.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(entity.TokenPair), args.Error(1)
+}
+
+type MockTokenRevoker struct {
+	mock.Mock
+}
+
+func NewMockTokenRevoker() *MockTokenRevoker {
+	return &MockTokenRevoker{}
+}
+
+func (m *MockTokenRevoker) Revoke(ctx context.Context, refreshToken string) error {
+	args := m.Called(ctx, refreshToken)
+	return args.Error(0)
+}
@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const refreshTokenTTL = RefreshTokenTTL(time.Hour)
+
+var accessToken = entity.AccessToken{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)}
+
+// isNewRefreshToken matches the refresh token stored for the given user and family
+func isNewRefreshToken(userID uint, familyID string) interface{} {
+	return mock.MatchedBy(func(t entity.RefreshToken) bool {
+		return t.UserID == userID &&
+			(familyID == "" || t.FamilyID == familyID) &&
+			len(t.FamilyID) == 32 &&
+			len(t.TokenHash) == 64 &&
+			time.Until(t.ExpiresAt) > 59*time.Minute
+	})
+}
+
+func TestTokenGranter_Grant(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() (*repository.MockRefreshToken, *security.MockTokenIssuer)
+		then  func(entity.TokenPair, error)
+	}{
+		{
+			name: "should grant tokens",
+			given: func() (*repository.MockRefreshToken, *security.MockTokenIssuer) {
+				r := repository.NewMockRefreshToken()
+				r.On("Create", context.Background(), isNewRefreshToken(1, "")).
+					Return(entity.RefreshToken{ID: 1, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				i := security.NewMockTokenIssuer()
+				i.On("Issue", context.Background(), entity.User{ID: 1}).Return(accessToken, nil)
+				return r, i
+			},
+			then: func(pair entity.TokenPair, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, accessToken, pair.AccessToken)
+				assert.Len(t, pair.RefreshToken, 43)
+				assert.WithinDuration(t, time.Now().Add(time.Hour), pair.RefreshTokenExpiresAt, time.Minute)
+			},
+		},
+		{
+			name: "should not grant tokens when the access token cannot be issued",
+			given: func() (*repository.MockRefreshToken, *security.MockTokenIssuer) {
+				r := repository.NewMockRefreshToken()
+				i := security.NewMockTokenIssuer()
+				i.On("Issue", context.Background(), entity.User{ID: 1}).Return(entity.AccessToken{}, errors.New("cannot sign"))
+				return r, i
+			},
+			then: func(pair entity.TokenPair, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, pair)
+			},
+		},
+		{
+			name: "should not grant tokens when the refresh token cannot be stored",
+			given: func() (*repository.MockRefreshToken, *security.MockTokenIssuer) {
+				r := repository.NewMockRefreshToken()
+				r.On("Create", context.Background(), mock.Anything).Return(entity.RefreshToken{}, errors.New("connection lost"))
+				i := security.NewMockTokenIssuer()
+				i.On("Issue", context.Background(), entity.User{ID: 1}).Return(accessToken, nil)
+				return r, i
+			},
+			then: func(pair entity.TokenPair, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, pair)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockRefreshToken, mockTokenIssuer := tt.given()
+
+			// When
+			pair, err := NewTokenGranter(mockRefreshToken, mockTokenIssuer, refreshTokenTTL).Grant(context.Background(), entity.User{ID: 1})
+
+			// Then
+			tt.then(pair, err)
+		})
+	}
+}
+
+func TestTokenRefresher_Refresh(t *testing.T) {
+	const (
+		token    = "refresh"
+		familyID = "0123456789abcdef0123456789abcdef"
+	)
+	hash := hashToken(token)
+	revokedAt := time.Now().Add(-time.Minute)
+	stored := entity.RefreshToken{ID: 1, UserID: 1, FamilyID: familyID, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name  string
+		given func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer)
+		then  func(*repository.MockRefreshToken, entity.TokenPair, error)
+	}{
+		{
+			name: "should rotate the refresh token",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1}, nil)
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(stored, nil)
+				r.On("Revoke", context.Background(), stored).Return(nil)
+				r.On("Create", context.Background(), isNewRefreshToken(1, familyID)).
+					Return(entity.RefreshToken{ID: 2, UserID: 1, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				i := security.NewMockTokenIssuer()
+				i.On("Issue", context.Background(), entity.User{ID: 1}).Return(accessToken, nil)
+				return u, r, i
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, accessToken, pair.AccessToken)
+				assert.NotEqual(t, token, pair.RefreshToken)
+				r.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should reject an unknown refresh token",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(entity.RefreshToken{}, domerrors.ErrRefreshTokenNotFound)
+				return repository.NewMockUser(), r, security.NewMockTokenIssuer()
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidRefreshToken)
+				assert.Empty(t, pair)
+			},
+		},
+		{
+			name: "should reject an expired refresh token",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				expired := stored
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(expired, nil)
+				return repository.NewMockUser(), r, security.NewMockTokenIssuer()
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidRefreshToken)
+				assert.Empty(t, pair)
+				r.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should revoke the family of a reused refresh token",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				revoked := stored
+				revoked.RevokedAt = &revokedAt
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(revoked, nil)
+				r.On("RevokeFamily", context.Background(), familyID).Return(nil)
+				return repository.NewMockUser(), r, security.NewMockTokenIssuer()
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrRefreshTokenReused)
+				assert.Empty(t, pair)
+				r.AssertCalled(t, "RevokeFamily", context.Background(), familyID)
+			},
+		},
+		{
+			name: "should revoke the family when a concurrent refresh rotated the token first",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(stored, nil)
+				r.On("Revoke", context.Background(), stored).Return(domerrors.ErrRefreshTokenRevoked)
+				r.On("RevokeFamily", context.Background(), familyID).Return(nil)
+				return repository.NewMockUser(), r, security.NewMockTokenIssuer()
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrRefreshTokenReused)
+				assert.Empty(t, pair)
+				r.AssertCalled(t, "RevokeFamily", context.Background(), familyID)
+			},
+		},
+		{
+			name: "should reject the refresh token of a removed user",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				u := repository.NewMockUser()
+				u.On("FindByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(stored, nil)
+				r.On("Revoke", context.Background(), stored).Return(nil)
+				return u, r, security.NewMockTokenIssuer()
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidRefreshToken)
+				assert.Empty(t, pair)
+			},
+		},
+		{
+			name: "should fail when the refresh token cannot be read",
+			given: func() (*repository.MockUser, *repository.MockRefreshToken, *security.MockTokenIssuer) {
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(entity.RefreshToken{}, errors.New("connection lost"))
+				return repository.NewMockUser(), r, security.NewMockTokenIssuer()
+			},
+			then: func(r *repository.MockRefreshToken, pair entity.TokenPair, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrInvalidRefreshToken)
+				assert.Empty(t, pair)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser, mockRefreshToken, mockTokenIssuer := tt.given()
+
+			// When
+			pair, err := NewTokenRefresher(mockUser, mockRefreshToken, mockTokenIssuer, refreshTokenTTL).
+				Refresh(context.Background(), token)
+
+			// Then
+			tt.then(mockRefreshToken, pair, err)
+		})
+	}
+}
+
+func TestTokenRevoker_Revoke(t *testing.T) {
+	const token = "refresh"
+	hash := hashToken(token)
+
+	tests := []struct {
+		name  string
+		given func() *repository.MockRefreshToken
+		then  func(*repository.MockRefreshToken, error)
+	}{
+		{
+			name: "should revoke the token family",
+			given: func() *repository.MockRefreshToken {
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(entity.RefreshToken{ID: 1, FamilyID: "family"}, nil)
+				r.On("RevokeFamily", context.Background(), "family").Return(nil)
+				return r
+			},
+			then: func(r *repository.MockRefreshToken, err error) {
+				assert.NoError(t, err)
+				r.AssertCalled(t, "RevokeFamily", context.Background(), "family")
+			},
+		},
+		{
+			name: "should ignore an unknown token",
+			given: func() *repository.MockRefreshToken {
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(entity.RefreshToken{}, domerrors.ErrRefreshTokenNotFound)
+				return r
+			},
+			then: func(r *repository.MockRefreshToken, err error) {
+				assert.NoError(t, err)
+				r.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should fail when the token cannot be read",
+			given: func() *repository.MockRefreshToken {
+				r := repository.NewMockRefreshToken()
+				r.On("FindByHash", context.Background(), hash).Return(entity.RefreshToken{}, errors.New("connection lost"))
+				return r
+			},
+			then: func(r *repository.MockRefreshToken, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockRefreshToken := tt.given()
+
+			// When
+			err := NewTokenRevoker(mockRefreshToken).Revoke(context.Background(), token)
+
+			// Then
+			tt.then(mockRefreshToken, err)
+		})
+	}
+}
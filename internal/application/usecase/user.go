@@ -2,28 +2,89 @@ package usecase
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
 )
 
 // UserFinderAll use case
 type UserFinderAll struct {
-	user repository.User
+	user    repository.User
+	cursors service.UserCursorCodec
 }
 
 // NewUserFinderAll creates a new usecase.UserFinderAll instance
-func NewUserFinderAll(user repository.User) usecase.UserFinderAll {
+func NewUserFinderAll(user repository.User, cursors service.UserCursorCodec) usecase.UserFinderAll {
 	return &UserFinderAll{
-		user: user,
+		user:    user,
+		cursors: cursors,
 	}
 }
 
-// Find returns all users or an error if something goes wrong
-func (u *UserFinderAll) Find(ctx context.Context) ([]entity.User, error) {
-	users, err := u.user.FindAll(ctx)
-	return users, err
+// Find returns the page of the users selected by the given query, errors.ErrInvalidUserQuery if it is not valid,
+// errors.ErrInvalidCursor if its cursor is not valid or was issued for another sort, or an error if something goes
+// wrong
+func (u *UserFinderAll) Find(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	if err := query.Validate(); err != nil {
+		return entity.UserPage{}, err
+	}
+	query = query.Normalized()
+
+	query.After = nil
+	if query.Cursor != "" {
+		after, err := u.cursors.Decode(query.Cursor)
+		if err != nil {
+			return entity.UserPage{}, err
+		}
+		if after.SortBy != query.SortBy || after.Direction != query.Direction {
+			return entity.UserPage{}, errors.Wrap(domerrors.ErrInvalidCursor, "cursor issued for another sort")
+		}
+		query.After = &after
+	}
+
+	page, err := u.user.FindAll(ctx, query)
+	if err != nil {
+		return entity.UserPage{}, err
+	}
+
+	if page.Next != nil {
+		if page.NextCursor, err = u.cursors.Encode(*page.Next); err != nil {
+			return entity.UserPage{}, err
+		}
+	}
+	return page, nil
+}
+
+// UserSearcher use case
+type UserSearcher struct {
+	search repository.UserSearch
+}
+
+// NewUserSearcher creates a new usecase.UserSearcher instance
+func NewUserSearcher(search repository.UserSearch) usecase.UserSearcher {
+	return &UserSearcher{
+		search: search,
+	}
+}
+
+// Search returns at most limit users matching the given query sorted by relevance, errors.ErrInvalidUserQuery
+// if the query is empty, or an error if something goes wrong.
+// The limit defaults to entity.DefaultUserPageLimit and is capped to entity.MaxUserPageLimit.
+func (u *UserSearcher) Search(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.Wrap(domerrors.ErrInvalidUserQuery, "search query is required")
+	}
+	if limit < 0 {
+		return nil, errors.Wrap(domerrors.ErrInvalidUserQuery, "limit cannot be negative")
+	}
+	return u.search.Search(ctx, query, entity.UserQuery{Limit: limit}.Normalized().Limit)
 }
 
 // UserFinderByID use case
@@ -45,51 +106,247 @@ func (u *UserFinderByID) Find(ctx context.Context, id uint) (entity.User, error)
 
 // UserCreator defines the use case for creating a user
 type UserCreator struct {
+	userEventRaiser
 	user repository.User
 }
 
-// NewUserCreator creates a new usecase.UserCreator instance
-func NewUserCreator(user repository.User) usecase.UserCreator {
+// NewUserCreator creates a new usecase.UserCreator instance, raising its events in the given outbox
+func NewUserCreator(user repository.User, tx repository.Transactor, outbox repository.UserOutbox) usecase.UserCreator {
 	return &UserCreator{
-		user: user,
+		userEventRaiser: userEventRaiser{tx: tx, outbox: outbox},
+		user:            user,
 	}
 }
 
-// Create creates a user and returns the created user or an error if something goes wrong
+// Create normalizes and creates a user and returns the created user, an *errors.ValidationError matching
+// errors.ErrInvalidUser if the user is not valid, or an error if something goes wrong.
+// Only admins can create users with roles. An entity.UserCreated event is raised along with the user.
 func (u *UserCreator) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	return u.user.Create(ctx, user)
+	user = user.Normalized()
+	if err := user.Validate(); err != nil {
+		return entity.User{}, err
+	}
+
+	if len(user.Roles) > 0 && !isAdmin(ctx) {
+		return entity.User{}, errors.Wrap(domerrors.ErrForbidden, "only admins can assign roles")
+	}
+
+	return u.change(ctx, entity.UserCreated, func(ctx context.Context) (entity.User, error) {
+		return u.user.Create(ctx, user)
+	})
 }
 
 // UserModifier defines the use case for modifying a user
 type UserModifier struct {
+	userEventRaiser
 	user repository.User
 }
 
-// NewUserModifier creates a new usecase.UserModifier instance
-func NewUserModifier(user repository.User) usecase.UserModifier {
+// NewUserModifier creates a new usecase.UserModifier instance, raising its events in the given outbox
+func NewUserModifier(user repository.User, tx repository.Transactor, outbox repository.UserOutbox) usecase.UserModifier {
 	return &UserModifier{
-		user: user,
+		userEventRaiser: userEventRaiser{tx: tx, outbox: outbox},
+		user:            user,
 	}
 }
 
-// Modify modifies a user and returns the modified user or an error if something goes wrong
+// Modify normalizes and modifies a user and returns the modified user, an *errors.ValidationError matching
+// errors.ErrInvalidUser if the user is not valid, errors.ErrConcurrentModification if the user is no longer at the
+// given version, or an error if something goes wrong.
+// The current version of the user is modified when the given user has no version.
+// The roles of the user are kept when the given user has no roles, and only admins can change them.
+// An entity.UserModified event is raised along with the modification.
 func (u *UserModifier) Modify(ctx context.Context, user entity.User) (entity.User, error) {
-	return u.user.Modify(ctx, user)
+	user = user.Normalized()
+	if err := user.Validate(); err != nil {
+		return entity.User{}, err
+	}
+
+	stored, err := u.user.FindByID(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Version == 0 {
+		user.Version = stored.Version
+	} else if user.Version != stored.Version {
+		return entity.User{}, domerrors.ErrConcurrentModification
+	}
+	user.CreatedAt = stored.CreatedAt
+
+	if user.Roles == nil {
+		user.Roles = stored.Roles
+	} else if !sameRoles(user.Roles, stored.Roles) && !isAdmin(ctx) {
+		return entity.User{}, errors.Wrap(domerrors.ErrForbidden, "only admins can change roles")
+	}
+
+	return u.change(ctx, entity.UserModified, func(ctx context.Context) (entity.User, error) {
+		return u.user.Modify(ctx, user)
+	})
+}
+
+// UserPatcher defines the use case for partially modifying a user
+type UserPatcher struct {
+	userEventRaiser
+	user    repository.User
+	patches service.UserPatchApplier
+}
+
+// NewUserPatcher creates a new usecase.UserPatcher instance, raising its events in the given outbox
+func NewUserPatcher(
+	user repository.User,
+	patches service.UserPatchApplier,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+) usecase.UserPatcher {
+	return &UserPatcher{
+		userEventRaiser: userEventRaiser{tx: tx, outbox: outbox},
+		user:            user,
+		patches:         patches,
+	}
+}
+
+// Patch loads the user of the given ID, applies the given patch to it and saves it. It returns the patched user,
+// errors.ErrUserNotFound if the user does not exist, errors.ErrInvalidPatch if the patch is malformed,
+// errors.ErrUnprocessablePatch if it cannot be applied or changes the ID of the user, errors.ErrPatchConflict if
+// one of its tests fails, an *errors.ValidationError matching errors.ErrInvalidUser if the patched user is not
+// valid, errors.ErrForbidden if it changes the roles and the caller is not an admin,
+// errors.ErrConcurrentModification if the user is no longer at the version of the patch, or an error if something
+// goes wrong. The patched user is normalized before being validated, and an entity.UserModified event is raised along
+// with the modification.
+func (u *UserPatcher) Patch(ctx context.Context, id uint, patch entity.UserPatch) (entity.User, error) {
+	stored, err := u.user.FindByID(ctx, id)
+	if err != nil {
+		return entity.User{}, err
+	}
+	if patch.Version != 0 && patch.Version != stored.Version {
+		return entity.User{}, domerrors.ErrConcurrentModification
+	}
+
+	patched, err := u.patches.Apply(stored, patch)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if patched.ID != stored.ID {
+		return entity.User{}, errors.Wrap(domerrors.ErrUnprocessablePatch, "id cannot be changed")
+	}
+	patched = patched.Normalized()
+	if err = patched.Validate(); err != nil {
+		return entity.User{}, err
+	}
+	if !sameRoles(patched.Roles, stored.Roles) && !isAdmin(ctx) {
+		return entity.User{}, errors.Wrap(domerrors.ErrForbidden, "only admins can change roles")
+	}
+
+	// the version and the timestamps are not part of the patched document
+	patched.Version = stored.Version
+	patched.CreatedAt = stored.CreatedAt
+	return u.change(ctx, entity.UserModified, func(ctx context.Context) (entity.User, error) {
+		return u.user.Modify(ctx, patched)
+	})
 }
 
 // UserDeleter defines the use case for deleting a user
 type UserDeleter struct {
+	userEventRaiser
 	user repository.User
 }
 
-// NewUserDeleter creates a new usecase.UserDeleter instance
-func NewUserDeleter(user repository.User) usecase.UserDeleter {
+// NewUserDeleter creates a new usecase.UserDeleter instance, raising its events in the given outbox
+func NewUserDeleter(user repository.User, tx repository.Transactor, outbox repository.UserOutbox) usecase.UserDeleter {
 	return &UserDeleter{
-		user: user,
+		userEventRaiser: userEventRaiser{tx: tx, outbox: outbox},
+		user:            user,
 	}
 }
 
-// Delete deletes a user and returns an error if something goes wrong
+// Delete deletes a user at its version, keeping it until it is purged, and returns
+// errors.ErrConcurrentModification if the user is no longer at that version, or an error if something goes wrong.
+// An entity.UserDeleted event is raised along with the deletion.
 func (u *UserDeleter) Delete(ctx context.Context, user entity.User) error {
-	return u.user.Delete(ctx, user)
+	_, err := u.change(ctx, entity.UserDeleted, func(ctx context.Context) (entity.User, error) {
+		if err := u.user.Delete(ctx, user); err != nil {
+			return entity.User{}, err
+		}
+		return u.user.FindDeletedByID(ctx, user.ID)
+	})
+	return err
+}
+
+// Purge permanently deletes the user of the given ID, deleted or not, at the given version or at its current one if
+// 0, and returns errors.ErrForbidden if the caller is not an admin, errors.ErrUserNotFound if there is no such user,
+// errors.ErrConcurrentModification if the user is no longer at the given version, or an error if something goes wrong.
+// An entity.UserPurged event of the user as it was before the purge is raised along with the purge.
+func (u *UserDeleter) Purge(ctx context.Context, id uint, version uint) error {
+	if !isAdmin(ctx) {
+		return errors.Wrap(domerrors.ErrForbidden, "only admins can purge the users")
+	}
+
+	user, err := u.user.FindByID(ctx, id)
+	if errors.Is(err, domerrors.ErrUserNotFound) {
+		user, err = u.user.FindDeletedByID(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != user.Version {
+		return domerrors.ErrConcurrentModification
+	}
+
+	_, err = u.change(ctx, entity.UserPurged, func(ctx context.Context) (entity.User, error) {
+		return user, u.user.Purge(ctx, user)
+	})
+	return err
+}
+
+// UserRestorer defines the use case for restoring a deleted user
+type UserRestorer struct {
+	userEventRaiser
+	user repository.User
+}
+
+// NewUserRestorer creates a new usecase.UserRestorer instance, raising its events in the given outbox
+func NewUserRestorer(user repository.User, tx repository.Transactor, outbox repository.UserOutbox) usecase.UserRestorer {
+	return &UserRestorer{
+		userEventRaiser: userEventRaiser{tx: tx, outbox: outbox},
+		user:            user,
+	}
+}
+
+// Restore restores the deleted user of the given ID at the given version or at its current one if 0, and returns
+// the restored user, errors.ErrUserNotDeleted if the user is not deleted, errors.ErrUserNotFound if there is no such
+// user, errors.ErrConcurrentModification if the user is no longer at the given version, or an error if something
+// goes wrong. An entity.UserRestored event is raised along with the restoration.
+func (u *UserRestorer) Restore(ctx context.Context, id uint, version uint) (entity.User, error) {
+	deleted, err := u.user.FindDeletedByID(ctx, id)
+	if errors.Is(err, domerrors.ErrUserNotFound) {
+		if _, findErr := u.user.FindByID(ctx, id); findErr == nil {
+			return entity.User{}, domerrors.ErrUserNotDeleted
+		}
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+	if version != 0 && version != deleted.Version {
+		return entity.User{}, domerrors.ErrConcurrentModification
+	}
+
+	return u.change(ctx, entity.UserRestored, func(ctx context.Context) (entity.User, error) {
+		return u.user.Restore(ctx, deleted)
+	})
+}
+
+// isAdmin reports whether the caller of the given context is an admin
+func isAdmin(ctx context.Context) bool {
+	p, ok := entity.PrincipalFromContext(ctx)
+	return ok && p.IsAdmin()
+}
+
+// sameRoles reports whether the given roles are the same regardless of their order
+func sameRoles(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
 }
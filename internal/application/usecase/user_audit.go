@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+// userAuditor records the changes of the users made by the audited use cases, in the transaction of the changes
+type userAuditor struct {
+	user  repository.User
+	tx    repository.Transactor
+	audit repository.UserAudit
+}
+
+// find returns the stored user of the given ID, deleted or not, or nil if there is none
+func (a userAuditor) find(ctx context.Context, id uint) *entity.User {
+	user, err := a.user.FindByID(ctx, id)
+	if err != nil {
+		if user, err = a.user.FindDeletedByID(ctx, id); err != nil {
+			return nil
+		}
+	}
+	return &user
+}
+
+// record records the given change of the user of the given ID, made by the principal and in the request of the
+// given context
+func (a userAuditor) record(ctx context.Context, action entity.UserAuditAction, id uint, before, after *entity.User) error {
+	entry := entity.UserAuditEntry{
+		UserID:    id,
+		Action:    action,
+		Before:    before,
+		After:     after,
+		RequestID: entity.RequestIDFromContext(ctx),
+	}
+	if p, ok := entity.PrincipalFromContext(ctx); ok {
+		entry.Actor = p.Subject
+		entry.ActorID = p.UserID
+	}
+
+	if _, err := a.audit.Append(ctx, entry); err != nil {
+		return errors.WithMessagef(err, "cannot audit %s of user %d", action, id)
+	}
+	return nil
+}
+
+// AuditedUserCreator decorates a usecase.UserCreator, recording the created users in their audit trail
+type AuditedUserCreator struct {
+	userAuditor
+	next usecase.UserCreator
+}
+
+// NewAuditedUserCreator creates a new usecase.UserCreator instance auditing the given one
+func NewAuditedUserCreator(
+	next usecase.UserCreator,
+	user repository.User,
+	tx repository.Transactor,
+	audit repository.UserAudit,
+) usecase.UserCreator {
+	return &AuditedUserCreator{
+		userAuditor: userAuditor{user: user, tx: tx, audit: audit},
+		next:        next,
+	}
+}
+
+// Create creates a user as the decorated use case does, and records its creation in the same transaction, so that
+// the user is not created unless its creation is recorded
+func (u *AuditedUserCreator) Create(ctx context.Context, user entity.User) (entity.User, error) {
+	var created entity.User
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = u.next.Create(ctx, user); err != nil {
+			return err
+		}
+		return u.record(ctx, entity.UserAuditCreate, created.ID, nil, &created)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return created, nil
+}
+
+// AuditedUserModifier decorates a usecase.UserModifier, recording the modified users in their audit trail
+type AuditedUserModifier struct {
+	userAuditor
+	next usecase.UserModifier
+}
+
+// NewAuditedUserModifier creates a new usecase.UserModifier instance auditing the given one
+func NewAuditedUserModifier(
+	next usecase.UserModifier,
+	user repository.User,
+	tx repository.Transactor,
+	audit repository.UserAudit,
+) usecase.UserModifier {
+	return &AuditedUserModifier{
+		userAuditor: userAuditor{user: user, tx: tx, audit: audit},
+		next:        next,
+	}
+}
+
+// Modify modifies a user as the decorated use case does, and records its modification along with the user it was
+// stored as beforehand, in the same transaction, so that the user is not modified unless its modification is recorded
+func (u *AuditedUserModifier) Modify(ctx context.Context, user entity.User) (entity.User, error) {
+	var modified entity.User
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before := u.find(ctx, user.ID)
+		var err error
+		if modified, err = u.next.Modify(ctx, user); err != nil {
+			return err
+		}
+		return u.record(ctx, entity.UserAuditModify, modified.ID, before, &modified)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return modified, nil
+}
+
+// AuditedUserPatcher decorates a usecase.UserPatcher, recording the patched users in their audit trail
+type AuditedUserPatcher struct {
+	userAuditor
+	next usecase.UserPatcher
+}
+
+// NewAuditedUserPatcher creates a new usecase.UserPatcher instance auditing the given one
+func NewAuditedUserPatcher(
+	next usecase.UserPatcher,
+	user repository.User,
+	tx repository.Transactor,
+	audit repository.UserAudit,
+) usecase.UserPatcher {
+	return &AuditedUserPatcher{
+		userAuditor: userAuditor{user: user, tx: tx, audit: audit},
+		next:        next,
+	}
+}
+
+// Patch patches a user as the decorated use case does, and records its modification along with the user it was
+// stored as beforehand, in the same transaction, so that the user is not patched unless its modification is recorded
+func (u *AuditedUserPatcher) Patch(ctx context.Context, id uint, patch entity.UserPatch) (entity.User, error) {
+	var patched entity.User
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before := u.find(ctx, id)
+		var err error
+		if patched, err = u.next.Patch(ctx, id, patch); err != nil {
+			return err
+		}
+		return u.record(ctx, entity.UserAuditPatch, id, before, &patched)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return patched, nil
+}
+
+// AuditedUserDeleter decorates a usecase.UserDeleter, recording the deleted and purged users in their audit trail
+type AuditedUserDeleter struct {
+	userAuditor
+	next usecase.UserDeleter
+}
+
+// NewAuditedUserDeleter creates a new usecase.UserDeleter instance auditing the given one
+func NewAuditedUserDeleter(
+	next usecase.UserDeleter,
+	user repository.User,
+	tx repository.Transactor,
+	audit repository.UserAudit,
+) usecase.UserDeleter {
+	return &AuditedUserDeleter{
+		userAuditor: userAuditor{user: user, tx: tx, audit: audit},
+		next:        next,
+	}
+}
+
+// Delete deletes a user as the decorated use case does, and records its deletion along with the user it was stored
+// as before and after it, in the same transaction, so that the user is not deleted unless its deletion is recorded
+func (u *AuditedUserDeleter) Delete(ctx context.Context, user entity.User) error {
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before := u.find(ctx, user.ID)
+		if err := u.next.Delete(ctx, user); err != nil {
+			return err
+		}
+		return u.record(ctx, entity.UserAuditDelete, user.ID, before, u.find(ctx, user.ID))
+	})
+}
+
+// Purge purges a user as the decorated use case does, and records its purge along with the user it was stored as
+// beforehand, in the same transaction, so that the user is not purged unless its purge is recorded. The audit trail
+// of the user is kept.
+func (u *AuditedUserDeleter) Purge(ctx context.Context, id uint, version uint) error {
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before := u.find(ctx, id)
+		if err := u.next.Purge(ctx, id, version); err != nil {
+			return err
+		}
+		return u.record(ctx, entity.UserAuditPurge, id, before, nil)
+	})
+}
+
+// AuditedUserRestorer decorates a usecase.UserRestorer, recording the restored users in their audit trail
+type AuditedUserRestorer struct {
+	userAuditor
+	next usecase.UserRestorer
+}
+
+// NewAuditedUserRestorer creates a new usecase.UserRestorer instance auditing the given one
+func NewAuditedUserRestorer(
+	next usecase.UserRestorer,
+	user repository.User,
+	tx repository.Transactor,
+	audit repository.UserAudit,
+) usecase.UserRestorer {
+	return &AuditedUserRestorer{
+		userAuditor: userAuditor{user: user, tx: tx, audit: audit},
+		next:        next,
+	}
+}
+
+// Restore restores a user as the decorated use case does, and records its restoration along with the deleted user
+// it was stored as beforehand, in the same transaction, so that the user is not restored unless its restoration is
+// recorded
+func (u *AuditedUserRestorer) Restore(ctx context.Context, id uint, version uint) (entity.User, error) {
+	var restored entity.User
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before := u.find(ctx, id)
+		var err error
+		if restored, err = u.next.Restore(ctx, id, version); err != nil {
+			return err
+		}
+		return u.record(ctx, entity.UserAuditRestore, id, before, &restored)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return restored, nil
+}
+
+// UserHistoryFinder defines the use case for finding the audit trail of a user
+type UserHistoryFinder struct {
+	user  repository.User
+	audit repository.UserAudit
+}
+
+// NewUserHistoryFinder creates a new usecase.UserHistoryFinder instance
+func NewUserHistoryFinder(user repository.User, audit repository.UserAudit) usecase.UserHistoryFinder {
+	return &UserHistoryFinder{
+		user:  user,
+		audit: audit,
+	}
+}
+
+// Find returns the changes of the user of the given ID in the order they occurred, which are kept after the user is
+// purged, errors.ErrUserNotFound if there is no such user nor any change of it, or an error if something goes wrong.
+// A user created before its changes were audited has an empty history.
+func (u *UserHistoryFinder) Find(ctx context.Context, id uint) ([]entity.UserAuditEntry, error) {
+	entries, err := u.audit.FindByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return entries, nil
+	}
+
+	_, err = u.user.FindByID(ctx, id)
+	if errors.Is(err, domerrors.ErrUserNotFound) {
+		_, err = u.user.FindDeletedByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
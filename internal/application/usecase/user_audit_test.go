@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// auditContext returns a context carrying an admin principal and the ID of its request
+func auditContext() context.Context {
+	return entity.ContextWithRequestID(adminContext(), "3f5c1a7e-request")
+}
+
+// auditEntry returns the entry expected to be recorded by the principal and in the request of auditContext
+func auditEntry(action entity.UserAuditAction, id uint, before, after *entity.User) entity.UserAuditEntry {
+	return entity.UserAuditEntry{
+		UserID:    id,
+		Action:    action,
+		Actor:     "1",
+		ActorID:   1,
+		Before:    before,
+		After:     after,
+		RequestID: "3f5c1a7e-request",
+	}
+}
+
+func TestAuditedUserCreator_Create(t *testing.T) {
+	user := entity.User{Name: "John", Surname: "Doe"}
+	created := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}
+
+	tests := []struct {
+		name  string
+		given func(*MockUserCreator, *repository.MockTransactor, *repository.MockUserAudit)
+		then  func(*repository.MockTransactor, *repository.MockUserAudit, entity.User, error)
+	}{
+		{
+			name: "should record the creation of a user in its transaction",
+			given: func(creator *MockUserCreator, tx *repository.MockTransactor, audit *repository.MockUserAudit) {
+				creator.On("Create", auditContext(), user).Return(created, nil)
+				audit.On("Append", auditContext(), auditEntry(entity.UserAuditCreate, 1, nil, &created)).
+					Return(entity.UserAuditEntry{ID: 1}, nil)
+				tx.On("WithinTransaction", auditContext(), nil).Return()
+			},
+			then: func(tx *repository.MockTransactor, audit *repository.MockUserAudit, user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, created, user)
+				tx.AssertExpectations(t)
+				audit.AssertExpectations(t)
+			},
+		},
+		{
+			name: "should not record a failed creation",
+			given: func(creator *MockUserCreator, tx *repository.MockTransactor, audit *repository.MockUserAudit) {
+				creator.On("Create", auditContext(), user).Return(entity.User{}, domerrors.ErrUserAlreadyExists)
+				tx.On("WithinTransaction", auditContext(), domerrors.ErrUserAlreadyExists).Return()
+			},
+			then: func(tx *repository.MockTransactor, audit *repository.MockUserAudit, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserAlreadyExists)
+				assert.Empty(t, user)
+				audit.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should roll back the creation which cannot be recorded",
+			given: func(creator *MockUserCreator, tx *repository.MockTransactor, audit *repository.MockUserAudit) {
+				creator.On("Create", auditContext(), user).Return(created, nil)
+				audit.On("Append", auditContext(), mock.Anything).Return(entity.UserAuditEntry{}, errors.New("connection refused"))
+				tx.On("WithinTransaction", auditContext(), mock.AnythingOfType("*errors.withMessage")).Return()
+			},
+			then: func(tx *repository.MockTransactor, audit *repository.MockUserAudit, user entity.User, err error) {
+				assert.EqualError(t, err, "cannot audit create of user 1: connection refused")
+				assert.Empty(t, user)
+				tx.AssertExpectations(t)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			creator, tx, audit := NewMockUserCreator(), repository.NewMockTransactor(), repository.NewMockUserAudit()
+			tt.given(creator, tx, audit)
+
+			// When
+			user, err := NewAuditedUserCreator(creator, repository.NewMockUser(), tx, audit).Create(auditContext(), user)
+
+			// Then
+			tt.then(tx, audit, user, err)
+		})
+	}
+}
+
+func TestAuditedUserModifier_Modify(t *testing.T) {
+	stored := entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 1}
+	modified := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}
+
+	// Given
+	mockUser := repository.NewMockUser()
+	mockUser.On("FindByID", auditContext(), uint(1)).Return(stored, nil)
+	modifier := NewMockUserModifier()
+	modifier.On("Modify", auditContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"}).Return(modified, nil)
+	audit := repository.NewMockUserAudit()
+	audit.On("Append", auditContext(), auditEntry(entity.UserAuditModify, 1, &stored, &modified)).
+		Return(entity.UserAuditEntry{ID: 1}, nil)
+
+	// When
+	user, err := NewAuditedUserModifier(modifier, mockUser, repository.NewTransactorInMemory(), audit).
+		Modify(auditContext(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, modified, user)
+	audit.AssertExpectations(t)
+}
+
+func TestAuditedUserPatcher_Patch(t *testing.T) {
+	stored := entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 1}
+	patched := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}
+	patch := entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Doe"}`)}
+
+	// Given
+	mockUser := repository.NewMockUser()
+	mockUser.On("FindByID", auditContext(), uint(1)).Return(stored, nil)
+	patcher := NewMockUserPatcher()
+	patcher.On("Patch", auditContext(), uint(1), patch).Return(patched, nil)
+	audit := repository.NewMockUserAudit()
+	audit.On("Append", auditContext(), auditEntry(entity.UserAuditPatch, 1, &stored, &patched)).
+		Return(entity.UserAuditEntry{ID: 1}, nil)
+
+	// When
+	user, err := NewAuditedUserPatcher(patcher, mockUser, repository.NewTransactorInMemory(), audit).Patch(auditContext(), 1, patch)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, patched, user)
+	audit.AssertExpectations(t)
+}
+
+func TestAuditedUserDeleter(t *testing.T) {
+	active := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}
+	deleted := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2, DeletedAt: time.Now()}
+
+	tests := []struct {
+		name  string
+		given func(*repository.MockUser, *MockUserDeleter, *repository.MockUserAudit)
+		when  func(usecase *AuditedUserDeleter) error
+		then  func(*repository.MockUserAudit, error)
+	}{
+		{
+			name: "should record the deletion of a user",
+			given: func(mockUser *repository.MockUser, deleter *MockUserDeleter, audit *repository.MockUserAudit) {
+				mockUser.On("FindByID", auditContext(), uint(1)).Return(active, nil).Once()
+				mockUser.On("FindByID", auditContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				mockUser.On("FindDeletedByID", auditContext(), uint(1)).Return(deleted, nil)
+				deleter.On("Delete", auditContext(), active).Return(nil)
+				audit.On("Append", auditContext(), auditEntry(entity.UserAuditDelete, 1, &active, &deleted)).
+					Return(entity.UserAuditEntry{ID: 1}, nil)
+			},
+			when: func(usecase *AuditedUserDeleter) error {
+				return usecase.Delete(auditContext(), active)
+			},
+			then: func(audit *repository.MockUserAudit, err error) {
+				assert.NoError(t, err)
+				audit.AssertExpectations(t)
+			},
+		},
+		{
+			name: "should not record a failed deletion",
+			given: func(mockUser *repository.MockUser, deleter *MockUserDeleter, audit *repository.MockUserAudit) {
+				mockUser.On("FindByID", auditContext(), uint(1)).Return(active, nil)
+				deleter.On("Delete", auditContext(), active).Return(domerrors.ErrConcurrentModification)
+			},
+			when: func(usecase *AuditedUserDeleter) error {
+				return usecase.Delete(auditContext(), active)
+			},
+			then: func(audit *repository.MockUserAudit, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+				audit.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should record the purge of a deleted user",
+			given: func(mockUser *repository.MockUser, deleter *MockUserDeleter, audit *repository.MockUserAudit) {
+				mockUser.On("FindByID", auditContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				mockUser.On("FindDeletedByID", auditContext(), uint(1)).Return(deleted, nil)
+				deleter.On("Purge", auditContext(), uint(1), uint(2)).Return(nil)
+				audit.On("Append", auditContext(), auditEntry(entity.UserAuditPurge, 1, &deleted, nil)).
+					Return(entity.UserAuditEntry{ID: 1}, nil)
+			},
+			when: func(usecase *AuditedUserDeleter) error {
+				return usecase.Purge(auditContext(), 1, 2)
+			},
+			then: func(audit *repository.MockUserAudit, err error) {
+				assert.NoError(t, err)
+				audit.AssertExpectations(t)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser, deleter, audit := repository.NewMockUser(), NewMockUserDeleter(), repository.NewMockUserAudit()
+			tt.given(mockUser, deleter, audit)
+
+			// When
+			err := tt.when(NewAuditedUserDeleter(deleter, mockUser, repository.NewTransactorInMemory(), audit).(*AuditedUserDeleter))
+
+			// Then
+			tt.then(audit, err)
+		})
+	}
+}
+
+func TestAuditedUserRestorer_Restore(t *testing.T) {
+	deleted := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2, DeletedAt: time.Now()}
+	restored := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}
+
+	// Given
+	mockUser := repository.NewMockUser()
+	mockUser.On("FindByID", auditContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+	mockUser.On("FindDeletedByID", auditContext(), uint(1)).Return(deleted, nil)
+	restorer := NewMockUserRestorer()
+	restorer.On("Restore", auditContext(), uint(1), uint(0)).Return(restored, nil)
+	audit := repository.NewMockUserAudit()
+	audit.On("Append", auditContext(), auditEntry(entity.UserAuditRestore, 1, &deleted, &restored)).
+		Return(entity.UserAuditEntry{ID: 1}, nil)
+
+	// When
+	user, err := NewAuditedUserRestorer(restorer, mockUser, repository.NewTransactorInMemory(), audit).Restore(auditContext(), 1, 0)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, restored, user)
+	audit.AssertExpectations(t)
+}
+
+func TestUserHistoryFinder_Find(t *testing.T) {
+	entries := []entity.UserAuditEntry{{ID: 1, UserID: 1, Action: entity.UserAuditCreate}}
+
+	tests := []struct {
+		name  string
+		given func(*repository.MockUser, *repository.MockUserAudit)
+		then  func([]entity.UserAuditEntry, error)
+	}{
+		{
+			name: "should find the history of a user",
+			given: func(mockUser *repository.MockUser, audit *repository.MockUserAudit) {
+				audit.On("FindByUserID", context.Background(), uint(1)).Return(entries, nil)
+			},
+			then: func(history []entity.UserAuditEntry, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entries, history)
+			},
+		},
+		{
+			name: "should find the empty history of a user created before the audit",
+			given: func(mockUser *repository.MockUser, audit *repository.MockUserAudit) {
+				audit.On("FindByUserID", context.Background(), uint(1)).Return([]entity.UserAuditEntry{}, nil)
+				mockUser.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1}, nil)
+			},
+			then: func(history []entity.UserAuditEntry, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, history)
+			},
+		},
+		{
+			name: "should not find the history of a missing user",
+			given: func(mockUser *repository.MockUser, audit *repository.MockUserAudit) {
+				audit.On("FindByUserID", context.Background(), uint(1)).Return([]entity.UserAuditEntry{}, nil)
+				mockUser.On("FindByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				mockUser.On("FindDeletedByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+			},
+			then: func(history []entity.UserAuditEntry, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Nil(t, history)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser, audit := repository.NewMockUser(), repository.NewMockUserAudit()
+			tt.given(mockUser, audit)
+
+			// When
+			history, err := NewUserHistoryFinder(mockUser, audit).Find(context.Background(), 1)
+
+			// Then
+			tt.then(history, err)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+// UserEventBatchSize is the maximum number of events of the users relayed at once
+type UserEventBatchSize int
+
+// userEventRaiser raises the events of the changes of the users, appending them to their outbox in the transaction
+// of the changes
+type userEventRaiser struct {
+	tx     repository.Transactor
+	outbox repository.UserOutbox
+}
+
+// change runs the given change in a transaction along with the appending of the event of the given type of the user
+// it returns, so that the change is not made unless its event is raised
+func (r userEventRaiser) change(
+	ctx context.Context,
+	eventType entity.UserEventType,
+	fn func(ctx context.Context) (entity.User, error),
+) (entity.User, error) {
+	var changed entity.User
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if changed, err = fn(ctx); err != nil {
+			return err
+		}
+		return r.raise(ctx, eventType, changed)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return changed, nil
+}
+
+// raise appends the event of the given type of the given user to the outbox
+func (r userEventRaiser) raise(ctx context.Context, eventType entity.UserEventType, user entity.User) error {
+	_, err := r.outbox.Append(ctx, entity.NewUserEvent(eventType, user))
+	return errors.WithMessagef(err, "cannot raise %s event of user %d", eventType, user.ID)
+}
+
+// UserEventRelayer defines the use case for relaying the events of the users from their outbox
+type UserEventRelayer struct {
+	tx        repository.Transactor
+	outbox    repository.UserOutbox
+	publisher service.UserEventPublisher
+	batchSize UserEventBatchSize
+}
+
+// NewUserEventRelayer creates a new usecase.UserEventRelayer instance
+func NewUserEventRelayer(
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+	publisher service.UserEventPublisher,
+	batchSize UserEventBatchSize,
+) usecase.UserEventRelayer {
+	return &UserEventRelayer{
+		tx:        tx,
+		outbox:    outbox,
+		publisher: publisher,
+		batchSize: batchSize,
+	}
+}
+
+// Relay publishes at most a batch of the pending events in the order they were raised, and marks them as published.
+// It stops at the first event which cannot be published, marking the ones published before it, and returns the
+// number of events published along with the error. The events are delivered at least once, since an event published
+// but not marked is published again by the next relay.
+func (u *UserEventRelayer) Relay(ctx context.Context) (int, error) {
+	var published []uint
+	var publishErr error
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		events, err := u.outbox.FindPending(ctx, int(u.batchSize))
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err = u.publisher.Publish(ctx, event); err != nil {
+				publishErr = errors.WithMessagef(err, "cannot publish user event %d", event.ID)
+				break
+			}
+			published = append(published, event.ID)
+		}
+
+		if len(published) == 0 {
+			return nil
+		}
+		return u.outbox.MarkPublished(ctx, published...)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(published), publishErr
+}
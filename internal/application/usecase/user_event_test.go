@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/event"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/patch"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserUseCases_RaiseEvents(t *testing.T) {
+	// Given
+	users := repository.NewUserInMemory()
+	tx := repository.NewTransactorInMemory()
+	outbox := repository.NewUserOutboxInMemory()
+	ctx := adminContext()
+
+	// When
+	created, err := NewUserCreator(users, tx, outbox).Create(ctx, entity.User{Name: "John", Surname: "Doe"})
+	require.NoError(t, err)
+	_, err = NewUserModifier(users, tx, outbox).Modify(ctx, entity.User{ID: created.ID, Name: "Jane", Surname: "Doe"})
+	require.NoError(t, err)
+	patched, err := NewUserPatcher(users, patch.NewJSONApplier(), tx, outbox).Patch(ctx, created.ID,
+		entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`)})
+	require.NoError(t, err)
+	require.NoError(t, NewUserDeleter(users, tx, outbox).Delete(ctx, patched))
+	_, err = NewUserRestorer(users, tx, outbox).Restore(ctx, created.ID, 0)
+	require.NoError(t, err)
+	require.NoError(t, NewUserDeleter(users, tx, outbox).Purge(ctx, created.ID, 0))
+
+	// Then
+	events, err := outbox.FindPending(context.Background(), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 6) {
+		for i, want := range []struct {
+			eventType entity.UserEventType
+			version   uint
+			surname   string
+			deleted   bool
+		}{
+			{eventType: entity.UserCreated, version: 1, surname: "Doe"},
+			{eventType: entity.UserModified, version: 2, surname: "Doe"},
+			{eventType: entity.UserModified, version: 3, surname: "Smith"},
+			{eventType: entity.UserDeleted, version: 4, surname: "Smith", deleted: true},
+			{eventType: entity.UserRestored, version: 5, surname: "Smith"},
+			// the purged user is the one before the purge
+			{eventType: entity.UserPurged, version: 5, surname: "Smith"},
+		} {
+			assert.Equal(t, want.eventType, events[i].Type)
+			assert.Equal(t, created.ID, events[i].UserID)
+			assert.Equal(t, want.version, events[i].User.Version)
+			assert.Equal(t, want.surname, events[i].User.Surname)
+			assert.Equal(t, want.deleted, events[i].User.Deleted())
+		}
+	}
+}
+
+func TestUserCreator_Create_EventNotRaised(t *testing.T) {
+	// Given
+	mockUser := repository.NewMockUser()
+	mockUser.On("save", context.Background(), mock.Anything).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}, nil)
+	mockOutbox := repository.NewMockUserOutbox()
+	mockOutbox.On("Append", context.Background(), mock.Anything).Return(entity.UserEvent{}, errors.New("failed to append"))
+
+	// When
+	user, err := NewUserCreator(mockUser, repository.NewTransactorInMemory(), mockOutbox).
+		Create(context.Background(), entity.User{Name: "John", Surname: "Doe"})
+
+	// Then
+	assert.ErrorContains(t, err, "cannot raise user.created event of user 1")
+	assert.Empty(t, user)
+	mockOutbox.AssertExpectations(t)
+}
+
+func TestUserEventRelayer_Relay(t *testing.T) {
+	events := []entity.UserEvent{
+		{ID: 1, Type: entity.UserCreated, UserID: 1},
+		{ID: 2, Type: entity.UserModified, UserID: 1},
+		{ID: 3, Type: entity.UserDeleted, UserID: 1},
+	}
+
+	tests := []struct {
+		name  string
+		given func() (*repository.MockUserOutbox, *event.MockUserEventPublisher)
+		then  func(int, error)
+	}{
+		{
+			name: "should publish and mark the pending events in order",
+			given: func() (*repository.MockUserOutbox, *event.MockUserEventPublisher) {
+				o := repository.NewMockUserOutbox()
+				o.On("FindPending", context.Background(), 10).Return(events, nil)
+				o.On("MarkPublished", context.Background(), []uint{1, 2, 3}).Return(nil)
+				p := event.NewMockUserEventPublisher()
+				for _, e := range events {
+					p.On("Publish", context.Background(), e).Return(nil).Once()
+				}
+				return o, p
+			},
+			then: func(n int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, n)
+			},
+		},
+		{
+			name: "should mark the events published before the first one which cannot be published",
+			given: func() (*repository.MockUserOutbox, *event.MockUserEventPublisher) {
+				o := repository.NewMockUserOutbox()
+				o.On("FindPending", context.Background(), 10).Return(events, nil)
+				o.On("MarkPublished", context.Background(), []uint{1}).Return(nil)
+				p := event.NewMockUserEventPublisher()
+				p.On("Publish", context.Background(), events[0]).Return(nil).Once()
+				p.On("Publish", context.Background(), events[1]).Return(errors.New("failed to publish")).Once()
+				return o, p
+			},
+			then: func(n int, err error) {
+				assert.ErrorContains(t, err, "cannot publish user event 2")
+				assert.Equal(t, 1, n)
+			},
+		},
+		{
+			name: "should relay nothing when no event is pending",
+			given: func() (*repository.MockUserOutbox, *event.MockUserEventPublisher) {
+				o := repository.NewMockUserOutbox()
+				o.On("FindPending", context.Background(), 10).Return([]entity.UserEvent{}, nil)
+				return o, event.NewMockUserEventPublisher()
+			},
+			then: func(n int, err error) {
+				assert.NoError(t, err)
+				assert.Zero(t, n)
+			},
+		},
+		{
+			name: "should not relay the events when they cannot be found",
+			given: func() (*repository.MockUserOutbox, *event.MockUserEventPublisher) {
+				o := repository.NewMockUserOutbox()
+				o.On("FindPending", context.Background(), 10).Return([]entity.UserEvent{}, errors.New("failed to find"))
+				return o, event.NewMockUserEventPublisher()
+			},
+			then: func(n int, err error) {
+				assert.Error(t, err)
+				assert.Zero(t, n)
+			},
+		},
+		{
+			name: "should not count the events published when they cannot be marked",
+			given: func() (*repository.MockUserOutbox, *event.MockUserEventPublisher) {
+				o := repository.NewMockUserOutbox()
+				o.On("FindPending", context.Background(), 10).Return(events[:1], nil)
+				o.On("MarkPublished", context.Background(), []uint{1}).Return(errors.New("failed to mark"))
+				p := event.NewMockUserEventPublisher()
+				p.On("Publish", context.Background(), events[0]).Return(nil).Once()
+				return o, p
+			},
+			then: func(n int, err error) {
+				assert.Error(t, err)
+				assert.Zero(t, n)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockOutbox, mockPublisher := tt.given()
+
+			// When
+			n, err := NewUserEventRelayer(repository.NewTransactorInMemory(), mockOutbox, mockPublisher, 10).
+				Relay(context.Background())
+
+			// Then
+			tt.then(n, err)
+			mockOutbox.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
@@ -15,8 +15,21 @@ func NewMockUserFinderAll() *MockUserFinderAll {
 	return &MockUserFinderAll{}
 }
 
-func (m *MockUserFinderAll) Find(ctx context.Context) ([]entity.User, error) {
-	args := m.Called(ctx)
+func (m *MockUserFinderAll) Find(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(entity.UserPage), args.Error(1)
+}
+
+type MockUserSearcher struct {
+	mock.Mock
+}
+
+func NewMockUserSearcher() *MockUserSearcher {
+	return &MockUserSearcher{}
+}
+
+func (m *MockUserSearcher) Search(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	args := m.Called(ctx, query, limit)
 	return args.Get(0).([]entity.User), args.Error(1)
 }
 
@@ -59,6 +72,19 @@ func (m *MockUserModifier) Modify(ctx context.Context, user entity.User) (entity
 	return args.Get(0).(entity.User), args.Error(1)
 }
 
+type MockUserPatcher struct {
+	mock.Mock
+}
+
+func NewMockUserPatcher() *MockUserPatcher {
+	return &MockUserPatcher{}
+}
+
+func (m *MockUserPatcher) Patch(ctx context.Context, id uint, patch entity.UserPatch) (entity.User, error) {
+	args := m.Called(ctx, id, patch)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
 type MockUserDeleter struct {
 	mock.Mock
 }
@@ -71,3 +97,47 @@ func (m *MockUserDeleter) Delete(ctx context.Context, user entity.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
+
+func (m *MockUserDeleter) Purge(ctx context.Context, id uint, version uint) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+type MockUserRestorer struct {
+	mock.Mock
+}
+
+func NewMockUserRestorer() *MockUserRestorer {
+	return &MockUserRestorer{}
+}
+
+func (m *MockUserRestorer) Restore(ctx context.Context, id uint, version uint) (entity.User, error) {
+	args := m.Called(ctx, id, version)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+type MockUserHistoryFinder struct {
+	mock.Mock
+}
+
+func NewMockUserHistoryFinder() *MockUserHistoryFinder {
+	return &MockUserHistoryFinder{}
+}
+
+func (m *MockUserHistoryFinder) Find(ctx context.Context, id uint) ([]entity.UserAuditEntry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]entity.UserAuditEntry), args.Error(1)
+}
+
+type MockUserEventRelayer struct {
+	mock.Mock
+}
+
+func NewMockUserEventRelayer() *MockUserEventRelayer {
+	return &MockUserEventRelayer{}
+}
+
+func (m *MockUserEventRelayer) Relay(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
@@ -4,21 +4,33 @@ import (
 	"cmp"
 	"context"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/patch"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// adminContext returns a context carrying an admin principal
+func adminContext() context.Context {
+	return entity.ContextWithPrincipal(context.Background(), entity.Principal{Subject: "1", UserID: 1, Roles: []string{entity.RoleAdmin}})
+}
+
 func TestUserFinderAll_Find(t *testing.T) {
+	defaultQuery := entity.UserQuery{Limit: entity.DefaultUserPageLimit, SortBy: entity.UserFieldID, Direction: entity.SortAsc}
+
 	tests := []struct {
 		name  string
 		given func() *repository.MockUser
-		when  func(mockUser *repository.MockUser) ([]entity.User, error)
-		then  func([]entity.User, error)
+		when  func(mockUser *repository.MockUser) (entity.UserPage, error)
+		then  func(entity.UserPage, error)
 	}{
 		{
 			name: "should find all users",
@@ -29,14 +41,16 @@ func TestUserFinderAll_Find(t *testing.T) {
 					{ID: 2, Name: "Jane", Surname: "Doe"},
 					{ID: 3, Name: "Alice", Surname: "Smith"},
 				}
-				m.On("FindAll", context.Background()).Return(users, nil)
+				m.On("FindAll", context.Background(), defaultQuery).Return(entity.UserPage{Users: users, Total: 3}, nil)
 				return m
 			},
-			when: func(mockUser *repository.MockUser) ([]entity.User, error) {
-				return NewUserFinderAll(mockUser).Find(context.Background())
+			when: func(mockUser *repository.MockUser) (entity.UserPage, error) {
+				return NewUserFinderAll(mockUser, nil).Find(context.Background(), entity.UserQuery{})
 			},
-			then: func(users []entity.User, err error) {
+			then: func(page entity.UserPage, err error) {
 				assert.NoError(t, err)
+				assert.Equal(t, int64(3), page.Total)
+				users := page.Users
 				assert.Len(t, users, 3)
 
 				slices.SortFunc(users, func(i, j entity.User) int {
@@ -51,20 +65,94 @@ func TestUserFinderAll_Find(t *testing.T) {
 				assert.Equal(t, "Smith", users[2].Surname)
 			},
 		},
+		{
+			name: "should find a page of users with the limit capped",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				query := entity.UserQuery{
+					Limit:     entity.MaxUserPageLimit,
+					Offset:    10,
+					SortBy:    entity.UserFieldName,
+					Direction: entity.SortDesc,
+					Filters:   []entity.UserFilter{{Field: entity.UserFieldSurname, Mode: entity.MatchPrefix, Value: "Do"}},
+				}
+				m.On("FindAll", context.Background(), query).Return(entity.UserPage{Total: 2}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.UserPage, error) {
+				return NewUserFinderAll(mockUser, nil).Find(context.Background(), entity.UserQuery{
+					Limit:     1000,
+					Offset:    10,
+					SortBy:    entity.UserFieldName,
+					Direction: entity.SortDesc,
+					Filters:   []entity.UserFilter{{Field: entity.UserFieldSurname, Mode: entity.MatchPrefix, Value: "Do"}},
+				})
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), page.Total)
+			},
+		},
+		{
+			name: "should not find users with an invalid query",
+			given: func() *repository.MockUser {
+				return repository.NewMockUser()
+			},
+			when: func(mockUser *repository.MockUser) (entity.UserPage, error) {
+				return NewUserFinderAll(mockUser, nil).Find(context.Background(), entity.UserQuery{SortBy: "password"})
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidUserQuery)
+				assert.Len(t, page.Users, 0)
+			},
+		},
+		{
+			name: "should find all users including the deleted ones for an admin",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				query := defaultQuery
+				query.IncludeDeleted = true
+				m.On("FindAll", adminContext(), query).Return(entity.UserPage{Total: 4}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.UserPage, error) {
+				return NewUserFinderAll(mockUser, nil).Find(adminContext(), entity.UserQuery{IncludeDeleted: true})
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(4), page.Total)
+			},
+		},
+		{
+			name: "should find all users including the deleted ones for a non-admin",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				query := defaultQuery
+				query.IncludeDeleted = true
+				m.On("FindAll", context.Background(), query).Return(entity.UserPage{Total: 4}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.UserPage, error) {
+				return NewUserFinderAll(mockUser, nil).Find(context.Background(), entity.UserQuery{IncludeDeleted: true})
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(4), page.Total)
+			},
+		},
 		{
 			name: "should not find users",
 			given: func() *repository.MockUser {
 				m := repository.NewMockUser()
-				var users []entity.User
-				m.On("FindAll", context.Background()).Return(users, errors.New("not found"))
+				m.On("FindAll", context.Background(), defaultQuery).Return(entity.UserPage{}, errors.New("not found"))
 				return m
 			},
-			when: func(mockUser *repository.MockUser) ([]entity.User, error) {
-				return NewUserFinderAll(mockUser).Find(context.Background())
+			when: func(mockUser *repository.MockUser) (entity.UserPage, error) {
+				return NewUserFinderAll(mockUser, nil).Find(context.Background(), entity.UserQuery{})
 			},
-			then: func(users []entity.User, err error) {
+			then: func(page entity.UserPage, err error) {
 				assert.Error(t, err)
-				assert.Len(t, users, 0)
+				assert.Len(t, page.Users, 0)
 			},
 		},
 	}
@@ -74,10 +162,180 @@ func TestUserFinderAll_Find(t *testing.T) {
 			mockUser := tt.given()
 
 			// When
-			users, err := tt.when(mockUser)
+			page, err := tt.when(mockUser)
+
+			// Then
+			tt.then(page, err)
+		})
+	}
+}
+
+func TestUserFinderAll_Find_Cursor(t *testing.T) {
+	after := entity.UserCursor{SortBy: entity.UserFieldName, Direction: entity.SortAsc, Value: "Jane", ID: 2}
+	next := entity.UserCursor{SortBy: entity.UserFieldName, Direction: entity.SortAsc, Value: "John", ID: 1}
+	query := entity.UserQuery{Limit: 1, SortBy: entity.UserFieldName, Cursor: "after"}
+
+	tests := []struct {
+		name  string
+		given func() (*repository.MockUser, *security.MockCursorCodec)
+		then  func(entity.UserPage, error)
+	}{
+		{
+			name: "should find the page after the cursor along with the next cursor",
+			given: func() (*repository.MockUser, *security.MockCursorCodec) {
+				m := repository.NewMockUser()
+				c := security.NewMockCursorCodec()
+				c.On("Decode", "after").Return(after, nil)
+				m.On("FindAll", context.Background(), entity.UserQuery{
+					Limit: 1, SortBy: entity.UserFieldName, Direction: entity.SortAsc, Cursor: "after", After: &after,
+				}).Return(entity.UserPage{Users: []entity.User{{ID: 1, Name: "John"}}, Next: &next}, nil)
+				c.On("Encode", next).Return("next", nil)
+				return m, c
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []entity.User{{ID: 1, Name: "John"}}, page.Users)
+				assert.Equal(t, "next", page.NextCursor)
+			},
+		},
+		{
+			name: "should not find users after an invalid cursor",
+			given: func() (*repository.MockUser, *security.MockCursorCodec) {
+				c := security.NewMockCursorCodec()
+				c.On("Decode", "after").Return(entity.UserCursor{}, domerrors.ErrInvalidCursor)
+				return repository.NewMockUser(), c
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidCursor)
+				assert.Len(t, page.Users, 0)
+			},
+		},
+		{
+			name: "should not find users after a cursor of another sort",
+			given: func() (*repository.MockUser, *security.MockCursorCodec) {
+				c := security.NewMockCursorCodec()
+				c.On("Decode", "after").Return(entity.UserCursor{SortBy: entity.UserFieldName, Direction: entity.SortDesc, ID: 2}, nil)
+				return repository.NewMockUser(), c
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidCursor)
+				assert.Len(t, page.Users, 0)
+			},
+		},
+		{
+			name: "should fail encoding the next cursor",
+			given: func() (*repository.MockUser, *security.MockCursorCodec) {
+				m := repository.NewMockUser()
+				c := security.NewMockCursorCodec()
+				c.On("Decode", "after").Return(after, nil)
+				m.On("FindAll", context.Background(), mock.Anything).
+					Return(entity.UserPage{Users: []entity.User{{ID: 1, Name: "John"}}, Next: &next}, nil)
+				c.On("Encode", next).Return("", errors.New("error"))
+				return m, c
+			},
+			then: func(page entity.UserPage, err error) {
+				assert.Error(t, err)
+				assert.Equal(t, entity.UserPage{}, page)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser, mockCursorCodec := tt.given()
+
+			// When
+			page, err := NewUserFinderAll(mockUser, mockCursorCodec).Find(context.Background(), query)
+
+			// Then
+			tt.then(page, err)
+			mockUser.AssertExpectations(t)
+			mockCursorCodec.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserSearcher_Search(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		given func() *repository.MockUserSearch
+		then  func([]entity.User, error)
+	}{
+		{
+			name:  "should search users with the default limit",
+			query: " doe ",
+			given: func() *repository.MockUserSearch {
+				m := repository.NewMockUserSearch()
+				m.On("Search", context.Background(), "doe", entity.DefaultUserPageLimit).
+					Return([]entity.User{{ID: 1, Name: "John", Surname: "Doe"}}, nil)
+				return m
+			},
+			then: func(users []entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []entity.User{{ID: 1, Name: "John", Surname: "Doe"}}, users)
+			},
+		},
+		{
+			name:  "should search users with the limit capped",
+			query: "doe",
+			limit: 1000,
+			given: func() *repository.MockUserSearch {
+				m := repository.NewMockUserSearch()
+				m.On("Search", context.Background(), "doe", entity.MaxUserPageLimit).Return([]entity.User{}, nil)
+				return m
+			},
+			then: func(users []entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, users)
+			},
+		},
+		{
+			name:  "should not search users without query",
+			query: "  ",
+			given: repository.NewMockUserSearch,
+			then: func(users []entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidUserQuery)
+				assert.Nil(t, users)
+			},
+		},
+		{
+			name:  "should not search users with a negative limit",
+			query: "doe",
+			limit: -1,
+			given: repository.NewMockUserSearch,
+			then: func(users []entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidUserQuery)
+				assert.Nil(t, users)
+			},
+		},
+		{
+			name:  "should fail searching users",
+			query: "doe",
+			given: func() *repository.MockUserSearch {
+				m := repository.NewMockUserSearch()
+				m.On("Search", context.Background(), "doe", entity.DefaultUserPageLimit).
+					Return([]entity.User(nil), errors.New("error"))
+				return m
+			},
+			then: func(users []entity.User, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, users)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUserSearch := tt.given()
+
+			// When
+			users, err := NewUserSearcher(mockUserSearch).Search(context.Background(), tt.query, tt.limit)
 
 			// Then
 			tt.then(users, err)
+			mockUserSearch.AssertExpectations(t)
 		})
 	}
 }
@@ -154,7 +412,7 @@ func TestUserCreator_Create(t *testing.T) {
 				return m
 			},
 			when: func(mockUser *repository.MockUser) (entity.User, error) {
-				return NewUserCreator(mockUser).Create(context.Background(), entity.User{Name: "John", Surname: "Doe"})
+				return NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(context.Background(), entity.User{Name: "John", Surname: "Doe"})
 			},
 			then: func(user entity.User, err error) {
 				assert.NoError(t, err)
@@ -172,13 +430,75 @@ func TestUserCreator_Create(t *testing.T) {
 				return m
 			},
 			when: func(mockUser *repository.MockUser) (entity.User, error) {
-				return NewUserCreator(mockUser).Create(context.Background(), entity.User{})
+				return NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(context.Background(), entity.User{Name: "John", Surname: "Doe"})
 			},
 			then: func(user entity.User, err error) {
 				assert.Error(t, err)
 				assert.Equal(t, entity.User{}, user)
 			},
 		},
+		{
+			name: "should create the normalized user",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				user := entity.User{Name: "José María", Surname: "O'Neil-Smith"}
+				m.On("save", context.Background(), user).Return(entity.User{ID: 1, Name: "José María", Surname: "O'Neil-Smith"}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(context.Background(), entity.User{Name: "  Jose\u0301 \t María ", Surname: "O'Neil-Smith\n"})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "José María", user.Name)
+				assert.Equal(t, "O'Neil-Smith", user.Surname)
+			},
+		},
+		{
+			name: "should create user with roles when the caller is an admin",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				user := entity.User{Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}
+				created := entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}
+				m.On("save", adminContext(), user).Return(created, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(adminContext(), entity.User{Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{entity.RoleAdmin}, user.Roles)
+			},
+		},
+		{
+			name: "should not create user with roles when the caller is not an admin",
+			given: func() *repository.MockUser {
+				return repository.NewMockUser()
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(context.Background(), entity.User{Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}})
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrForbidden)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should not create user with roles when the caller is an API key of an admin without the admin scope",
+			given: func() *repository.MockUser {
+				return repository.NewMockUser()
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				ctx := entity.ContextWithPrincipal(context.Background(), entity.Principal{Subject: "apikey:0123456789ab", UserID: 1,
+					Roles: []string{entity.RoleAdmin}, Scopes: []string{entity.ScopeUsersWrite}, Scoped: true})
+				return NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(ctx, entity.User{Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}})
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrForbidden)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -194,7 +514,68 @@ func TestUserCreator_Create(t *testing.T) {
 	}
 }
 
+func TestUserCreator_Create_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		user entity.User
+		want []domerrors.FieldViolation
+	}{
+		{
+			name: "should require the name and surname",
+			user: entity.User{Name: " ", Surname: ""},
+			want: []domerrors.FieldViolation{
+				{Field: "name", Message: "is required"},
+				{Field: "surname", Message: "is required"},
+			},
+		},
+		{
+			name: "should limit the length of the name and surname",
+			user: entity.User{Name: strings.Repeat("é", entity.MaxUserNameLength), Surname: strings.Repeat("a", entity.MaxUserNameLength+1)},
+			want: []domerrors.FieldViolation{
+				{Field: "surname", Message: "must be at most 100 characters"},
+			},
+		},
+		{
+			name: "should only allow letters and some punctuation in the name and surname",
+			user: entity.User{Name: "John2", Surname: "<Doe>"},
+			want: []domerrors.FieldViolation{
+				{Field: "name", Message: "must only contain letters, spaces, apostrophes, hyphens and periods"},
+				{Field: "surname", Message: "must only contain letters, spaces, apostrophes, hyphens and periods"},
+			},
+		},
+		{
+			name: "should validate every role",
+			user: entity.User{Name: "John", Surname: "Doe", Roles: []string{"admin", " ", "team lead", strings.Repeat("r", entity.MaxRoleLength+1)}},
+			want: []domerrors.FieldViolation{
+				{Field: "roles[1]", Message: "is required"},
+				{Field: "roles[2]", Message: "must only contain letters, digits, underscores, hyphens, periods and colons"},
+				{Field: "roles[3]", Message: "must be at most 50 characters"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser := repository.NewMockUser()
+
+			// When
+			user, err := NewUserCreator(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Create(adminContext(), tt.user)
+
+			// Then
+			assert.ErrorIs(t, err, domerrors.ErrInvalidUser)
+			var validationErr *domerrors.ValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Equal(t, tt.want, validationErr.Violations)
+			}
+			assert.Equal(t, entity.User{}, user)
+			mockUser.AssertNotCalled(t, "save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestUserModifier_Modify(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		name  string
 		given func() *repository.MockUser
@@ -205,12 +586,13 @@ func TestUserModifier_Modify(t *testing.T) {
 			name: "should modify user",
 			given: func() *repository.MockUser {
 				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Smith"}, nil)
 				user := entity.User{ID: 1, Name: "John", Surname: "Doe"}
 				m.On("save", context.Background(), user).Return(user, nil)
 				return m
 			},
 			when: func(mockUser *repository.MockUser) (entity.User, error) {
-				return NewUserModifier(mockUser).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
 			},
 			then: func(user entity.User, err error) {
 				assert.NoError(t, err)
@@ -220,21 +602,167 @@ func TestUserModifier_Modify(t *testing.T) {
 				assert.Equal(t, "Doe", user.Surname)
 			},
 		},
+		{
+			name: "should modify the user at its current version when none is given",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 3}, nil)
+				m.On("save", context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 4}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 4}, user)
+			},
+		},
+		{
+			name: "should keep the creation time of the user",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 3, CreatedAt: createdAt, UpdatedAt: createdAt}, nil)
+				m.On("save", context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3, CreatedAt: createdAt}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 4, CreatedAt: createdAt}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, createdAt, user.CreatedAt)
+			},
+		},
+		{
+			name: "should not modify a user modified since the given version",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 3}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2})
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
 		{
 			name: "should not modify user",
 			given: func() *repository.MockUser {
 				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
 				m.On("save", context.Background(), mock.Anything).Return(entity.User{}, errors.New("not modified"))
 				return m
 			},
 			when: func(mockUser *repository.MockUser) (entity.User, error) {
-				return NewUserModifier(mockUser).Modify(context.Background(), entity.User{})
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
 			},
 			then: func(user entity.User, err error) {
 				assert.Error(t, err)
 				assert.Equal(t, entity.User{}, user)
 			},
 		},
+		{
+			name: "should not modify an invalid user",
+			given: func() *repository.MockUser {
+				return repository.NewMockUser()
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "\t"})
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidUser)
+				assert.EqualError(t, err, "invalid user: surname is required")
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should not modify a user not found",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should keep the roles of the user when none are given",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Smith", Roles: []string{entity.RoleAdmin}}, nil)
+				user := entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}
+				m.On("save", context.Background(), user).Return(user, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{entity.RoleAdmin}, user.Roles)
+			},
+		},
+		{
+			name: "should modify user with the same roles in another order when the caller is not an admin",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"auditor", "editor"}}, nil)
+				user := entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"editor", "auditor"}}
+				m.On("save", context.Background(), user).Return(user, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"editor", "auditor"}})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"editor", "auditor"}, user.Roles)
+			},
+		},
+		{
+			name: "should change the roles of the user when the caller is an admin",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", adminContext(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				user := entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}
+				m.On("save", adminContext(), user).Return(user, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(adminContext(), entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}})
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{entity.RoleAdmin}, user.Roles)
+			},
+		},
+		{
+			name: "should not change the roles of the user when the caller is not an admin",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				return m
+			},
+			when: func(mockUser *repository.MockUser) (entity.User, error) {
+				return NewUserModifier(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}})
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrForbidden)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -250,6 +778,173 @@ func TestUserModifier_Modify(t *testing.T) {
 	}
 }
 
+func TestUserPatcher_Patch(t *testing.T) {
+	mergePatch := entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`)}
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		given func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier)
+		then  func(user entity.User, err error)
+	}{
+		{
+			name: "should patch the user",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				stored := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}
+				m.On("FindByID", ctx, uint(1)).Return(stored, nil)
+				m.On("save", ctx, entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 2}).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 3}, nil)
+				p := patch.NewMockUserPatchApplier()
+				// the version is not part of the patched document
+				p.On("Apply", stored, mergePatch).Return(entity.User{ID: 1, Name: "John", Surname: "Smith"}, nil)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 3}, user)
+			},
+		},
+		{
+			name: "should not patch a user not found",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				return m, patch.NewMockUserPatchApplier()
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should not patch the user when the patch cannot be applied",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				p := patch.NewMockUserPatchApplier()
+				p.On("Apply", entity.User{ID: 1, Name: "John", Surname: "Doe"}, mergePatch).Return(entity.User{}, domerrors.ErrPatchConflict)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrPatchConflict)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should not change the ID of the user",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				p := patch.NewMockUserPatchApplier()
+				p.On("Apply", entity.User{ID: 1, Name: "John", Surname: "Doe"}, mergePatch).Return(entity.User{ID: 2, Name: "John", Surname: "Doe"}, nil)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUnprocessablePatch)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should save the normalized user",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				user := entity.User{ID: 1, Name: "John", Surname: "Smith"}
+				m.On("save", ctx, user).Return(user, nil)
+				p := patch.NewMockUserPatchApplier()
+				p.On("Apply", entity.User{ID: 1, Name: "John", Surname: "Doe"}, mergePatch).Return(entity.User{ID: 1, Name: "John", Surname: " Smith "}, nil)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "Smith", user.Surname)
+			},
+		},
+		{
+			name: "should not save an invalid user",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				p := patch.NewMockUserPatchApplier()
+				p.On("Apply", entity.User{ID: 1, Name: "John", Surname: "Doe"}, mergePatch).Return(entity.User{ID: 1, Surname: "Doe"}, nil)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidUser)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+		{
+			name: "should change the roles of the user when the caller is an admin",
+			ctx:  adminContext(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				user := entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}
+				m.On("save", ctx, user).Return(user, nil)
+				p := patch.NewMockUserPatchApplier()
+				p.On("Apply", entity.User{ID: 1, Name: "John", Surname: "Doe"}, mergePatch).Return(user, nil)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{entity.RoleAdmin}, user.Roles)
+			},
+		},
+		{
+			name: "should not change the roles of the user when the caller is not an admin",
+			ctx:  context.Background(),
+			given: func(ctx context.Context) (*repository.MockUser, *patch.MockUserPatchApplier) {
+				m := repository.NewMockUser()
+				m.On("FindByID", ctx, uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}, nil)
+				p := patch.NewMockUserPatchApplier()
+				p.On("Apply", entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}}, mergePatch).
+					Return(entity.User{ID: 1, Name: "John", Surname: "Doe"}, nil)
+				return m, p
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrForbidden)
+				assert.Equal(t, entity.User{}, user)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser, mockApplier := tt.given(tt.ctx)
+
+			// When
+			user, err := NewUserPatcher(mockUser, mockApplier, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Patch(tt.ctx, 1, mergePatch)
+
+			// Then
+			tt.then(user, err)
+			mockUser.AssertExpectations(t)
+			mockApplier.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserPatcher_Patch_ConcurrentModification(t *testing.T) {
+	m := repository.NewMockUser()
+	m.On("FindByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}, nil)
+	p := patch.NewMockUserPatchApplier()
+
+	user, err := NewUserPatcher(m, p, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Patch(context.Background(), 1,
+		entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`), Version: 2})
+
+	assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+	assert.Equal(t, entity.User{}, user)
+	m.AssertExpectations(t)
+	p.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+}
+
 func TestUserDeleter_Delete(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -263,10 +958,11 @@ func TestUserDeleter_Delete(t *testing.T) {
 				m := repository.NewMockUser()
 				user := entity.User{ID: 1, Name: "John", Surname: "Doe"}
 				m.On("Delete", context.Background(), user).Return(nil)
+				m.On("FindDeletedByID", context.Background(), uint(1)).Return(entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2}, nil)
 				return m
 			},
 			when: func(mockUser *repository.MockUser) error {
-				return NewUserDeleter(mockUser).Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+				return NewUserDeleter(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
 			},
 			then: func(err error) {
 				assert.NoError(t, err)
@@ -280,7 +976,7 @@ func TestUserDeleter_Delete(t *testing.T) {
 				return m
 			},
 			when: func(mockUser *repository.MockUser) error {
-				return NewUserDeleter(mockUser).Delete(context.Background(), entity.User{})
+				return NewUserDeleter(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Delete(context.Background(), entity.User{})
 			},
 			then: func(err error) {
 				assert.Error(t, err)
@@ -300,3 +996,177 @@ func TestUserDeleter_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserDeleter_Purge(t *testing.T) {
+	deleted := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2, DeletedAt: time.Now()}
+
+	tests := []struct {
+		name    string
+		given   func() *repository.MockUser
+		ctx     context.Context
+		version uint
+		then    func(*repository.MockUser, error)
+	}{
+		{
+			name: "should purge an active user",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				user := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}
+				m.On("FindByID", adminContext(), uint(1)).Return(user, nil)
+				m.On("Purge", adminContext(), user).Return(nil)
+				return m
+			},
+			version: 1,
+			ctx:     adminContext(),
+			then: func(m *repository.MockUser, err error) {
+				assert.NoError(t, err)
+				m.AssertExpectations(t)
+			},
+		},
+		{
+			name: "should purge a deleted user at its current version",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", adminContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				m.On("FindDeletedByID", adminContext(), uint(1)).Return(deleted, nil)
+				m.On("Purge", adminContext(), deleted).Return(nil)
+				return m
+			},
+			ctx: adminContext(),
+			then: func(m *repository.MockUser, err error) {
+				assert.NoError(t, err)
+				m.AssertExpectations(t)
+			},
+		},
+		{
+			name: "should not purge a user at another version",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", adminContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				m.On("FindDeletedByID", adminContext(), uint(1)).Return(deleted, nil)
+				return m
+			},
+			version: 1,
+			ctx:     adminContext(),
+			then: func(m *repository.MockUser, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+				m.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should not purge a user for a non-admin",
+			given: func() *repository.MockUser {
+				return repository.NewMockUser()
+			},
+			ctx: context.Background(),
+			then: func(m *repository.MockUser, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrForbidden)
+				m.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name: "should not purge a missing user",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindByID", adminContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				m.On("FindDeletedByID", adminContext(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				return m
+			},
+			ctx: adminContext(),
+			then: func(m *repository.MockUser, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				m.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser := tt.given()
+
+			// When
+			err := NewUserDeleter(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Purge(tt.ctx, 1, tt.version)
+
+			// Then
+			tt.then(mockUser, err)
+		})
+	}
+}
+
+func TestUserRestorer_Restore(t *testing.T) {
+	deleted := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2, DeletedAt: time.Now()}
+	restored := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 3}
+
+	tests := []struct {
+		name    string
+		given   func() *repository.MockUser
+		version uint
+		then    func(entity.User, error)
+	}{
+		{
+			name: "should restore user",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindDeletedByID", context.Background(), uint(1)).Return(deleted, nil)
+				m.On("Restore", context.Background(), deleted).Return(restored, nil)
+				return m
+			},
+			version: 2,
+			then: func(user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, restored, user)
+			},
+		},
+		{
+			name: "should not restore a user which is not deleted",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindDeletedByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				m.On("FindByID", context.Background(), uint(1)).Return(restored, nil)
+				return m
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotDeleted)
+				assert.Empty(t, user)
+			},
+		},
+		{
+			name: "should not restore a missing user",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindDeletedByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				m.On("FindByID", context.Background(), uint(1)).Return(entity.User{}, domerrors.ErrUserNotFound)
+				return m
+			},
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, user)
+			},
+		},
+		{
+			name: "should not restore a user at another version",
+			given: func() *repository.MockUser {
+				m := repository.NewMockUser()
+				m.On("FindDeletedByID", context.Background(), uint(1)).Return(deleted, nil)
+				return m
+			},
+			version: 1,
+			then: func(user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+				assert.Empty(t, user)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockUser := tt.given()
+
+			// When
+			user, err := NewUserRestorer(mockUser, repository.NewTransactorInMemory(), repository.NewUserOutboxInMemory()).Restore(context.Background(), 1, tt.version)
+
+			// Then
+			tt.then(user, err)
+		})
+	}
+}
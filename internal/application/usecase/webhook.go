@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/pkg/errors"
+)
+
+const (
+	// webhookSecretSize is the number of random bytes of the secrets generated for the webhooks
+	webhookSecretSize = 32
+	// webhookDeliveryLogSize is the number of latest deliveries listed in the delivery log of a webhook
+	webhookDeliveryLogSize = 100
+)
+
+// WebhookBatchSize is the maximum number of deliveries of the webhooks attempted at once
+type WebhookBatchSize int
+
+// WebhookLease is the time the deliveries of the webhooks claimed for an attempt are left out of the due deliveries,
+// after which they are attempted again unless their outcome was recorded meanwhile
+type WebhookLease time.Duration
+
+// WebhookRetryPolicy defines the retries of the failed deliveries of the webhooks.
+// A failed delivery is retried after a backoff starting at InitialBackoff and doubling on every attempt up to
+// MaxBackoff, and given up as a dead letter once MaxAttempts attempts failed.
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff returns the delay before the retry following the given number of failed attempts
+func (p WebhookRetryPolicy) backoff(attempts int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.MaxBackoff)
+}
+
+// attempted returns the given delivery after an attempt made at the given time, answered with the given status and
+// failing with the given error if not nil
+func (p WebhookRetryPolicy) attempted(
+	delivery entity.WebhookDelivery,
+	now time.Time,
+	status int,
+	err error,
+) entity.WebhookDelivery {
+	delivery.Attempts++
+	delivery.LastStatusCode = status
+	delivery.LastError = ""
+	if err == nil {
+		delivery.Status = entity.WebhookDeliveryDelivered
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= p.MaxAttempts {
+		delivery.Status = entity.WebhookDeliveryDead
+	} else {
+		delivery.Status = entity.WebhookDeliveryPending
+		delivery.NextAttemptAt = now.Add(p.backoff(delivery.Attempts))
+	}
+	return delivery
+}
+
+// WebhookFinderAll use case
+type WebhookFinderAll struct {
+	webhooks repository.Webhook
+}
+
+// NewWebhookFinderAll creates a new usecase.WebhookFinderAll instance
+func NewWebhookFinderAll(webhooks repository.Webhook) usecase.WebhookFinderAll {
+	return &WebhookFinderAll{
+		webhooks: webhooks,
+	}
+}
+
+// Find returns all webhooks or an error if something goes wrong
+func (u *WebhookFinderAll) Find(ctx context.Context) ([]entity.Webhook, error) {
+	return u.webhooks.FindAll(ctx)
+}
+
+// WebhookCreator use case
+type WebhookCreator struct {
+	webhooks repository.Webhook
+}
+
+// NewWebhookCreator creates a new usecase.WebhookCreator instance
+func NewWebhookCreator(webhooks repository.Webhook) usecase.WebhookCreator {
+	return &WebhookCreator{
+		webhooks: webhooks,
+	}
+}
+
+// Create creates a webhook and returns it along with its secret, an *errors.ValidationError matching
+// errors.ErrInvalidWebhook if it is not valid, or an error if something goes wrong.
+// A random secret is generated when none is given.
+func (u *WebhookCreator) Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error) {
+	if webhook.Secret == "" {
+		secret, err := randomToken(webhookSecretSize)
+		if err != nil {
+			return entity.Webhook{}, err
+		}
+		webhook.Secret = secret
+	}
+	if err := webhook.Validate(); err != nil {
+		return entity.Webhook{}, err
+	}
+
+	return u.webhooks.Create(ctx, webhook)
+}
+
+// WebhookDeleter use case
+type WebhookDeleter struct {
+	tx         repository.Transactor
+	webhooks   repository.Webhook
+	deliveries repository.WebhookDelivery
+}
+
+// NewWebhookDeleter creates a new usecase.WebhookDeleter instance
+func NewWebhookDeleter(
+	tx repository.Transactor,
+	webhooks repository.Webhook,
+	deliveries repository.WebhookDelivery,
+) usecase.WebhookDeleter {
+	return &WebhookDeleter{
+		tx:         tx,
+		webhooks:   webhooks,
+		deliveries: deliveries,
+	}
+}
+
+// Delete deletes the webhook of the given ID along with its deliveries, pending or not, or returns
+// errors.ErrWebhookNotFound
+func (u *WebhookDeleter) Delete(ctx context.Context, id uint) error {
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.webhooks.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.deliveries.DeleteByWebhookID(ctx, id)
+	})
+}
+
+// WebhookDeliveryFinder use case
+type WebhookDeliveryFinder struct {
+	webhooks   repository.Webhook
+	deliveries repository.WebhookDelivery
+}
+
+// NewWebhookDeliveryFinder creates a new usecase.WebhookDeliveryFinder instance
+func NewWebhookDeliveryFinder(
+	webhooks repository.Webhook,
+	deliveries repository.WebhookDelivery,
+) usecase.WebhookDeliveryFinder {
+	return &WebhookDeliveryFinder{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+	}
+}
+
+// Find returns the latest deliveries of the webhook of the given ID, errors.ErrWebhookNotFound if there is no such
+// webhook, or an error if something goes wrong
+func (u *WebhookDeliveryFinder) Find(ctx context.Context, webhookID uint) ([]entity.WebhookDelivery, error) {
+	if _, err := u.webhooks.FindByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return u.deliveries.FindByWebhookID(ctx, webhookID, webhookDeliveryLogSize)
+}
+
+// WebhookScheduler use case
+type WebhookScheduler struct {
+	webhooks   repository.Webhook
+	deliveries repository.WebhookDelivery
+	now        func() time.Time
+}
+
+// NewWebhookScheduler creates a new usecase.WebhookScheduler instance
+func NewWebhookScheduler(
+	webhooks repository.Webhook,
+	deliveries repository.WebhookDelivery,
+) usecase.WebhookScheduler {
+	return &WebhookScheduler{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		now:        time.Now,
+	}
+}
+
+// Schedule schedules the immediate delivery of the given event to every webhook subscribed to it, or returns an error
+// if something goes wrong. The deliveries are created in the transaction of the given context, if any.
+func (u *WebhookScheduler) Schedule(ctx context.Context, event entity.UserEvent) error {
+	webhooks, err := u.webhooks.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		_, err = u.deliveries.Create(ctx, entity.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Status:        entity.WebhookDeliveryPending,
+			NextAttemptAt: u.now(),
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "cannot schedule user event %d to webhook %d", event.ID, webhook.ID)
+		}
+	}
+
+	return nil
+}
+
+// WebhookDeliverer use case
+type WebhookDeliverer struct {
+	tx         repository.Transactor
+	webhooks   repository.Webhook
+	deliveries repository.WebhookDelivery
+	sender     service.WebhookSender
+	policy     WebhookRetryPolicy
+	batchSize  WebhookBatchSize
+	lease      WebhookLease
+	now        func() time.Time
+}
+
+// NewWebhookDeliverer creates a new usecase.WebhookDeliverer instance
+func NewWebhookDeliverer(
+	tx repository.Transactor,
+	webhooks repository.Webhook,
+	deliveries repository.WebhookDelivery,
+	sender service.WebhookSender,
+	policy WebhookRetryPolicy,
+	batchSize WebhookBatchSize,
+	lease WebhookLease,
+) usecase.WebhookDeliverer {
+	return &WebhookDeliverer{
+		tx:         tx,
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		sender:     sender,
+		policy:     policy,
+		batchSize:  batchSize,
+		lease:      lease,
+		now:        time.Now,
+	}
+}
+
+// Deliver attempts at most a batch of the due deliveries in the order they are due, and records their outcome as
+// defined by the retry policy. It returns the number of deliveries attempted, along with an error if something goes
+// wrong, in which case it stops at the delivery failing.
+// The deliveries are claimed for the lease in a transaction of their own, attempted outside of any transaction, and
+// their outcome recorded one by one, so that the deliveries claimed but whose outcome is not recorded are attempted
+// again once their lease expires. A delivery whose webhook no longer exists is given up at once.
+func (u *WebhookDeliverer) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := u.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, delivery := range deliveries {
+		if err = u.attempt(ctx, delivery); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// claim returns at most a batch of the due deliveries, postponing their next attempt by the lease so that they are
+// left out of the due deliveries meanwhile
+func (u *WebhookDeliverer) claim(ctx context.Context) ([]entity.WebhookDelivery, error) {
+	var claimed []entity.WebhookDelivery
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := u.now()
+		deliveries, err := u.deliveries.FindDue(ctx, now, int(u.batchSize))
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			leased := delivery
+			leased.NextAttemptAt = now.Add(time.Duration(u.lease))
+			if _, err = u.deliveries.Update(ctx, leased); err != nil {
+				return errors.WithMessagef(err, "cannot claim webhook delivery %d", delivery.ID)
+			}
+		}
+
+		claimed = deliveries
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// attempt attempts the given claimed delivery, and records its outcome in a transaction of its own
+func (u *WebhookDeliverer) attempt(ctx context.Context, delivery entity.WebhookDelivery) error {
+	webhook, err := u.webhooks.FindByID(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, domerrors.ErrWebhookNotFound):
+		delivery.Status = entity.WebhookDeliveryDead
+		delivery.LastError = err.Error()
+	case err != nil:
+		return err
+	default:
+		status, sendErr := u.sender.Send(ctx, webhook, delivery)
+		if ctx.Err() != nil {
+			// the attempt was interrupted rather than failed
+			return ctx.Err()
+		}
+		delivery = u.policy.attempted(delivery, u.now(), status, sendErr)
+	}
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := u.deliveries.Update(ctx, delivery)
+		return errors.WithMessagef(err, "cannot record the attempt of webhook delivery %d", delivery.ID)
+	})
+}
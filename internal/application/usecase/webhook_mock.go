@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookFinderAll struct {
+	mock.Mock
+}
+
+func NewMockWebhookFinderAll() *MockWebhookFinderAll {
+	return &MockWebhookFinderAll{}
+}
+
+func (m *MockWebhookFinderAll) Find(ctx context.Context) ([]entity.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.Webhook), args.Error(1)
+}
+
+type MockWebhookCreator struct {
+	mock.Mock
+}
+
+func NewMockWebhookCreator() *MockWebhookCreator {
+	return &MockWebhookCreator{}
+}
+
+func (m *MockWebhookCreator) Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error) {
+	args := m.Called(ctx, webhook)
+	return args.Get(0).(entity.Webhook), args.Error(1)
+}
+
+type MockWebhookDeleter struct {
+	mock.Mock
+}
+
+func NewMockWebhookDeleter() *MockWebhookDeleter {
+	return &MockWebhookDeleter{}
+}
+
+func (m *MockWebhookDeleter) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockWebhookDeliveryFinder struct {
+	mock.Mock
+}
+
+func NewMockWebhookDeliveryFinder() *MockWebhookDeliveryFinder {
+	return &MockWebhookDeliveryFinder{}
+}
+
+func (m *MockWebhookDeliveryFinder) Find(ctx context.Context, webhookID uint) ([]entity.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID)
+	return args.Get(0).([]entity.WebhookDelivery), args.Error(1)
+}
@@ -0,0 +1,381 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/webhook"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRetryPolicy_Backoff(t *testing.T) {
+	policy := WebhookRetryPolicy{MaxAttempts: 8, InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute}
+
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{attempts: 1, backoff: 10 * time.Second},
+		{attempts: 2, backoff: 20 * time.Second},
+		{attempts: 3, backoff: 40 * time.Second},
+		{attempts: 4, backoff: time.Minute},
+		{attempts: 100, backoff: time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.backoff, policy.backoff(tt.attempts), "after %d attempts", tt.attempts)
+	}
+}
+
+func TestWebhookCreator_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook entity.Webhook
+		then    func(entity.Webhook, error)
+	}{
+		{
+			name:    "should create a webhook with a generated secret",
+			webhook: entity.Webhook{URL: "https://example.com/hooks"},
+			then: func(webhook entity.Webhook, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), webhook.ID)
+				assert.GreaterOrEqual(t, len(webhook.Secret), entity.MinWebhookSecretLength)
+			},
+		},
+		{
+			name:    "should create a webhook with the given secret",
+			webhook: entity.Webhook{URL: "https://example.com/hooks", Secret: "0123456789abcdef"},
+			then: func(webhook entity.Webhook, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "0123456789abcdef", webhook.Secret)
+			},
+		},
+		{
+			name: "should not create an invalid webhook",
+			webhook: entity.Webhook{URL: "example.com", Secret: "short",
+				EventTypes: []entity.UserEventType{"user.unknown"}},
+			then: func(webhook entity.Webhook, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidWebhook)
+				var validationErr *domerrors.ValidationError
+				if assert.ErrorAs(t, err, &validationErr) {
+					assert.Len(t, validationErr.Violations, 3)
+				}
+				assert.Empty(t, webhook)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			webhook, err := NewWebhookCreator(repository.NewWebhookInMemory()).Create(context.Background(), tt.webhook)
+
+			// Then
+			tt.then(webhook, err)
+		})
+	}
+}
+
+func TestWebhookDeleter_Delete(t *testing.T) {
+	// Given
+	webhooks := repository.NewWebhookInMemory()
+	deliveries := repository.NewWebhookDeliveryInMemory()
+	created, err := webhooks.Create(context.Background(), entity.Webhook{URL: "https://example.com/hooks",
+		Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	_, err = deliveries.Create(context.Background(), entity.WebhookDelivery{WebhookID: created.ID,
+		Status: entity.WebhookDeliveryPending})
+	require.NoError(t, err)
+	deleter := NewWebhookDeleter(repository.NewTransactorInMemory(), webhooks, deliveries)
+
+	// When
+	err = deleter.Delete(context.Background(), created.ID)
+
+	// Then
+	assert.NoError(t, err)
+	found, err := deliveries.FindByWebhookID(context.Background(), created.ID, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	assert.ErrorIs(t, deleter.Delete(context.Background(), created.ID), domerrors.ErrWebhookNotFound)
+}
+
+func TestWebhookDeliveryFinder_Find(t *testing.T) {
+	// Given
+	mockWebhook := repository.NewMockWebhook()
+	mockWebhook.On("FindByID", context.Background(), uint(1)).Return(entity.Webhook{ID: 1}, nil)
+	mockWebhook.On("FindByID", context.Background(), uint(2)).Return(entity.Webhook{}, domerrors.ErrWebhookNotFound)
+	mockDelivery := repository.NewMockWebhookDelivery()
+	mockDelivery.On("FindByWebhookID", context.Background(), uint(1), webhookDeliveryLogSize).
+		Return([]entity.WebhookDelivery{{ID: 1, WebhookID: 1}}, nil)
+	finder := NewWebhookDeliveryFinder(mockWebhook, mockDelivery)
+
+	// When
+	deliveries, err := finder.Find(context.Background(), 1)
+	_, notFoundErr := finder.Find(context.Background(), 2)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.WebhookDelivery{{ID: 1, WebhookID: 1}}, deliveries)
+	assert.ErrorIs(t, notFoundErr, domerrors.ErrWebhookNotFound)
+	mockDelivery.AssertExpectations(t)
+}
+
+func TestWebhookScheduler_Schedule(t *testing.T) {
+	// Given
+	webhooks := repository.NewWebhookInMemory()
+	deliveries := repository.NewWebhookDeliveryInMemory()
+	for _, w := range []entity.Webhook{
+		{URL: "https://example.com/all", Secret: "0123456789abcdef"},
+		{URL: "https://example.com/deleted", Secret: "0123456789abcdef",
+			EventTypes: []entity.UserEventType{entity.UserDeleted}},
+	} {
+		_, err := webhooks.Create(context.Background(), w)
+		require.NoError(t, err)
+	}
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	scheduler := NewWebhookScheduler(webhooks, deliveries).(*WebhookScheduler)
+	scheduler.now = func() time.Time { return now }
+	event := entity.UserEvent{ID: 1, Type: entity.UserCreated, UserID: 1}
+
+	// When
+	err := scheduler.Schedule(context.Background(), event)
+
+	// Then
+	assert.NoError(t, err)
+	due, err := deliveries.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, uint(1), due[0].WebhookID)
+		assert.Equal(t, event, due[0].Event)
+		assert.Equal(t, entity.WebhookDeliveryPending, due[0].Status)
+		assert.Equal(t, now, due[0].NextAttemptAt)
+	}
+}
+
+func TestWebhookScheduler_Schedule_NotScheduled(t *testing.T) {
+	// Given
+	mockWebhook := repository.NewMockWebhook()
+	mockWebhook.On("FindAll", context.Background()).Return([]entity.Webhook{{ID: 1}}, nil)
+	mockDelivery := repository.NewMockWebhookDelivery()
+	mockDelivery.On("Create", context.Background(), mock.Anything).
+		Return(entity.WebhookDelivery{}, errors.New("failed to create"))
+
+	// When
+	err := NewWebhookScheduler(mockWebhook, mockDelivery).
+		Schedule(context.Background(), entity.UserEvent{ID: 3, Type: entity.UserCreated})
+
+	// Then
+	assert.ErrorContains(t, err, "cannot schedule user event 3 to webhook 1")
+}
+
+func TestWebhookDeliverer_Deliver(t *testing.T) {
+	// Given
+	const secret = "0123456789abcdef"
+	var flakyCalls, brokenCalls atomic.Int32
+	receiver := http.NewServeMux()
+	// the flaky receiver fails the first attempt only
+	receiver.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, webhook.Sign(secret, r.Header.Get(webhook.TimestampHeader), body),
+			r.Header.Get(webhook.SignatureHeader))
+		if flakyCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	receiver.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		brokenCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	webhooks := repository.NewWebhookInMemory()
+	deliveries := repository.NewWebhookDeliveryInMemory()
+	flaky, err := webhooks.Create(context.Background(), entity.Webhook{URL: server.URL + "/flaky", Secret: secret})
+	require.NoError(t, err)
+	broken, err := webhooks.Create(context.Background(), entity.Webhook{URL: server.URL + "/broken", Secret: secret})
+	require.NoError(t, err)
+	gone, err := webhooks.Create(context.Background(), entity.Webhook{URL: server.URL + "/gone", Secret: secret})
+	require.NoError(t, err)
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	scheduler := NewWebhookScheduler(webhooks, deliveries).(*WebhookScheduler)
+	scheduler.now = func() time.Time { return now }
+	require.NoError(t, scheduler.Schedule(context.Background(), entity.UserEvent{ID: 1, Type: entity.UserCreated,
+		UserID: 1, User: entity.User{ID: 1, Name: "John", Surname: "Doe"}, OccurredAt: now}))
+	require.NoError(t, webhooks.Delete(context.Background(), gone.ID))
+
+	deliverer := NewWebhookDeliverer(repository.NewTransactorInMemory(), webhooks, deliveries,
+		webhook.NewHTTPSender(time.Second),
+		WebhookRetryPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute}, 10,
+		WebhookLease(time.Minute),
+	).(*WebhookDeliverer)
+	deliverer.now = func() time.Time { return now }
+	log := func(webhookID uint) entity.WebhookDelivery {
+		found, err := deliveries.FindByWebhookID(context.Background(), webhookID, 1)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		return found[0]
+	}
+
+	// When the deliveries are first attempted
+	attempted, err := deliverer.Deliver(context.Background())
+
+	// Then they are retried later, but the one of the deleted webhook
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempted)
+	for webhookID, status := range map[uint]int{
+		flaky.ID:  http.StatusServiceUnavailable,
+		broken.ID: http.StatusInternalServerError,
+	} {
+		delivery := log(webhookID)
+		assert.Equal(t, entity.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, now.Add(10*time.Second), delivery.NextAttemptAt)
+		assert.Equal(t, status, delivery.LastStatusCode)
+	}
+	assert.Equal(t, entity.WebhookDeliveryDead, log(gone.ID).Status)
+
+	// When the deliveries are attempted before they are due
+	attempted, err = deliverer.Deliver(context.Background())
+
+	// Then none is attempted
+	assert.NoError(t, err)
+	assert.Zero(t, attempted)
+
+	// When the deliveries are attempted once they are due
+	now = now.Add(10 * time.Second)
+	attempted, err = deliverer.Deliver(context.Background())
+
+	// Then the flaky one is delivered, and the broken one given up
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempted)
+	delivered := log(flaky.ID)
+	assert.Equal(t, entity.WebhookDeliveryDelivered, delivered.Status)
+	assert.Equal(t, 2, delivered.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivered.LastStatusCode)
+	assert.Empty(t, delivered.LastError)
+	dead := log(broken.ID)
+	assert.Equal(t, entity.WebhookDeliveryDead, dead.Status)
+	assert.Equal(t, 2, dead.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, dead.LastStatusCode)
+	assert.Equal(t, "unexpected status 500", dead.LastError)
+	assert.Equal(t, int32(2), flakyCalls.Load())
+	assert.Equal(t, int32(2), brokenCalls.Load())
+
+	// When the deliveries are attempted again
+	attempted, err = deliverer.Deliver(context.Background())
+
+	// Then none is left
+	assert.NoError(t, err)
+	assert.Zero(t, attempted)
+}
+
+func TestWebhookDeliverer_Deliver_NotRecorded(t *testing.T) {
+	// Given
+	mockDelivery := repository.NewMockWebhookDelivery()
+	mockDelivery.On("FindDue", context.Background(), mock.Anything, 10).
+		Return([]entity.WebhookDelivery{}, errors.New("failed to find"))
+
+	// When
+	attempted, err := NewWebhookDeliverer(repository.NewTransactorInMemory(), repository.NewMockWebhook(),
+		mockDelivery, webhook.NewMockWebhookSender(), WebhookRetryPolicy{}, 10, WebhookLease(time.Minute)).
+		Deliver(context.Background())
+
+	// Then
+	assert.EqualError(t, err, "failed to find")
+	assert.Zero(t, attempted)
+}
+
+func TestWebhookDeliverer_Deliver_Claimed(t *testing.T) {
+	// Given
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	webhooks := repository.NewWebhookInMemory()
+	deliveries := repository.NewWebhookDeliveryInMemory()
+	hook, err := webhooks.Create(context.Background(), entity.Webhook{URL: "https://partner.example.com/hook", Secret: "secret"})
+	require.NoError(t, err)
+	scheduler := NewWebhookScheduler(webhooks, deliveries).(*WebhookScheduler)
+	scheduler.now = func() time.Time { return now }
+	require.NoError(t, scheduler.Schedule(context.Background(), entity.UserEvent{ID: 1, Type: entity.UserCreated, UserID: 1}))
+
+	tx := repository.NewMockTransactor()
+	tx.On("WithinTransaction", mock.Anything, nil).Return()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender := webhook.NewMockWebhookSender()
+	sender.On("Send", mock.Anything, hook, mock.Anything).Run(func(mock.Arguments) {
+		// the delivery is sent once its claim is committed, outside of any transaction
+		tx.AssertNumberOfCalls(t, "WithinTransaction", 1)
+		due, err := deliveries.FindDue(context.Background(), now, 10)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+		// the instance attempting the delivery stops before recording its outcome
+		cancel()
+	}).Return(0, context.Canceled).Once()
+	sender.On("Send", mock.Anything, hook, mock.Anything).Return(http.StatusNoContent, nil).Once()
+
+	deliverer := NewWebhookDeliverer(tx, webhooks, deliveries, sender, WebhookRetryPolicy{MaxAttempts: 2},
+		10, WebhookLease(time.Minute)).(*WebhookDeliverer)
+	deliverer.now = func() time.Time { return now }
+
+	// When the attempt is interrupted
+	attempted, err := deliverer.Deliver(ctx)
+
+	// Then the delivery is left claimed for the lease
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, attempted)
+	claimed, err := deliveries.FindByWebhookID(context.Background(), hook.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WebhookDeliveryPending, claimed[0].Status)
+	assert.Equal(t, now.Add(time.Minute), claimed[0].NextAttemptAt)
+
+	// When the lease expires
+	now = now.Add(time.Minute)
+	attempted, err = deliverer.Deliver(context.Background())
+
+	// Then the delivery is attempted again, and its outcome recorded in a transaction of its own
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	delivered, err := deliveries.FindByWebhookID(context.Background(), hook.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WebhookDeliveryDelivered, delivered[0].Status)
+	tx.AssertNumberOfCalls(t, "WithinTransaction", 3)
+	sender.AssertExpectations(t)
+}
+
+func TestWebhookDeliverer_Deliver_OutcomeNotRecorded(t *testing.T) {
+	// Given
+	due := entity.WebhookDelivery{ID: 1, WebhookID: 1, Status: entity.WebhookDeliveryPending}
+	mockWebhook := repository.NewMockWebhook()
+	mockWebhook.On("FindByID", context.Background(), uint(1)).Return(entity.Webhook{ID: 1}, nil)
+	mockDelivery := repository.NewMockWebhookDelivery()
+	mockDelivery.On("FindDue", context.Background(), mock.Anything, 10).Return([]entity.WebhookDelivery{due}, nil)
+	mockDelivery.On("Update", context.Background(), mock.MatchedBy(func(d entity.WebhookDelivery) bool {
+		return d.Attempts == 0
+	})).Return(due, nil)
+	mockDelivery.On("Update", context.Background(), mock.MatchedBy(func(d entity.WebhookDelivery) bool {
+		return d.Attempts == 1
+	})).Return(entity.WebhookDelivery{}, errors.New("failed to update"))
+	sender := webhook.NewMockWebhookSender()
+	sender.On("Send", context.Background(), entity.Webhook{ID: 1}, due).Return(http.StatusNoContent, nil)
+
+	// When
+	attempted, err := NewWebhookDeliverer(repository.NewTransactorInMemory(), mockWebhook, mockDelivery, sender,
+		WebhookRetryPolicy{MaxAttempts: 2}, 10, WebhookLease(time.Minute)).Deliver(context.Background())
+
+	// Then
+	assert.EqualError(t, err, "cannot record the attempt of webhook delivery 1: failed to update")
+	assert.Zero(t, attempted)
+	mockDelivery.AssertExpectations(t)
+}
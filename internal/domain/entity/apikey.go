@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// APIKey represents a long-lived credential of a machine client, acting on behalf of its owner.
+// Only the hash of the key is kept, and its Prefix, which is part of the key, finds it on authentication.
+type APIKey struct {
+	ID         uint
+	Name       string
+	Prefix     string
+	KeyHash    string
+	OwnerID    uint
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// IsRevoked reports whether the API key has been revoked
+func (k APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsExpired reports whether the API key is expired at the given time. Keys without expiry never expire.
+func (k APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IssuedAPIKey represents a newly created API key along with its plain value, which is never available again
+type IssuedAPIKey struct {
+	APIKey APIKey
+	Key    string
+}
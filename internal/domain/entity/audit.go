@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// UserAuditAction is the kind of change of a user recorded by its audit trail
+type UserAuditAction string
+
+// actions of the changes of the users
+const (
+	UserAuditCreate  UserAuditAction = "create"
+	UserAuditModify  UserAuditAction = "modify"
+	UserAuditPatch   UserAuditAction = "patch"
+	UserAuditDelete  UserAuditAction = "delete"
+	UserAuditRestore UserAuditAction = "restore"
+	UserAuditPurge   UserAuditAction = "purge"
+)
+
+// requestIDKey is the context key of the ID of the request
+type requestIDKey struct{}
+
+// UserAuditEntry represents a change of a user recorded by its audit trail, which is kept after the user is purged
+type UserAuditEntry struct {
+	ID     uint
+	UserID uint
+	Action UserAuditAction
+	// Actor is the subject of the principal making the change, and ActorID the ID of its user if any
+	Actor   string
+	ActorID uint
+	// Before and After are the user before and after the change, Before being nil for a creation and After for a
+	// purge
+	Before *User
+	After  *User
+	// RequestID is the ID of the request making the change, to correlate it with the logs
+	RequestID  string
+	OccurredAt time.Time
+}
+
+// ContextWithRequestID returns a copy of the given context carrying the given ID of its request
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID of the request carried by the given context, or an empty string if none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
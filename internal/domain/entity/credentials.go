@@ -0,0 +1,8 @@
+package entity
+
+// Credentials represents the login credentials of a user
+type Credentials struct {
+	UserID       uint
+	Username     string
+	PasswordHash string
+}
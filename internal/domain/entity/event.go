@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+)
+
+// UserEventType is the kind of change of a user notified to the other services
+type UserEventType string
+
+// types of the events of the users
+const (
+	UserCreated  UserEventType = "user.created"
+	UserModified UserEventType = "user.modified"
+	UserDeleted  UserEventType = "user.deleted"
+	UserRestored UserEventType = "user.restored"
+	UserPurged   UserEventType = "user.purged"
+)
+
+// UserEventTypes are the types of the events of the users
+var UserEventTypes = []UserEventType{UserCreated, UserModified, UserDeleted, UserRestored, UserPurged}
+
+// UserEvent represents a change of a user raised by the use cases, to be relayed to the other services
+type UserEvent struct {
+	ID     uint
+	Type   UserEventType
+	UserID uint
+	// User is the user after the change, or before it for a purge
+	User       User
+	OccurredAt time.Time
+}
+
+// NewUserEvent returns the event of the given type of the given user
+func NewUserEvent(eventType UserEventType, user User) UserEvent {
+	return UserEvent{Type: eventType, UserID: user.ID, User: user}
+}
+
+// UserEventFilter selects the events of some users, of some types. It matches the events of every user when it has
+// no UserIDs, and the events of every type when it has no EventTypes.
+type UserEventFilter struct {
+	UserIDs    []uint
+	EventTypes []UserEventType
+}
+
+// Matches reports whether the given event is selected by the filter
+func (f UserEventFilter) Matches(event UserEvent) bool {
+	return (len(f.UserIDs) == 0 || slices.Contains(f.UserIDs, event.UserID)) &&
+		(len(f.EventTypes) == 0 || slices.Contains(f.EventTypes, event.Type))
+}
+
+// Validate returns an *errors.ValidationError matching errors.ErrInvalidUserEventFilter and listing every broken
+// invariant, or nil if the filter is valid
+func (f UserEventFilter) Validate() error {
+	var violations []domerrors.FieldViolation
+	violate := func(field, message string) {
+		violations = append(violations, domerrors.FieldViolation{Field: field, Message: message})
+	}
+
+	for i, userID := range f.UserIDs {
+		if userID == 0 {
+			violate(fmt.Sprintf("user_ids[%d]", i), "must be positive")
+		}
+	}
+
+	for i, eventType := range f.EventTypes {
+		if !slices.Contains(UserEventTypes, eventType) {
+			violate(fmt.Sprintf("event_types[%d]", i), fmt.Sprintf("must be one of %v", UserEventTypes))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &domerrors.ValidationError{Err: domerrors.ErrInvalidUserEventFilter, Violations: violations}
+	}
+	return nil
+}
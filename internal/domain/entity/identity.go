@@ -0,0 +1,11 @@
+package entity
+
+// ExternalIdentity represents the identity of a user authenticated by an external identity provider
+type ExternalIdentity struct {
+	Issuer     string
+	Subject    string
+	Email      string
+	Name       string
+	GivenName  string
+	FamilyName string
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "crypto"
+
+// PublicKey represents a public key verifying the access tokens, identified by the `kid` header of the tokens
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+const (
+	// ScopeUsersRead grants the scoped principals the reads of the users
+	ScopeUsersRead = "users:read"
+	// ScopeUsersWrite grants the scoped principals the changes of the users
+	ScopeUsersWrite = "users:write"
+	// ScopeAdmin grants the scoped principals the role of admin of their owner, if any
+	ScopeAdmin = "admin"
+)
+
+// principalKey is the context key of the Principal
+type principalKey struct{}
+
+// Principal represents the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller for the token issuer
+	Subject string
+	// UserID is the ID of the user of the caller, or zero when the caller is not a user of this API
+	UserID uint
+	Roles  []string
+	Scopes []string
+	// Scoped limits the principal to what both its Roles and its Scopes allow, as for the API keys, rather than to
+	// its Roles only
+	Scoped bool
+	// ExpiresAt is the time the credentials of the caller expire, or zero if they do not
+	ExpiresAt time.Time
+}
+
+// HasRole reports whether the principal has the given role
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal has been granted the given scope
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Allows reports whether the principal is allowed what the given scope grants, which the principals not Scoped are
+func (p Principal) Allows(scope string) bool {
+	return !p.Scoped || p.HasScope(scope)
+}
+
+// IsAdmin reports whether the principal has the role of admin, and is allowed to act as such
+func (p Principal) IsAdmin() bool {
+	return p.HasRole(RoleAdmin) && p.Allows(ScopeAdmin)
+}
+
+// ContextWithPrincipal returns a copy of the given context carrying the given principal
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal carried by the given context, if any
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+// AccessToken represents a signed access token issued to a user
+type AccessToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// RefreshToken represents an opaque refresh token issued to a user.
+// Only the hash of the token is kept, and every token rotated from the same login shares the FamilyID.
+type RefreshToken struct {
+	ID        uint
+	UserID    uint
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// IsRevoked reports whether the refresh token has been revoked
+func (t RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired reports whether the refresh token is expired at the given time
+func (t RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// TokenPair represents the tokens granted to an authenticated user
+type TokenPair struct {
+	AccessToken           AccessToken
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
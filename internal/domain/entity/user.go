@@ -1,8 +1,142 @@
 package entity
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// RoleAdmin is the role of the users allowed to administer other users
+const RoleAdmin = "admin"
+
+const (
+	// MaxUserNameLength is the maximum number of characters of the name and of the surname of a user
+	MaxUserNameLength = 100
+	// MaxRoleLength is the maximum number of characters of a role
+	MaxRoleLength = 50
+)
+
 // User represents a user entity
 type User struct {
-	ID      uint   `json:"id"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
+	ID      uint     `json:"id"`
+	Name    string   `json:"name"`
+	Surname string   `json:"surname"`
+	Roles   []string `json:"roles"`
+	// Version is incremented every time the user is saved, starting at 1. It is given to the repositories as the
+	// version the user is modified or deleted from, and exposed by the API as the entity tag of the user rather than
+	// in its JSON.
+	Version uint `json:"-"`
+	// CreatedAt and UpdatedAt are the times the user was created and last saved, set by the repositories
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+	// DeletedAt is the time the user was deleted, or zero if it is not. A deleted user is kept until it is purged,
+	// and can be restored meanwhile.
+	DeletedAt time.Time `json:"-"`
+}
+
+// Deleted reports whether the user is deleted
+func (u User) Deleted() bool {
+	return !u.DeletedAt.IsZero()
+}
+
+// HasRole reports whether the user has the given role
+func (u User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// Normalized returns the user with its name and surname in Unicode NFC, trimmed and with a single space between
+// their words, and with its roles trimmed
+func (u User) Normalized() User {
+	u.Name = normalizeName(u.Name)
+	u.Surname = normalizeName(u.Surname)
+	if u.Roles != nil {
+		roles := make([]string, len(u.Roles))
+		for i, role := range u.Roles {
+			roles[i] = strings.TrimSpace(role)
+		}
+		u.Roles = roles
+	}
+	return u
+}
+
+// Validate returns an *errors.ValidationError matching errors.ErrInvalidUser and listing every field of the
+// normalized user breaking its invariants, or nil if there is none:
+// the name and surname are required, of MaxUserNameLength characters at most, and made of letters, spaces,
+// apostrophes, hyphens and periods; the roles are required, of MaxRoleLength characters at most, and made of
+// letters, digits, underscores, hyphens, periods and colons
+func (u User) Validate() error {
+	var violations []domerrors.FieldViolation
+	violate := func(field, message string) {
+		violations = append(violations, domerrors.FieldViolation{Field: field, Message: message})
+	}
+
+	for _, f := range []struct {
+		field string
+		value string
+	}{
+		{field: string(UserFieldName), value: u.Name},
+		{field: string(UserFieldSurname), value: u.Surname},
+	} {
+		switch {
+		case f.value == "":
+			violate(f.field, "is required")
+		case utf8.RuneCountInString(f.value) > MaxUserNameLength:
+			violate(f.field, fmt.Sprintf("must be at most %d characters", MaxUserNameLength))
+		case strings.IndexFunc(f.value, isNotNameRune) >= 0:
+			violate(f.field, "must only contain letters, spaces, apostrophes, hyphens and periods")
+		}
+	}
+
+	for i, role := range u.Roles {
+		field := fmt.Sprintf("roles[%d]", i)
+		switch {
+		case role == "":
+			violate(field, "is required")
+		case utf8.RuneCountInString(role) > MaxRoleLength:
+			violate(field, fmt.Sprintf("must be at most %d characters", MaxRoleLength))
+		case strings.IndexFunc(role, isNotRoleRune) >= 0:
+			violate(field, "must only contain letters, digits, underscores, hyphens, periods and colons")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &domerrors.ValidationError{Err: domerrors.ErrInvalidUser, Violations: violations}
+	}
+	return nil
+}
+
+// SanitizedName returns the given name normalized as the names of the users are, once its characters not allowed in
+// them are replaced by spaces and its characters beyond MaxUserNameLength dropped, so that it is either empty or valid
+func SanitizedName(name string) string {
+	name = normalizeName(strings.Map(func(r rune) rune {
+		if isNotNameRune(r) {
+			return ' '
+		}
+		return r
+	}, name))
+	if runes := []rune(name); len(runes) > MaxUserNameLength {
+		name = normalizeName(string(runes[:MaxUserNameLength]))
+	}
+	return name
+}
+
+// normalizeName returns the given name in Unicode NFC, trimmed and with a single space between its words
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(name)), " ")
+}
+
+// isNotNameRune reports whether the given rune is not allowed in the names and surnames
+func isNotNameRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.Is(unicode.M, r) && !strings.ContainsRune(" '’-.", r)
+}
+
+// isNotRoleRune reports whether the given rune is not allowed in the roles
+func isNotRoleRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.:", r)
 }
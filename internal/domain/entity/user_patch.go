@@ -0,0 +1,19 @@
+package entity
+
+// PatchFormat is the format of the document of a patch
+type PatchFormat string
+
+const (
+	// PatchMerge is a JSON Merge Patch document (RFC 7396), merged into the patched document
+	PatchMerge PatchFormat = "merge-patch"
+	// PatchJSON is a JSON Patch document (RFC 6902), made of the operations applied in order to the patched document
+	PatchJSON PatchFormat = "json-patch"
+)
+
+// UserPatch is a partial update of a user, given as a document of the given format applied to the JSON of the user
+type UserPatch struct {
+	Format   PatchFormat
+	Document []byte
+	// Version is the version of the user the patch applies to, or 0 to apply it to the current one
+	Version uint
+}
@@ -0,0 +1,145 @@
+package entity
+
+import (
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultUserPageLimit is the number of users of a page when no limit is given
+	DefaultUserPageLimit = 20
+	// MaxUserPageLimit is the maximum number of users of a page
+	MaxUserPageLimit = 100
+)
+
+// UserField is a field of the users which can be sorted or filtered by
+type UserField string
+
+const (
+	UserFieldID      UserField = "id"
+	UserFieldName    UserField = "name"
+	UserFieldSurname UserField = "surname"
+)
+
+// SortDirection is the direction of a sort
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// MatchMode is how a filter matches the value of a field
+type MatchMode string
+
+const (
+	MatchExact    MatchMode = "exact"
+	MatchPrefix   MatchMode = "prefix"
+	MatchContains MatchMode = "contains"
+)
+
+// UserFilter keeps the users whose field matches the value in the given mode. Matching is case-sensitive.
+type UserFilter struct {
+	Field UserField
+	Mode  MatchMode
+	Value string
+}
+
+// UserQuery selects a page of the users matching every filter, sorted by the given field and then by ID.
+// The page starts at the Offset, or right after the user of the Cursor, which keeps the pages stable while users
+// are created or deleted.
+type UserQuery struct {
+	Limit     int
+	Offset    int
+	SortBy    UserField
+	Direction SortDirection
+	Filters   []UserFilter
+	// Cursor is the opaque token of the cursor after which the page starts
+	Cursor string
+	// After is the decoded Cursor given to the repositories
+	After *UserCursor
+	// IncludeDeleted selects the deleted users too
+	IncludeDeleted bool
+}
+
+// UserCursor is the position of a user in the users sorted by the given field and then by ID
+type UserCursor struct {
+	SortBy    UserField
+	Direction SortDirection
+	// Value is the value of the sort field of the user, which is empty when sorting by ID
+	Value string
+	ID    uint
+}
+
+// UserPage represents a page of users along with the total number of users matching the query.
+// The total is not counted for the pages after a cursor.
+type UserPage struct {
+	Users []User
+	Total int64
+	// Next is the cursor of the last user of the page when more users follow
+	Next *UserCursor
+	// NextCursor is the opaque token of the Next cursor
+	NextCursor string
+}
+
+// Normalized returns a copy of the query with the defaults applied and the limit capped to MaxUserPageLimit
+func (q UserQuery) Normalized() UserQuery {
+	if q.Limit <= 0 {
+		q.Limit = DefaultUserPageLimit
+	}
+	if q.Limit > MaxUserPageLimit {
+		q.Limit = MaxUserPageLimit
+	}
+	if q.SortBy == "" {
+		q.SortBy = UserFieldID
+	}
+	if q.Direction == "" {
+		q.Direction = SortAsc
+	}
+	return q
+}
+
+// Validate returns an error wrapping errors.ErrInvalidUserQuery if the query is not valid
+func (q UserQuery) Validate() error {
+	if q.Limit < 0 || q.Offset < 0 {
+		return errors.Wrap(domerrors.ErrInvalidUserQuery, "limit and offset cannot be negative")
+	}
+	if q.Offset > 0 && q.Cursor != "" {
+		return errors.Wrap(domerrors.ErrInvalidUserQuery, "offset and cursor cannot be combined")
+	}
+	switch q.SortBy {
+	case "", UserFieldID, UserFieldName, UserFieldSurname:
+	default:
+		return errors.Wrapf(domerrors.ErrInvalidUserQuery, "cannot sort by %q", q.SortBy)
+	}
+	switch q.Direction {
+	case "", SortAsc, SortDesc:
+	default:
+		return errors.Wrapf(domerrors.ErrInvalidUserQuery, "unknown sort direction %q", q.Direction)
+	}
+	for _, f := range q.Filters {
+		switch f.Field {
+		case UserFieldName, UserFieldSurname:
+		default:
+			return errors.Wrapf(domerrors.ErrInvalidUserQuery, "cannot filter by %q", f.Field)
+		}
+		switch f.Mode {
+		case MatchExact, MatchPrefix, MatchContains:
+		default:
+			return errors.Wrapf(domerrors.ErrInvalidUserQuery, "unknown match mode %q", f.Mode)
+		}
+	}
+	return nil
+}
+
+// CursorAt returns the cursor of the given user in the users sorted as the query
+func (q UserQuery) CursorAt(user User) UserCursor {
+	c := UserCursor{SortBy: q.SortBy, Direction: q.Direction, ID: user.ID}
+	switch q.SortBy {
+	case UserFieldName:
+		c.Value = user.Name
+	case UserFieldSurname:
+		c.Value = user.Surname
+	}
+	return c
+}
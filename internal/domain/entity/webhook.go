@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+)
+
+// MinWebhookSecretLength is the minimum number of characters of the secrets of the webhooks
+const MinWebhookSecretLength = 16
+
+// Webhook represents a subscription of a partner to the events of the users, which are delivered to its URL by HTTP
+// callbacks signed with its Secret. It is subscribed to every event when it has no EventTypes.
+type Webhook struct {
+	ID         uint
+	URL        string
+	Secret     string
+	EventTypes []UserEventType
+	CreatedAt  time.Time
+}
+
+// Subscribes reports whether the webhook is subscribed to the events of the given type
+func (w Webhook) Subscribes(eventType UserEventType) bool {
+	return len(w.EventTypes) == 0 || slices.Contains(w.EventTypes, eventType)
+}
+
+// Validate returns an *errors.ValidationError matching errors.ErrInvalidWebhook and listing every broken invariant,
+// or nil if the webhook is valid
+func (w Webhook) Validate() error {
+	var violations []domerrors.FieldViolation
+	violate := func(field, message string) {
+		violations = append(violations, domerrors.FieldViolation{Field: field, Message: message})
+	}
+
+	if w.URL == "" {
+		violate("url", "is required")
+	} else if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		violate("url", "must be an absolute http or https URL")
+	}
+
+	if utf8.RuneCountInString(w.Secret) < MinWebhookSecretLength {
+		violate("secret", fmt.Sprintf("must be at least %d characters", MinWebhookSecretLength))
+	}
+
+	for i, eventType := range w.EventTypes {
+		if !slices.Contains(UserEventTypes, eventType) {
+			violate(fmt.Sprintf("event_types[%d]", i), fmt.Sprintf("must be one of %v", UserEventTypes))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &domerrors.ValidationError{Err: domerrors.ErrInvalidWebhook, Violations: violations}
+	}
+	return nil
+}
+
+// WebhookDeliveryStatus is the state of the delivery of an event to a webhook
+type WebhookDeliveryStatus string
+
+// states of the deliveries of the webhooks
+const (
+	// WebhookDeliveryPending is the state of a delivery waiting for its first attempt or for a retry
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered is the state of a delivery accepted by the webhook
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryDead is the state of a delivery given up after its last attempt failed, kept as a dead letter
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery represents the delivery of an event of a user to a webhook, along with the outcome of its last
+// attempt
+type WebhookDelivery struct {
+	ID        uint
+	WebhookID uint
+	Event     UserEvent
+	Status    WebhookDeliveryStatus
+	Attempts  int
+	// NextAttemptAt is the time of the next attempt of a pending delivery
+	NextAttemptAt time.Time
+	// LastStatusCode is the HTTP status answered to the last attempt, if any, and LastError its failure
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -1,6 +1,10 @@
 package errors
 
-import "github.com/pkg/errors"
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 // User errors
 
@@ -9,3 +13,107 @@ var ErrUserNotFound = errors.New("user not found")
 
 // ErrUserAlreadyExists is an error returned when a user already exists.
 var ErrUserAlreadyExists = errors.New("user already exists")
+
+// ErrUserNotDeleted is an error returned when restoring a user which is not deleted.
+var ErrUserNotDeleted = errors.New("user not deleted")
+
+// ErrInvalidUser is an error matched by the validation errors of the users, returned when a user breaks its invariants.
+var ErrInvalidUser = errors.New("invalid user")
+
+// ErrInvalidUserQuery is an error returned when the given query of users is not valid.
+var ErrInvalidUserQuery = errors.New("invalid user query")
+
+// ErrInvalidCursor is an error returned when the given pagination cursor is malformed or not signed by this API.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidPatch is an error returned when the given patch is malformed or of an unsupported format.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ErrUnprocessablePatch is an error returned when the given patch cannot be applied to the user, or would leave it
+// in a state which cannot be saved.
+var ErrUnprocessablePatch = errors.New("unprocessable patch")
+
+// ErrPatchConflict is an error returned when a test operation of the given patch does not match the current user.
+var ErrPatchConflict = errors.New("patch test failed")
+
+// ErrConcurrentModification is an error returned when the user was modified or deleted since the version the caller
+// read.
+var ErrConcurrentModification = errors.New("concurrent modification")
+
+// ErrInvalidUserEventFilter is an error matched by the validation errors of the filters of the events of the users,
+// returned when subscribing to the events with an invalid filter.
+var ErrInvalidUserEventFilter = errors.New("invalid user event filter")
+
+// Auth errors
+
+// ErrInvalidCredentials is an error returned when the given username or password are not valid.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidRefreshToken is an error returned when the given refresh token is unknown or expired.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrRefreshTokenReused is an error returned when an already rotated refresh token is used again.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// ErrRefreshTokenNotFound is an error returned when a refresh token is not found.
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+// ErrRefreshTokenRevoked is an error returned when revoking a refresh token that is already revoked.
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
+// ErrUnauthorized is an error returned when the caller is not authenticated.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden is an error returned when the caller is not allowed to perform an operation.
+var ErrForbidden = errors.New("forbidden")
+
+// API key errors
+
+// ErrAPIKeyNotFound is an error returned when an API key is not found.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// ErrInvalidAPIKey is an error returned when the given API key is unknown, revoked or expired.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ErrInvalidAPIKeyExpiry is an error returned when creating an API key which is already expired.
+var ErrInvalidAPIKeyExpiry = errors.New("api key expiry is not in the future")
+
+// Webhook errors
+
+// ErrWebhookNotFound is an error returned when a webhook is not found.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrInvalidWebhook is an error matched by the validation errors of the webhooks, returned when a webhook breaks its
+// invariants.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Validation errors
+
+// FieldViolation is an invariant broken by a field.
+type FieldViolation struct {
+	// Field is the path of the field, such as "name" or "roles[0]"
+	Field string
+	// Message tells which invariant the field breaks
+	Message string
+}
+
+// ValidationError is an error returned when some fields break their invariants. It lists every broken invariant
+// rather than the first one, and matches the sentinel error of the validated entity, such as ErrInvalidUser.
+type ValidationError struct {
+	Err        error
+	Violations []FieldViolation
+}
+
+// Error returns the message of the sentinel error followed by the broken invariants
+func (e *ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.Field+" "+v.Message)
+	}
+	return e.Err.Error() + ": " + strings.Join(violations, ", ")
+}
+
+// Unwrap returns the sentinel error of the validated entity
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
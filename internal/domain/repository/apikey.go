@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// APIKey defines the port for the store of the API keys
+type APIKey interface {
+	// FindAll returns every API key, including the revoked and expired ones
+	FindAll(ctx context.Context) ([]entity.APIKey, error)
+	// FindByPrefix returns the API key with the given prefix or errors.ErrAPIKeyNotFound
+	FindByPrefix(ctx context.Context, prefix string) (entity.APIKey, error)
+	// Create stores a new API key
+	Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	// Revoke revokes the API key with the given ID or returns errors.ErrAPIKeyNotFound
+	Revoke(ctx context.Context, id uint) error
+	// Touch records the last time the API key with the given ID was used
+	Touch(ctx context.Context, id uint, usedAt time.Time) error
+}
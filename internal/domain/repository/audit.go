@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// UserAudit defines the port for the store of the audit trail of the users
+type UserAudit interface {
+	// Append records the given change of a user and returns it with its ID and the time it occurred at
+	Append(ctx context.Context, entry entity.UserAuditEntry) (entity.UserAuditEntry, error)
+	// FindByUserID returns the changes of the user of the given ID in the order they occurred, including the ones
+	// of a purged user
+	FindByUserID(ctx context.Context, userID uint) ([]entity.UserAuditEntry, error)
+}
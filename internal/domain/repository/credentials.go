@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// UserCredentials defines the port for the store of the user login credentials
+type UserCredentials interface {
+	// FindCredentialsByUsername returns the credentials of the given username or errors.ErrUserNotFound
+	FindCredentialsByUsername(ctx context.Context, username string) (entity.Credentials, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// UserIdentity defines the port for the store of the links between the users and their external identities
+type UserIdentity interface {
+	// FindByIdentity returns the user linked to the given issuer and subject or errors.ErrUserNotFound
+	FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error)
+	// CreateWithIdentity creates a user linked to the given external identity.
+	// It returns errors.ErrUserAlreadyExists when the identity is already linked to a user.
+	CreateWithIdentity(ctx context.Context, user entity.User, identity entity.ExternalIdentity) (entity.User, error)
+}
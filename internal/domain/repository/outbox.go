@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// UserOutbox defines the port for the outbox of the events of the users, which are appended in the transaction of
+// the changes raising them and are pending until they are published
+type UserOutbox interface {
+	// Append appends the given event and returns it with its ID and the time it occurred at
+	Append(ctx context.Context, event entity.UserEvent) (entity.UserEvent, error)
+	// FindPending returns at most limit pending events in the order they were appended. Within a transaction, the
+	// returned events are locked until it ends, and left out of the pending events found by the other transactions.
+	FindPending(ctx context.Context, limit int) ([]entity.UserEvent, error)
+	// MarkPublished marks the events of the given IDs as published, so that they are no longer pending
+	MarkPublished(ctx context.Context, ids ...uint) error
+}
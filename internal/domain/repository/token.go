@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// RefreshToken defines the port for the store of the refresh tokens
+type RefreshToken interface {
+	// Create stores a new refresh token
+	Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error)
+	// FindByHash returns the refresh token with the given hash or errors.ErrRefreshTokenNotFound
+	FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error)
+	// Revoke revokes the given refresh token or returns errors.ErrRefreshTokenRevoked if it was already revoked
+	Revoke(ctx context.Context, token entity.RefreshToken) error
+	// RevokeFamily revokes every refresh token of the given family
+	RevokeFamily(ctx context.Context, familyID string) error
+}
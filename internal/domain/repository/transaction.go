@@ -0,0 +1,15 @@
+package repository
+
+import "context"
+
+// Transactor defines the port for running units of work atomically
+type Transactor interface {
+	// WithinTransaction runs the given function in a transaction, which is committed if the function returns nil
+	// and rolled back otherwise. The repositories called with the context given to the function take part in the
+	// transaction, and a transaction started within another one is nested in it.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// AfterCommit calls the given function once the transaction of the given context is committed, along with the
+	// transactions it is nested in, and never if one of them is rolled back. The function is called at once outside
+	// of any transaction.
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
+}
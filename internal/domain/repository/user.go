@@ -7,9 +7,37 @@ import (
 )
 
 type User interface {
-	FindAll(ctx context.Context) ([]entity.User, error)
+	// FindAll returns the page of the users selected by the given normalized query, starting after its After cursor
+	// when given, along with the cursor of its last user when more users follow
+	FindAll(ctx context.Context, query entity.UserQuery) (entity.UserPage, error)
+	// FindByID returns the user of the given ID, or errors.ErrUserNotFound if there is none or it is deleted
 	FindByID(ctx context.Context, id uint) (entity.User, error)
+	// FindDeletedByID returns the deleted user of the given ID, or errors.ErrUserNotFound if there is none
+	FindDeletedByID(ctx context.Context, id uint) (entity.User, error)
+	// Create creates the given user at version 1 with its ID, or with the next one if not given, setting its creation
+	// and modification times, and returns errors.ErrUserAlreadyExists if another user, even a deleted one, has its ID
 	Create(ctx context.Context, user entity.User) (entity.User, error)
+	// Modify replaces the user of the ID of the given one, increments its version and sets its modification time, or
+	// returns errors.ErrUserNotFound if there is none, or errors.ErrConcurrentModification if its version is not the
+	// given one. The creation time of the given user is returned as is, since it is never modified.
 	Modify(ctx context.Context, user entity.User) (entity.User, error)
+	// Delete deletes the user of the ID of the given one, keeping it until it is purged, and increments its version,
+	// or returns errors.ErrUserNotFound if there is none or it is already deleted, or
+	// errors.ErrConcurrentModification if its version is not the given one
 	Delete(ctx context.Context, user entity.User) error
+	// Restore restores the deleted user of the ID of the given one and increments its version, or returns
+	// errors.ErrUserNotFound if there is no deleted user of that ID, or errors.ErrConcurrentModification if its
+	// version is not the given one
+	Restore(ctx context.Context, user entity.User) (entity.User, error)
+	// Purge permanently deletes the user of the ID of the given one, deleted or not, along with its credentials and
+	// identities, or returns errors.ErrUserNotFound if there is none, or errors.ErrConcurrentModification if its
+	// version is not the given one
+	Purge(ctx context.Context, user entity.User) error
+}
+
+// UserSearch defines the port for the full-text search of the users, which is kept by the same adapter as the users
+type UserSearch interface {
+	// Search returns at most limit users whose name or surname match every term of the given query, ignoring accents
+	// and case, sorted by relevance and then by ID
+	Search(ctx context.Context, query string, limit int) ([]entity.User, error)
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// Webhook defines the port for the store of the webhooks
+type Webhook interface {
+	// FindAll returns every webhook in the order they were created
+	FindAll(ctx context.Context) ([]entity.Webhook, error)
+	// FindByID returns the webhook of the given ID, or errors.ErrWebhookNotFound if there is none
+	FindByID(ctx context.Context, id uint) (entity.Webhook, error)
+	// Create stores a new webhook and returns it with its ID and creation time
+	Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error)
+	// Delete deletes the webhook of the given ID, or returns errors.ErrWebhookNotFound if there is none
+	Delete(ctx context.Context, id uint) error
+}
+
+// WebhookDelivery defines the port for the store of the deliveries of the events to the webhooks
+type WebhookDelivery interface {
+	// Create stores a new delivery and returns it with its ID and creation and modification times
+	Create(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error)
+	// FindDue returns at most limit pending deliveries whose next attempt is due at the given time, in the order they
+	// are due. Within a transaction, the returned deliveries are locked until it ends, and left out of the due
+	// deliveries found by the other transactions.
+	FindDue(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error)
+	// Update replaces the status, the attempts and the outcome of the last attempt of the given delivery, and sets its
+	// modification time
+	Update(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error)
+	// FindByWebhookID returns at most limit deliveries of the webhook of the given ID, the latest first
+	FindByWebhookID(ctx context.Context, webhookID uint, limit int) ([]entity.WebhookDelivery, error)
+	// DeleteByWebhookID deletes the deliveries of the webhook of the given ID
+	DeleteByWebhookID(ctx context.Context, webhookID uint) error
+}
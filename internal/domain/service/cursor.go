@@ -0,0 +1,11 @@
+package service
+
+import "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+
+// UserCursorCodec defines the port for encoding the cursors of the pages of users into opaque signed tokens
+type UserCursorCodec interface {
+	// Encode returns the opaque token of the given cursor
+	Encode(cursor entity.UserCursor) (string, error)
+	// Decode returns the cursor of the given token or errors.ErrInvalidCursor if it is not valid
+	Decode(token string) (entity.UserCursor, error)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// UserEventPublisher defines the port for publishing the events of the users to the other services
+type UserEventPublisher interface {
+	// Publish publishes the given event, which is delivered at least once if it returns nil
+	Publish(ctx context.Context, event entity.UserEvent) error
+}
+
+// UserEventSubscriber defines the port for subscribing to the events of the users as they are published
+type UserEventSubscriber interface {
+	// Subscribe returns a channel receiving the events published after the one of the given ID, replaying the ones
+	// still buffered, and then the events published from now on, along with the function cancelling the subscription.
+	// No event is replayed for a zero ID. The channel is closed when the subscription is cancelled, or when the
+	// subscriber falls behind, in which case it can subscribe again from the last event it received.
+	Subscribe(lastEventID uint) (<-chan entity.UserEvent, func())
+}
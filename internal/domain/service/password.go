@@ -0,0 +1,9 @@
+package service
+
+// PasswordHasher defines the port for hashing and verifying user passwords
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the given password
+	Hash(password string) (string, error)
+	// Verify reports whether the given password matches the encoded hash
+	Verify(hash, password string) (bool, error)
+}
@@ -0,0 +1,11 @@
+package service
+
+import "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+
+// UserPatchApplier defines the port for applying the patches of the users
+type UserPatchApplier interface {
+	// Apply returns the given user with the given patch applied, errors.ErrInvalidPatch if the patch is malformed,
+	// errors.ErrUnprocessablePatch if it cannot be applied to the user, or errors.ErrPatchConflict if one of its
+	// tests does not match the user
+	Apply(user entity.User, patch entity.UserPatch) (entity.User, error)
+}
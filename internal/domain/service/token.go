@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// TokenIssuer defines the port for issuing the access tokens of the authenticated users
+type TokenIssuer interface {
+	// Issue returns a new signed access token for the given user
+	Issue(ctx context.Context, user entity.User) (entity.AccessToken, error)
+}
+
+// PublicKeySet defines the port for publishing the public keys verifying the access tokens
+type PublicKeySet interface {
+	// PublicKeys returns the public keys currently accepted to verify the access tokens
+	PublicKeys(ctx context.Context) ([]entity.PublicKey, error)
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// WebhookSender defines the port for sending the events of the users to the webhooks
+type WebhookSender interface {
+	// Send sends the event of the given delivery to the given webhook, signed with its secret, and returns the HTTP
+	// status answered, if any, along with an error unless the webhook accepted the event
+	Send(ctx context.Context, webhook entity.Webhook, delivery entity.WebhookDelivery) (int, error)
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// APIKeyFinderAll defines the use case for finding all API keys
+type APIKeyFinderAll interface {
+	// Find returns all API keys or an error if something goes wrong
+	Find(ctx context.Context) ([]entity.APIKey, error)
+}
+
+// APIKeyCreator defines the use case for creating an API key
+type APIKeyCreator interface {
+	// Create creates an API key and returns it along with its plain value, or an error if something goes wrong
+	Create(ctx context.Context, key entity.APIKey) (entity.IssuedAPIKey, error)
+}
+
+// APIKeyRevoker defines the use case for revoking an API key
+type APIKeyRevoker interface {
+	// Revoke revokes the API key with the given ID or returns errors.ErrAPIKeyNotFound
+	Revoke(ctx context.Context, id uint) error
+}
+
+// APIKeyAuthenticator defines the use case for authenticating a machine client by its API key
+type APIKeyAuthenticator interface {
+	// Authenticate returns the principal of the given API key or errors.ErrInvalidAPIKey if it is not valid
+	Authenticate(ctx context.Context, key string) (entity.Principal, error)
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// UserAuthenticator defines the use case for authenticating a user by its credentials
+type UserAuthenticator interface {
+	// Authenticate returns the user owning the given credentials or errors.ErrInvalidCredentials if they do not match
+	Authenticate(ctx context.Context, username, password string) (entity.User, error)
+}
+
+// TokenGranter defines the use case for granting the tokens of an authenticated user
+type TokenGranter interface {
+	// Grant returns a new access token and a refresh token of a new token family
+	Grant(ctx context.Context, user entity.User) (entity.TokenPair, error)
+}
+
+// TokenRefresher defines the use case for rotating a refresh token
+type TokenRefresher interface {
+	// Refresh revokes the given refresh token and returns a new access token and refresh token of the same family.
+	// Reusing an already rotated refresh token revokes its whole family and returns errors.ErrRefreshTokenReused.
+	Refresh(ctx context.Context, refreshToken string) (entity.TokenPair, error)
+}
+
+// TokenRevoker defines the use case for revoking a refresh token
+type TokenRevoker interface {
+	// Revoke revokes the whole family of the given refresh token
+	Revoke(ctx context.Context, refreshToken string) error
+}
+
+// UserProvisioner defines the use case for mapping an external identity to a local user
+type UserProvisioner interface {
+	// Provision returns the user linked to the given identity, creating it the first time the identity is seen
+	Provision(ctx context.Context, identity entity.ExternalIdentity) (entity.User, error)
+}
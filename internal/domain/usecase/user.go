@@ -8,8 +8,17 @@ import (
 
 // UserFinderAll defines the use case for finding all users
 type UserFinderAll interface {
-	// Find returns all users or an error if something goes wrong
-	Find(ctx context.Context) ([]entity.User, error)
+	// Find returns the page of the users selected by the given query, errors.ErrInvalidUserQuery if it is not valid,
+	// errors.ErrInvalidCursor if its cursor is not valid, errors.ErrForbidden if it includes the deleted users and the
+	// caller is not an admin, or an error if something goes wrong
+	Find(ctx context.Context, query entity.UserQuery) (entity.UserPage, error)
+}
+
+// UserSearcher defines the use case for searching users by their name or surname
+type UserSearcher interface {
+	// Search returns at most limit users matching the given query sorted by relevance, errors.ErrInvalidUserQuery
+	// if the query is empty, or an error if something goes wrong
+	Search(ctx context.Context, query string, limit int) ([]entity.User, error)
 }
 
 // UserFinderByID defines the use case for finding a user by ID
@@ -20,18 +29,51 @@ type UserFinderByID interface {
 
 // UserCreator defines the use case for creating a user
 type UserCreator interface {
-	// Create creates a user and returns the created user or an error if something goes wrong
+	// Create creates a user and returns the created user, an *errors.ValidationError if it is not valid,
+	// or an error if something goes wrong
 	Create(ctx context.Context, user entity.User) (entity.User, error)
 }
 
 // UserModifier defines the use case for modifying a user
 type UserModifier interface {
-	// Modify modifies a user and returns the modified user or an error if something goes wrong
+	// Modify modifies a user and returns the modified user, an *errors.ValidationError if it is not valid,
+	// or an error if something goes wrong
 	Modify(ctx context.Context, user entity.User) (entity.User, error)
 }
 
+// UserPatcher defines the use case for partially modifying a user
+type UserPatcher interface {
+	// Patch applies the given patch to the user of the given ID and returns the patched user,
+	// an *errors.ValidationError if the patched user is not valid, or an error if something goes wrong
+	Patch(ctx context.Context, id uint, patch entity.UserPatch) (entity.User, error)
+}
+
 // UserDeleter defines the use case for deleting a user
 type UserDeleter interface {
-	// Delete deletes a user and returns an error if something goes wrong
+	// Delete deletes a user, which can be restored until it is purged, and returns an error if something goes wrong
 	Delete(ctx context.Context, user entity.User) error
+	// Purge permanently deletes the user of the given ID, deleted or not, at the given version or at its current one
+	// if 0, and returns an error if something goes wrong
+	Purge(ctx context.Context, id uint, version uint) error
+}
+
+// UserRestorer defines the use case for restoring a deleted user
+type UserRestorer interface {
+	// Restore restores the deleted user of the given ID at the given version or at its current one if 0, and returns
+	// the restored user, errors.ErrUserNotDeleted if the user is not deleted, or an error if something goes wrong
+	Restore(ctx context.Context, id uint, version uint) (entity.User, error)
+}
+
+// UserHistoryFinder defines the use case for finding the audit trail of a user
+type UserHistoryFinder interface {
+	// Find returns the changes of the user of the given ID in the order they occurred, errors.ErrUserNotFound if
+	// there is no such user nor any change of it, or an error if something goes wrong
+	Find(ctx context.Context, id uint) ([]entity.UserAuditEntry, error)
+}
+
+// UserEventRelayer defines the use case for relaying the events of the users from their outbox to the other services
+type UserEventRelayer interface {
+	// Relay publishes a batch of the pending events in the order they were raised and returns the number of them
+	// published, or an error if something goes wrong
+	Relay(ctx context.Context) (int, error)
 }
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// WebhookFinderAll defines the use case for finding all webhooks
+type WebhookFinderAll interface {
+	// Find returns all webhooks or an error if something goes wrong
+	Find(ctx context.Context) ([]entity.Webhook, error)
+}
+
+// WebhookCreator defines the use case for creating a webhook
+type WebhookCreator interface {
+	// Create creates a webhook and returns it along with its secret, an *errors.ValidationError if it is not valid, or
+	// an error if something goes wrong
+	Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error)
+}
+
+// WebhookDeleter defines the use case for deleting a webhook
+type WebhookDeleter interface {
+	// Delete deletes the webhook of the given ID along with its deliveries, or returns errors.ErrWebhookNotFound
+	Delete(ctx context.Context, id uint) error
+}
+
+// WebhookDeliveryFinder defines the use case for finding the delivery log of a webhook
+type WebhookDeliveryFinder interface {
+	// Find returns the latest deliveries of the webhook of the given ID, errors.ErrWebhookNotFound if there is no such
+	// webhook, or an error if something goes wrong
+	Find(ctx context.Context, webhookID uint) ([]entity.WebhookDelivery, error)
+}
+
+// WebhookScheduler defines the use case for scheduling the delivery of the events of the users to the webhooks
+type WebhookScheduler interface {
+	// Schedule schedules the delivery of the given event to every webhook subscribed to it, or returns an error if
+	// something goes wrong
+	Schedule(ctx context.Context, event entity.UserEvent) error
+}
+
+// WebhookDeliverer defines the use case for delivering the events of the users to the webhooks
+type WebhookDeliverer interface {
+	// Deliver attempts a batch of the due deliveries and returns the number of them attempted, or an error if
+	// something goes wrong
+	Deliver(ctx context.Context) (int, error)
+}
@@ -19,7 +19,7 @@ func Start() error {
 		return errors.Wrapf(err, "cannot load config")
 	}
 
-	server, err = di.InitializeAPI(cfg.DB)
+	server, err = di.InitializeAPI(cfg)
 	if err != nil {
 		return errors.Wrapf(err, "cannot initialize server")
 	} else {
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/db"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
+)
+
+// migrateUsage is the usage of the migrate command
+const migrateUsage = "usage: migrate up | down | status | to <version>"
+
+// Migrate migrates the schema of the configured database as the given arguments of the migrate command say, and
+// writes its status to the given output:
+//   - up applies all the pending migrations
+//   - down reverts the latest migration applied
+//   - status only writes the status of the migrations
+//   - to <version> applies or reverts the migrations up to the given version, 0 reverting them all
+func Migrate(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return errors.Wrapf(err, "cannot load config")
+	}
+	if cfg.DB.Type == config.InMemoryDB {
+		return errors.Errorf("the %s database has no schema to migrate", config.InMemoryDB)
+	}
+
+	conn, err := db.OpenDatabase(cfg.DB)
+	if err != nil {
+		return errors.Wrapf(err, "cannot connect to the database")
+	}
+	if sqlDB, err := conn.DB(); err == nil {
+		defer func() { _ = sqlDB.Close() }()
+	}
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) == 1:
+		err = migrator.Down(ctx)
+	case args[0] == "to" && len(args) == 2:
+		version, parseErr := strconv.ParseUint(args[1], 10, 32)
+		if parseErr != nil {
+			return errors.Errorf("invalid version %q: %s", args[1], migrateUsage)
+		}
+		err = migrator.To(ctx, uint(version))
+	case args[0] == "status" && len(args) == 1:
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return writeMigrationStatus(out, statuses)
+}
+
+// writeMigrationStatus writes the given status of the migrations as a table
+func writeMigrationStatus(out io.Writer, statuses []db.MigrationStatus) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
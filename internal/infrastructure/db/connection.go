@@ -1,28 +1,73 @@
 package db
 
 import (
-	"fmt"
+	"context"
+	"database/sql"
 
-	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
-	"gorm.io/driver/postgres"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
+// ConnectDatabase connects to the database of the given configuration, and migrates its schema as configured
 func ConnectDatabase(cfg config.DB) (*gorm.DB, error) {
-	psqlInfo := fmt.Sprintf("host=%s user=%s port=%s password=%s sslmode=disable", cfg.Host, cfg.User, cfg.Port, cfg.Password)
-	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{
+	db, err := OpenDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migrateSchema(context.Background(), db, cfg.Migrations); err != nil {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			_ = sqlDB.Close()
+		}
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenDatabase connects to the database of the driver registered for the configured type through a pool of
+// connections, leaving its schema as it is
+func OpenDatabase(cfg config.DB) (*gorm.DB, error) {
+	driver, ok := lookupDriver(cfg.Type)
+	if !ok {
+		return nil, errors.Errorf("unsupported database type %q", cfg.Type)
+	}
+
+	dialector, err := driver.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		SkipDefaultTransaction: true,
+		TranslateError:         true,
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&repository.UserDBEntity{})
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
+	configurePool(sqlDB, cfg.Pool)
 
 	return db, nil
 }
+
+// configurePool applies the given configuration to the pool of connections, keeping the defaults of its zero values
+func configurePool(sqlDB *sql.DB, pool config.DBPool) {
+	if pool.MaxOpenConns != 0 {
+		sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns != 0 {
+		sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime != 0 {
+		sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime != 0 {
+		sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
@@ -0,0 +1,482 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// migrationsFS holds the migrations of the schema, in a directory by dialect
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+const (
+	// migrationsDir is the directory of the migrations of the schema
+	migrationsDir = "migrations"
+	// migrationLockName is the name of the advisory lock serializing the migrations in MySQL
+	migrationLockName = "schema_migrations"
+	// migrationLockID is the key of the advisory lock serializing the migrations in Postgres
+	migrationLockID = 7246810117
+)
+
+// migrationFile matches the names of the files of the migrations, such as 0001_create_schema.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationGuard is the prefix of the comment guarding the statement it precedes by a query, the statement being
+// skipped when the query counts any row, as the databases which cannot add a column unless it exists need
+const migrationGuard = "-- migrate:unless "
+
+// createMigrationsTable creates the table of the versions of the schema applied to the database
+const createMigrationsTable = "CREATE TABLE IF NOT EXISTS schema_migrations (" +
+	"version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)"
+
+// Migration is a version of the schema of the database, applied by its Up statements and reverted by its Down ones
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is the status of a version of the schema of the database, applied at AppliedAt, or pending if nil
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+}
+
+// migrationLock serializes the migrations of the replicas sharing a database, on the connection migrating it
+type migrationLock struct {
+	lock   func(ctx context.Context, conn *sql.Conn) error
+	unlock func(ctx context.Context, conn *sql.Conn) error
+}
+
+// migrationLocks are the advisory locks of the migrations by dialect. SQLite has none, but its transactions take the
+// lock of the whole database when they begin, and every migration checks whether it is still pending once in its
+// transaction.
+var migrationLocks = map[string]migrationLock{
+	"postgres": {
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID)
+			return err
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+			return err
+		},
+	},
+	"mysql": {
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			var locked sql.NullInt64
+			if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", migrationLockName).Scan(&locked); err != nil {
+				return err
+			}
+			if locked.Int64 != 1 {
+				return errors.Errorf("lock %q not granted", migrationLockName)
+			}
+			return nil
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+			return err
+		},
+	},
+}
+
+// statementTimeoutResets are the statements lifting the statement timeout of a connection by dialect, so that the
+// configured one, meant for the queries of the application, never aborts a migration or the wait for its lock
+var statementTimeoutResets = map[string]string{
+	"postgres": "SET statement_timeout = 0",
+	"mysql":    "SET SESSION max_execution_time = 0",
+}
+
+// Migrator migrates the schema of a database through the versioned migrations of its dialect, embedded in the binary.
+// The migrations are serialized by an advisory lock, so that the replicas starting together apply them once, and each
+// of them is applied in a transaction along with its version. MySQL commits the changes of the schema as soon as they
+// are made, so a migration failing there must be fixed by hand before it is applied again.
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewMigrator creates a new instance of Migrator of the given database
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	dialect := db.Dialector.Name()
+	migrations, err := loadMigrations(migrationsFS, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, dialect: dialect, migrations: migrations}, nil
+}
+
+// loadMigrations returns the migrations of the given dialect found in the given file system, sorted by version
+func loadMigrations(fsys fs.FS, dialect string) ([]Migration, error) {
+	dir := path.Join(migrationsDir, dialect)
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Errorf("no migrations for the %q database", dialect)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, errors.Errorf("invalid name of migration file %q", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, errors.Errorf("invalid version of migration file %q", entry.Name())
+		}
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		} else if migration.Name != match[2] {
+			return nil, errors.Errorf("migrations %q and %q share version %d", migration.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, errors.Errorf("migration %d %s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the latest version of the schema known by the migrator, or 0 if there is none
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all the pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the latest migration applied
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return errors.New("no migration to revert")
+		}
+		return m.revert(ctx, conn, applied[len(applied)-1].Version)
+	})
+}
+
+// To applies the pending migrations up to the given version, and reverts the applied ones after it. The version 0
+// reverts them all.
+func (m *Migrator) To(ctx context.Context, version uint) error {
+	if _, ok := m.find(version); !ok && version != 0 {
+		return errors.Errorf("unknown version %d of the schema", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		isApplied := map[uint]bool{}
+		for i := len(applied) - 1; i >= 0; i-- {
+			isApplied[applied[i].Version] = true
+			if applied[i].Version > version {
+				if err = m.revert(ctx, conn, applied[i].Version); err != nil {
+					return err
+				}
+			}
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version && !isApplied[migration.Version] {
+				if err = m.migrate(ctx, conn, migration, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status returns the status of the versions of the schema, known by the migrator or applied to the database, sorted
+// by version
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		byVersion := map[uint]MigrationStatus{}
+		for _, status := range applied {
+			byVersion[status.Version] = status
+		}
+		for _, migration := range m.migrations {
+			if _, ok := byVersion[migration.Version]; !ok {
+				byVersion[migration.Version] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+			}
+		}
+		statuses = make([]MigrationStatus, 0, len(byVersion))
+		for _, status := range byVersion {
+			statuses = append(statuses, status)
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+		return nil
+	})
+	return statuses, err
+}
+
+// Check returns an error if migrations of the schema known by the migrator are pending
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, strconv.FormatUint(uint64(status.Version), 10)+" "+status.Name)
+		}
+	}
+	if len(pending) > 0 {
+		return errors.Errorf("the schema of the database is behind, with the pending migrations %s: run the migrate up command",
+			strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// find returns the migration of the given version, if known
+func (m *Migrator) find(version uint) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock calls the given function on a connection holding the lock of the migrations, once the table of the
+// versions of the schema exists
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if reset, ok := statementTimeoutResets[m.dialect]; ok {
+		if _, err = conn.ExecContext(ctx, reset); err != nil {
+			return errors.Wrap(err, "cannot lift the statement timeout of the migrations")
+		}
+		// the connection is discarded rather than returned to the pool without its statement timeout
+		defer func() { _ = conn.Raw(func(any) error { return driver.ErrBadConn }) }()
+	}
+
+	if lock, ok := migrationLocks[m.dialect]; ok {
+		if err = lock.lock(ctx, conn); err != nil {
+			return errors.Wrap(err, "cannot lock the migrations")
+		}
+		defer func() {
+			if unlockErr := lock.unlock(context.Background(), conn); unlockErr != nil {
+				// the connection is discarded rather than returned to the pool, holding the lock until it is closed
+				_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+				if err == nil {
+					err = errors.Wrap(unlockErr, "cannot unlock the migrations")
+				}
+			}
+		}()
+	}
+
+	if _, err = conn.ExecContext(ctx, createMigrationsTable); err != nil {
+		return errors.Wrap(err, "cannot create the table of the migrations")
+	}
+	return fn(conn)
+}
+
+// applied returns the versions of the schema applied to the database, sorted by version
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var applied []MigrationStatus
+	for rows.Next() {
+		var status MigrationStatus
+		var appliedAt time.Time
+		if err = rows.Scan(&status.Version, &status.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		status.AppliedAt = &appliedAt
+		applied = append(applied, status)
+	}
+	return applied, rows.Err()
+}
+
+// revert reverts the migration of the given version
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, version uint) error {
+	migration, ok := m.find(version)
+	if !ok {
+		return errors.Errorf("version %d of the schema is unknown, so it must be reverted by the release which applied it", version)
+	}
+	return m.migrate(ctx, conn, migration, false)
+}
+
+// migrate applies the given migration, or reverts it if not up, in a transaction along with its version, unless
+// another migrator did it meanwhile
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	script, record, args, done := migration.Down, "DELETE FROM schema_migrations WHERE version = ?", []any{migration.Version}, "Reverted"
+	if up {
+		script, record, done = migration.Up, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", "Applied"
+		args = append(args, migration.Name, time.Now().UTC())
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int
+	err = tx.QueryRowContext(ctx, m.bind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), migration.Version).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if (count > 0) == up {
+		return nil
+	}
+
+	for _, statement := range splitStatements(script) {
+		if guard, ok := guardOf(statement); ok {
+			var count int
+			if err = tx.QueryRowContext(ctx, guard).Scan(&count); err != nil {
+				return errors.Wrapf(err, "migration %d %s failed", migration.Version, migration.Name)
+			}
+			if count > 0 {
+				continue
+			}
+		}
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return errors.Wrapf(err, "migration %d %s failed", migration.Version, migration.Name)
+		}
+	}
+	if _, err = tx.ExecContext(ctx, m.bind(record), args...); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Infof("%s the migration %d %s of the schema", done, migration.Version, migration.Name)
+	return nil
+}
+
+// bind returns the given query with the placeholders of the dialect of the migrator, ? being replaced by $1, $2...
+// in Postgres
+func (m *Migrator) bind(query string) string {
+	if m.dialect != "postgres" {
+		return query
+	}
+	var bound strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			bound.WriteString("$" + strconv.Itoa(n))
+		} else {
+			bound.WriteRune(r)
+		}
+	}
+	return bound.String()
+}
+
+// splitStatements splits the given script into its statements, which end with a semicolon at the end of a line,
+// leaving out the ones made of comments only
+func splitStatements(script string) []string {
+	var statements []string
+	var statement strings.Builder
+	hasCode := false
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		statement.WriteString(line + "\n")
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		hasCode = true
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(statement.String()))
+			statement.Reset()
+			hasCode = false
+		}
+	}
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(statement.String()))
+	}
+	return statements
+}
+
+// guardOf returns the query guarding the given statement, if any
+func guardOf(statement string) (string, bool) {
+	for _, line := range strings.Split(statement, "\n") {
+		if guard, ok := strings.CutPrefix(strings.TrimSpace(line), migrationGuard); ok {
+			return guard, true
+		}
+	}
+	return "", false
+}
+
+// migrateSchema migrates the schema of the given database as the given mode of the configuration says
+func migrateSchema(ctx context.Context, db *gorm.DB, mode string) error {
+	if mode == config.MigrationsOff {
+		return nil
+	}
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case "", config.MigrationsAuto:
+		return migrator.Up(ctx)
+	case config.MigrationsCheck:
+		return migrator.Check(ctx)
+	}
+	return errors.Errorf("unsupported migrations mode %q", mode)
+}
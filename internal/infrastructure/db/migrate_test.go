@@ -0,0 +1,369 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// baselineUserDBEntity is the user entity of the first releases, whose schema was automatically migrated
+type baselineUserDBEntity struct {
+	ID      uint `gorm:"unique;not null"`
+	Name    string
+	Surname string
+
+	gorm.Model
+}
+
+// TableName overrides the table name used by baselineUserDBEntity to `users`
+func (baselineUserDBEntity) TableName() string {
+	return "users"
+}
+
+// openSQLiteDB opens a new SQLite database without schema
+func openSQLiteDB(t *testing.T) *gorm.DB {
+	db, err := OpenDatabase(config.DB{Type: config.SQLiteDB, Name: filepath.Join(t.TempDir(), "hexagonal.db")})
+	require.NoError(t, err)
+	return db
+}
+
+// versions returns the versions of the given statuses, and whether they are applied
+func versions(statuses []MigrationStatus) map[uint]bool {
+	applied := map[uint]bool{}
+	for _, status := range statuses {
+		applied[status.Version] = status.AppliedAt != nil
+	}
+	return applied
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("should load the same versions for every dialect", func(t *testing.T) {
+		for _, dialect := range []string{"postgres", "mysql", "sqlite"} {
+			migrations, err := loadMigrations(migrationsFS, dialect)
+			require.NoError(t, err, dialect)
+			if assert.Len(t, migrations, 2, dialect) {
+				assert.Equal(t, Migration{Version: 1, Name: "create_schema"},
+					Migration{Version: migrations[0].Version, Name: migrations[0].Name}, dialect)
+				assert.Equal(t, uint(2), migrations[1].Version, dialect)
+			}
+		}
+	})
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "should fail without the migrations of the dialect",
+			fsys:    fstest.MapFS{},
+			wantErr: `no migrations for the "sqlite" database`,
+		},
+		{
+			name: "should fail with an invalid name of file",
+			fsys: fstest.MapFS{
+				"migrations/sqlite/create_schema.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: `invalid name of migration file "create_schema.up.sql"`,
+		},
+		{
+			name: "should fail without the down file",
+			fsys: fstest.MapFS{
+				"migrations/sqlite/0001_create_schema.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "migration 1 create_schema needs both an up and a down file",
+		},
+		{
+			name: "should fail with two migrations of the same version",
+			fsys: fstest.MapFS{
+				"migrations/sqlite/0001_create_schema.up.sql": {Data: []byte("SELECT 1;")},
+				"migrations/sqlite/0001_create_users.up.sql":  {Data: []byte("SELECT 1;")},
+			},
+			wantErr: `migrations "create_schema" and "create_users" share version 1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.fsys, "sqlite")
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- The users.
+CREATE TABLE users (
+    id integer -- the key; of the user
+);
+
+-- unaccent is not immutable;
+CREATE FUNCTION f(text) RETURNS text
+    AS $$ SELECT lower($1) $$;
+SELECT 1`
+
+	assert.Equal(t, []string{
+		"-- The users.\nCREATE TABLE users (\n    id integer -- the key; of the user\n);",
+		"-- unaccent is not immutable;\nCREATE FUNCTION f(text) RETURNS text\n    AS $$ SELECT lower($1) $$;",
+		"SELECT 1",
+	}, splitStatements(script))
+	assert.Empty(t, splitStatements("-- The users are searched without index.\n"))
+}
+
+func TestGuardOf(t *testing.T) {
+	guard, ok := guardOf("-- the roles of the users\n" +
+		"-- migrate:unless SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'roles'\n" +
+		"ALTER TABLE `users` ADD COLUMN `roles` text;")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'roles'", guard)
+
+	_, ok = guardOf("-- the roles of the users\nALTER TABLE `users` ADD COLUMN `roles` text;")
+	assert.False(t, ok)
+}
+
+func TestMigrator(t *testing.T) {
+	tests := []struct {
+		name string
+		when func(ctx context.Context, db *gorm.DB, m *Migrator) error
+		then func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error)
+	}{
+		{
+			name: "should apply all the pending migrations",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				return m.Up(ctx)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[uint]bool{1: true, 2: true}, versions(statuses))
+				assert.True(t, db.Migrator().HasTable("users"))
+			},
+		},
+		{
+			name: "should revert the latest migration applied",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				if err := m.Up(ctx); err != nil {
+					return err
+				}
+				return m.Down(ctx)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[uint]bool{1: true, 2: false}, versions(statuses))
+			},
+		},
+		{
+			name: "should fail to revert without migrations applied",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				return m.Down(ctx)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.EqualError(t, err, "no migration to revert")
+				assert.Equal(t, map[uint]bool{1: false, 2: false}, versions(statuses))
+			},
+		},
+		{
+			name: "should migrate up and down to a version",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				if err := m.To(ctx, 1); err != nil {
+					return err
+				}
+				if err := m.To(ctx, 2); err != nil {
+					return err
+				}
+				return m.To(ctx, 0)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[uint]bool{1: false, 2: false}, versions(statuses))
+				assert.False(t, db.Migrator().HasTable("users"))
+			},
+		},
+		{
+			name: "should fail to migrate to an unknown version",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				return m.To(ctx, 99)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.EqualError(t, err, "unknown version 99 of the schema")
+			},
+		},
+		{
+			name: "should refuse a schema behind",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				if err := m.To(ctx, 1); err != nil {
+					return err
+				}
+				return m.Check(ctx)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.EqualError(t, err, "the schema of the database is behind, with the pending migrations "+
+					"2 create_user_search: run the migrate up command")
+			},
+		},
+		{
+			name: "should adopt a schema migrated before the versioned migrations",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				if err := db.AutoMigrate(&repository.UserDBEntity{}, &repository.UserOutboxDBEntity{}); err != nil {
+					return err
+				}
+				return m.Up(ctx)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[uint]bool{1: true, 2: true}, versions(statuses))
+			},
+		},
+		{
+			name: "should add the missing columns to the users of the first releases",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				if err := db.AutoMigrate(&baselineUserDBEntity{}); err != nil {
+					return err
+				}
+				if err := db.Create(&baselineUserDBEntity{Name: "John", Surname: "Doe"}).Error; err != nil {
+					return err
+				}
+				return m.Up(ctx)
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, map[uint]bool{1: true, 2: true}, versions(statuses))
+				assert.True(t, db.Migrator().HasColumn(&repository.UserDBEntity{}, "roles"))
+				assert.True(t, db.Migrator().HasColumn(&repository.UserDBEntity{}, "version"))
+				user, err := repository.NewUserDB(db).FindByID(context.Background(), 1)
+				require.NoError(t, err)
+				assert.Equal(t, "John", user.Name)
+				assert.Equal(t, uint(1), user.Version)
+			},
+		},
+		{
+			name: "should apply the migrations once when migrated concurrently",
+			when: func(ctx context.Context, db *gorm.DB, m *Migrator) error {
+				var wg sync.WaitGroup
+				errs := make([]error, 4)
+				for i := range errs {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						errs[i] = m.Up(ctx)
+					}()
+				}
+				wg.Wait()
+				for _, err := range errs {
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			then: func(t *testing.T, db *gorm.DB, statuses []MigrationStatus, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, statuses, 2)
+				assert.Equal(t, map[uint]bool{1: true, 2: true}, versions(statuses))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			ctx := context.Background()
+			db := openSQLiteDB(t)
+			m, err := NewMigrator(db)
+			require.NoError(t, err)
+
+			// When
+			err = tt.when(ctx, db, m)
+
+			// Then
+			statuses, statusErr := m.Status(ctx)
+			require.NoError(t, statusErr)
+			tt.then(t, db, statuses, err)
+		})
+	}
+}
+
+func TestMigrationLocks(t *testing.T) {
+	tests := []struct {
+		dialect string
+		lock    string
+		unlock  string
+	}{
+		{dialect: "postgres", lock: "SELECT pg_advisory_lock($1)", unlock: "SELECT pg_advisory_unlock($1)"},
+		{dialect: "mysql", lock: "SELECT GET_LOCK(?, -1)", unlock: "SELECT RELEASE_LOCK(?)"},
+	}
+	for _, tt := range tests {
+		t.Run("should lock the migrations of "+tt.dialect, func(t *testing.T) {
+			// Given
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			if tt.dialect == "mysql" {
+				mock.ExpectQuery(regexp.QuoteMeta(tt.lock)).WithArgs(migrationLockName).
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(tt.unlock)).WithArgs(migrationLockName).WillReturnResult(sqlmock.NewResult(0, 0))
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta(tt.lock)).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(tt.unlock)).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			conn, err := sqlDB.Conn(context.Background())
+			require.NoError(t, err)
+
+			// When
+			lock := migrationLocks[tt.dialect]
+			lockErr := lock.lock(context.Background(), conn)
+			unlockErr := lock.unlock(context.Background(), conn)
+
+			// Then
+			assert.NoError(t, lockErr)
+			assert.NoError(t, unlockErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_WithLock(t *testing.T) {
+	tests := []struct {
+		dialect string
+		reset   string
+		lock    string
+		unlock  string
+	}{
+		{dialect: "postgres", reset: "SET statement_timeout = 0", lock: "SELECT pg_advisory_lock($1)",
+			unlock: "SELECT pg_advisory_unlock($1)"},
+		{dialect: "mysql", reset: "SET SESSION max_execution_time = 0", lock: "SELECT GET_LOCK(?, -1)",
+			unlock: "SELECT RELEASE_LOCK(?)"},
+	}
+	for _, tt := range tests {
+		t.Run("should lift the statement timeout of the migrations of "+tt.dialect+" before locking them", func(t *testing.T) {
+			// Given
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			mock.ExpectExec(regexp.QuoteMeta(tt.reset)).WillReturnResult(sqlmock.NewResult(0, 0))
+			if tt.dialect == "mysql" {
+				mock.ExpectQuery(regexp.QuoteMeta(tt.lock)).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta(tt.lock)).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			mock.ExpectExec(regexp.QuoteMeta(createMigrationsTable)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(tt.unlock)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectClose()
+
+			// When
+			err = (&Migrator{db: sqlDB, dialect: tt.dialect}).withLock(context.Background(), func(*sql.Conn) error {
+				return nil
+			})
+
+			// Then
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet(), "the connection without timeout is discarded")
+		})
+	}
+}
@@ -0,0 +1,141 @@
+-- The schema of the users, their credentials, identities, tokens and API keys, their audit, the outbox of their
+-- events and the webhooks. The tables are created unless they exist, so that the schemas migrated before the
+-- versioned migrations are adopted, and the columns of the users missing from the schema of the first releases are
+-- added.
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` bigint unsigned AUTO_INCREMENT NOT NULL,
+    `name` varchar(191),
+    `surname` varchar(191),
+    `roles` longtext,
+    `version` bigint unsigned NOT NULL DEFAULT 1,
+    `created_at` datetime(6) NULL,
+    `updated_at` datetime(6) NULL,
+    `deleted_at` datetime(6) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_deleted_at` (`deleted_at`),
+    INDEX `idx_users_name_id` (`name`,`id`),
+    INDEX `idx_users_surname_id` (`surname`,`id`),
+    CONSTRAINT `uni_users_id` UNIQUE (`id`)
+);
+-- migrate:unless SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'roles'
+ALTER TABLE `users` ADD COLUMN `roles` longtext;
+-- migrate:unless SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'version'
+ALTER TABLE `users` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
+-- the names were long texts, which cannot be indexed
+-- migrate:unless SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'name' AND data_type = 'varchar'
+ALTER TABLE `users` MODIFY `name` varchar(191), MODIFY `surname` varchar(191);
+-- migrate:unless SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'users' AND index_name = 'idx_users_name_id'
+CREATE INDEX `idx_users_name_id` ON `users` (`name`,`id`);
+-- migrate:unless SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'users' AND index_name = 'idx_users_surname_id'
+CREATE INDEX `idx_users_surname_id` ON `users` (`surname`,`id`);
+
+CREATE TABLE IF NOT EXISTS `user_credentials` (
+    `user_id` bigint unsigned NOT NULL,
+    `username` varchar(255) NOT NULL,
+    `password_hash` longtext NOT NULL,
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(6) NULL,
+    `updated_at` datetime(6) NULL,
+    `deleted_at` datetime(6) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_credentials_user_id` (`user_id`),
+    UNIQUE INDEX `idx_user_credentials_username` (`username`),
+    INDEX `idx_user_credentials_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_identities` (
+    `user_id` bigint unsigned NOT NULL,
+    `issuer` varchar(255) NOT NULL,
+    `subject` varchar(255) NOT NULL,
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(6) NULL,
+    `updated_at` datetime(6) NULL,
+    `deleted_at` datetime(6) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_identities_deleted_at` (`deleted_at`),
+    INDEX `idx_user_identities_user_id` (`user_id`),
+    UNIQUE INDEX `idx_user_identities_issuer_subject` (`issuer`,`subject`)
+);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `family_id` varchar(191) NOT NULL,
+    `token_hash` varchar(255) NOT NULL,
+    `expires_at` datetime(6) NOT NULL,
+    `created_at` datetime(6) NULL,
+    `revoked_at` datetime(6) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_refresh_tokens_user_id` (`user_id`),
+    INDEX `idx_refresh_tokens_family_id` (`family_id`),
+    UNIQUE INDEX `idx_refresh_tokens_token_hash` (`token_hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` longtext NOT NULL,
+    `prefix` varchar(255) NOT NULL,
+    `key_hash` longtext NOT NULL,
+    `owner_id` bigint unsigned NOT NULL,
+    `scopes` longtext,
+    `expires_at` datetime(6) NULL,
+    `last_used_at` datetime(6) NULL,
+    `created_at` datetime(6) NULL,
+    `revoked_at` datetime(6) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_api_keys_prefix` (`prefix`),
+    INDEX `idx_api_keys_owner_id` (`owner_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_audit` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `action` longtext NOT NULL,
+    `actor` longtext NOT NULL,
+    `actor_id` bigint unsigned NOT NULL,
+    `before` longtext,
+    `after` longtext,
+    `request_id` longtext NOT NULL,
+    `occurred_at` datetime(6) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_audit_user_id` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_outbox` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `type` longtext NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `user` longtext NOT NULL,
+    `occurred_at` datetime(6) NOT NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `webhooks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `url` longtext NOT NULL,
+    `secret` longtext NOT NULL,
+    `event_types` longtext,
+    `created_at` datetime(6) NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `webhook_id` bigint unsigned NOT NULL,
+    `event_id` bigint unsigned NOT NULL,
+    `event_type` longtext NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `user` longtext NOT NULL,
+    `occurred_at` datetime(6) NOT NULL,
+    `status` varchar(191) NOT NULL,
+    `attempts` bigint NOT NULL,
+    `next_attempt_at` datetime(6) NOT NULL,
+    `last_status_code` bigint NOT NULL,
+    `last_error` longtext NOT NULL,
+    `created_at` datetime(6) NULL,
+    `updated_at` datetime(6) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`),
+    INDEX `idx_webhook_deliveries_due` (`status`,`next_attempt_at`)
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhooks";
+DROP TABLE IF EXISTS "user_outbox";
+DROP TABLE IF EXISTS "user_audit";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "refresh_tokens";
+DROP TABLE IF EXISTS "user_identities";
+DROP TABLE IF EXISTS "user_credentials";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,132 @@
+-- The schema of the users, their credentials, identities, tokens and API keys, their audit, the outbox of their
+-- events and the webhooks. The tables are created unless they exist, so that the schemas migrated before the
+-- versioned migrations are adopted, and the columns of the users missing from the schema of the first releases are
+-- added.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial NOT NULL,
+    "name" text,
+    "surname" text,
+    "roles" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_id" UNIQUE ("id")
+);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "roles" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS "idx_users_name_id" ON "users" ("name","id");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_surname_id" ON "users" ("surname","id");
+
+CREATE TABLE IF NOT EXISTS "user_credentials" (
+    "user_id" bigint NOT NULL,
+    "username" varchar(255) NOT NULL,
+    "password_hash" text NOT NULL,
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_credentials_deleted_at" ON "user_credentials" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_credentials_username" ON "user_credentials" ("username");
+CREATE INDEX IF NOT EXISTS "idx_user_credentials_user_id" ON "user_credentials" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_identities" (
+    "user_id" bigint NOT NULL,
+    "issuer" varchar(255) NOT NULL,
+    "subject" varchar(255) NOT NULL,
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_identities_deleted_at" ON "user_identities" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_identities_issuer_subject" ON "user_identities" ("issuer","subject");
+CREATE INDEX IF NOT EXISTS "idx_user_identities_user_id" ON "user_identities" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "family_id" text NOT NULL,
+    "token_hash" varchar(255) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "prefix" varchar(255) NOT NULL,
+    "key_hash" text NOT NULL,
+    "owner_id" bigint NOT NULL,
+    "scopes" text,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_owner_id" ON "api_keys" ("owner_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_prefix" ON "api_keys" ("prefix");
+
+CREATE TABLE IF NOT EXISTS "user_audit" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "action" text NOT NULL,
+    "actor" text NOT NULL,
+    "actor_id" bigint NOT NULL,
+    "before" text,
+    "after" text,
+    "request_id" text NOT NULL,
+    "occurred_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_audit_user_id" ON "user_audit" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_outbox" (
+    "id" bigserial,
+    "type" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "user" text NOT NULL,
+    "occurred_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhooks" (
+    "id" bigserial,
+    "url" text NOT NULL,
+    "secret" text NOT NULL,
+    "event_types" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" bigserial,
+    "webhook_id" bigint NOT NULL,
+    "event_id" bigint NOT NULL,
+    "event_type" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "user" text NOT NULL,
+    "occurred_at" timestamptz NOT NULL,
+    "status" text NOT NULL,
+    "attempts" bigint NOT NULL,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_status_code" bigint NOT NULL,
+    "last_error" text NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
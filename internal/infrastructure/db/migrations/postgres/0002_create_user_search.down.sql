@@ -0,0 +1,4 @@
+-- The extensions are left, as other schemas may use them.
+DROP INDEX IF EXISTS idx_users_search_trgm;
+DROP INDEX IF EXISTS idx_users_search_tsv;
+DROP FUNCTION IF EXISTS users_search_text(text);
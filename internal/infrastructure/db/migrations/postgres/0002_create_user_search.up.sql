@@ -0,0 +1,12 @@
+-- The search of the users, lowercase and without accents, through a full-text index matching the prefixes of the
+-- words and a trigram index matching any part of the words, whose expression is the userSearchDocument of the
+-- repository.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent is not immutable, as its dictionary could change, so it cannot be indexed unless wrapped
+CREATE OR REPLACE FUNCTION users_search_text(text) RETURNS text LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT lower(public.unaccent('public.unaccent', $1)) $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_search_tsv ON users USING gin (to_tsvector('simple', users_search_text(name || ' ' || surname)));
+CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users USING gin (users_search_text(name || ' ' || surname) gin_trgm_ops);
@@ -0,0 +1,125 @@
+-- The schema of the users, their credentials, identities, tokens and API keys, their audit, the outbox of their
+-- events and the webhooks. The tables are created unless they exist, so that the schemas migrated before the
+-- versioned migrations are adopted, and the columns of the users missing from the schema of the first releases are
+-- added.
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    `name` text,
+    `surname` text,
+    `roles` text,
+    `version` integer NOT NULL DEFAULT 1,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `uni_users_id` UNIQUE (`id`)
+);
+-- migrate:unless SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'roles'
+ALTER TABLE `users` ADD COLUMN `roles` text;
+-- migrate:unless SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'version'
+ALTER TABLE `users` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_users_surname_id` ON `users`(`surname`,`id`);
+CREATE INDEX IF NOT EXISTS `idx_users_name_id` ON `users`(`name`,`id`);
+
+CREATE TABLE IF NOT EXISTS `user_credentials` (
+    `user_id` integer NOT NULL,
+    `username` text NOT NULL,
+    `password_hash` text NOT NULL,
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_user_credentials_username` ON `user_credentials`(`username`);
+CREATE INDEX IF NOT EXISTS `idx_user_credentials_user_id` ON `user_credentials`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_user_credentials_deleted_at` ON `user_credentials`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `user_identities` (
+    `user_id` integer NOT NULL,
+    `issuer` text NOT NULL,
+    `subject` text NOT NULL,
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_user_identities_issuer_subject` ON `user_identities`(`issuer`,`subject`);
+CREATE INDEX IF NOT EXISTS `idx_user_identities_user_id` ON `user_identities`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_user_identities_deleted_at` ON `user_identities`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `family_id` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `expires_at` datetime NOT NULL,
+    `created_at` datetime,
+    `revoked_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_refresh_tokens_token_hash` ON `refresh_tokens`(`token_hash`);
+
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `prefix` text NOT NULL,
+    `key_hash` text NOT NULL,
+    `owner_id` integer NOT NULL,
+    `scopes` text,
+    `expires_at` datetime,
+    `last_used_at` datetime,
+    `created_at` datetime,
+    `revoked_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_api_keys_owner_id` ON `api_keys`(`owner_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_api_keys_prefix` ON `api_keys`(`prefix`);
+
+CREATE TABLE IF NOT EXISTS `user_audit` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `action` text NOT NULL,
+    `actor` text NOT NULL,
+    `actor_id` integer NOT NULL,
+    `before` text,
+    `after` text,
+    `request_id` text NOT NULL,
+    `occurred_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_user_audit_user_id` ON `user_audit`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `user_outbox` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `type` text NOT NULL,
+    `user_id` integer NOT NULL,
+    `user` text NOT NULL,
+    `occurred_at` datetime NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `webhooks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `url` text NOT NULL,
+    `secret` text NOT NULL,
+    `event_types` text,
+    `created_at` datetime
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `webhook_id` integer NOT NULL,
+    `event_id` integer NOT NULL,
+    `event_type` text NOT NULL,
+    `user_id` integer NOT NULL,
+    `user` text NOT NULL,
+    `occurred_at` datetime NOT NULL,
+    `status` text NOT NULL,
+    `attempts` integer NOT NULL,
+    `next_attempt_at` datetime NOT NULL,
+    `last_status_code` integer NOT NULL,
+    `last_error` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_due` ON `webhook_deliveries`(`status`,`next_attempt_at`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
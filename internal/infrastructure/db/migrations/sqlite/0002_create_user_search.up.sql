@@ -0,0 +1 @@
+-- The users are searched without index in this database, so that its versions follow the ones of Postgres.
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
+)
+
+// newTLSConfig returns the TLS configuration of the connections to the database of the given configuration, following
+// the SSL modes of Postgres: the allow, prefer and require modes encrypt the connections without verifying the
+// server, the verify-ca mode verifies that its certificate is signed by the root CA, and the verify-full mode verifies
+// its host name as well. The root CA is given by its certificate file, or else by the system, and the client presents
+// its certificate, if any.
+func newTLSConfig(cfg config.DB) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.SSLCert != "" || cfg.SSLKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading the client certificate of the database")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.SSLRootCert != "" {
+		pem, err := os.ReadFile(cfg.SSLRootCert)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading the root certificate of the database")
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificate found in the root certificate file %q of the database", cfg.SSLRootCert)
+		}
+	}
+
+	switch cfg.SSLMode {
+	case "allow", "prefer", "require":
+		tlsConfig.InsecureSkipVerify = true
+	case "verify-ca":
+		// the certificate is verified without its host name, which needs the default verification to be skipped
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = verifyCA(tlsConfig.RootCAs)
+	case "verify-full":
+	default:
+		return nil, errors.Errorf("unsupported ssl mode %q", cfg.SSLMode)
+	}
+	return tlsConfig, nil
+}
+
+// verifyCA returns the verification of the connections whose certificate is signed by the given root CAs, or by the
+// ones of the system if nil, whatever their host name
+func verifyCA(roots *x509.CertPool) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("the database presented no certificate")
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range cs.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCertificate returns a certificate of the given host signed by the given parent, or self-signed if nil
+func newCertificate(t *testing.T, host string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// writePEM writes the given certificate to a PEM file, and returns its path
+func writePEM(t *testing.T, cert *x509.Certificate) string {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600))
+	return path
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	ca, caKey := newCertificate(t, "ca", nil, nil)
+	rootCert := writePEM(t, ca)
+	signed, _ := newCertificate(t, "db.internal", ca, caKey)
+	selfSigned, _ := newCertificate(t, "db.internal", nil, nil)
+
+	tests := []struct {
+		name string
+		cfg  config.DB
+		then func(t *testing.T, tlsConfig *tls.Config, err error)
+	}{
+		{
+			name: "should encrypt without verifying the server",
+			cfg:  config.DB{SSLMode: "require"},
+			then: func(t *testing.T, tlsConfig *tls.Config, err error) {
+				require.NoError(t, err)
+				assert.True(t, tlsConfig.InsecureSkipVerify)
+				assert.Nil(t, tlsConfig.VerifyConnection)
+			},
+		},
+		{
+			name: "should verify the certificate of the server whatever its host name",
+			cfg:  config.DB{SSLMode: "verify-ca", SSLRootCert: rootCert},
+			then: func(t *testing.T, tlsConfig *tls.Config, err error) {
+				require.NoError(t, err)
+				assert.NoError(t, tlsConfig.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{signed}}))
+				assert.Error(t, tlsConfig.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{selfSigned}}))
+			},
+		},
+		{
+			name: "should verify the certificate of the server and its host name",
+			cfg:  config.DB{SSLMode: "verify-full", SSLRootCert: rootCert},
+			then: func(t *testing.T, tlsConfig *tls.Config, err error) {
+				require.NoError(t, err)
+				assert.False(t, tlsConfig.InsecureSkipVerify)
+				_, err = signed.Verify(x509.VerifyOptions{Roots: tlsConfig.RootCAs, DNSName: "db.internal"})
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should fail without the root certificate file",
+			cfg:  config.DB{SSLMode: "verify-full", SSLRootCert: filepath.Join(t.TempDir(), "missing.pem")},
+			then: func(t *testing.T, tlsConfig *tls.Config, err error) {
+				assert.ErrorContains(t, err, "error loading the root certificate of the database")
+				assert.Nil(t, tlsConfig)
+			},
+		},
+		{
+			name: "should fail without the client key",
+			cfg:  config.DB{SSLMode: "require", SSLCert: rootCert},
+			then: func(t *testing.T, tlsConfig *tls.Config, err error) {
+				assert.ErrorContains(t, err, "error loading the client certificate of the database")
+				assert.Nil(t, tlsConfig)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := newTLSConfig(tt.cfg)
+			tt.then(t, tlsConfig, err)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package event
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+)
+
+// DefaultSubscriberBufferSize is the number of events buffered for a subscriber of a Broker before it is considered
+// to have fallen behind
+const DefaultSubscriberBufferSize = 64
+
+// Broker publishes the events of the users to its in-process subscribers without waiting for them, and keeps the
+// latest ones in a bounded replay buffer so the subscribers can resume from the last event they received.
+// A Broker is not shared between the instances, so its subscribers only receive the events published by its own.
+// A subscriber whose buffer is full has fallen behind and is unsubscribed, rather than holding back the publication.
+// The events are replayed in the order they were published, which is not always the order of their IDs.
+type Broker struct {
+	mu          sync.Mutex
+	replaySize  int
+	bufferSize  int
+	replay      []entity.UserEvent
+	subscribers map[chan entity.UserEvent]struct{}
+	closed      bool
+}
+
+var _ service.UserEventSubscriber = (*Broker)(nil)
+
+// NewBroker creates a new Broker replaying at most the given number of events, and buffering the given number of
+// events for every subscriber
+func NewBroker(replaySize, bufferSize int) *Broker {
+	return &Broker{
+		replaySize:  replaySize,
+		bufferSize:  bufferSize,
+		replay:      make([]entity.UserEvent, 0, replaySize),
+		subscribers: make(map[chan entity.UserEvent]struct{}),
+	}
+}
+
+// Publish sends the given event to every subscriber and keeps it for replay. An event already kept is ignored.
+func (b *Broker) Publish(_ context.Context, event entity.UserEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.ContainsFunc(b.replay, func(e entity.UserEvent) bool { return e.ID == event.ID }) {
+		return nil
+	}
+	if b.replaySize > 0 {
+		if len(b.replay) == b.replaySize {
+			b.replay = slices.Delete(b.replay, 0, 1)
+		}
+		b.replay = append(b.replay, event)
+	}
+
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			b.unsubscribe(events)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel receiving the events published after the one of the given ID, and then the ones
+// published from now on, along with the function cancelling the subscription. Every event kept for replay is replayed
+// when the given one is no longer kept, and none for a zero ID.
+// The channel is closed at once when the Broker is closed.
+func (b *Broker) Subscribe(lastEventID uint) (<-chan entity.UserEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []entity.UserEvent
+	if lastEventID != 0 {
+		i := slices.IndexFunc(b.replay, func(e entity.UserEvent) bool { return e.ID == lastEventID })
+		replay = b.replay[i+1:]
+	}
+
+	events := make(chan entity.UserEvent, len(replay)+b.bufferSize)
+	for _, event := range replay {
+		events <- event
+	}
+	if b.closed {
+		close(events)
+		return events, func() {}
+	}
+	b.subscribers[events] = struct{}{}
+
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.unsubscribe(events)
+	}
+}
+
+// Close unsubscribes every subscriber and the ones subscribing from now on, which ends their streams
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for events := range b.subscribers {
+		b.unsubscribe(events)
+	}
+}
+
+// unsubscribe closes the channel of the given subscriber, if still subscribed. It must be called with the lock held.
+func (b *Broker) unsubscribe(events chan entity.UserEvent) {
+	if _, ok := b.subscribers[events]; ok {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
@@ -0,0 +1,20 @@
+package event
+
+import (
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserEventSubscriber is a mock implementation of service.UserEventSubscriber by using testify mock.Mock
+type MockUserEventSubscriber struct {
+	mock.Mock
+}
+
+func NewMockUserEventSubscriber() *MockUserEventSubscriber {
+	return &MockUserEventSubscriber{}
+}
+
+func (m *MockUserEventSubscriber) Subscribe(lastEventID uint) (<-chan entity.UserEvent, func()) {
+	args := m.Called(lastEventID)
+	return args.Get(0).(<-chan entity.UserEvent), args.Get(1).(func())
+}
@@ -0,0 +1,165 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive returns the events received from the given channel until it is empty, and whether it is closed
+func receive(events <-chan entity.UserEvent) ([]uint, bool) {
+	var ids []uint
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return ids, true
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids, false
+		}
+	}
+}
+
+func TestBroker(t *testing.T) {
+	publish := func(t *testing.T, b *Broker, ids ...uint) {
+		for _, id := range ids {
+			require.NoError(t, b.Publish(context.Background(), entity.UserEvent{ID: id, Type: entity.UserCreated}))
+		}
+	}
+
+	tests := []struct {
+		name string
+		test func(*testing.T, *Broker)
+	}{
+		{
+			name: "should send the events published to every subscriber",
+			test: func(t *testing.T, b *Broker) {
+				first, cancelFirst := b.Subscribe(0)
+				defer cancelFirst()
+				second, cancelSecond := b.Subscribe(0)
+				defer cancelSecond()
+
+				publish(t, b, 1, 2)
+
+				for _, events := range []<-chan entity.UserEvent{first, second} {
+					ids, closed := receive(events)
+					assert.Equal(t, []uint{1, 2}, ids)
+					assert.False(t, closed)
+				}
+			},
+		},
+		{
+			name: "should replay the events published after the last one received",
+			test: func(t *testing.T, b *Broker) {
+				publish(t, b, 1, 3, 2, 4)
+
+				events, cancel := b.Subscribe(3)
+				defer cancel()
+				publish(t, b, 5)
+
+				ids, _ := receive(events)
+				// in the order they were published
+				assert.Equal(t, []uint{2, 4, 5}, ids)
+			},
+		},
+		{
+			name: "should replay every event kept when the last one received is no longer kept",
+			test: func(t *testing.T, b *Broker) {
+				publish(t, b, 1, 2, 3, 4, 5)
+
+				events, cancel := b.Subscribe(1)
+				defer cancel()
+
+				ids, _ := receive(events)
+				assert.Equal(t, []uint{3, 4, 5}, ids)
+			},
+		},
+		{
+			name: "should not replay any event without the last one received",
+			test: func(t *testing.T, b *Broker) {
+				publish(t, b, 1, 2)
+
+				events, cancel := b.Subscribe(0)
+				defer cancel()
+
+				ids, _ := receive(events)
+				assert.Empty(t, ids)
+			},
+		},
+		{
+			name: "should ignore the events published again",
+			test: func(t *testing.T, b *Broker) {
+				events, cancel := b.Subscribe(0)
+				defer cancel()
+
+				publish(t, b, 1, 2, 1)
+
+				ids, _ := receive(events)
+				assert.Equal(t, []uint{1, 2}, ids)
+			},
+		},
+		{
+			name: "should unsubscribe the subscribers falling behind",
+			test: func(t *testing.T, b *Broker) {
+				slow, cancelSlow := b.Subscribe(0)
+				defer cancelSlow()
+
+				publish(t, b, 1, 2, 3)
+
+				ids, closed := receive(slow)
+				assert.Equal(t, []uint{1, 2}, ids)
+				assert.True(t, closed)
+
+				// the slow subscriber resumes from the last event it received
+				resumed, cancelResumed := b.Subscribe(2)
+				defer cancelResumed()
+				ids, _ = receive(resumed)
+				assert.Equal(t, []uint{3}, ids)
+			},
+		},
+		{
+			name: "should close the channel of a cancelled subscription",
+			test: func(t *testing.T, b *Broker) {
+				events, cancel := b.Subscribe(0)
+				cancel()
+				cancel()
+
+				publish(t, b, 1)
+				ids, closed := receive(events)
+				assert.Empty(t, ids)
+				assert.True(t, closed)
+			},
+		},
+		{
+			name: "should close the channels of the subscriptions when closed",
+			test: func(t *testing.T, b *Broker) {
+				publish(t, b, 1)
+				events, cancel := b.Subscribe(0)
+				defer cancel()
+
+				b.Close()
+
+				_, closed := receive(events)
+				assert.True(t, closed)
+
+				// the late subscribers only get the events replayed
+				publish(t, b, 2)
+				late, cancelLate := b.Subscribe(1)
+				defer cancelLate()
+				ids, closed := receive(late)
+				assert.Equal(t, []uint{2}, ids)
+				assert.True(t, closed)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, NewBroker(3, 2))
+		})
+	}
+}
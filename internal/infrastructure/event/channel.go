@@ -0,0 +1,71 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// ChannelPublisher publishes the events of the users to the channels of its in-process subscribers.
+// It is meant for the tests and the local mode, where the events are not relayed to other services.
+type ChannelPublisher struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+// subscriber is a subscription to the events of a ChannelPublisher
+type subscriber struct {
+	events chan entity.UserEvent
+	done   chan struct{}
+}
+
+// NewChannelPublisher creates a new ChannelPublisher without subscribers
+func NewChannelPublisher() *ChannelPublisher {
+	return &ChannelPublisher{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the events published from now on, buffered with the given size, and the
+// function cancelling the subscription. The channel is not closed when the subscription is cancelled.
+func (p *ChannelPublisher) Subscribe(size int) (<-chan entity.UserEvent, func()) {
+	s := &subscriber{
+		events: make(chan entity.UserEvent, size),
+		done:   make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers[s] = struct{}{}
+
+	var once sync.Once
+	return s.events, func() {
+		once.Do(func() {
+			// closed first to release a Publish waiting for the subscriber, which holds the read lock
+			close(s.done)
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			delete(p.subscribers, s)
+		})
+	}
+}
+
+// Publish sends the given event to every subscriber, waiting for the ones whose buffer is full, or returns the error
+// of the given context if it is done first. The events published without subscribers are dropped.
+func (p *ChannelPublisher) Publish(ctx context.Context, event entity.UserEvent) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for s := range p.subscribers {
+		select {
+		case s.events <- event:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
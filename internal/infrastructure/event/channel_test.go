@@ -0,0 +1,85 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelPublisher_Publish(t *testing.T) {
+	created := entity.UserEvent{ID: 1, Type: entity.UserCreated, UserID: 1}
+	deleted := entity.UserEvent{ID: 2, Type: entity.UserDeleted, UserID: 1}
+
+	tests := []struct {
+		name string
+		test func(*testing.T, *ChannelPublisher)
+	}{
+		{
+			name: "should send the events to every subscriber",
+			test: func(t *testing.T, p *ChannelPublisher) {
+				first, cancelFirst := p.Subscribe(2)
+				defer cancelFirst()
+				second, cancelSecond := p.Subscribe(2)
+				defer cancelSecond()
+
+				require.NoError(t, p.Publish(context.Background(), created))
+				require.NoError(t, p.Publish(context.Background(), deleted))
+
+				for _, events := range []<-chan entity.UserEvent{first, second} {
+					assert.Equal(t, created, <-events)
+					assert.Equal(t, deleted, <-events)
+				}
+			},
+		},
+		{
+			name: "should drop the events without subscribers",
+			test: func(t *testing.T, p *ChannelPublisher) {
+				assert.NoError(t, p.Publish(context.Background(), created))
+			},
+		},
+		{
+			name: "should not send the events to the cancelled subscriptions",
+			test: func(t *testing.T, p *ChannelPublisher) {
+				events, cancel := p.Subscribe(1)
+				cancel()
+				cancel()
+
+				require.NoError(t, p.Publish(context.Background(), created))
+				assert.Empty(t, events)
+			},
+		},
+		{
+			name: "should wait for a subscriber whose buffer is full until the context is done",
+			test: func(t *testing.T, p *ChannelPublisher) {
+				_, cancel := p.Subscribe(1)
+				defer cancel()
+				require.NoError(t, p.Publish(context.Background(), created))
+
+				ctx, cancelCtx := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancelCtx()
+				assert.ErrorIs(t, p.Publish(ctx, deleted), context.DeadlineExceeded)
+			},
+		},
+		{
+			name: "should release the publish waiting for a subscriber when it is cancelled",
+			test: func(t *testing.T, p *ChannelPublisher) {
+				_, cancel := p.Subscribe(0)
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					cancel()
+				}()
+
+				assert.NoError(t, p.Publish(context.Background(), created))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, NewChannelPublisher())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package event
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+)
+
+// PublisherFunc adapts a function to a service.UserEventPublisher
+type PublisherFunc func(ctx context.Context, event entity.UserEvent) error
+
+// Publish calls f(ctx, event)
+func (f PublisherFunc) Publish(ctx context.Context, event entity.UserEvent) error {
+	return f(ctx, event)
+}
+
+// FanOutPublisher publishes the events of the users to several publishers, in order
+type FanOutPublisher []service.UserEventPublisher
+
+// NewFanOutPublisher creates a new FanOutPublisher publishing to the given publishers
+func NewFanOutPublisher(publishers ...service.UserEventPublisher) FanOutPublisher {
+	return publishers
+}
+
+// Publish publishes the given event to every publisher, stopping at the first one failing. The event is published
+// again to all of them when it is relayed again, so the publishers receive it at least once.
+func (p FanOutPublisher) Publish(ctx context.Context, event entity.UserEvent) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOutPublisher_Publish(t *testing.T) {
+	ctx := context.Background()
+	event := entity.UserEvent{ID: 1, Type: entity.UserCreated, UserID: 1}
+
+	tests := []struct {
+		name  string
+		given func(first, second, third *MockUserEventPublisher)
+		then  func(error)
+	}{
+		{
+			name: "should publish the event to every publisher",
+			given: func(first, second, third *MockUserEventPublisher) {
+				first.On("Publish", ctx, event).Return(nil)
+				second.On("Publish", ctx, event).Return(nil)
+				third.On("Publish", ctx, event).Return(nil)
+			},
+			then: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "should stop at the first publisher failing",
+			given: func(first, second, third *MockUserEventPublisher) {
+				first.On("Publish", ctx, event).Return(nil)
+				second.On("Publish", ctx, event).Return(errors.New("broker down"))
+			},
+			then: func(err error) {
+				assert.EqualError(t, err, "broker down")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			first, second, third := NewMockUserEventPublisher(), NewMockUserEventPublisher(), NewMockUserEventPublisher()
+			tt.given(first, second, third)
+			var published []uint
+			recorder := PublisherFunc(func(_ context.Context, e entity.UserEvent) error {
+				published = append(published, e.ID)
+				return nil
+			})
+
+			// When
+			err := NewFanOutPublisher(recorder, first, second, third).Publish(ctx, event)
+
+			// Then
+			tt.then(err)
+			assert.Equal(t, []uint{1}, published)
+			first.AssertExpectations(t)
+			second.AssertExpectations(t)
+			third.AssertExpectations(t)
+		})
+	}
+}
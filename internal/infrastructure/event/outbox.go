@@ -0,0 +1,48 @@
+package event
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+)
+
+// NotifyingUserOutbox decorates a repository.UserOutbox, publishing the events raised by the use cases to a
+// publisher, such as the Broker streaming them to the clients, once the transaction appending them is committed.
+// The events of a rolled back transaction are never published.
+type NotifyingUserOutbox struct {
+	repository.UserOutbox
+	tx        repository.Transactor
+	publisher service.UserEventPublisher
+}
+
+// NewNotifyingUserOutbox creates a new repository.UserOutbox instance decorating the given one, and publishing the
+// events appended in the transactions of the given transactor to the given publisher once committed
+func NewNotifyingUserOutbox(
+	outbox repository.UserOutbox,
+	tx repository.Transactor,
+	publisher service.UserEventPublisher,
+) repository.UserOutbox {
+	return &NotifyingUserOutbox{
+		UserOutbox: outbox,
+		tx:         tx,
+		publisher:  publisher,
+	}
+}
+
+// Append appends the given event to the decorated outbox, and publishes it once its transaction is committed
+func (o *NotifyingUserOutbox) Append(ctx context.Context, event entity.UserEvent) (entity.UserEvent, error) {
+	appended, err := o.UserOutbox.Append(ctx, event)
+	if err != nil {
+		return entity.UserEvent{}, err
+	}
+
+	o.tx.AfterCommit(ctx, func(ctx context.Context) {
+		if err := o.publisher.Publish(ctx, appended); err != nil {
+			log.Warnf("Cannot publish user event %d: %v", appended.ID, err)
+		}
+	})
+	return appended, nil
+}
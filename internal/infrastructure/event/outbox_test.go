@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotifyingUserOutbox_Append(t *testing.T) {
+	event := entity.UserEvent{Type: entity.UserCreated, UserID: 1, User: entity.User{ID: 1, Name: "John", Surname: "Doe"}}
+	appended := entity.UserEvent{ID: 7, Type: entity.UserCreated, UserID: 1, User: event.User}
+
+	tests := []struct {
+		name  string
+		given func(*repository.MockUserOutbox, *MockUserEventPublisher)
+		then  func(*MockUserEventPublisher, entity.UserEvent, error)
+	}{
+		{
+			name: "should publish the appended event once its transaction is committed",
+			given: func(outbox *repository.MockUserOutbox, publisher *MockUserEventPublisher) {
+				outbox.On("Append", context.Background(), event).Return(appended, nil)
+				publisher.On("Publish", context.Background(), appended).Return(nil)
+			},
+			then: func(publisher *MockUserEventPublisher, got entity.UserEvent, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, appended, got)
+				publisher.AssertExpectations(t)
+			},
+		},
+		{
+			name: "should keep the appended event when it cannot be published",
+			given: func(outbox *repository.MockUserOutbox, publisher *MockUserEventPublisher) {
+				outbox.On("Append", context.Background(), event).Return(appended, nil)
+				publisher.On("Publish", context.Background(), appended).Return(errors.New("broker closed"))
+			},
+			then: func(publisher *MockUserEventPublisher, got entity.UserEvent, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, appended, got)
+				publisher.AssertExpectations(t)
+			},
+		},
+		{
+			name: "should not publish an event that cannot be appended",
+			given: func(outbox *repository.MockUserOutbox, publisher *MockUserEventPublisher) {
+				outbox.On("Append", context.Background(), event).Return(entity.UserEvent{}, errors.New("connection refused"))
+			},
+			then: func(publisher *MockUserEventPublisher, _ entity.UserEvent, err error) {
+				assert.Error(t, err)
+				publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			outbox := repository.NewMockUserOutbox()
+			publisher := NewMockUserEventPublisher()
+			tt.given(outbox, publisher)
+
+			// When
+			got, err := NewNotifyingUserOutbox(outbox, repository.NewTransactorInMemory(), publisher).
+				Append(context.Background(), event)
+
+			// Then
+			tt.then(publisher, got, err)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package event
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserEventPublisher is a mock implementation of service.UserEventPublisher by using testify mock.Mock
+type MockUserEventPublisher struct {
+	mock.Mock
+}
+
+func NewMockUserEventPublisher() *MockUserEventPublisher {
+	return &MockUserEventPublisher{}
+}
+
+func (m *MockUserEventPublisher) Publish(ctx context.Context, event entity.UserEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
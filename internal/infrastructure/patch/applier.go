@@ -0,0 +1,62 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// JSONApplier applies the JSON Merge Patch and JSON Patch documents to the JSON representation of the users
+type JSONApplier struct{}
+
+// NewJSONApplier creates a new instance of service.UserPatchApplier
+func NewJSONApplier() service.UserPatchApplier {
+	return &JSONApplier{}
+}
+
+// Apply returns the given user with the given patch applied to its JSON representation,
+// errors.ErrInvalidPatch if the patch is malformed or of an unsupported format,
+// errors.ErrUnprocessablePatch if it cannot be applied or its result is not a user,
+// or errors.ErrPatchConflict if one of its tests does not match the user
+func (a *JSONApplier) Apply(user entity.User, patch entity.UserPatch) (entity.User, error) {
+	// the missing roles are patched as an empty list, so that roles can be appended to it
+	if user.Roles == nil {
+		user.Roles = []string{}
+	}
+	original, err := json.Marshal(user)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "cannot encode user")
+	}
+	var doc any
+	if err = json.Unmarshal(original, &doc); err != nil {
+		return entity.User{}, errors.Wrap(err, "cannot decode user")
+	}
+
+	switch patch.Format {
+	case entity.PatchMerge:
+		doc, err = applyMergePatch(doc, patch.Document)
+	case entity.PatchJSON:
+		doc, err = applyJSONPatch(doc, patch.Document)
+	default:
+		err = errors.Wrapf(domerrors.ErrInvalidPatch, "unsupported patch format %q", patch.Format)
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	patched, err := json.Marshal(doc)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "cannot encode patched user")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	var result entity.User
+	if err = decoder.Decode(&result); err != nil {
+		return entity.User{}, errors.Wrapf(domerrors.ErrUnprocessablePatch, "patched document is not a user: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,20 @@
+package patch
+
+import (
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserPatchApplier is a mock implementation of service.UserPatchApplier by using testify mock.Mock
+type MockUserPatchApplier struct {
+	mock.Mock
+}
+
+func NewMockUserPatchApplier() *MockUserPatchApplier {
+	return &MockUserPatchApplier{}
+}
+
+func (m *MockUserPatchApplier) Apply(user entity.User, patch entity.UserPatch) (entity.User, error) {
+	args := m.Called(user, patch)
+	return args.Get(0).(entity.User), args.Error(1)
+}
@@ -0,0 +1,94 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONApplier_Apply(t *testing.T) {
+	user := entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"editor"}}
+
+	tests := []struct {
+		name    string
+		user    entity.User
+		patch   entity.UserPatch
+		want    entity.User
+		wantErr error
+	}{
+		{
+			name:  "should merge the given members",
+			user:  user,
+			patch: entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"surname": "Smith"}`)},
+			want:  entity.User{ID: 1, Name: "John", Surname: "Smith", Roles: []string{"editor"}},
+		},
+		{
+			name:  "should remove the null members",
+			user:  user,
+			patch: entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"roles": null}`)},
+			want:  entity.User{ID: 1, Name: "John", Surname: "Doe"},
+		},
+		{
+			name:  "should apply the operations",
+			user:  user,
+			patch: entity.UserPatch{Format: entity.PatchJSON, Document: []byte(`[{"op": "test", "path": "/name", "value": "John"}, {"op": "replace", "path": "/name", "value": "Johnny"}, {"op": "add", "path": "/roles/-", "value": "admin"}]`)},
+			want:  entity.User{ID: 1, Name: "Johnny", Surname: "Doe", Roles: []string{"editor", "admin"}},
+		},
+		{
+			name:  "should add roles to a user without roles",
+			user:  entity.User{ID: 2, Name: "Jane", Surname: "Doe"},
+			patch: entity.UserPatch{Format: entity.PatchJSON, Document: []byte(`[{"op": "add", "path": "/roles/0", "value": "admin"}]`)},
+			want:  entity.User{ID: 2, Name: "Jane", Surname: "Doe", Roles: []string{"admin"}},
+		},
+		{
+			name:    "should not apply a failed test",
+			user:    user,
+			patch:   entity.UserPatch{Format: entity.PatchJSON, Document: []byte(`[{"op": "test", "path": "/name", "value": "Jane"}, {"op": "replace", "path": "/name", "value": "Johnny"}]`)},
+			wantErr: domerrors.ErrPatchConflict,
+		},
+		{
+			name:    "should not add unknown members",
+			user:    user,
+			patch:   entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"email": "john@doe.com"}`)},
+			wantErr: domerrors.ErrUnprocessablePatch,
+		},
+		{
+			name:    "should not set members of another type",
+			user:    user,
+			patch:   entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"name": 42}`)},
+			wantErr: domerrors.ErrUnprocessablePatch,
+		},
+		{
+			name:    "should not replace the user by another document",
+			user:    user,
+			patch:   entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`"John"`)},
+			wantErr: domerrors.ErrUnprocessablePatch,
+		},
+		{
+			name:    "should not apply a malformed merge patch",
+			user:    user,
+			patch:   entity.UserPatch{Format: entity.PatchMerge, Document: []byte(`{"name": `)},
+			wantErr: domerrors.ErrInvalidPatch,
+		},
+		{
+			name:    "should not apply a patch of an unsupported format",
+			user:    user,
+			patch:   entity.UserPatch{Format: "xml-patch", Document: []byte(`<name>Jane</name>`)},
+			wantErr: domerrors.ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewJSONApplier().Apply(tt.user, tt.patch)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, entity.User{}, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
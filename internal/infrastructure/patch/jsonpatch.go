@@ -0,0 +1,303 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+// operations of the JSON Patch documents
+const (
+	opAdd     = "add"
+	opRemove  = "remove"
+	opReplace = "replace"
+	opMove    = "move"
+	opCopy    = "copy"
+	opTest    = "test"
+)
+
+// endOfArray is the last token of the paths adding a value after the last element of an array
+const endOfArray = "-"
+
+// pointerUnescaper unescapes the reference tokens of the JSON pointers (RFC 6901)
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// operation is an operation of a JSON Patch document
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch returns the given document with the operations of the given JSON Patch document applied in order
+// (RFC 6902). The document is left as is when an operation fails, since the whole patch must be applied or none.
+func applyJSONPatch(doc any, document []byte) (any, error) {
+	var operations []operation
+	if err := json.Unmarshal(document, &operations); err != nil {
+		return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "malformed json patch: %v", err)
+	}
+
+	doc = deepCopy(doc)
+	for i, op := range operations {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			return nil, errors.WithMessagef(err, "operation %d", i)
+		}
+	}
+	return doc, nil
+}
+
+// apply returns the given document with the operation applied
+func (o operation) apply(doc any) (any, error) {
+	if !slices.Contains([]string{opAdd, opRemove, opReplace, opMove, opCopy, opTest}, o.Op) {
+		return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "unknown operation %q", o.Op)
+	}
+	path, err := o.pointer("path", o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case opAdd, opReplace, opTest:
+		if len(o.Value) == 0 {
+			return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "%s requires a value", o.Op)
+		}
+		var value any
+		if err = json.Unmarshal(o.Value, &value); err != nil {
+			return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "malformed value: %v", err)
+		}
+		switch o.Op {
+		case opAdd:
+			return add(doc, path, value)
+		case opReplace:
+			return replace(doc, path, value)
+		default:
+			return doc, test(doc, path, value)
+		}
+	case opRemove:
+		doc, _, err = remove(doc, path)
+		return doc, err
+	default: // opMove, opCopy
+		from, err := o.pointer("from", o.From)
+		if err != nil {
+			return nil, err
+		}
+		if o.Op == opCopy {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+		if isPrefix(from, path) {
+			if len(from) == len(path) {
+				return doc, nil
+			}
+			return nil, errors.Wrap(domerrors.ErrUnprocessablePatch, "cannot move a value into one of its children")
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+}
+
+// pointer returns the reference tokens of the given JSON pointer member of the operation
+func (o operation) pointer(member string, pointer *string) ([]string, error) {
+	if pointer == nil {
+		return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "%s requires a %s", o.Op, member)
+	}
+	if *pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(*pointer, "/") {
+		return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "malformed %s %q", member, *pointer)
+	}
+	tokens := strings.Split((*pointer)[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// add returns the given document with the value added at the given path: it sets a member of an object, inserts
+// an element into an array, or replaces the whole document
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i := len(node)
+			if token != endOfArray {
+				var err error
+				if i, err = index(token, len(node)+1); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, errors.Wrapf(domerrors.ErrUnprocessablePatch, "cannot add %q to a %T", token, parent)
+		}
+	})
+}
+
+// remove returns the given document without the value at the given path, along with the removed value
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.Wrap(domerrors.ErrUnprocessablePatch, "cannot remove the whole document")
+	}
+	var removed any
+	doc, err := update(doc, path, func(parent any, token string) (any, error) {
+		var err error
+		if removed, err = child(parent, token); err != nil {
+			return nil, err
+		}
+		switch node := parent.(type) {
+		case map[string]any:
+			delete(node, token)
+		case []any:
+			i, _ := index(token, len(node))
+			parent = append(node[:i], node[i+1:]...)
+		}
+		return parent, nil
+	})
+	return doc, removed, err
+}
+
+// replace returns the given document with the existing value at the given path replaced
+func replace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	doc, _, err := remove(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	return add(doc, path, value)
+}
+
+// test returns errors.ErrPatchConflict if the value at the given path is not equal to the given value
+func test(doc any, path []string, value any) error {
+	actual, err := get(doc, path)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(actual, value) {
+		return errors.Wrapf(domerrors.ErrPatchConflict, "value at /%s is not the expected one", strings.Join(path, "/"))
+	}
+	return nil
+}
+
+// update returns the given document with the parent of the value at the given non-empty path replaced by the
+// result of the given function on it and on the last token of the path
+func update(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	value, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if value, err = update(value, path[1:], fn); err != nil {
+		return nil, err
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		node[path[0]] = value
+	case []any:
+		i, _ := index(path[0], len(node))
+		node[i] = value
+	}
+	return doc, nil
+}
+
+// get returns the value at the given path of the document
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// child returns the member of an object or the element of an array referenced by the given token
+func child(node any, token string) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		value, ok := n[token]
+		if !ok {
+			return nil, errors.Wrapf(domerrors.ErrUnprocessablePatch, "member %q not found", token)
+		}
+		return value, nil
+	case []any:
+		i, err := index(token, len(n))
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	default:
+		return nil, errors.Wrapf(domerrors.ErrUnprocessablePatch, "%q not found in a %T", token, node)
+	}
+}
+
+// index returns the array index of the given token, which must be lower than the given size
+func index(token string, size int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || strings.Trim(token, "0123456789") != "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Wrapf(domerrors.ErrUnprocessablePatch, "malformed array index %q", token)
+	}
+	if i >= size {
+		return 0, errors.Wrapf(domerrors.ErrUnprocessablePatch, "array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+// isPrefix reports whether the given path is a prefix of, or equal to, the other one
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// deepCopy returns a copy of the given decoded JSON value not sharing any object or array with it
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for name, member := range v {
+			c[name] = deepCopy(member)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, element := range v {
+			c[i] = deepCopy(element)
+		}
+		return c
+	default:
+		return value
+	}
+}
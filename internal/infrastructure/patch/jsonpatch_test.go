@@ -0,0 +1,93 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mostly the examples of the appendix A of RFC 6902
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "should add an object member", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, want: `{"baz":"qux","foo":"bar"}`},
+		{name: "should add an array element", doc: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, want: `{"foo":["bar","qux","baz"]}`},
+		{name: "should add to the end of an array", doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, want: `{"foo":["bar",["abc","def"]]}`},
+		{name: "should add a nested member", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, want: `{"foo":"bar","child":{"grandchild":{}}}`},
+		{name: "should add a null value", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":null}]`, want: `{"foo":"bar","baz":null}`},
+		{name: "should remove an object member", doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, want: `{"foo":"bar"}`},
+		{name: "should remove an array element", doc: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, want: `{"foo":["bar","baz"]}`},
+		{name: "should replace a value", doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, want: `{"baz":"boo","foo":"bar"}`},
+		{name: "should replace the whole document", doc: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"","value":{"baz":"qux"}}]`, want: `{"baz":"qux"}`},
+		{name: "should move a value", doc: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, want: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{name: "should move an array element", doc: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, want: `{"foo":["all","cows","eat","grass"]}`},
+		{name: "should move a value to itself", doc: `{"foo":"bar"}`, patch: `[{"op":"move","from":"/foo","path":"/foo"}]`, want: `{"foo":"bar"}`},
+		{name: "should copy a value", doc: `{"foo":{"bar":1}}`, patch: `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, want: `{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{name: "should test values", doc: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, want: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{name: "should unescape the paths", doc: `{"/":9,"~1":10}`, patch: `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, want: `{"~1":10}`},
+		{name: "should apply no operations", doc: `{"foo":"bar"}`, patch: `[]`, want: `{"foo":"bar"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc, want any
+			require.NoError(t, json.Unmarshal([]byte(tt.doc), &doc))
+			require.NoError(t, json.Unmarshal([]byte(tt.want), &want))
+
+			got, err := applyJSONPatch(doc, []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestApplyJSONPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		wantErr error
+	}{
+		{name: "should fail a test of another value", doc: `{"baz":"qux"}`, patch: `[{"op":"test","path":"/baz","value":"bar"}]`, wantErr: domerrors.ErrPatchConflict},
+		{name: "should fail a test of a number of another type", doc: `{"foo":["1"]}`, patch: `[{"op":"test","path":"/foo/0","value":1}]`, wantErr: domerrors.ErrPatchConflict},
+		{name: "should not add to a missing parent", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not add out of the bounds of an array", doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/2","value":"qux"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not add at a malformed array index", doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/01","value":"qux"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not remove a missing member", doc: `{"foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not remove the whole document", doc: `{"foo":"bar"}`, patch: `[{"op":"remove","path":""}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not replace a missing member", doc: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"qux"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not move a value into its children", doc: `{"foo":{"bar":1}}`, patch: `[{"op":"move","from":"/foo","path":"/foo/baz"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not copy a missing member", doc: `{"foo":"bar"}`, patch: `[{"op":"copy","from":"/baz","path":"/qux"}]`, wantErr: domerrors.ErrUnprocessablePatch},
+		{name: "should not apply an unknown operation", doc: `{"foo":"bar"}`, patch: `[{"op":"merge","path":"/foo","value":"baz"}]`, wantErr: domerrors.ErrInvalidPatch},
+		{name: "should not apply an operation without path", doc: `{"foo":"bar"}`, patch: `[{"op":"remove"}]`, wantErr: domerrors.ErrInvalidPatch},
+		{name: "should not apply an operation without value", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz"}]`, wantErr: domerrors.ErrInvalidPatch},
+		{name: "should not apply a move without from", doc: `{"foo":"bar"}`, patch: `[{"op":"move","path":"/baz"}]`, wantErr: domerrors.ErrInvalidPatch},
+		{name: "should not apply a malformed path", doc: `{"foo":"bar"}`, patch: `[{"op":"remove","path":"foo"}]`, wantErr: domerrors.ErrInvalidPatch},
+		{name: "should not apply a patch which is not an array", doc: `{"foo":"bar"}`, patch: `{"op":"remove","path":"/foo"}`, wantErr: domerrors.ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc any
+			require.NoError(t, json.Unmarshal([]byte(tt.doc), &doc))
+
+			got, err := applyJSONPatch(doc, []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func TestApplyJSONPatch_Atomic(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{"foo":{"bar":1}}`), &doc))
+
+	_, err := applyJSONPatch(doc, []byte(`[{"op":"add","path":"/foo/baz","value":2},{"op":"remove","path":"/qux"}]`))
+	assert.ErrorIs(t, err, domerrors.ErrUnprocessablePatch)
+	assert.Equal(t, map[string]any{"foo": map[string]any{"bar": float64(1)}}, doc)
+}
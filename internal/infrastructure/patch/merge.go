@@ -0,0 +1,38 @@
+package patch
+
+import (
+	"encoding/json"
+
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+)
+
+// applyMergePatch returns the given document with the given JSON Merge Patch document merged into it (RFC 7396)
+func applyMergePatch(doc any, document []byte) (any, error) {
+	var patch any
+	if err := json.Unmarshal(document, &patch); err != nil {
+		return nil, errors.Wrapf(domerrors.ErrInvalidPatch, "malformed merge patch: %v", err)
+	}
+	return merge(doc, patch), nil
+}
+
+// merge returns the target with the given patch merged into it: the members of a patch object are merged into
+// the members of the target object, removing those whose patch is null, and any other patch replaces the target
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	result, ok := target.(map[string]any)
+	if !ok {
+		result = make(map[string]any, len(members))
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = merge(result[name], value)
+	}
+	return result
+}
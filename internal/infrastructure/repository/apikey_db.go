@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// APIKeyDBEntity represents an API key entity in the database
+type APIKeyDBEntity struct {
+	ID         uint     `gorm:"primarykey"`
+	Name       string   `gorm:"not null"`
+	Prefix     string   `gorm:"size:255;not null;uniqueIndex"`
+	KeyHash    string   `gorm:"not null"`
+	OwnerID    uint     `gorm:"not null;index"`
+	Scopes     []string `gorm:"serializer:json"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// TableName overrides the table name used by APIKeyDBEntity to `api_keys`
+func (APIKeyDBEntity) TableName() string {
+	return "api_keys"
+}
+
+type APIKeyDB struct {
+	DB *gorm.DB
+}
+
+// NewAPIKeyDB creates a new instance of repository.APIKeyDB
+func NewAPIKeyDB(DB *gorm.DB) repository.APIKey {
+	return &APIKeyDB{DB}
+}
+
+// FindAll returns all API keys
+func (r *APIKeyDB) FindAll(ctx context.Context) ([]entity.APIKey, error) {
+	var keyEntities []APIKeyDBEntity
+	err := r.DB.Order("id").Find(&keyEntities).Error
+
+	keys := make([]entity.APIKey, 0, len(keyEntities))
+	for _, e := range keyEntities {
+		keys = append(keys, e.toEntityAPIKey())
+	}
+
+	return keys, err
+}
+
+// FindByPrefix returns an API key by its prefix
+func (r *APIKeyDB) FindByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	var keyEntity APIKeyDBEntity
+	err := r.DB.Where("prefix = ?", prefix).First(&keyEntity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.APIKey{}, domerrors.ErrAPIKeyNotFound
+		}
+		return entity.APIKey{}, err
+	}
+
+	return keyEntity.toEntityAPIKey(), nil
+}
+
+// Create creates an API key
+func (r *APIKeyDB) Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	keyEntity := APIKeyDBEntity{}.fromEntityAPIKey(key)
+	err := r.DB.Create(&keyEntity).Error
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	return keyEntity.toEntityAPIKey(), nil
+}
+
+// Revoke revokes an API key. Revoking an already revoked key is not an error.
+func (r *APIKeyDB) Revoke(ctx context.Context, id uint) error {
+	result := r.DB.Model(&APIKeyDBEntity{}).
+		Where("id = ?", id).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domerrors.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Touch records the last use of an API key
+func (r *APIKeyDB) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.DB.Model(&APIKeyDBEntity{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyDB_Create(t *testing.T) {
+	const query = "INSERT INTO `api_keys` (`name`,`prefix`,`key_hash`,`owner_id`,`scopes`,`expires_at`,`last_used_at`,`created_at`,`revoked_at`) VALUES (?,?,?,?,?,?,?,?,?)"
+
+	tests := []struct {
+		name  string
+		given func() (repository.APIKey, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.APIKey, error)
+	}{
+		{
+			name: "should create API key",
+			given: func() (repository.APIKey, sqlmock.Sqlmock) {
+				// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+				// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("batch", "0123456789ab", "hash", 1, `["users:read"]`, nil, nil, AnyTime{}, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return NewAPIKeyDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, key entity.APIKey, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), key.ID)
+				assert.Equal(t, "0123456789ab", key.Prefix)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not create API key",
+			given: func() (repository.APIKey, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(errors.New("failed to create api key"))
+				mock.ExpectRollback()
+
+				return NewAPIKeyDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, key entity.APIKey, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, key)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			key, err := repo.Create(context.Background(), entity.APIKey{
+				Name:    "batch",
+				Prefix:  "0123456789ab",
+				KeyHash: "hash",
+				OwnerID: 1,
+				Scopes:  []string{"users:read"},
+			})
+
+			// Then
+			tt.then(mock, key, err)
+		})
+	}
+}
+
+func TestAPIKeyDB_FindByPrefix(t *testing.T) {
+	const query = `SELECT * FROM "api_keys" WHERE prefix = $1 ORDER BY "api_keys"."id" LIMIT $2`
+
+	tests := []struct {
+		name  string
+		given func() (repository.APIKey, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.APIKey, error)
+	}{
+		{
+			name: "should find API key by prefix",
+			given: func() (repository.APIKey, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rows := sqlmock.NewRows([]string{"id", "name", "prefix", "key_hash", "owner_id", "scopes"}).
+					AddRow(1, "batch", "0123456789ab", "hash", 2, `["users:read"]`)
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("0123456789ab", 1).
+					WillReturnRows(rows)
+
+				return NewAPIKeyDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, key entity.APIKey, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), key.ID)
+				assert.Equal(t, uint(2), key.OwnerID)
+				assert.Equal(t, []string{"users:read"}, key.Scopes)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not find API key by prefix",
+			given: func() (repository.APIKey, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("0123456789ab", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				return NewAPIKeyDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, key entity.APIKey, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrAPIKeyNotFound)
+				assert.Empty(t, key)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			key, err := repo.FindByPrefix(context.Background(), "0123456789ab")
+
+			// Then
+			tt.then(mock, key, err)
+		})
+	}
+}
+
+func TestAPIKeyDB_Revoke(t *testing.T) {
+	const query = `UPDATE "api_keys" SET "revoked_at"=COALESCE(revoked_at, $1) WHERE id = $2`
+
+	tests := []struct {
+		name  string
+		given func() (repository.APIKey, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, error)
+	}{
+		{
+			name: "should revoke API key",
+			given: func() (repository.APIKey, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				return NewAPIKeyDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.NoError(t, err)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not revoke an unknown API key",
+			given: func() (repository.APIKey, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+
+				return NewAPIKeyDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrAPIKeyNotFound)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			err := repo.Revoke(context.Background(), 1)
+
+			// Then
+			tt.then(mock, err)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// APIKeyInMemoryEntity represents an API key entity in the in-memory database
+type APIKeyInMemoryEntity struct {
+	ID         uint
+	Name       string
+	Prefix     string
+	KeyHash    string
+	OwnerID    uint
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// APIKeyInMemory represents an API key repository in the in-memory database.
+// The keys are kept by their ID as pointers, which are replaced on every change, and their prefixes are indexed.
+type APIKeyInMemory struct {
+	DB       sync.Map
+	Prefixes sync.Map
+	lastID   atomic.Uint64
+}
+
+// NewAPIKeyInMemory creates a new instance of repository.APIKeyInMemory
+func NewAPIKeyInMemory() repository.APIKey {
+	return &APIKeyInMemory{}
+}
+
+// FindAll returns all API keys
+func (r *APIKeyInMemory) FindAll(ctx context.Context) ([]entity.APIKey, error) {
+	keys := make([]entity.APIKey, 0)
+	r.DB.Range(func(key, value interface{}) bool {
+		keys = append(keys, value.(*APIKeyInMemoryEntity).toEntityAPIKey())
+		return true
+	})
+
+	slices.SortFunc(keys, func(a, b entity.APIKey) int {
+		return int(a.ID) - int(b.ID)
+	})
+
+	return keys, nil
+}
+
+// FindByPrefix returns an API key by its prefix
+func (r *APIKeyInMemory) FindByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	id, ok := r.Prefixes.Load(prefix)
+	if !ok {
+		return entity.APIKey{}, errors.ErrAPIKeyNotFound
+	}
+
+	value, ok := r.DB.Load(id)
+	if !ok {
+		return entity.APIKey{}, errors.ErrAPIKeyNotFound
+	}
+
+	return value.(*APIKeyInMemoryEntity).toEntityAPIKey(), nil
+}
+
+// Create creates an API key
+func (r *APIKeyInMemory) Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	key.ID = uint(r.lastID.Add(1))
+	key.CreatedAt = time.Now()
+
+	keyEntity := APIKeyInMemoryEntity{}.fromEntityAPIKey(key)
+	r.DB.Store(key.ID, &keyEntity)
+	r.Prefixes.Store(key.Prefix, key.ID)
+
+	return keyEntity.toEntityAPIKey(), nil
+}
+
+// Revoke revokes an API key. Revoking an already revoked key is not an error.
+func (r *APIKeyInMemory) Revoke(ctx context.Context, id uint) error {
+	return r.update(id, func(e *APIKeyInMemoryEntity) {
+		if e.RevokedAt == nil {
+			now := time.Now()
+			e.RevokedAt = &now
+		}
+	})
+}
+
+// Touch records the last use of an API key
+func (r *APIKeyInMemory) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.update(id, func(e *APIKeyInMemoryEntity) {
+		e.LastUsedAt = &usedAt
+	})
+}
+
+// update applies the given change to the API key with the given ID, retrying when a concurrent change wins the race
+func (r *APIKeyInMemory) update(id uint, change func(e *APIKeyInMemoryEntity)) error {
+	for {
+		value, ok := r.DB.Load(id)
+		if !ok {
+			return errors.ErrAPIKeyNotFound
+		}
+
+		old := value.(*APIKeyInMemoryEntity)
+		updated := *old
+		change(&updated)
+		if r.DB.CompareAndSwap(id, old, &updated) {
+			return nil
+		}
+	}
+}
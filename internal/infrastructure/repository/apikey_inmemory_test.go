@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyInMemory_CreateAndFind(t *testing.T) {
+	repo := NewAPIKeyInMemory()
+	created, err := repo.Create(context.Background(), entity.APIKey{Name: "batch", Prefix: "prefix", KeyHash: "hash", OwnerID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	found, err := repo.FindByPrefix(context.Background(), "prefix")
+	assert.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	all, err := repo.FindAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.APIKey{created}, all)
+}
+
+func TestAPIKeyInMemory_FindByPrefix_NotFound(t *testing.T) {
+	repo := NewAPIKeyInMemory()
+	_, err := repo.FindByPrefix(context.Background(), "unknown")
+	assert.ErrorIs(t, err, errors.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyInMemory_RevokeAndTouch(t *testing.T) {
+	repo := NewAPIKeyInMemory()
+	created, err := repo.Create(context.Background(), entity.APIKey{Name: "batch", Prefix: "prefix", Scopes: []string{"users:read"}})
+	require.NoError(t, err)
+
+	usedAt := time.Now()
+	assert.NoError(t, repo.Touch(context.Background(), created.ID, usedAt))
+	assert.NoError(t, repo.Revoke(context.Background(), created.ID))
+	found, err := repo.FindByPrefix(context.Background(), "prefix")
+	require.NoError(t, err)
+	assert.True(t, found.IsRevoked())
+	assert.Equal(t, &usedAt, found.LastUsedAt)
+	assert.Equal(t, []string{"users:read"}, found.Scopes)
+
+	// revoking again keeps the first revocation
+	revokedAt := found.RevokedAt
+	assert.NoError(t, repo.Revoke(context.Background(), created.ID))
+	found, err = repo.FindByPrefix(context.Background(), "prefix")
+	require.NoError(t, err)
+	assert.Equal(t, revokedAt, found.RevokedAt)
+
+	assert.ErrorIs(t, repo.Revoke(context.Background(), 99), errors.ErrAPIKeyNotFound)
+}
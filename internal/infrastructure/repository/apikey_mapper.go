@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// toEntityAPIKey converts an APIKeyDBEntity to an entity.APIKey
+func (kb APIKeyDBEntity) toEntityAPIKey() entity.APIKey {
+	return entity.APIKey{
+		ID:         kb.ID,
+		Name:       kb.Name,
+		Prefix:     kb.Prefix,
+		KeyHash:    kb.KeyHash,
+		OwnerID:    kb.OwnerID,
+		Scopes:     kb.Scopes,
+		ExpiresAt:  kb.ExpiresAt,
+		LastUsedAt: kb.LastUsedAt,
+		CreatedAt:  kb.CreatedAt,
+		RevokedAt:  kb.RevokedAt,
+	}
+}
+
+// fromEntityAPIKey converts an entity.APIKey to an APIKeyDBEntity
+func (kb APIKeyDBEntity) fromEntityAPIKey(k entity.APIKey) APIKeyDBEntity {
+	kb.ID = k.ID
+	kb.Name = k.Name
+	kb.Prefix = k.Prefix
+	kb.KeyHash = k.KeyHash
+	kb.OwnerID = k.OwnerID
+	kb.Scopes = k.Scopes
+	kb.ExpiresAt = k.ExpiresAt
+	kb.LastUsedAt = k.LastUsedAt
+	kb.CreatedAt = k.CreatedAt
+	kb.RevokedAt = k.RevokedAt
+	return kb
+}
+
+// toEntityAPIKey converts an APIKeyInMemoryEntity to an entity.APIKey
+func (km APIKeyInMemoryEntity) toEntityAPIKey() entity.APIKey {
+	return entity.APIKey{
+		ID:         km.ID,
+		Name:       km.Name,
+		Prefix:     km.Prefix,
+		KeyHash:    km.KeyHash,
+		OwnerID:    km.OwnerID,
+		Scopes:     slices.Clone(km.Scopes),
+		ExpiresAt:  km.ExpiresAt,
+		LastUsedAt: km.LastUsedAt,
+		CreatedAt:  km.CreatedAt,
+		RevokedAt:  km.RevokedAt,
+	}
+}
+
+// fromEntityAPIKey converts an entity.APIKey to an APIKeyInMemoryEntity
+func (km APIKeyInMemoryEntity) fromEntityAPIKey(k entity.APIKey) APIKeyInMemoryEntity {
+	km.ID = k.ID
+	km.Name = k.Name
+	km.Prefix = k.Prefix
+	km.KeyHash = k.KeyHash
+	km.OwnerID = k.OwnerID
+	km.Scopes = slices.Clone(k.Scopes)
+	km.ExpiresAt = k.ExpiresAt
+	km.LastUsedAt = k.LastUsedAt
+	km.CreatedAt = k.CreatedAt
+	km.RevokedAt = k.RevokedAt
+	return km
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntityAPIKey() entity.APIKey {
+	expiresAt := time.Now().Add(time.Hour)
+	lastUsedAt := time.Now()
+	return entity.APIKey{
+		ID:         1,
+		Name:       "batch",
+		Prefix:     "prefix",
+		KeyHash:    "hash",
+		OwnerID:    2,
+		Scopes:     []string{"users:read"},
+		ExpiresAt:  &expiresAt,
+		LastUsedAt: &lastUsedAt,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func TestAPIKeyDBEntity_Mapping(t *testing.T) {
+	key := newEntityAPIKey()
+	keyEntity := APIKeyDBEntity{}.fromEntityAPIKey(key)
+	assert.Equal(t, key, keyEntity.toEntityAPIKey())
+}
+
+func TestAPIKeyInMemoryEntity_Mapping(t *testing.T) {
+	key := newEntityAPIKey()
+	keyEntity := APIKeyInMemoryEntity{}.fromEntityAPIKey(key)
+	assert.Equal(t, key, keyEntity.toEntityAPIKey())
+
+	// the scopes are not shared with the stored entity
+	key.Scopes[0] = "users:write"
+	assert.Equal(t, []string{"users:read"}, keyEntity.Scopes)
+}
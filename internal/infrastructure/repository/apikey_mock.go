@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKey is a mock implementation of repository.APIKey by using testify mock.Mock
+type MockAPIKey struct {
+	mock.Mock
+}
+
+func NewMockAPIKey() *MockAPIKey {
+	return &MockAPIKey{}
+}
+
+func (m *MockAPIKey) FindAll(ctx context.Context) ([]entity.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKey) FindByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKey) Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKey) Revoke(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKey) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenDBEntity represents a refresh token entity in the database
+type RefreshTokenDBEntity struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"not null;index"`
+	FamilyID  string    `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:255;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// TableName overrides the table name used by RefreshTokenDBEntity to `refresh_tokens`
+func (RefreshTokenDBEntity) TableName() string {
+	return "refresh_tokens"
+}
+
+type RefreshTokenDB struct {
+	DB *gorm.DB
+}
+
+// NewRefreshTokenDB creates a new instance of repository.RefreshTokenDB
+func NewRefreshTokenDB(DB *gorm.DB) repository.RefreshToken {
+	return &RefreshTokenDB{DB}
+}
+
+// Create creates a refresh token
+func (r *RefreshTokenDB) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	tokenEntity := RefreshTokenDBEntity{}.fromEntityRefreshToken(token)
+	err := r.DB.Create(&tokenEntity).Error
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	return tokenEntity.toEntityRefreshToken(), nil
+}
+
+// FindByHash returns a refresh token by its hash
+func (r *RefreshTokenDB) FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error) {
+	var tokenEntity RefreshTokenDBEntity
+	err := r.DB.Where("token_hash = ?", hash).First(&tokenEntity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.RefreshToken{}, domerrors.ErrRefreshTokenNotFound
+		}
+		return entity.RefreshToken{}, err
+	}
+
+	return tokenEntity.toEntityRefreshToken(), nil
+}
+
+// Revoke revokes a refresh token unless it was already revoked
+func (r *RefreshTokenDB) Revoke(ctx context.Context, token entity.RefreshToken) error {
+	result := r.DB.Model(&RefreshTokenDBEntity{}).
+		Where("id = ? AND revoked_at IS NULL", token.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domerrors.ErrRefreshTokenRevoked
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token of a family
+func (r *RefreshTokenDB) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.DB.Model(&RefreshTokenDBEntity{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenDB_Create(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() (repository.RefreshToken, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.RefreshToken, error)
+	}{
+		{
+			name: "should create refresh token",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+				// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `refresh_tokens` (`user_id`,`family_id`,`token_hash`,`expires_at`,`created_at`,`revoked_at`) VALUES (?,?,?,?,?,?)")).
+					WithArgs(1, "family", "hash", AnyTime{}, AnyTime{}, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, token entity.RefreshToken, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), token.ID)
+				assert.Equal(t, "hash", token.TokenHash)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not create refresh token",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `refresh_tokens` (`user_id`,`family_id`,`token_hash`,`expires_at`,`created_at`,`revoked_at`) VALUES (?,?,?,?,?,?)")).
+					WithArgs(1, "family", "hash", AnyTime{}, AnyTime{}, nil).
+					WillReturnError(errors.New("failed to create refresh token"))
+				mock.ExpectRollback()
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, token entity.RefreshToken, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, token)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			token, err := repo.Create(context.Background(), entity.RefreshToken{
+				UserID:    1,
+				FamilyID:  "family",
+				TokenHash: "hash",
+				ExpiresAt: time.Now().Add(time.Hour),
+			})
+
+			// Then
+			tt.then(mock, token, err)
+		})
+	}
+}
+
+func TestRefreshTokenDB_FindByHash(t *testing.T) {
+	const query = `SELECT * FROM "refresh_tokens" WHERE token_hash = $1 ORDER BY "refresh_tokens"."id" LIMIT $2`
+
+	tests := []struct {
+		name  string
+		given func() (repository.RefreshToken, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.RefreshToken, error)
+	}{
+		{
+			name: "should find refresh token by hash",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "family_id", "token_hash"}).
+					AddRow(1, 2, "family", "hash")
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("hash", 1).
+					WillReturnRows(rows)
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, token entity.RefreshToken, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), token.ID)
+				assert.Equal(t, uint(2), token.UserID)
+				assert.Equal(t, "family", token.FamilyID)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not find refresh token by hash",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("hash", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, token entity.RefreshToken, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrRefreshTokenNotFound)
+				assert.Empty(t, token)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			token, err := repo.FindByHash(context.Background(), "hash")
+
+			// Then
+			tt.then(mock, token, err)
+		})
+	}
+}
+
+func TestRefreshTokenDB_Revoke(t *testing.T) {
+	const query = `UPDATE "refresh_tokens" SET "revoked_at"=$1 WHERE id = $2 AND revoked_at IS NULL`
+
+	tests := []struct {
+		name  string
+		given func() (repository.RefreshToken, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, error)
+	}{
+		{
+			name: "should revoke refresh token",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.NoError(t, err)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not revoke an already revoked refresh token",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrRefreshTokenRevoked)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should fail revoking refresh token",
+			given: func() (repository.RefreshToken, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(AnyTime{}, 1).
+					WillReturnError(errors.New("connection lost"))
+				mock.ExpectRollback()
+
+				return NewRefreshTokenDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrRefreshTokenRevoked)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			err := repo.Revoke(context.Background(), entity.RefreshToken{ID: 1})
+
+			// Then
+			tt.then(mock, err)
+		})
+	}
+}
+
+func TestRefreshTokenDB_RevokeFamily(t *testing.T) {
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "revoked_at"=$1 WHERE family_id = $2 AND revoked_at IS NULL`)).
+		WithArgs(AnyTime{}, "family").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = NewRefreshTokenDB(db).RevokeFamily(context.Background(), "family")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
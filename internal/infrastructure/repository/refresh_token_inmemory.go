@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// RefreshTokenInMemoryEntity represents a refresh token entity in the in-memory database
+type RefreshTokenInMemoryEntity struct {
+	ID        uint
+	UserID    uint
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// RefreshTokenInMemory represents a refresh token repository in the in-memory database.
+// The tokens are kept by their hash.
+type RefreshTokenInMemory struct {
+	DB     sync.Map
+	lastID atomic.Uint64
+}
+
+// NewRefreshTokenInMemory creates a new instance of repository.RefreshTokenInMemory
+func NewRefreshTokenInMemory() repository.RefreshToken {
+	return &RefreshTokenInMemory{}
+}
+
+// Create creates a refresh token
+func (r *RefreshTokenInMemory) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	token.ID = uint(r.lastID.Add(1))
+	token.CreatedAt = time.Now()
+
+	tokenEntity := RefreshTokenInMemoryEntity{}.fromEntityRefreshToken(token)
+	r.DB.Store(token.TokenHash, tokenEntity)
+
+	return tokenEntity.toEntityRefreshToken(), nil
+}
+
+// FindByHash returns a refresh token by its hash
+func (r *RefreshTokenInMemory) FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error) {
+	value, ok := r.DB.Load(hash)
+	if !ok {
+		return entity.RefreshToken{}, errors.ErrRefreshTokenNotFound
+	}
+
+	return value.(RefreshTokenInMemoryEntity).toEntityRefreshToken(), nil
+}
+
+// Revoke revokes a refresh token unless it was already revoked
+func (r *RefreshTokenInMemory) Revoke(ctx context.Context, token entity.RefreshToken) error {
+	value, ok := r.DB.Load(token.TokenHash)
+	if !ok {
+		return errors.ErrRefreshTokenNotFound
+	}
+
+	old := value.(RefreshTokenInMemoryEntity)
+	if old.RevokedAt != nil {
+		return errors.ErrRefreshTokenRevoked
+	}
+
+	revoked := old
+	now := time.Now()
+	revoked.RevokedAt = &now
+	// another revocation won the race when the stored token is not the loaded one anymore
+	if !r.DB.CompareAndSwap(token.TokenHash, old, revoked) {
+		return errors.ErrRefreshTokenRevoked
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token of a family
+func (r *RefreshTokenInMemory) RevokeFamily(ctx context.Context, familyID string) error {
+	r.DB.Range(func(key, value interface{}) bool {
+		tokenEntity := value.(RefreshTokenInMemoryEntity)
+		if tokenEntity.FamilyID == familyID && tokenEntity.RevokedAt == nil {
+			_ = r.Revoke(ctx, tokenEntity.toEntityRefreshToken())
+		}
+		return true
+	})
+
+	return nil
+}
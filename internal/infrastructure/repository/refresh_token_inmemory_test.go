@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRefreshToken(hash, familyID string) entity.RefreshToken {
+	return entity.RefreshToken{
+		UserID:    1,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestRefreshTokenInMemory_CreateAndFindByHash(t *testing.T) {
+	repo := NewRefreshTokenInMemory()
+	created, err := repo.Create(context.Background(), newRefreshToken("hash", "family"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	found, err := repo.FindByHash(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, created, found)
+}
+
+func TestRefreshTokenInMemory_FindByHash_NotFound(t *testing.T) {
+	repo := NewRefreshTokenInMemory()
+	_, err := repo.FindByHash(context.Background(), "unknown")
+	assert.ErrorIs(t, err, errors.ErrRefreshTokenNotFound)
+}
+
+func TestRefreshTokenInMemory_Revoke(t *testing.T) {
+	repo := NewRefreshTokenInMemory()
+	created, err := repo.Create(context.Background(), newRefreshToken("hash", "family"))
+	require.NoError(t, err)
+
+	err = repo.Revoke(context.Background(), created)
+	assert.NoError(t, err)
+
+	found, err := repo.FindByHash(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.True(t, found.IsRevoked())
+
+	err = repo.Revoke(context.Background(), created)
+	assert.ErrorIs(t, err, errors.ErrRefreshTokenRevoked)
+}
+
+func TestRefreshTokenInMemory_Revoke_Concurrently(t *testing.T) {
+	repo := NewRefreshTokenInMemory()
+	created, err := repo.Create(context.Background(), newRefreshToken("hash", "family"))
+	require.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		revoked int
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if repo.Revoke(context.Background(), created) == nil {
+				mu.Lock()
+				revoked++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, revoked)
+}
+
+func TestRefreshTokenInMemory_RevokeFamily(t *testing.T) {
+	repo := NewRefreshTokenInMemory()
+	_, err := repo.Create(context.Background(), newRefreshToken("hash1", "family"))
+	require.NoError(t, err)
+	_, err = repo.Create(context.Background(), newRefreshToken("hash2", "family"))
+	require.NoError(t, err)
+	_, err = repo.Create(context.Background(), newRefreshToken("hash3", "other"))
+	require.NoError(t, err)
+
+	err = repo.RevokeFamily(context.Background(), "family")
+	assert.NoError(t, err)
+
+	for hash, revoked := range map[string]bool{"hash1": true, "hash2": true, "hash3": false} {
+		found, err := repo.FindByHash(context.Background(), hash)
+		assert.NoError(t, err)
+		assert.Equal(t, revoked, found.IsRevoked(), hash)
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+
+// toEntityRefreshToken converts a RefreshTokenDBEntity to an entity.RefreshToken
+func (tb RefreshTokenDBEntity) toEntityRefreshToken() entity.RefreshToken {
+	return entity.RefreshToken{
+		ID:        tb.ID,
+		UserID:    tb.UserID,
+		FamilyID:  tb.FamilyID,
+		TokenHash: tb.TokenHash,
+		ExpiresAt: tb.ExpiresAt,
+		CreatedAt: tb.CreatedAt,
+		RevokedAt: tb.RevokedAt,
+	}
+}
+
+// fromEntityRefreshToken converts an entity.RefreshToken to a RefreshTokenDBEntity
+func (tb RefreshTokenDBEntity) fromEntityRefreshToken(t entity.RefreshToken) RefreshTokenDBEntity {
+	tb.ID = t.ID
+	tb.UserID = t.UserID
+	tb.FamilyID = t.FamilyID
+	tb.TokenHash = t.TokenHash
+	tb.ExpiresAt = t.ExpiresAt
+	tb.CreatedAt = t.CreatedAt
+	tb.RevokedAt = t.RevokedAt
+	return tb
+}
+
+// toEntityRefreshToken converts a RefreshTokenInMemoryEntity to an entity.RefreshToken
+func (tm RefreshTokenInMemoryEntity) toEntityRefreshToken() entity.RefreshToken {
+	return entity.RefreshToken{
+		ID:        tm.ID,
+		UserID:    tm.UserID,
+		FamilyID:  tm.FamilyID,
+		TokenHash: tm.TokenHash,
+		ExpiresAt: tm.ExpiresAt,
+		CreatedAt: tm.CreatedAt,
+		RevokedAt: tm.RevokedAt,
+	}
+}
+
+// fromEntityRefreshToken converts an entity.RefreshToken to a RefreshTokenInMemoryEntity
+func (tm RefreshTokenInMemoryEntity) fromEntityRefreshToken(t entity.RefreshToken) RefreshTokenInMemoryEntity {
+	tm.ID = t.ID
+	tm.UserID = t.UserID
+	tm.FamilyID = t.FamilyID
+	tm.TokenHash = t.TokenHash
+	tm.ExpiresAt = t.ExpiresAt
+	tm.CreatedAt = t.CreatedAt
+	tm.RevokedAt = t.RevokedAt
+	return tm
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenDBEntity_toEntityRefreshToken(t *testing.T) {
+	revokedAt := time.Now()
+	tokenEntity := RefreshTokenDBEntity{
+		ID:        1,
+		UserID:    2,
+		FamilyID:  "family",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+		RevokedAt: &revokedAt,
+	}
+	token := tokenEntity.toEntityRefreshToken()
+	assert.Equal(t, tokenEntity.ID, token.ID)
+	assert.Equal(t, tokenEntity.UserID, token.UserID)
+	assert.Equal(t, tokenEntity.FamilyID, token.FamilyID)
+	assert.Equal(t, tokenEntity.TokenHash, token.TokenHash)
+	assert.Equal(t, tokenEntity.ExpiresAt, token.ExpiresAt)
+	assert.Equal(t, tokenEntity.CreatedAt, token.CreatedAt)
+	assert.Equal(t, tokenEntity.RevokedAt, token.RevokedAt)
+}
+
+func TestRefreshTokenDBEntity_fromEntityRefreshToken(t *testing.T) {
+	token := entity.RefreshToken{
+		ID:        1,
+		UserID:    2,
+		FamilyID:  "family",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	tokenEntity := RefreshTokenDBEntity{}.fromEntityRefreshToken(token)
+	assert.Equal(t, token.ID, tokenEntity.ID)
+	assert.Equal(t, token.UserID, tokenEntity.UserID)
+	assert.Equal(t, token.FamilyID, tokenEntity.FamilyID)
+	assert.Equal(t, token.TokenHash, tokenEntity.TokenHash)
+	assert.Equal(t, token.ExpiresAt, tokenEntity.ExpiresAt)
+	assert.Nil(t, tokenEntity.RevokedAt)
+}
+
+func TestRefreshTokenInMemoryEntity_toEntityRefreshToken(t *testing.T) {
+	tokenEntity := RefreshTokenInMemoryEntity{
+		ID:        1,
+		UserID:    2,
+		FamilyID:  "family",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	token := tokenEntity.toEntityRefreshToken()
+	assert.Equal(t, tokenEntity.ID, token.ID)
+	assert.Equal(t, tokenEntity.UserID, token.UserID)
+	assert.Equal(t, tokenEntity.FamilyID, token.FamilyID)
+	assert.Equal(t, tokenEntity.TokenHash, token.TokenHash)
+	assert.Equal(t, tokenEntity.ExpiresAt, token.ExpiresAt)
+}
+
+func TestRefreshTokenInMemoryEntity_fromEntityRefreshToken(t *testing.T) {
+	token := entity.RefreshToken{
+		ID:        1,
+		UserID:    2,
+		FamilyID:  "family",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	tokenEntity := RefreshTokenInMemoryEntity{}.fromEntityRefreshToken(token)
+	assert.Equal(t, token.ID, tokenEntity.ID)
+	assert.Equal(t, token.UserID, tokenEntity.UserID)
+	assert.Equal(t, token.FamilyID, tokenEntity.FamilyID)
+	assert.Equal(t, token.TokenHash, tokenEntity.TokenHash)
+	assert.Equal(t, token.ExpiresAt, tokenEntity.ExpiresAt)
+}
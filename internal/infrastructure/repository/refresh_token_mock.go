@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRefreshToken is a mock implementation of repository.RefreshToken by using testify mock.Mock
+type MockRefreshToken struct {
+	mock.Mock
+}
+
+func NewMockRefreshToken() *MockRefreshToken {
+	return &MockRefreshToken{}
+}
+
+func (m *MockRefreshToken) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	args := m.Called(ctx, token)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshToken) FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshToken) Revoke(ctx context.Context, token entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshToken) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
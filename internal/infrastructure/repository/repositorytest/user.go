@@ -0,0 +1,334 @@
+// Package repositorytest provides the contract test suites of the domain repositories, which every adapter must pass
+package repositorytest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/stretchr/testify/suite"
+)
+
+// missingUserID is the ID of a user which no repository under test holds
+const missingUserID = math.MaxInt32
+
+// UserSuite is the contract of the repository.User implementations.
+// The repository under test is created by NewRepository before every test, and it may already hold other users.
+// The users created by a test are purged after it, so that a shared database is left as it was found.
+//
+//	func TestUserInMemory_Contract(t *testing.T) {
+//		suite.Run(t, &repositorytest.UserSuite{NewRepository: func(t *testing.T) repository.User {
+//			return NewUserInMemory()
+//		}})
+//	}
+type UserSuite struct {
+	suite.Suite
+	// NewRepository returns the repository under test
+	NewRepository func(t *testing.T) repository.User
+
+	repo repository.User
+	// created are the users created by the running test
+	created []entity.User
+}
+
+// SetupTest creates the repository under test
+func (s *UserSuite) SetupTest() {
+	s.repo = s.NewRepository(s.T())
+}
+
+// TearDownTest purges the users created by the test, unless the test purged them
+func (s *UserSuite) TearDownTest() {
+	for _, created := range s.created {
+		// purged at its current version, which the test may have incremented
+		user, err := s.repo.FindByID(context.Background(), created.ID)
+		if err != nil {
+			user, err = s.repo.FindDeletedByID(context.Background(), created.ID)
+		}
+		if err == nil {
+			_ = s.repo.Purge(context.Background(), user)
+		}
+	}
+	s.created = nil
+}
+
+// create creates the given user, failing the test if it cannot
+func (s *UserSuite) create(user entity.User) entity.User {
+	created, err := s.repo.Create(context.Background(), user)
+	s.Require().NoError(err)
+	s.Require().NotZero(created.ID)
+	s.Require().Equal(uint(1), created.Version)
+	s.Require().False(created.CreatedAt.IsZero())
+	s.Require().Equal(created.CreatedAt, created.UpdatedAt)
+	s.created = append(s.created, created)
+	return created
+}
+
+// equalUser asserts that the given user is the expected one. Their timestamps are compared as instants, since the
+// databases may store them at a lower precision and read them back in another location.
+func (s *UserSuite) equalUser(expected, actual entity.User) {
+	s.WithinDuration(expected.CreatedAt, actual.CreatedAt, time.Microsecond)
+	s.WithinDuration(expected.UpdatedAt, actual.UpdatedAt, time.Microsecond)
+	s.WithinDuration(expected.DeletedAt, actual.DeletedAt, time.Microsecond)
+	s.Equal(expected.Deleted(), actual.Deleted())
+	expected.CreatedAt, expected.UpdatedAt, expected.DeletedAt = time.Time{}, time.Time{}, time.Time{}
+	actual.CreatedAt, actual.UpdatedAt, actual.DeletedAt = time.Time{}, time.Time{}, time.Time{}
+	s.Equal(expected, actual)
+}
+
+func (s *UserSuite) TestFindByID() {
+	created := s.create(entity.User{Name: "Ada", Surname: "Lovelace", Roles: []string{"mathematician"}})
+
+	user, err := s.repo.FindByID(context.Background(), created.ID)
+
+	s.NoError(err)
+	s.equalUser(created, user)
+}
+
+func (s *UserSuite) TestFindByID_NotFound() {
+	user, err := s.repo.FindByID(context.Background(), missingUserID)
+
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+	s.Empty(user)
+}
+
+func (s *UserSuite) TestFindAll() {
+	created := s.create(entity.User{Name: "Grace", Surname: "Hopper-Contract"})
+
+	page, err := s.repo.FindAll(context.Background(), entity.UserQuery{
+		Limit:     10,
+		SortBy:    entity.UserFieldID,
+		Direction: entity.SortAsc,
+		Filters:   []entity.UserFilter{{Field: entity.UserFieldSurname, Value: created.Surname, Mode: entity.MatchExact}},
+	})
+
+	s.NoError(err)
+	if s.Len(page.Users, 1) {
+		s.equalUser(created, page.Users[0])
+	}
+	s.Equal(int64(1), page.Total)
+	s.Nil(page.Next)
+}
+
+func (s *UserSuite) TestCreate_AssignsTheNextID() {
+	first := s.create(entity.User{Name: "Alan", Surname: "Turing"})
+	second := s.create(entity.User{Name: "Alonzo", Surname: "Church"})
+
+	s.Greater(second.ID, first.ID)
+	s.Equal("Alonzo", second.Name)
+	s.Equal("Church", second.Surname)
+}
+
+func (s *UserSuite) TestCreate_AlreadyExists() {
+	created := s.create(entity.User{Name: "Edsger", Surname: "Dijkstra"})
+
+	user, err := s.repo.Create(context.Background(), entity.User{ID: created.ID, Name: "Tony", Surname: "Hoare"})
+
+	s.ErrorIs(err, domerrors.ErrUserAlreadyExists)
+	s.Empty(user)
+	stored, err := s.repo.FindByID(context.Background(), created.ID)
+	s.NoError(err)
+	s.equalUser(created, stored)
+}
+
+func (s *UserSuite) TestModify() {
+	created := s.create(entity.User{Name: "Barbara", Surname: "Liskov"})
+	modified := created
+	modified.Surname = "Liskov-Huberman"
+	modified.Roles = []string{"admin"}
+
+	user, err := s.repo.Modify(context.Background(), modified)
+
+	s.NoError(err)
+	s.False(user.UpdatedAt.Before(created.UpdatedAt))
+	modified.Version = 2
+	modified.UpdatedAt = user.UpdatedAt
+	s.equalUser(modified, user)
+	stored, err := s.repo.FindByID(context.Background(), created.ID)
+	s.NoError(err)
+	s.equalUser(modified, stored)
+}
+
+func (s *UserSuite) TestModify_ConcurrentModification() {
+	created := s.create(entity.User{Name: "Frances", Surname: "Allen"})
+	fran, frank := created, created
+	fran.Name, frank.Name = "Fran", "Frank"
+	first, err := s.repo.Modify(context.Background(), fran)
+	s.Require().NoError(err)
+
+	user, err := s.repo.Modify(context.Background(), frank)
+
+	s.ErrorIs(err, domerrors.ErrConcurrentModification)
+	s.Empty(user)
+	stored, err := s.repo.FindByID(context.Background(), created.ID)
+	s.NoError(err)
+	s.equalUser(first, stored)
+}
+
+func (s *UserSuite) TestModify_NotFound() {
+	user, err := s.repo.Modify(context.Background(), entity.User{ID: missingUserID, Name: "Ken", Surname: "Thompson"})
+
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+	s.Empty(user)
+	_, err = s.repo.FindByID(context.Background(), missingUserID)
+	s.ErrorIs(err, domerrors.ErrUserNotFound, "a missing user must not be created by Modify")
+}
+
+func (s *UserSuite) TestDelete() {
+	created := s.create(entity.User{Name: "Dennis", Surname: "Ritchie-Contract"})
+
+	err := s.repo.Delete(context.Background(), created)
+
+	s.NoError(err)
+	_, err = s.repo.FindByID(context.Background(), created.ID)
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+	deleted, err := s.repo.FindDeletedByID(context.Background(), created.ID)
+	s.NoError(err)
+	s.Equal(uint(2), deleted.Version)
+	s.True(deleted.Deleted())
+	s.False(deleted.UpdatedAt.Before(created.UpdatedAt))
+	s.ErrorIs(s.repo.Delete(context.Background(), deleted), domerrors.ErrUserNotFound, "a user must be deleted once")
+}
+
+func (s *UserSuite) TestDelete_KeepsTheUser() {
+	created := s.create(entity.User{Name: "Ken", Surname: "Iverson-Contract"})
+	s.Require().NoError(s.repo.Delete(context.Background(), created))
+	query := entity.UserQuery{
+		Limit:     10,
+		SortBy:    entity.UserFieldID,
+		Direction: entity.SortAsc,
+		Filters:   []entity.UserFilter{{Field: entity.UserFieldSurname, Value: created.Surname, Mode: entity.MatchExact}},
+	}
+
+	active, err := s.repo.FindAll(context.Background(), query)
+	s.NoError(err)
+	query.IncludeDeleted = true
+	all, err := s.repo.FindAll(context.Background(), query)
+	s.NoError(err)
+	user, createErr := s.repo.Create(context.Background(), entity.User{ID: created.ID, Name: "Ken", Surname: "Iverson"})
+
+	s.Empty(active.Users)
+	s.Equal(int64(0), active.Total)
+	if s.Len(all.Users, 1) {
+		s.True(all.Users[0].Deleted())
+	}
+	s.Equal(int64(1), all.Total)
+	s.ErrorIs(createErr, domerrors.ErrUserAlreadyExists, "the ID of a deleted user must not be reused")
+	s.Empty(user)
+}
+
+func (s *UserSuite) TestDelete_ConcurrentModification() {
+	created := s.create(entity.User{Name: "John", Surname: "Backus"})
+	modified := created
+	modified.Surname = "W. Backus"
+	modified, err := s.repo.Modify(context.Background(), modified)
+	s.Require().NoError(err)
+
+	err = s.repo.Delete(context.Background(), created)
+
+	s.ErrorIs(err, domerrors.ErrConcurrentModification)
+	stored, err := s.repo.FindByID(context.Background(), created.ID)
+	s.NoError(err)
+	s.equalUser(modified, stored)
+}
+
+func (s *UserSuite) TestDelete_NotFound() {
+	err := s.repo.Delete(context.Background(), entity.User{ID: missingUserID})
+
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+}
+
+func (s *UserSuite) TestFindDeletedByID_NotDeleted() {
+	created := s.create(entity.User{Name: "Peter", Surname: "Naur"})
+
+	user, err := s.repo.FindDeletedByID(context.Background(), created.ID)
+
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+	s.Empty(user)
+}
+
+func (s *UserSuite) TestRestore() {
+	created := s.create(entity.User{Name: "Niklaus", Surname: "Wirth"})
+	s.Require().NoError(s.repo.Delete(context.Background(), created))
+	deleted, err := s.repo.FindDeletedByID(context.Background(), created.ID)
+	s.Require().NoError(err)
+
+	user, err := s.repo.Restore(context.Background(), deleted)
+
+	s.NoError(err)
+	s.False(user.UpdatedAt.Before(deleted.UpdatedAt))
+	restored := created
+	restored.Version = 3
+	restored.UpdatedAt = user.UpdatedAt
+	s.equalUser(restored, user)
+	stored, err := s.repo.FindByID(context.Background(), created.ID)
+	s.NoError(err)
+	s.equalUser(restored, stored)
+	_, err = s.repo.FindDeletedByID(context.Background(), created.ID)
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+}
+
+func (s *UserSuite) TestRestore_ConcurrentModification() {
+	created := s.create(entity.User{Name: "Robin", Surname: "Milner"})
+	s.Require().NoError(s.repo.Delete(context.Background(), created))
+
+	user, err := s.repo.Restore(context.Background(), created)
+
+	s.ErrorIs(err, domerrors.ErrConcurrentModification)
+	s.Empty(user)
+	_, err = s.repo.FindDeletedByID(context.Background(), created.ID)
+	s.NoError(err)
+}
+
+func (s *UserSuite) TestRestore_NotDeleted() {
+	created := s.create(entity.User{Name: "Butler", Surname: "Lampson"})
+
+	user, err := s.repo.Restore(context.Background(), created)
+
+	s.ErrorIs(err, domerrors.ErrUserNotFound)
+	s.Empty(user)
+}
+
+func (s *UserSuite) TestPurge() {
+	tests := []struct {
+		name    string
+		deleted bool
+	}{
+		{name: "active user"},
+		{name: "deleted user", deleted: true},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			user := s.create(entity.User{Name: "Leslie", Surname: "Lamport"})
+			if tt.deleted {
+				s.Require().NoError(s.repo.Delete(context.Background(), user))
+				var err error
+				user, err = s.repo.FindDeletedByID(context.Background(), user.ID)
+				s.Require().NoError(err)
+			}
+
+			err := s.repo.Purge(context.Background(), user)
+
+			s.NoError(err)
+			_, err = s.repo.FindByID(context.Background(), user.ID)
+			s.ErrorIs(err, domerrors.ErrUserNotFound)
+			_, err = s.repo.FindDeletedByID(context.Background(), user.ID)
+			s.ErrorIs(err, domerrors.ErrUserNotFound)
+			s.ErrorIs(s.repo.Purge(context.Background(), user), domerrors.ErrUserNotFound, "a user must be purged once")
+		})
+	}
+}
+
+func (s *UserSuite) TestPurge_ConcurrentModification() {
+	created := s.create(entity.User{Name: "Jim", Surname: "Gray"})
+	s.Require().NoError(s.repo.Delete(context.Background(), created))
+
+	err := s.repo.Purge(context.Background(), created)
+
+	s.ErrorIs(err, domerrors.ErrConcurrentModification)
+	_, err = s.repo.FindDeletedByID(context.Background(), created.ID)
+	s.NoError(err)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the transaction of the repositories in the database
+type txKey struct{}
+
+type TransactorDB struct {
+	DB *gorm.DB
+}
+
+// NewTransactorDB creates a new instance of repository.TransactorDB
+func NewTransactorDB(DB *gorm.DB) repository.Transactor {
+	return &TransactorDB{DB}
+}
+
+// WithinTransaction runs the given function in a database transaction carried by its context, or in a savepoint of
+// the transaction of the given context if any
+func (t *TransactorDB) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withCommitHooks(ctx, func(ctx context.Context) error {
+		return dbOf(ctx, t.DB).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	})
+}
+
+// AfterCommit calls the given function once the transaction of the given context is committed
+func (t *TransactorDB) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	afterCommit(ctx, fn)
+}
+
+// dbOf returns the transaction carried by the given context, or else the given database
+func dbOf(ctx context.Context, DB *gorm.DB) *gorm.DB {
+	if tx, ok := txOf(ctx); ok {
+		return tx
+	}
+	return DB
+}
+
+// txOf returns the transaction carried by the given context, if any
+func txOf(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok
+}
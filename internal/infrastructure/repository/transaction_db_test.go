@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactorDB_WithinTransaction(t *testing.T) {
+	const (
+		userQuery   = "INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)"
+		outboxQuery = "INSERT INTO `user_outbox` (`type`,`user_id`,`user`,`occurred_at`) VALUES (?,?,?,?)"
+		auditQuery  = "INSERT INTO `user_audit` (`user_id`,`action`,`actor`,`actor_id`,`before`,`after`,`request_id`,`occurred_at`) VALUES (?,?,?,?,?,?,?,?)"
+	)
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, error)
+	}{
+		{
+			name: "should commit the writes of the repositories in a single transaction",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(userQuery)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(outboxQuery)).
+					WithArgs("user.created", 1, sqlmock.AnyArg(), AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(auditQuery)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.NoError(t, err)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should roll back the writes of the repositories when one of them fails",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(userQuery)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(outboxQuery)).
+					WillReturnError(errors.New("failed to append"))
+				mock.ExpectRollback()
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.Error(t, err)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should roll back the writes of the repositories when their audit fails",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(userQuery)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(outboxQuery)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(auditQuery)).
+					WillReturnError(errors.New("failed to audit"))
+				mock.ExpectRollback()
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.Error(t, err)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+			// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+			db, mock, err := newMockMySqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+			users, outbox, audit := NewUserDB(db), NewUserOutboxDB(db), NewUserAuditDB(db)
+
+			// When
+			err = NewTransactorDB(db).WithinTransaction(context.Background(), func(ctx context.Context) error {
+				user, err := users.Create(ctx, entity.User{Name: "John", Surname: "Doe"})
+				if err != nil {
+					return err
+				}
+				if _, err = outbox.Append(ctx, entity.NewUserEvent(entity.UserCreated, user)); err != nil {
+					return err
+				}
+				_, err = audit.Append(ctx, entity.UserAuditEntry{UserID: user.ID, Action: entity.UserAuditCreate, After: &user})
+				return err
+			})
+
+			// Then
+			tt.then(mock, err)
+		})
+	}
+}
+
+func TestTransactorDB_AfterCommit(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     func(sqlmock.Sqlmock)
+		when      func(tx repository.Transactor, hook func(context.Context)) error
+		committed bool
+	}{
+		{
+			name: "should call the function once the transaction is committed",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			when: func(tx repository.Transactor, hook func(context.Context)) error {
+				return tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+					tx.AfterCommit(ctx, hook)
+					return nil
+				})
+			},
+			committed: true,
+		},
+		{
+			name: "should not call the function when the transaction is rolled back",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			when: func(tx repository.Transactor, hook func(context.Context)) error {
+				return tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+					tx.AfterCommit(ctx, hook)
+					return errors.New("failed to audit")
+				})
+			},
+		},
+		{
+			name: "should not call the function of a savepoint when its transaction is rolled back",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			when: func(tx repository.Transactor, hook func(context.Context)) error {
+				return tx.WithinTransaction(context.Background(), func(ctx context.Context) error {
+					if err := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+						tx.AfterCommit(ctx, hook)
+						return nil
+					}); err != nil {
+						return err
+					}
+					return errors.New("failed to audit")
+				})
+			},
+		},
+		{
+			name:  "should call the function at once outside of any transaction",
+			given: func(sqlmock.Sqlmock) {},
+			when: func(tx repository.Transactor, hook func(context.Context)) error {
+				tx.AfterCommit(context.Background(), hook)
+				return nil
+			},
+			committed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			db, mock, err := newMockMySqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+			var committed bool
+
+			// When
+			_ = tt.when(NewTransactorDB(db), func(ctx context.Context) {
+				_, inTransaction := txOf(ctx)
+				assert.False(t, inTransaction)
+				committed = true
+			})
+
+			// Then
+			assert.Equal(t, tt.committed, committed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
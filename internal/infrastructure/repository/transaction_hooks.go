@@ -0,0 +1,37 @@
+package repository
+
+import "context"
+
+// commitHooksKey is the context key of the functions called once the transaction of the context is committed
+type commitHooksKey struct{}
+
+// commitHooks are the functions called once a transaction is committed, in the order they were added
+type commitHooks []func(ctx context.Context)
+
+// withCommitHooks runs the given function of a transaction with a context collecting its commit hooks. Once the
+// function succeeds, the hooks are handed to the transaction it is nested in, if any, and called otherwise.
+func withCommitHooks(ctx context.Context, fn func(ctx context.Context) error) error {
+	hooks := &commitHooks{}
+	if err := fn(context.WithValue(ctx, commitHooksKey{}, hooks)); err != nil {
+		return err
+	}
+
+	if parent, ok := ctx.Value(commitHooksKey{}).(*commitHooks); ok {
+		*parent = append(*parent, *hooks...)
+		return nil
+	}
+	for _, hook := range *hooks {
+		hook(ctx)
+	}
+	return nil
+}
+
+// afterCommit adds the given function to the commit hooks of the transaction of the given context, or calls it at
+// once outside of any transaction
+func afterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn(ctx)
+}
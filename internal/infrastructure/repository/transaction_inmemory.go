@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// TransactorInMemory represents a transactor of the repositories in the in-memory database.
+// The in-memory repositories are not transactional, so the function is run as is, and the changes it made before
+// failing are kept.
+type TransactorInMemory struct{}
+
+// NewTransactorInMemory creates a new instance of repository.TransactorInMemory
+func NewTransactorInMemory() repository.Transactor {
+	return &TransactorInMemory{}
+}
+
+// WithinTransaction runs the given function
+func (t *TransactorInMemory) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// AfterCommit calls the given function at once, as the changes of the in-memory repositories are kept as soon as
+// they are made
+func (t *TransactorInMemory) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	fn(ctx)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransactor is a mock implementation of repository.Transactor by using testify mock.Mock.
+// It runs the given functions as they are, and records them as called with the error they returned, which rolls their
+// transaction back unless nil.
+type MockTransactor struct {
+	mock.Mock
+}
+
+func NewMockTransactor() *MockTransactor {
+	return &MockTransactor{}
+}
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	m.Called(ctx, err)
+	return err
+}
+
+func (m *MockTransactor) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	fn(ctx)
+}
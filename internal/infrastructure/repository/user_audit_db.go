@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// UserAuditDBEntity represents a change of a user in the database, kept after the user is purged
+type UserAuditDBEntity struct {
+	ID         uint                  `gorm:"primarykey"`
+	UserID     uint                  `gorm:"not null;index"`
+	Action     string                `gorm:"not null"`
+	Actor      string                `gorm:"not null"`
+	ActorID    uint                  `gorm:"not null"`
+	Before     *UserSnapshotDBEntity `gorm:"serializer:json"`
+	After      *UserSnapshotDBEntity `gorm:"serializer:json"`
+	RequestID  string                `gorm:"not null"`
+	OccurredAt time.Time             `gorm:"not null"`
+}
+
+// TableName overrides the table name used by UserAuditDBEntity to `user_audit`
+func (UserAuditDBEntity) TableName() string {
+	return "user_audit"
+}
+
+// UserSnapshotDBEntity represents a user before or after a change, stored as JSON in its UserAuditDBEntity
+type UserSnapshotDBEntity struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	Surname   string     `json:"surname"`
+	Roles     []string   `json:"roles"`
+	Version   uint       `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type UserAuditDB struct {
+	DB *gorm.DB
+}
+
+// NewUserAuditDB creates a new instance of repository.UserAuditDB
+func NewUserAuditDB(DB *gorm.DB) repository.UserAudit {
+	return &UserAuditDB{DB}
+}
+
+// Append records a change of a user
+func (r *UserAuditDB) Append(ctx context.Context, entry entity.UserAuditEntry) (entity.UserAuditEntry, error) {
+	entry.OccurredAt = time.Now()
+	auditEntity := UserAuditDBEntity{}.fromEntityUserAuditEntry(entry)
+	if err := dbOf(ctx, r.DB).Create(&auditEntity).Error; err != nil {
+		return entity.UserAuditEntry{}, err
+	}
+
+	return auditEntity.toEntityUserAuditEntry(), nil
+}
+
+// FindByUserID returns the changes of a user in the order they occurred
+func (r *UserAuditDB) FindByUserID(ctx context.Context, userID uint) ([]entity.UserAuditEntry, error) {
+	var auditEntities []UserAuditDBEntity
+	if err := dbOf(ctx, r.DB).Where("user_id = ?", userID).Order("id").Find(&auditEntities).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]entity.UserAuditEntry, 0, len(auditEntities))
+	for _, e := range auditEntities {
+		entries = append(entries, e.toEntityUserAuditEntry())
+	}
+
+	return entries, nil
+}
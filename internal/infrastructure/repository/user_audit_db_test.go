@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAuditDB_Append(t *testing.T) {
+	const query = "INSERT INTO `user_audit` (`user_id`,`action`,`actor`,`actor_id`,`before`,`after`,`request_id`,`occurred_at`) VALUES (?,?,?,?,?,?,?,?)"
+
+	tests := []struct {
+		name  string
+		given func() (repository.UserAudit, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.UserAuditEntry, error)
+	}{
+		{
+			name: "should append the change of a user",
+			given: func() (repository.UserAudit, sqlmock.Sqlmock) {
+				// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+				// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1, "create", "john", 1, nil,
+						`{"id":1,"name":"John","surname":"Doe","roles":null,"version":1,"created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T12:00:00Z"}`,
+						"3f5c1a7e-request", AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return NewUserAuditDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, entry entity.UserAuditEntry, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), entry.ID)
+				assert.False(t, entry.OccurredAt.IsZero())
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not append the change of a user",
+			given: func() (repository.UserAudit, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(errors.New("failed to append"))
+				mock.ExpectRollback()
+
+				return NewUserAuditDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, entry entity.UserAuditEntry, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, entry)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+			createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+			// When
+			entry, err := repo.Append(context.Background(), entity.UserAuditEntry{
+				UserID:    1,
+				Action:    entity.UserAuditCreate,
+				Actor:     "john",
+				ActorID:   1,
+				After:     &entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1, CreatedAt: createdAt, UpdatedAt: createdAt},
+				RequestID: "3f5c1a7e-request",
+			})
+
+			// Then
+			tt.then(mock, entry, err)
+		})
+	}
+}
+
+func TestUserAuditDB_FindByUserID(t *testing.T) {
+	// Given
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := sqlmock.NewRows([]string{"id", "user_id", "action", "actor", "actor_id", "before", "after", "request_id"}).
+		AddRow(1, 1, "create", "john", 1, nil, `{"id":1,"name":"John","surname":"Doe","version":1}`, "3f5c1a7e-request").
+		AddRow(2, 1, "purge", "john", 1, `{"id":1,"name":"John","surname":"Doe","version":1}`, nil, "5b7e9d1f-request")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_audit" WHERE user_id = $1 ORDER BY id`)).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	// When
+	history, err := NewUserAuditDB(db).FindByUserID(context.Background(), 1)
+
+	// Then
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, entity.UserAuditCreate, history[0].Action)
+		assert.Nil(t, history[0].Before)
+		assert.Equal(t, &entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}, history[0].After)
+		assert.Equal(t, entity.UserAuditPurge, history[1].Action)
+		assert.Equal(t, &entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}, history[1].Before)
+		assert.Nil(t, history[1].After)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
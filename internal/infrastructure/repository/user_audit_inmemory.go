@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// UserAuditInMemoryEntity represents a change of a user in the in-memory database
+type UserAuditInMemoryEntity struct {
+	ID         uint
+	UserID     uint
+	Action     entity.UserAuditAction
+	Actor      string
+	ActorID    uint
+	Before     *UserInMemoryEntity
+	After      *UserInMemoryEntity
+	RequestID  string
+	OccurredAt time.Time
+}
+
+// UserAuditInMemory represents a user audit repository in the in-memory database.
+// The changes are appended in the order they occurred, and never changed afterwards.
+type UserAuditInMemory struct {
+	mu      sync.RWMutex
+	entries []UserAuditInMemoryEntity
+}
+
+// NewUserAuditInMemory creates a new instance of repository.UserAuditInMemory
+func NewUserAuditInMemory() repository.UserAudit {
+	return &UserAuditInMemory{}
+}
+
+// Append records a change of a user
+func (r *UserAuditInMemory) Append(ctx context.Context, entry entity.UserAuditEntry) (entity.UserAuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = uint(len(r.entries) + 1)
+	entry.OccurredAt = time.Now()
+	auditEntity := UserAuditInMemoryEntity{}.fromEntityUserAuditEntry(entry)
+	r.entries = append(r.entries, auditEntity)
+
+	return auditEntity.toEntityUserAuditEntry(), nil
+}
+
+// FindByUserID returns the changes of a user in the order they occurred
+func (r *UserAuditInMemory) FindByUserID(ctx context.Context, userID uint) ([]entity.UserAuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]entity.UserAuditEntry, 0)
+	for _, e := range r.entries {
+		if e.UserID == userID {
+			entries = append(entries, e.toEntityUserAuditEntry())
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAuditInMemory_AppendAndFind(t *testing.T) {
+	repo := NewUserAuditInMemory()
+	created := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}
+	first, err := repo.Append(context.Background(), entity.UserAuditEntry{UserID: 1, Action: entity.UserAuditCreate, After: &created})
+	require.NoError(t, err)
+	_, err = repo.Append(context.Background(), entity.UserAuditEntry{UserID: 2, Action: entity.UserAuditCreate})
+	require.NoError(t, err)
+	second, err := repo.Append(context.Background(), entity.UserAuditEntry{UserID: 1, Action: entity.UserAuditPurge, Before: &created})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(1), first.ID)
+	assert.Equal(t, uint(3), second.ID)
+	assert.False(t, first.OccurredAt.IsZero())
+	assert.False(t, second.OccurredAt.Before(first.OccurredAt))
+
+	history, err := repo.FindByUserID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.UserAuditEntry{first, second}, history)
+
+	history, err = repo.FindByUserID(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
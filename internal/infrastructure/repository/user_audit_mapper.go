@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// toEntityUserAuditEntry converts a UserAuditDBEntity to an entity.UserAuditEntry
+func (ab UserAuditDBEntity) toEntityUserAuditEntry() entity.UserAuditEntry {
+	return entity.UserAuditEntry{
+		ID:         ab.ID,
+		UserID:     ab.UserID,
+		Action:     entity.UserAuditAction(ab.Action),
+		Actor:      ab.Actor,
+		ActorID:    ab.ActorID,
+		Before:     ab.Before.toEntityUser(),
+		After:      ab.After.toEntityUser(),
+		RequestID:  ab.RequestID,
+		OccurredAt: ab.OccurredAt,
+	}
+}
+
+// fromEntityUserAuditEntry converts an entity.UserAuditEntry to a UserAuditDBEntity
+func (ab UserAuditDBEntity) fromEntityUserAuditEntry(a entity.UserAuditEntry) UserAuditDBEntity {
+	ab.ID = a.ID
+	ab.UserID = a.UserID
+	ab.Action = string(a.Action)
+	ab.Actor = a.Actor
+	ab.ActorID = a.ActorID
+	ab.Before = fromEntityUserSnapshot(a.Before)
+	ab.After = fromEntityUserSnapshot(a.After)
+	ab.RequestID = a.RequestID
+	ab.OccurredAt = a.OccurredAt
+	return ab
+}
+
+// toEntityUser converts a UserSnapshotDBEntity to an entity.User, or nil to nil
+func (sb *UserSnapshotDBEntity) toEntityUser() *entity.User {
+	if sb == nil {
+		return nil
+	}
+	user := entity.User{
+		ID:        sb.ID,
+		Name:      sb.Name,
+		Surname:   sb.Surname,
+		Roles:     sb.Roles,
+		Version:   sb.Version,
+		CreatedAt: sb.CreatedAt,
+		UpdatedAt: sb.UpdatedAt,
+	}
+	if sb.DeletedAt != nil {
+		user.DeletedAt = *sb.DeletedAt
+	}
+	return &user
+}
+
+// fromEntityUserSnapshot converts an entity.User to a UserSnapshotDBEntity, or nil to nil
+func fromEntityUserSnapshot(u *entity.User) *UserSnapshotDBEntity {
+	if u == nil {
+		return nil
+	}
+	snapshot := UserSnapshotDBEntity{
+		ID:        u.ID,
+		Name:      u.Name,
+		Surname:   u.Surname,
+		Roles:     u.Roles,
+		Version:   u.Version,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+	if u.Deleted() {
+		snapshot.DeletedAt = &u.DeletedAt
+	}
+	return &snapshot
+}
+
+// toEntityUserAuditEntry converts a UserAuditInMemoryEntity to an entity.UserAuditEntry
+func (am UserAuditInMemoryEntity) toEntityUserAuditEntry() entity.UserAuditEntry {
+	return entity.UserAuditEntry{
+		ID:         am.ID,
+		UserID:     am.UserID,
+		Action:     am.Action,
+		Actor:      am.Actor,
+		ActorID:    am.ActorID,
+		Before:     toEntityUserInMemorySnapshot(am.Before),
+		After:      toEntityUserInMemorySnapshot(am.After),
+		RequestID:  am.RequestID,
+		OccurredAt: am.OccurredAt,
+	}
+}
+
+// fromEntityUserAuditEntry converts an entity.UserAuditEntry to a UserAuditInMemoryEntity
+func (am UserAuditInMemoryEntity) fromEntityUserAuditEntry(a entity.UserAuditEntry) UserAuditInMemoryEntity {
+	am.ID = a.ID
+	am.UserID = a.UserID
+	am.Action = a.Action
+	am.Actor = a.Actor
+	am.ActorID = a.ActorID
+	am.Before = fromEntityUserInMemorySnapshot(a.Before)
+	am.After = fromEntityUserInMemorySnapshot(a.After)
+	am.RequestID = a.RequestID
+	am.OccurredAt = a.OccurredAt
+	return am
+}
+
+// toEntityUserInMemorySnapshot converts a snapshot UserInMemoryEntity to an entity.User, or nil to nil
+func toEntityUserInMemorySnapshot(um *UserInMemoryEntity) *entity.User {
+	if um == nil {
+		return nil
+	}
+	user := um.toEntityUser()
+	return &user
+}
+
+// fromEntityUserInMemorySnapshot converts an entity.User to a snapshot UserInMemoryEntity, or nil to nil
+func fromEntityUserInMemorySnapshot(u *entity.User) *UserInMemoryEntity {
+	if u == nil {
+		return nil
+	}
+	snapshot := UserInMemoryEntity{}.fromEntityUser(*u)
+	return &snapshot
+}
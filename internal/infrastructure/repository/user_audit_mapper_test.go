@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntityUserAuditEntry() entity.UserAuditEntry {
+	createdAt := time.Now().Add(-time.Hour)
+	return entity.UserAuditEntry{
+		ID:     1,
+		UserID: 2,
+		Action: entity.UserAuditDelete,
+		Actor:  "john",
+		Before: &entity.User{ID: 2, Name: "Jane", Surname: "Doe", Roles: []string{"admin"}, Version: 1,
+			CreatedAt: createdAt, UpdatedAt: createdAt},
+		After: &entity.User{ID: 2, Name: "Jane", Surname: "Doe", Roles: []string{"admin"}, Version: 2,
+			CreatedAt: createdAt, UpdatedAt: time.Now(), DeletedAt: time.Now()},
+		RequestID:  "3f5c1a7e-request",
+		OccurredAt: time.Now(),
+	}
+}
+
+func TestUserAuditDBEntity_Mapping(t *testing.T) {
+	entry := newEntityUserAuditEntry()
+	auditEntity := UserAuditDBEntity{}.fromEntityUserAuditEntry(entry)
+	assert.Equal(t, entry, auditEntity.toEntityUserAuditEntry())
+
+	// a creation has no user before it
+	entry.Before = nil
+	auditEntity = UserAuditDBEntity{}.fromEntityUserAuditEntry(entry)
+	assert.Nil(t, auditEntity.Before)
+	assert.Equal(t, entry, auditEntity.toEntityUserAuditEntry())
+}
+
+func TestUserAuditInMemoryEntity_Mapping(t *testing.T) {
+	entry := newEntityUserAuditEntry()
+	auditEntity := UserAuditInMemoryEntity{}.fromEntityUserAuditEntry(entry)
+	assert.Equal(t, entry, auditEntity.toEntityUserAuditEntry())
+
+	// the snapshots are not shared with the stored entity
+	entry.Before.Roles[0] = "guest"
+	assert.Equal(t, []string{"admin"}, auditEntity.Before.Roles)
+}
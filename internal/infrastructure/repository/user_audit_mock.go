@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserAudit is a mock implementation of repository.UserAudit by using testify mock.Mock
+type MockUserAudit struct {
+	mock.Mock
+}
+
+func NewMockUserAudit() *MockUserAudit {
+	return &MockUserAudit{}
+}
+
+func (m *MockUserAudit) Append(ctx context.Context, entry entity.UserAuditEntry) (entity.UserAuditEntry, error) {
+	args := m.Called(ctx, entry)
+	return args.Get(0).(entity.UserAuditEntry), args.Error(1)
+}
+
+func (m *MockUserAudit) FindByUserID(ctx context.Context, userID uint) ([]entity.UserAuditEntry, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.UserAuditEntry), args.Error(1)
+}
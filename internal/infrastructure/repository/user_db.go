@@ -2,17 +2,24 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserDBEntity represents a user entity in the database
 type UserDBEntity struct {
-	ID      uint   `json:"id" gorm:"unique;not null"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
+	ID      uint     `json:"id" gorm:"unique;not null;index:idx_users_name_id,priority:2;index:idx_users_surname_id,priority:2"`
+	Name    string   `json:"name" gorm:"index:idx_users_name_id,priority:1"`
+	Surname string   `json:"surname" gorm:"index:idx_users_surname_id,priority:1"`
+	Roles   []string `json:"roles" gorm:"serializer:json"`
+	Version uint     `json:"version" gorm:"not null;default:1"`
 
 	gorm.Model
 }
@@ -22,6 +29,34 @@ func (UserDBEntity) TableName() string {
 	return "users"
 }
 
+// UserCredentialsDBEntity represents the login credentials of a user in the database
+type UserCredentialsDBEntity struct {
+	UserID       uint   `gorm:"not null;index"`
+	Username     string `gorm:"size:255;uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+
+	gorm.Model
+}
+
+// TableName overrides the table name used by UserCredentialsDBEntity to `user_credentials`
+func (UserCredentialsDBEntity) TableName() string {
+	return "user_credentials"
+}
+
+// UserIdentityDBEntity represents the link between a user and an external identity in the database
+type UserIdentityDBEntity struct {
+	UserID  uint   `gorm:"not null;index"`
+	Issuer  string `gorm:"size:255;not null;uniqueIndex:idx_user_identities_issuer_subject"`
+	Subject string `gorm:"size:255;not null;uniqueIndex:idx_user_identities_issuer_subject"`
+
+	gorm.Model
+}
+
+// TableName overrides the table name used by UserIdentityDBEntity to `user_identities`
+func (UserIdentityDBEntity) TableName() string {
+	return "user_identities"
+}
+
 type UserDB struct {
 	DB *gorm.DB
 }
@@ -31,53 +66,374 @@ func NewUserDB(DB *gorm.DB) repository.User {
 	return &UserDB{DB}
 }
 
-// FindAll returns all users
-func (r *UserDB) FindAll(ctx context.Context) ([]entity.User, error) {
+// db returns the transaction of the given context if any, or else the database
+func (r *UserDB) db(ctx context.Context) *gorm.DB {
+	return dbOf(ctx, r.DB)
+}
+
+// userDBColumns maps the fields of the users to their columns in the database
+var userDBColumns = map[entity.UserField]string{
+	entity.UserFieldID:      "id",
+	entity.UserFieldName:    "name",
+	entity.UserFieldSurname: "surname",
+}
+
+// likeEscaper escapes the wildcards of the LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// like returns the LIKE condition of the given column in the given statement, whose wildcards are escaped by
+// likeEscaper. The backslash is the escape character of Postgres and MySQL, but it must be declared to SQLite.
+func like(tx *gorm.DB, column string) string {
+	if tx.Dialector.Name() == "sqlite" {
+		return column + ` LIKE ? ESCAPE '\'`
+	}
+	return column + " LIKE ?"
+}
+
+// userSearchDocument is the text of the users matched by the search, lowercase and without accents, as indexed by the
+// migrations of Postgres
+const userSearchDocument = "users_search_text(name || ' ' || surname)"
+
+// FindAll returns the page of the users selected by the given query
+func (r *UserDB) FindAll(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	tx := r.db(ctx).Model(&UserDBEntity{})
+	if query.IncludeDeleted {
+		tx = tx.Unscoped()
+	}
+	for _, f := range query.Filters {
+		column, ok := userDBColumns[f.Field]
+		if !ok {
+			return entity.UserPage{}, errors.Wrapf(domerrors.ErrInvalidUserQuery, "cannot filter by %q", f.Field)
+		}
+		switch f.Mode {
+		case entity.MatchPrefix:
+			tx = tx.Where(like(tx, column), likeEscaper.Replace(f.Value)+"%")
+		case entity.MatchContains:
+			tx = tx.Where(like(tx, column), "%"+likeEscaper.Replace(f.Value)+"%")
+		default:
+			tx = tx.Where(column+" = ?", f.Value)
+		}
+	}
+	column, ok := userDBColumns[query.SortBy]
+	if !ok {
+		return entity.UserPage{}, errors.Wrapf(domerrors.ErrInvalidUserQuery, "cannot sort by %q", query.SortBy)
+	}
+	desc := query.Direction == entity.SortDesc
+
+	var total int64
+	if query.After == nil {
+		// the filtered statement is shared by the count and the select of the page
+		tx = tx.Session(&gorm.Session{})
+		if err := tx.Count(&total).Error; err != nil {
+			return entity.UserPage{}, err
+		}
+	} else {
+		// the page starts right after the cursor, which is served by the (column, id) indexes
+		op := " > "
+		if desc {
+			op = " < "
+		}
+		if column == "id" {
+			tx = tx.Where("id"+op+"?", query.After.ID)
+		} else {
+			tx = tx.Where("("+column+", id)"+op+"(?, ?)", query.After.Value, query.After.ID)
+		}
+	}
+
+	tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	if column != "id" {
+		tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+	}
+
+	// one more user is read to know whether more users follow the page
 	var userEntities []UserDBEntity
-	err := r.DB.Find(&userEntities).Error
+	err := tx.Limit(query.Limit + 1).Offset(query.Offset).Find(&userEntities).Error
+	if err != nil {
+		return entity.UserPage{}, err
+	}
 
-	users := make([]entity.User, 0, len(userEntities))
-	for _, e := range userEntities {
-		users = append(users, e.toEntityUser())
+	page := entity.UserPage{Users: make([]entity.User, 0, min(len(userEntities), query.Limit)), Total: total}
+	for i, e := range userEntities {
+		if i == query.Limit {
+			next := query.CursorAt(page.Users[i-1])
+			page.Next = &next
+			break
+		}
+		page.Users = append(page.Users, e.toEntityUser())
 	}
 
-	return users, err
+	return page, nil
 }
 
-// FindByID returns a user by ID
+// FindByID returns a user by ID, unless it is deleted
 func (r *UserDB) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	return r.find(r.db(ctx), id)
+}
+
+// FindDeletedByID returns a deleted user by ID
+func (r *UserDB) FindDeletedByID(ctx context.Context, id uint) (entity.User, error) {
+	return r.find(deletedUsers(r.db(ctx)), id)
+}
+
+// deletedUsers scopes the given statement to the deleted users, which are left out of the statements by default
+func deletedUsers(tx *gorm.DB) *gorm.DB {
+	return tx.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+// find returns the user of the given ID in the users of the given statement
+func (r *UserDB) find(tx *gorm.DB, id uint) (entity.User, error) {
 	var userEntity UserDBEntity
-	err := r.DB.First(&userEntity, id).Error
+	err := tx.First(&userEntity, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.User{}, domerrors.ErrUserNotFound
+		}
+		return entity.User{}, err
+	}
 
-	return userEntity.toEntityUser(), err
+	return userEntity.toEntityUser(), nil
 }
 
-// Create creates a user
+// Create creates a user at version 1 with the given ID, or with the next one if not given
 func (r *UserDB) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	return r.save(ctx, user)
+	userEntity := UserDBEntity{}.fromEntityUser(user)
+	userEntity.Version = 1
+	err := r.db(ctx).Create(&userEntity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return entity.User{}, domerrors.ErrUserAlreadyExists
+		}
+		return entity.User{}, err
+	}
+
+	return userEntity.toEntityUser(), nil
 }
 
-// Modify modifies a user
+// Modify modifies an existing user at the given version, and increments its version
 func (r *UserDB) Modify(ctx context.Context, user entity.User) (entity.User, error) {
-	return r.save(ctx, user)
+	userEntity := UserDBEntity{}.fromEntityUser(user)
+	userEntity.Version = user.Version + 1
+	result := r.db(ctx).Model(&userEntity).
+		Where("version = ?", user.Version).
+		Select("name", "surname", "roles", "version").
+		Updates(&userEntity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return entity.User{}, domerrors.ErrUserAlreadyExists
+		}
+		return entity.User{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.User{}, r.missingOrModified(r.db(ctx), user.ID)
+	}
+
+	return userEntity.toEntityUser(), nil
+}
+
+// Delete deletes an existing user at the given version, keeping it until it is purged, and increments its version
+func (r *UserDB) Delete(ctx context.Context, user entity.User) error {
+	userEntity := UserDBEntity{}.fromEntityUser(user)
+	now := time.Now()
+	result := r.db(ctx).Model(&userEntity).
+		Where("version = ?", user.Version).
+		Updates(map[string]any{"version": user.Version + 1, "updated_at": now, "deleted_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrModified(r.db(ctx), user.ID)
+	}
+
+	return nil
 }
 
-// save saves a user
-func (r *UserDB) save(ctx context.Context, user entity.User) (entity.User, error) {
-	userEntity := UserDBEntity{}
-	userEntity = userEntity.fromEntityUser(user)
-	err := r.DB.Save(&userEntity).Error
+// Restore restores the given deleted user at its version, and increments its version
+func (r *UserDB) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	userEntity := UserDBEntity{}.fromEntityUser(user)
+	now := time.Now()
+	result := deletedUsers(r.db(ctx)).Model(&userEntity).
+		Where("version = ?", user.Version).
+		Updates(map[string]any{"version": user.Version + 1, "updated_at": now, "deleted_at": nil})
+	if result.Error != nil {
+		return entity.User{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.User{}, r.missingOrModified(deletedUsers(r.db(ctx)), user.ID)
+	}
+
+	user.Version++
+	user.UpdatedAt = now
+	user.DeletedAt = time.Time{}
+	return user, nil
+}
+
+// Purge permanently deletes a user at the given version, deleted or not, along with its credentials and identities
+func (r *UserDB) Purge(ctx context.Context, user entity.User) error {
+	userEntity := UserDBEntity{}.fromEntityUser(user)
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("version = ?", user.Version).Delete(&userEntity)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&UserCredentialsDBEntity{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&UserIdentityDBEntity{}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.missingOrModified(r.db(ctx).Unscoped(), user.ID)
+	}
+
+	return err
+}
+
+// missingOrModified returns the error of a conditional write of the user of the given ID which changed no row:
+// errors.ErrUserNotFound if the user is not one of the users of the given statement, or else
+// errors.ErrConcurrentModification
+func (r *UserDB) missingOrModified(tx *gorm.DB, id uint) error {
+	if _, err := r.find(tx, id); err != nil {
+		return err
+	}
+	return domerrors.ErrConcurrentModification
+}
+
+// Search returns at most limit users whose name or surname match every term of the given query, ignoring accents
+// and case, sorted by relevance and then by ID.
+// The terms match the prefixes of the words through the full-text index and any part of the words through the
+// trigram index, and the users are ranked by both of them, as created by the migrations of Postgres.
+// In the databases other than Postgres, the users are matched by searchLike instead.
+func (r *UserDB) Search(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []entity.User{}, nil
+	}
+	if r.DB.Dialector.Name() != "postgres" {
+		return r.searchLike(ctx, terms, limit)
+	}
+
+	prefixes := make([]string, 0, len(terms))
+	contains := make([]string, 0, len(terms))
+	patterns := make([]interface{}, 0, len(terms))
+	for _, t := range terms {
+		prefixes = append(prefixes, t+":*")
+		contains = append(contains, userSearchDocument+" LIKE users_search_text(?)")
+		// the terms are made of letters and digits, so they are free of LIKE wildcards and tsquery operators
+		patterns = append(patterns, "%"+t+"%")
+	}
+	tsquery := strings.Join(prefixes, " & ")
+	text := strings.Join(terms, " ")
+
+	var userEntities []UserDBEntity
+	err := r.db(ctx).
+		Where("to_tsvector('simple', "+userSearchDocument+") @@ to_tsquery('simple', users_search_text(?)) OR ("+
+			strings.Join(contains, " AND ")+")", append([]interface{}{tsquery}, patterns...)...).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL: "ts_rank(to_tsvector('simple', " + userSearchDocument + "), to_tsquery('simple', users_search_text(?))) + " +
+				"similarity(" + userSearchDocument + ", users_search_text(?)) DESC, id",
+			Vars:               []interface{}{tsquery, text},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&userEntities).Error
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]entity.User, 0, len(userEntities))
+	for _, e := range userEntities {
+		users = append(users, e.toEntityUser())
+	}
+
+	return users, nil
+}
+
+// searchLike returns at most limit users whose name or surname contain every given term, sorted by ID. The terms are
+// matched regardless of the case of the ASCII letters, as far as the database supports it, but not of the accents,
+// and the users are not ranked, since there is no index of their words.
+func (r *UserDB) searchLike(ctx context.Context, terms []string, limit int) ([]entity.User, error) {
+	tx := r.db(ctx)
+	for _, t := range terms {
+		// the terms are made of letters and digits, so they are free of LIKE wildcards
+		pattern := "%" + t + "%"
+		tx = tx.Where(like(tx, "LOWER(name)")+" OR "+like(tx, "LOWER(surname)"), pattern, pattern)
+	}
+
+	var userEntities []UserDBEntity
+	if err := tx.Order("id").Limit(limit).Find(&userEntities).Error; err != nil {
+		return nil, err
+	}
+
+	users := make([]entity.User, 0, len(userEntities))
+	for _, e := range userEntities {
+		users = append(users, e.toEntityUser())
+	}
+
+	return users, nil
+}
+
+// FindCredentialsByUsername returns the credentials of the given username
+func (r *UserDB) FindCredentialsByUsername(ctx context.Context, username string) (entity.Credentials, error) {
+	var credentialsEntity UserCredentialsDBEntity
+	err := r.db(ctx).Where("username = ?", username).First(&credentialsEntity).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Credentials{}, domerrors.ErrUserNotFound
+		}
+		return entity.Credentials{}, err
+	}
+
+	return credentialsEntity.toEntityCredentials(), nil
+}
+
+// FindByIdentity returns the user linked to the given issuer and subject
+func (r *UserDB) FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	var identityEntity UserIdentityDBEntity
+	err := r.db(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identityEntity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.User{}, domerrors.ErrUserNotFound
+		}
+		return entity.User{}, err
+	}
+
+	var userEntity UserDBEntity
+	err = r.db(ctx).First(&userEntity, identityEntity.UserID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.User{}, domerrors.ErrUserNotFound
+		}
 		return entity.User{}, err
 	}
 
 	return userEntity.toEntityUser(), nil
 }
 
-// Delete deletes a user
-func (r *UserDB) Delete(ctx context.Context, user entity.User) error {
-	userEntity := UserDBEntity{}
-	userEntity = userEntity.fromEntityUser(user)
+// CreateWithIdentity creates a user linked to the given external identity
+func (r *UserDB) CreateWithIdentity(ctx context.Context, user entity.User, identity entity.ExternalIdentity) (entity.User, error) {
+	userEntity := UserDBEntity{}.fromEntityUser(user)
+	userEntity.Version = 1
+
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&userEntity).Error; err != nil {
+			return err
+		}
 
-	return r.DB.Delete(&userEntity).Error
+		return tx.Create(&UserIdentityDBEntity{
+			UserID:  userEntity.ID,
+			Issuer:  identity.Issuer,
+			Subject: identity.Subject,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return entity.User{}, domerrors.ErrUserAlreadyExists
+		}
+		return entity.User{}, err
+	}
+
+	return userEntity.toEntityUser(), nil
 }
@@ -3,23 +3,29 @@ package repository
 import (
 	"context"
 	"database/sql/driver"
+	"path/filepath"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestUserDB_FindAll(t *testing.T) {
+	defaultQuery := entity.UserQuery{Limit: entity.DefaultUserPageLimit, SortBy: entity.UserFieldID, Direction: entity.SortAsc}
+
 	tests := []struct {
 		name  string
 		given func() (repository.User, sqlmock.Sqlmock)
-		when  func(r repository.User) ([]entity.User, error)
-		then  func(sqlmock.Sqlmock, []entity.User, error)
+		when  func(r repository.User) (entity.UserPage, error)
+		then  func(sqlmock.Sqlmock, entity.UserPage, error)
 	}{
 		{
 			name: "should find all users",
@@ -34,16 +40,21 @@ func TestUserDB_FindAll(t *testing.T) {
 					AddRow(2, "Jane", "Doe").
 					AddRow(3, "Alice", "Smith")
 
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE "users"."deleted_at" IS NULL`)).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL ORDER BY "id" LIMIT $1`)).
+					WithArgs(entity.DefaultUserPageLimit + 1).
 					WillReturnRows(rows)
 
 				return NewUserDB(db), mock
 			},
-			when: func(r repository.User) ([]entity.User, error) {
-				return r.FindAll(context.Background())
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), defaultQuery)
 			},
-			then: func(mock sqlmock.Sqlmock, users []entity.User, err error) {
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
 				assert.NoError(t, err)
+				assert.Equal(t, int64(3), page.Total)
+				users := page.Users
 				assert.Len(t, users, 3)
 				assert.Equal(t, "John", users[0].Name)
 				assert.Equal(t, "Doe", users[0].Surname)
@@ -51,6 +62,137 @@ func TestUserDB_FindAll(t *testing.T) {
 				assert.Equal(t, "Doe", users[1].Surname)
 				assert.Equal(t, "Alice", users[2].Name)
 				assert.Equal(t, "Smith", users[2].Surname)
+				assert.Nil(t, page.Next)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should find a filtered and sorted page of users",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE name = $1 AND surname LIKE $2 AND surname LIKE $3 AND "users"."deleted_at" IS NULL`)).
+					WithArgs("Jane", `D\_%`, `%o\%e%`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE name = $1 AND surname LIKE $2 AND surname LIKE $3 AND "users"."deleted_at" IS NULL ORDER BY "surname" DESC,"id" DESC LIMIT $4 OFFSET $5`)).
+					WithArgs("Jane", `D\_%`, `%o\%e%`, 3, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname"}).
+						AddRow(5, "Jane", "D_o%ey").
+						AddRow(2, "Jane", "D_o%e").
+						AddRow(4, "Jane", "D_o%e"))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), entity.UserQuery{
+					Limit:     2,
+					Offset:    2,
+					SortBy:    entity.UserFieldSurname,
+					Direction: entity.SortDesc,
+					Filters: []entity.UserFilter{
+						{Field: entity.UserFieldName, Mode: entity.MatchExact, Value: "Jane"},
+						{Field: entity.UserFieldSurname, Mode: entity.MatchPrefix, Value: "D_"},
+						{Field: entity.UserFieldSurname, Mode: entity.MatchContains, Value: "o%e"},
+					},
+				})
+			},
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.UserPage{
+					Users: []entity.User{{ID: 5, Name: "Jane", Surname: "D_o%ey"}, {ID: 2, Name: "Jane", Surname: "D_o%e"}},
+					Total: 3,
+					Next:  &entity.UserCursor{SortBy: entity.UserFieldSurname, Direction: entity.SortDesc, Value: "D_o%e", ID: 2},
+				}, page)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should find the page after a cursor without counting the users",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE (name, id) < ($1, $2) AND "users"."deleted_at" IS NULL ORDER BY "name" DESC,"id" DESC LIMIT $3`)).
+					WithArgs("Jane", 2, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname"}).AddRow(3, "Alice", "Smith"))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), entity.UserQuery{
+					Limit:     1,
+					SortBy:    entity.UserFieldName,
+					Direction: entity.SortDesc,
+					After:     &entity.UserCursor{SortBy: entity.UserFieldName, Direction: entity.SortDesc, Value: "Jane", ID: 2},
+				})
+			},
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.UserPage{Users: []entity.User{{ID: 3, Name: "Alice", Surname: "Smith"}}}, page)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should find the page after a cursor sorted by ID",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id > $1 AND "users"."deleted_at" IS NULL ORDER BY "id" LIMIT $2`)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname"}).
+						AddRow(2, "Jane", "Doe").
+						AddRow(3, "Alice", "Smith"))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), entity.UserQuery{
+					Limit:     1,
+					SortBy:    entity.UserFieldID,
+					Direction: entity.SortAsc,
+					After:     &entity.UserCursor{SortBy: entity.UserFieldID, Direction: entity.SortAsc, ID: 1},
+				})
+			},
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.UserPage{
+					Users: []entity.User{{ID: 2, Name: "Jane", Surname: "Doe"}},
+					Next:  &entity.UserCursor{SortBy: entity.UserFieldID, Direction: entity.SortAsc, ID: 2},
+				}, page)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not find users by an unknown field",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), entity.UserQuery{
+					Limit:   1,
+					SortBy:  entity.UserFieldID,
+					Filters: []entity.UserFilter{{Field: "password", Mode: entity.MatchExact, Value: "secret"}},
+				})
+			},
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrInvalidUserQuery)
+				assert.Len(t, page.Users, 0)
 
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -63,17 +205,42 @@ func TestUserDB_FindAll(t *testing.T) {
 					t.Fatal(err)
 				}
 
-				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL`)).
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE "users"."deleted_at" IS NULL`)).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL ORDER BY "id" LIMIT $1`)).
 					WillReturnError(errors.New("not found"))
 
 				return NewUserDB(db), mock
 			},
-			when: func(r repository.User) ([]entity.User, error) {
-				return r.FindAll(context.Background())
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), defaultQuery)
 			},
-			then: func(mock sqlmock.Sqlmock, users []entity.User, err error) {
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
+				assert.Error(t, err)
+				assert.Len(t, page.Users, 0)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not count users",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE "users"."deleted_at" IS NULL`)).
+					WillReturnError(errors.New("error"))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.UserPage, error) {
+				return r.FindAll(context.Background(), defaultQuery)
+			},
+			then: func(mock sqlmock.Sqlmock, page entity.UserPage, err error) {
 				assert.Error(t, err)
-				assert.Len(t, users, 0)
+				assert.Equal(t, entity.UserPage{}, page)
 
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -85,10 +252,10 @@ func TestUserDB_FindAll(t *testing.T) {
 			repo, mock := tt.given()
 
 			// When
-			users, err := tt.when(repo)
+			page, err := tt.when(repo)
 
 			// Then
-			tt.then(mock, users, err)
+			tt.then(mock, page, err)
 		})
 	}
 }
@@ -108,10 +275,10 @@ func TestUserDB_FindByID(t *testing.T) {
 					t.Fatal(err)
 				}
 
-				rows := sqlmock.NewRows([]string{"id", "name", "surname"}).
-					AddRow(1, "John", "Doe").
-					AddRow(2, "Jane", "Doe").
-					AddRow(3, "Alice", "Smith")
+				rows := sqlmock.NewRows([]string{"id", "name", "surname", "roles"}).
+					AddRow(1, "John", "Doe", `["admin"]`).
+					AddRow(2, "Jane", "Doe", nil).
+					AddRow(3, "Alice", "Smith", nil)
 
 				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
 					WithArgs(1, 1).
@@ -126,6 +293,7 @@ func TestUserDB_FindByID(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, "John", user.Name)
 				assert.Equal(t, "Doe", user.Surname)
+				assert.Equal(t, []string{entity.RoleAdmin}, user.Roles)
 
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -151,6 +319,30 @@ func TestUserDB_FindByID(t *testing.T) {
 				assert.Error(t, err)
 				assert.Empty(t, user)
 
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should return user not found when there is no row",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname"}))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.User, error) {
+				return r.FindByID(context.Background(), 1)
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, user)
+
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
 		},
@@ -195,8 +387,8 @@ func TestUserDB_Create(t *testing.T) {
 				}
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?)")).
-					WithArgs("John", "Doe", AnyTime{}, AnyTime{}, nil).
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
+					WithArgs("John", "Doe", nil, 1, AnyTime{}, AnyTime{}, nil).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
@@ -217,6 +409,32 @@ func TestUserDB_Create(t *testing.T) {
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
 		},
+		{
+			name: "should not create a user with the ID of another one",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?)")).
+					WithArgs("John", "Doe", nil, 1, AnyTime{}, AnyTime{}, nil, 1).
+					WillReturnError(gorm.ErrDuplicatedKey)
+				mock.ExpectRollback()
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.User, error) {
+				return r.Create(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserAlreadyExists)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
 		{
 			name: "should not create user",
 			given: func() (repository.User, sqlmock.Sqlmock) {
@@ -228,8 +446,8 @@ func TestUserDB_Create(t *testing.T) {
 				}
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?)")).
-					WithArgs("John", "Doe", AnyTime{}, AnyTime{}, nil).
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
+					WithArgs("John", "Doe", nil, 1, AnyTime{}, AnyTime{}, nil).
 					WillReturnError(errors.New("failed to create user"))
 				mock.ExpectRollback()
 
@@ -284,15 +502,15 @@ func TestUserDB_Modify(t *testing.T) {
 				}
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name`=?,`surname`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `users`.`deleted_at` IS NULL AND `id` = ?")).
-					WithArgs("John", "Doe", AnyTime{}, AnyTime{}, nil, 1).
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name`=?,`surname`=?,`roles`=?,`version`=?,`updated_at`=? WHERE version = ? AND `users`.`deleted_at` IS NULL AND `id` = ?")).
+					WithArgs("John", "Doe", nil, 2, AnyTime{}, 1, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return NewUserDB(db), mock
 			},
 			when: func(r repository.User) (entity.User, error) {
-				return r.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+				return r.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
 			},
 			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
 				assert.NoError(t, err)
@@ -302,6 +520,64 @@ func TestUserDB_Modify(t *testing.T) {
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
 		},
+		{
+			name: "should not modify a missing user",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name`=?,`surname`=?,`roles`=?,`version`=?,`updated_at`=? WHERE version = ? AND `users`.`deleted_at` IS NULL AND `id` = ?")).
+					WithArgs("John", "Doe", nil, 2, AnyTime{}, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL ORDER BY `users`.`id` LIMIT ?")).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "version"}))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.User, error) {
+				return r.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not modify a user modified since the given version",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name`=?,`surname`=?,`roles`=?,`version`=?,`updated_at`=? WHERE version = ? AND `users`.`deleted_at` IS NULL AND `id` = ?")).
+					WithArgs("John", "Doe", nil, 2, AnyTime{}, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? AND `users`.`deleted_at` IS NULL ORDER BY `users`.`id` LIMIT ?")).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "version"}).AddRow(1, "John", "Smith", 2))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) (entity.User, error) {
+				return r.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
 		{
 			name: "should not modify user",
 			given: func() (repository.User, sqlmock.Sqlmock) {
@@ -313,15 +589,15 @@ func TestUserDB_Modify(t *testing.T) {
 				}
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name`=?,`surname`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `users`.`deleted_at` IS NULL AND `id` = ?")).
-					WithArgs("John", "Doe", AnyTime{}, AnyTime{}, nil, 1).
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name`=?,`surname`=?,`roles`=?,`version`=?,`updated_at`=? WHERE version = ? AND `users`.`deleted_at` IS NULL AND `id` = ?")).
+					WithArgs("John", "Doe", nil, 2, AnyTime{}, 1, 1).
 					WillReturnError(errors.New("failed to create user"))
 				mock.ExpectRollback()
 
 				return NewUserDB(db), mock
 			},
 			when: func(r repository.User) (entity.User, error) {
-				return r.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+				return r.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
 			},
 			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
 				assert.Error(t, err)
@@ -353,7 +629,7 @@ func TestUserDB_Delete(t *testing.T) {
 		then  func(sqlmock.Sqlmock, error)
 	}{
 		{
-			name: "should find user by ID",
+			name: "should delete user",
 			given: func() (repository.User, sqlmock.Sqlmock) {
 				db, mock, err := newMockPostgresSqlDB()
 				if err != nil {
@@ -361,15 +637,15 @@ func TestUserDB_Delete(t *testing.T) {
 				}
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2 AND "users"."deleted_at" IS NULL`)).
-					WithArgs(AnyTime{}, 1).
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2,"version"=$3 WHERE version = $4 AND "users"."deleted_at" IS NULL AND "id" = $5`)).
+					WithArgs(AnyTime{}, AnyTime{}, 2, 1, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return NewUserDB(db), mock
 			},
 			when: func(r repository.User) error {
-				return r.Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+				return r.Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
 			},
 			then: func(mock sqlmock.Sqlmock, err error) {
 				assert.NoError(t, err)
@@ -378,7 +654,63 @@ func TestUserDB_Delete(t *testing.T) {
 			},
 		},
 		{
-			name: "should not find user by ID",
+			name: "should not delete a missing user",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2,"version"=$3 WHERE version = $4 AND "users"."deleted_at" IS NULL AND "id" = $5`)).
+					WithArgs(AnyTime{}, AnyTime{}, 2, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "version"}))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) error {
+				return r.Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not delete a user modified since the given version",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2,"version"=$3 WHERE version = $4 AND "users"."deleted_at" IS NULL AND "id" = $5`)).
+					WithArgs(AnyTime{}, AnyTime{}, 2, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "version"}).AddRow(1, "John", "Smith", 2))
+
+				return NewUserDB(db), mock
+			},
+			when: func(r repository.User) error {
+				return r.Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not delete user",
 			given: func() (repository.User, sqlmock.Sqlmock) {
 				db, mock, err := newMockPostgresSqlDB()
 				if err != nil {
@@ -386,15 +718,15 @@ func TestUserDB_Delete(t *testing.T) {
 				}
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2 AND "users"."deleted_at" IS NULL`)).
-					WithArgs(AnyTime{}, 1).
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2,"version"=$3 WHERE version = $4 AND "users"."deleted_at" IS NULL AND "id" = $5`)).
+					WithArgs(AnyTime{}, AnyTime{}, 2, 1, 1).
 					WillReturnError(errors.New("not found"))
 				mock.ExpectRollback()
 
 				return NewUserDB(db), mock
 			},
 			when: func(r repository.User) error {
-				return r.Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe"})
+				return r.Delete(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
 			},
 			then: func(mock sqlmock.Sqlmock, err error) {
 				assert.Error(t, err)
@@ -416,3 +748,594 @@ func TestUserDB_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserDB_Restore(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() (repository.User, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.User, error)
+	}{
+		{
+			name: "should restore user",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2,"version"=$3 WHERE deleted_at IS NOT NULL AND version = $4 AND "id" = $5`)).
+					WithArgs(nil, AnyTime{}, 3, 2, 1).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return NewUserDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(3), user.Version)
+				assert.False(t, user.Deleted())
+				assert.False(t, user.UpdatedAt.IsZero())
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not restore a user which is not deleted",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1,"updated_at"=$2,"version"=$3 WHERE deleted_at IS NOT NULL AND version = $4 AND "id" = $5`)).
+					WithArgs(nil, AnyTime{}, 3, 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE deleted_at IS NOT NULL AND "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "version"}))
+
+				return NewUserDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			user, err := repo.Restore(context.Background(), entity.User{
+				ID: 1, Name: "John", Surname: "Doe", Version: 2, DeletedAt: time.Now(),
+			})
+
+			// Then
+			tt.then(mock, user, err)
+		})
+	}
+}
+
+func TestUserDB_Purge(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() (repository.User, sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, error)
+	}{
+		{
+			name: "should purge user along with its credentials and identities",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE version = $1 AND "users"."id" = $2`)).
+					WithArgs(2, 1).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "user_credentials" WHERE user_id = $1`)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "user_identities" WHERE user_id = $1`)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+
+				return NewUserDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.NoError(t, err)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not purge a user modified concurrently",
+			given: func() (repository.User, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE version = $1 AND "users"."id" = $2`)).
+					WithArgs(2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "version"}).AddRow(1, "John", "Doe", 3))
+
+				return NewUserDB(db), mock
+			},
+			then: func(mock sqlmock.Sqlmock, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrConcurrentModification)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			err := repo.Purge(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 2})
+
+			// Then
+			tt.then(mock, err)
+		})
+	}
+}
+
+func TestUserDB_Search(t *testing.T) {
+	const doc = "users_search_text(name || ' ' || surname)"
+
+	tests := []struct {
+		name  string
+		query string
+		given func(mock sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, []entity.User, error)
+	}{
+		{
+			name:  "should search users by the prefixes and parts of their words",
+			query: "José  d'Oe",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE `+
+					`(to_tsvector('simple', `+doc+`) @@ to_tsquery('simple', users_search_text($1)) OR `+
+					`(`+doc+` LIKE users_search_text($2) AND `+doc+` LIKE users_search_text($3) AND `+doc+` LIKE users_search_text($4))) `+
+					`AND "users"."deleted_at" IS NULL `+
+					`ORDER BY ts_rank(to_tsvector('simple', `+doc+`), to_tsquery('simple', users_search_text($5))) + `+
+					`similarity(`+doc+`, users_search_text($6)) DESC, id LIMIT $7`)).
+					WithArgs("jose:* & d:* & oe:*", "%jose%", "%d%", "%oe%", "jose:* & d:* & oe:*", "jose d oe", 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname"}).AddRow(4, "José", "D'Oe"))
+			},
+			then: func(mock sqlmock.Sqlmock, users []entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []entity.User{{ID: 4, Name: "José", Surname: "D'Oe"}}, users)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name:  "should not search users without terms",
+			query: "&|!",
+			given: func(mock sqlmock.Sqlmock) {},
+			then: func(mock sqlmock.Sqlmock, users []entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, users)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name:  "should fail searching users",
+			query: "doe",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WillReturnError(errors.New("function users_search_text(text) does not exist"))
+			},
+			then: func(mock sqlmock.Sqlmock, users []entity.User, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, users)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			db, mock, err := newMockPostgresSqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+
+			// When
+			users, err := NewUserDB(db).(repository.UserSearch).Search(context.Background(), tt.query, 10)
+
+			// Then
+			tt.then(mock, users, err)
+		})
+	}
+}
+
+func TestUserDB_Search_Like(t *testing.T) {
+	t.Run("should search users by the parts of their names in the databases other than Postgres", func(t *testing.T) {
+		db, mock, err := newMockMySqlDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE "+
+			"(LOWER(name) LIKE ? OR LOWER(surname) LIKE ?) AND (LOWER(name) LIKE ? OR LOWER(surname) LIKE ?) "+
+			"AND `users`.`deleted_at` IS NULL ORDER BY id LIMIT ?")).
+			WithArgs("%jose%", "%jose%", "%oe%", "%oe%", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname"}).AddRow(4, "Jose", "Doe"))
+
+		users, err := NewUserDB(db).(repository.UserSearch).Search(context.Background(), "José oe", 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.User{{ID: 4, Name: "Jose", Surname: "Doe"}}, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserDB_SQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&UserDBEntity{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewUserDB(db)
+	for _, u := range []entity.User{{Name: "Jane", Surname: "D_oe"}, {Name: "John", Surname: "Dxoe"}, {Name: "Ann", Surname: "Lee"}} {
+		if _, err = repo.Create(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("should escape the wildcards of the filters", func(t *testing.T) {
+		page, err := repo.FindAll(context.Background(), entity.UserQuery{
+			Limit:     10,
+			SortBy:    entity.UserFieldID,
+			Direction: entity.SortAsc,
+			Filters:   []entity.UserFilter{{Field: entity.UserFieldSurname, Value: "D_", Mode: entity.MatchPrefix}},
+		})
+
+		assert.NoError(t, err)
+		if assert.Len(t, page.Users, 1) {
+			assert.Equal(t, "D_oe", page.Users[0].Surname)
+		}
+	})
+
+	t.Run("should search users regardless of the case", func(t *testing.T) {
+		users, err := repo.(repository.UserSearch).Search(context.Background(), "J OE", 10)
+
+		assert.NoError(t, err)
+		if assert.Len(t, users, 2) {
+			assert.Equal(t, "D_oe", users[0].Surname)
+			assert.Equal(t, "Dxoe", users[1].Surname)
+		}
+	})
+}
+
+func TestUserDB_FindCredentialsByUsername(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() (repository.UserCredentials, sqlmock.Sqlmock)
+		when  func(r repository.UserCredentials) (entity.Credentials, error)
+		then  func(sqlmock.Sqlmock, entity.Credentials, error)
+	}{
+		{
+			name: "should find credentials by username",
+			given: func() (repository.UserCredentials, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rows := sqlmock.NewRows([]string{"id", "user_id", "username", "password_hash"}).
+					AddRow(1, 1, "john", "hash")
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_credentials" WHERE username = $1 AND "user_credentials"."deleted_at" IS NULL ORDER BY "user_credentials"."id" LIMIT $2`)).
+					WithArgs("john", 1).
+					WillReturnRows(rows)
+
+				return NewUserDB(db).(repository.UserCredentials), mock
+			},
+			when: func(r repository.UserCredentials) (entity.Credentials, error) {
+				return r.FindCredentialsByUsername(context.Background(), "john")
+			},
+			then: func(mock sqlmock.Sqlmock, credentials entity.Credentials, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), credentials.UserID)
+				assert.Equal(t, "john", credentials.Username)
+				assert.Equal(t, "hash", credentials.PasswordHash)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not find credentials by username",
+			given: func() (repository.UserCredentials, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_credentials" WHERE username = $1 AND "user_credentials"."deleted_at" IS NULL ORDER BY "user_credentials"."id" LIMIT $2`)).
+					WithArgs("unknown", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "username", "password_hash"}))
+
+				return NewUserDB(db).(repository.UserCredentials), mock
+			},
+			when: func(r repository.UserCredentials) (entity.Credentials, error) {
+				return r.FindCredentialsByUsername(context.Background(), "unknown")
+			},
+			then: func(mock sqlmock.Sqlmock, credentials entity.Credentials, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, credentials)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should fail finding credentials by username",
+			given: func() (repository.UserCredentials, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_credentials" WHERE username = $1 AND "user_credentials"."deleted_at" IS NULL ORDER BY "user_credentials"."id" LIMIT $2`)).
+					WithArgs("john", 1).
+					WillReturnError(errors.New("connection lost"))
+
+				return NewUserDB(db).(repository.UserCredentials), mock
+			},
+			when: func(r repository.UserCredentials) (entity.Credentials, error) {
+				return r.FindCredentialsByUsername(context.Background(), "john")
+			},
+			then: func(mock sqlmock.Sqlmock, credentials entity.Credentials, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, credentials)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			credentials, err := tt.when(repo)
+
+			// Then
+			tt.then(mock, credentials, err)
+		})
+	}
+}
+
+func TestUserDB_FindByIdentity(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() (repository.UserIdentity, sqlmock.Sqlmock)
+		when  func(r repository.UserIdentity) (entity.User, error)
+		then  func(sqlmock.Sqlmock, entity.User, error)
+	}{
+		{
+			name: "should find user by identity",
+			given: func() (repository.UserIdentity, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_identities" WHERE (issuer = $1 AND subject = $2) AND "user_identities"."deleted_at" IS NULL ORDER BY "user_identities"."id" LIMIT $3`)).
+					WithArgs("https://issuer.example.com", "external-subject", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "issuer", "subject"}).
+						AddRow(1, 2, "https://issuer.example.com", "external-subject"))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`)).
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "surname", "roles"}).
+						AddRow(2, "Jane", "Doe", nil))
+
+				return NewUserDB(db).(repository.UserIdentity), mock
+			},
+			when: func(r repository.UserIdentity) (entity.User, error) {
+				return r.FindByIdentity(context.Background(), "https://issuer.example.com", "external-subject")
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(2), user.ID)
+				assert.Equal(t, "Jane", user.Name)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not find user by unknown identity",
+			given: func() (repository.UserIdentity, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_identities" WHERE (issuer = $1 AND subject = $2) AND "user_identities"."deleted_at" IS NULL ORDER BY "user_identities"."id" LIMIT $3`)).
+					WithArgs("https://issuer.example.com", "unknown", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "issuer", "subject"}))
+
+				return NewUserDB(db).(repository.UserIdentity), mock
+			},
+			when: func(r repository.UserIdentity) (entity.User, error) {
+				return r.FindByIdentity(context.Background(), "https://issuer.example.com", "unknown")
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should fail finding user by identity",
+			given: func() (repository.UserIdentity, sqlmock.Sqlmock) {
+				db, mock, err := newMockPostgresSqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_identities" WHERE (issuer = $1 AND subject = $2) AND "user_identities"."deleted_at" IS NULL ORDER BY "user_identities"."id" LIMIT $3`)).
+					WithArgs("https://issuer.example.com", "external-subject", 1).
+					WillReturnError(errors.New("connection lost"))
+
+				return NewUserDB(db).(repository.UserIdentity), mock
+			},
+			when: func(r repository.UserIdentity) (entity.User, error) {
+				return r.FindByIdentity(context.Background(), "https://issuer.example.com", "external-subject")
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrUserNotFound)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			user, err := tt.when(repo)
+
+			// Then
+			tt.then(mock, user, err)
+		})
+	}
+}
+
+func TestUserDB_CreateWithIdentity(t *testing.T) {
+	identity := entity.ExternalIdentity{Issuer: "https://issuer.example.com", Subject: "external-subject"}
+
+	tests := []struct {
+		name  string
+		given func() (repository.UserIdentity, sqlmock.Sqlmock)
+		when  func(r repository.UserIdentity) (entity.User, error)
+		then  func(sqlmock.Sqlmock, entity.User, error)
+	}{
+		{
+			name: "should create user linked to the identity",
+			given: func() (repository.UserIdentity, sqlmock.Sqlmock) {
+				// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+				// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
+					WithArgs("John", "Doe", nil, 1, AnyTime{}, AnyTime{}, nil).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `user_identities` (`user_id`,`issuer`,`subject`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?)")).
+					WithArgs(2, identity.Issuer, identity.Subject, AnyTime{}, AnyTime{}, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return NewUserDB(db).(repository.UserIdentity), mock
+			},
+			when: func(r repository.UserIdentity) (entity.User, error) {
+				return r.CreateWithIdentity(context.Background(), entity.User{Name: "John", Surname: "Doe"}, identity)
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(2), user.ID)
+				assert.Equal(t, "John", user.Name)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not create user when the identity is already linked",
+			given: func() (repository.UserIdentity, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
+					WithArgs("John", "Doe", nil, 1, AnyTime{}, AnyTime{}, nil).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `user_identities` (`user_id`,`issuer`,`subject`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?)")).
+					WithArgs(2, identity.Issuer, identity.Subject, AnyTime{}, AnyTime{}, nil).
+					WillReturnError(gorm.ErrDuplicatedKey)
+				mock.ExpectRollback()
+
+				return NewUserDB(db).(repository.UserIdentity), mock
+			},
+			when: func(r repository.UserIdentity) (entity.User, error) {
+				return r.CreateWithIdentity(context.Background(), entity.User{Name: "John", Surname: "Doe"}, identity)
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrUserAlreadyExists)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not create user",
+			given: func() (repository.UserIdentity, sqlmock.Sqlmock) {
+				db, mock, err := newMockMySqlDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`,`surname`,`roles`,`version`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
+					WithArgs("John", "Doe", nil, 1, AnyTime{}, AnyTime{}, nil).
+					WillReturnError(errors.New("failed to create user"))
+				mock.ExpectRollback()
+
+				return NewUserDB(db).(repository.UserIdentity), mock
+			},
+			when: func(r repository.UserIdentity) (entity.User, error) {
+				return r.CreateWithIdentity(context.Background(), entity.User{Name: "John", Surname: "Doe"}, identity)
+			},
+			then: func(mock sqlmock.Sqlmock, user entity.User, err error) {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, domerrors.ErrUserAlreadyExists)
+				assert.Empty(t, user)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo, mock := tt.given()
+
+			// When
+			user, err := tt.when(repo)
+
+			// Then
+			tt.then(mock, user, err)
+		})
+	}
+}
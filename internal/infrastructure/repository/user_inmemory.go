@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
@@ -11,14 +14,68 @@ import (
 
 // UserInMemoryEntity represents a user entity in the in-memory database
 type UserInMemoryEntity struct {
-	ID      uint   `json:"id"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
+	ID      uint     `json:"id"`
+	Name    string   `json:"name"`
+	Surname string   `json:"surname"`
+	Roles   []string `json:"roles"`
+	Version uint     `json:"version"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// UserCredentialsInMemoryEntity represents the login credentials of a user in the in-memory database
+type UserCredentialsInMemoryEntity struct {
+	UserID       uint
+	Username     string
+	PasswordHash string
+}
+
+// UserIdentityInMemoryEntity represents the link between a user and an external identity in the in-memory database
+type UserIdentityInMemoryEntity struct {
+	UserID  uint
+	Issuer  string
+	Subject string
+}
+
+// userIdentityKey is the key of the UserIdentityInMemoryEntity in the in-memory database
+type userIdentityKey struct {
+	issuer  string
+	subject string
+}
+
+// userState selects the users of the in-memory database by whether they are deleted
+type userState int
+
+const (
+	activeUser userState = iota
+	deletedUser
+	anyUser
+)
+
+// holds reports whether the given user is in the state
+func (s userState) holds(e *UserInMemoryEntity) bool {
+	switch s {
+	case activeUser:
+		return e.DeletedAt.IsZero()
+	case deletedUser:
+		return !e.DeletedAt.IsZero()
+	default:
+		return true
+	}
 }
 
 // UserInMemory represents a user repository in the in-memory database
 type UserInMemory struct {
-	DB sync.Map
+	// DB holds the *UserInMemoryEntity by their IDs, which are replaced rather than changed, so that a user is only
+	// modified or deleted by a compare-and-swap on the pointer of the version it was read at
+	DB          sync.Map
+	Credentials sync.Map
+	Identities  sync.Map
+
+	// index is the full-text search index of the users, kept up to date by insert, Modify and Delete
+	index userSearchIndex
 }
 
 // NewUserInMemory creates a new instance of repository.UserInMemory
@@ -26,78 +83,334 @@ func NewUserInMemory() repository.User {
 	u := &UserInMemory{}
 
 	// Add some initial DB
-	u.DB.Store(uint(1), UserInMemoryEntity{ID: 1, Name: "John", Surname: "Doe"})
-	u.DB.Store(uint(2), UserInMemoryEntity{ID: 2, Name: "Jane", Surname: "Doe"})
-	u.DB.Store(uint(3), UserInMemoryEntity{ID: 3, Name: "Alice", Surname: "Smith"})
+	_, _ = u.Create(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{entity.RoleAdmin}})
+	_, _ = u.Create(context.Background(), entity.User{ID: 2, Name: "Jane", Surname: "Doe"})
+	_, _ = u.Create(context.Background(), entity.User{ID: 3, Name: "Alice", Surname: "Smith"})
+
+	// Add the credentials of John for local development, the password is "lark"
+	u.Credentials.Store("john", UserCredentialsInMemoryEntity{
+		UserID:       1,
+		Username:     "john",
+		PasswordHash: "$2a$10$jbRdr4jyTjRRHM6iiXlCO.LSrrqujwL3MjupTLU4MA/paEiS/AJiG",
+	})
 
 	return u
 }
 
-// FindAll returns all users
-func (r *UserInMemory) FindAll(ctx context.Context) ([]entity.User, error) {
+// FindAll returns the page of the users selected by the given query
+func (r *UserInMemory) FindAll(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
 	var userEntities []UserInMemoryEntity
-	// get all users from the in-memory database
+	// get the users matching the filters from the in-memory database
 	r.DB.Range(func(key, value interface{}) bool {
-		userEntities = append(userEntities, value.(UserInMemoryEntity))
+		e := value.(*UserInMemoryEntity)
+		if (query.IncludeDeleted || activeUser.holds(e)) && e.matches(query.Filters) {
+			userEntities = append(userEntities, *e)
+		}
 		return true
 	})
 
-	users := make([]entity.User, 0, len(userEntities))
-	for _, e := range userEntities {
-		users = append(users, e.toEntityUser())
+	sort.Slice(userEntities, func(i, j int) bool {
+		return userPrecedes(query, userEntities[i].cursor(query), userEntities[j].cursor(query))
+	})
+
+	total := len(userEntities)
+	start := min(query.Offset, total)
+	if query.After != nil {
+		// the page starts at the first user sorted after the cursor
+		start = sort.Search(total, func(i int) bool {
+			return userPrecedes(query, *query.After, userEntities[i].cursor(query))
+		})
+		total = 0
 	}
+	end := min(start+query.Limit, len(userEntities))
 
-	return users, nil
+	page := entity.UserPage{Users: make([]entity.User, 0, end-start), Total: int64(total)}
+	for _, e := range userEntities[start:end] {
+		page.Users = append(page.Users, e.toEntityUser())
+	}
+	if end < len(userEntities) {
+		next := query.CursorAt(page.Users[len(page.Users)-1])
+		page.Next = &next
+	}
+
+	return page, nil
 }
 
-// FindByID returns a user by ID
+// FindByID returns a user by ID, unless it is deleted
 func (r *UserInMemory) FindByID(ctx context.Context, id uint) (entity.User, error) {
-	var userEntity UserInMemoryEntity
+	return r.find(id, activeUser)
+}
+
+// FindDeletedByID returns a deleted user by ID
+func (r *UserInMemory) FindDeletedByID(ctx context.Context, id uint) (entity.User, error) {
+	return r.find(id, deletedUser)
+}
+
+// find returns the user of the given ID in the given state
+func (r *UserInMemory) find(id uint, state userState) (entity.User, error) {
 	// get the user by ID from the in-memory database
 	value, ok := r.DB.Load(id)
-	if !ok {
+	if !ok || !state.holds(value.(*UserInMemoryEntity)) {
 		return entity.User{}, errors.ErrUserNotFound
 	}
 
-	userEntity = value.(UserInMemoryEntity)
-	return userEntity.toEntityUser(), nil
+	return value.(*UserInMemoryEntity).toEntityUser(), nil
 }
 
-// Create creates a user
+// Create creates a user at version 1 with the given ID, or with the next one if not given
 func (r *UserInMemory) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	// get last ID from memory
-	lastID := uint(0)
-	r.DB.Range(func(key, value interface{}) bool {
-		ID := key.(uint)
-		if ID > lastID {
-			lastID = ID
+	if user.ID != 0 {
+		created, ok := r.insert(user)
+		if !ok {
+			return entity.User{}, errors.ErrUserAlreadyExists
 		}
-		return true
-	})
-	user.ID = lastID + 1
-	return r.save(ctx, user)
+		return created, nil
+	}
+
+	for {
+		// get last ID from memory
+		lastID := uint(0)
+		r.DB.Range(func(key, value interface{}) bool {
+			ID := key.(uint)
+			if ID > lastID {
+				lastID = ID
+			}
+			return true
+		})
+		user.ID = lastID + 1
+		// tried again with the next ID when a concurrent call took this one first
+		if created, ok := r.insert(user); ok {
+			return created, nil
+		}
+	}
 }
 
-// Modify modifies a user
+// insert saves a user unless there is already one with its ID, and reports whether it was saved
+func (r *UserInMemory) insert(user entity.User) (entity.User, bool) {
+	userEntity := UserInMemoryEntity{}.fromEntityUser(user)
+	userEntity.Version = 1
+	userEntity.CreatedAt = time.Now()
+	userEntity.UpdatedAt = userEntity.CreatedAt
+	if _, loaded := r.DB.LoadOrStore(user.ID, &userEntity); loaded {
+		return entity.User{}, false
+	}
+	r.index.put(user.ID, user.Name, user.Surname)
+
+	return userEntity.toEntityUser(), true
+}
+
+// Modify modifies an existing user at the given version, and increments its version
 func (r *UserInMemory) Modify(ctx context.Context, user entity.User) (entity.User, error) {
-	_, err := r.FindByID(ctx, user.ID)
+	stored, err := r.load(user, activeUser)
 	if err != nil {
 		return entity.User{}, err
 	}
-	return r.save(ctx, user)
-}
 
-// save saves a user
-func (r *UserInMemory) save(ctx context.Context, user entity.User) (entity.User, error) {
 	userEntity := UserInMemoryEntity{}.fromEntityUser(user)
-	r.DB.Store(user.ID, userEntity)
+	userEntity.Version = stored.Version + 1
+	userEntity.CreatedAt = stored.CreatedAt
+	userEntity.UpdatedAt = time.Now()
+	if !r.DB.CompareAndSwap(user.ID, stored, &userEntity) {
+		return entity.User{}, r.missingOrModified(user, activeUser)
+	}
+	r.index.put(user.ID, user.Name, user.Surname)
 
 	return userEntity.toEntityUser(), nil
 }
 
-// Delete deletes a user
+// Delete deletes an existing user at the given version, keeping it until it is purged, and increments its version
 func (r *UserInMemory) Delete(ctx context.Context, user entity.User) error {
-	r.DB.Delete(user.ID)
+	stored, err := r.load(user, activeUser)
+	if err != nil {
+		return err
+	}
+
+	deleted := *stored
+	deleted.Version++
+	deleted.UpdatedAt = time.Now()
+	deleted.DeletedAt = deleted.UpdatedAt
+	if !r.DB.CompareAndSwap(user.ID, stored, &deleted) {
+		return r.missingOrModified(user, activeUser)
+	}
+	r.index.remove(user.ID)
 
 	return nil
 }
+
+// Restore restores a deleted user at the given version, and increments its version
+func (r *UserInMemory) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	stored, err := r.load(user, deletedUser)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	restored := *stored
+	restored.Version++
+	restored.UpdatedAt = time.Now()
+	restored.DeletedAt = time.Time{}
+	if !r.DB.CompareAndSwap(user.ID, stored, &restored) {
+		return entity.User{}, r.missingOrModified(user, deletedUser)
+	}
+	r.index.put(user.ID, restored.Name, restored.Surname)
+
+	return restored.toEntityUser(), nil
+}
+
+// Purge permanently deletes a user at the given version, deleted or not, along with its credentials and identities
+func (r *UserInMemory) Purge(ctx context.Context, user entity.User) error {
+	stored, err := r.load(user, anyUser)
+	if err != nil {
+		return err
+	}
+
+	if !r.DB.CompareAndDelete(user.ID, stored) {
+		return r.missingOrModified(user, anyUser)
+	}
+	r.index.remove(user.ID)
+	r.Credentials.Range(func(key, value interface{}) bool {
+		if value.(UserCredentialsInMemoryEntity).UserID == user.ID {
+			r.Credentials.Delete(key)
+		}
+		return true
+	})
+	r.Identities.Range(func(key, value interface{}) bool {
+		if value.(UserIdentityInMemoryEntity).UserID == user.ID {
+			r.Identities.Delete(key)
+		}
+		return true
+	})
+
+	return nil
+}
+
+// load returns the stored entity of the given user, which must be in the given state and at the version of the user
+func (r *UserInMemory) load(user entity.User, state userState) (*UserInMemoryEntity, error) {
+	value, ok := r.DB.Load(user.ID)
+	if !ok || !state.holds(value.(*UserInMemoryEntity)) {
+		return nil, errors.ErrUserNotFound
+	}
+
+	stored := value.(*UserInMemoryEntity)
+	if stored.Version != user.Version {
+		return nil, errors.ErrConcurrentModification
+	}
+	return stored, nil
+}
+
+// missingOrModified returns the error of a compare-and-swap of the given user in the given state which failed,
+// since a concurrent call deleted the user or changed its state, returning errors.ErrUserNotFound, or modified it,
+// returning errors.ErrConcurrentModification
+func (r *UserInMemory) missingOrModified(user entity.User, state userState) error {
+	if _, err := r.load(user, state); err != nil {
+		return err
+	}
+	return errors.ErrConcurrentModification
+}
+
+// Search returns at most limit users whose name or surname match every term of the given query, ignoring accents
+// and case, sorted by relevance and then by ID
+func (r *UserInMemory) Search(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	ids := r.index.search(query, limit)
+
+	users := make([]entity.User, 0, len(ids))
+	for _, id := range ids {
+		// the user may have been deleted since the search
+		if user, err := r.find(id, activeUser); err == nil {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// FindCredentialsByUsername returns the credentials of the given username
+func (r *UserInMemory) FindCredentialsByUsername(ctx context.Context, username string) (entity.Credentials, error) {
+	value, ok := r.Credentials.Load(username)
+	if !ok {
+		return entity.Credentials{}, errors.ErrUserNotFound
+	}
+
+	return value.(UserCredentialsInMemoryEntity).toEntityCredentials(), nil
+}
+
+// FindByIdentity returns the user linked to the given issuer and subject
+func (r *UserInMemory) FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	value, ok := r.Identities.Load(userIdentityKey{issuer: issuer, subject: subject})
+	if !ok {
+		return entity.User{}, errors.ErrUserNotFound
+	}
+
+	return r.FindByID(ctx, value.(UserIdentityInMemoryEntity).UserID)
+}
+
+// CreateWithIdentity creates a user linked to the given external identity
+func (r *UserInMemory) CreateWithIdentity(ctx context.Context, user entity.User, identity entity.ExternalIdentity) (entity.User, error) {
+	key := userIdentityKey{issuer: identity.Issuer, subject: identity.Subject}
+	if _, ok := r.Identities.Load(key); ok {
+		return entity.User{}, errors.ErrUserAlreadyExists
+	}
+
+	created, err := r.Create(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	identityEntity := UserIdentityInMemoryEntity{UserID: created.ID, Issuer: identity.Issuer, Subject: identity.Subject}
+	if _, loaded := r.Identities.LoadOrStore(key, identityEntity); loaded {
+		// a concurrent call linked the identity first
+		_ = r.Purge(ctx, created)
+		return entity.User{}, errors.ErrUserAlreadyExists
+	}
+
+	return created, nil
+}
+
+// field returns the value of the given field of the user
+func (um UserInMemoryEntity) field(f entity.UserField) string {
+	switch f {
+	case entity.UserFieldName:
+		return um.Name
+	case entity.UserFieldSurname:
+		return um.Surname
+	default:
+		return ""
+	}
+}
+
+// cursor returns the cursor of the user in the users sorted as the given query
+func (um UserInMemoryEntity) cursor(query entity.UserQuery) entity.UserCursor {
+	return entity.UserCursor{SortBy: query.SortBy, Direction: query.Direction, Value: um.field(query.SortBy), ID: um.ID}
+}
+
+// matches reports whether the user matches every given filter
+func (um UserInMemoryEntity) matches(filters []entity.UserFilter) bool {
+	for _, f := range filters {
+		value := um.field(f.Field)
+		switch f.Mode {
+		case entity.MatchPrefix:
+			if !strings.HasPrefix(value, f.Value) {
+				return false
+			}
+		case entity.MatchContains:
+			if !strings.Contains(value, f.Value) {
+				return false
+			}
+		default:
+			if value != f.Value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// userPrecedes reports whether the user at the cursor a is sorted before the user at the cursor b by the given query
+func userPrecedes(query entity.UserQuery, a, b entity.UserCursor) bool {
+	if query.Direction == entity.SortDesc {
+		a, b = b, a
+	}
+	if c := strings.Compare(a.Value, b.Value); c != 0 {
+		return c < 0
+	}
+	return a.ID < b.ID
+}
@@ -8,13 +8,18 @@ import (
 
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository/repositorytest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
 )
 
 func TestUserInMemory_FindAll(t *testing.T) {
 	repo := NewUserInMemory()
-	users, err := repo.FindAll(context.Background())
+	page, err := repo.FindAll(context.Background(), entity.UserQuery{}.Normalized())
 	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	users := page.Users
 	assert.Len(t, users, 3)
 
 	slices.SortFunc(users, func(i, j entity.User) int {
@@ -29,12 +34,141 @@ func TestUserInMemory_FindAll(t *testing.T) {
 	assert.Equal(t, "Smith", users[2].Surname)
 }
 
+func TestUserInMemory_FindAll_Query(t *testing.T) {
+	tests := []struct {
+		name  string
+		query entity.UserQuery
+		ids   []uint
+		total int64
+	}{
+		{
+			name:  "should sort by ID",
+			query: entity.UserQuery{},
+			ids:   []uint{1, 2, 3},
+			total: 3,
+		},
+		{
+			name:  "should sort by name",
+			query: entity.UserQuery{SortBy: entity.UserFieldName},
+			ids:   []uint{3, 2, 1},
+			total: 3,
+		},
+		{
+			name:  "should sort by surname and then by ID descending",
+			query: entity.UserQuery{SortBy: entity.UserFieldSurname, Direction: entity.SortDesc},
+			ids:   []uint{3, 2, 1},
+			total: 3,
+		},
+		{
+			name:  "should page the users",
+			query: entity.UserQuery{Limit: 2, Offset: 1},
+			ids:   []uint{2, 3},
+			total: 3,
+		},
+		{
+			name:  "should return an empty page past the end",
+			query: entity.UserQuery{Offset: 5},
+			ids:   []uint{},
+			total: 3,
+		},
+		{
+			name:  "should start after the cursor",
+			query: entity.UserQuery{SortBy: entity.UserFieldName, After: &entity.UserCursor{SortBy: entity.UserFieldName, Value: "Jane", ID: 2}},
+			ids:   []uint{1},
+			total: 0,
+		},
+		{
+			name: "should start after the cursor of a deleted user",
+			query: entity.UserQuery{
+				SortBy:    entity.UserFieldSurname,
+				Direction: entity.SortDesc,
+				After:     &entity.UserCursor{SortBy: entity.UserFieldSurname, Direction: entity.SortDesc, Value: "Doe", ID: 5},
+			},
+			ids:   []uint{2, 1},
+			total: 0,
+		},
+		{
+			name:  "should filter by exact surname",
+			query: entity.UserQuery{Filters: []entity.UserFilter{{Field: entity.UserFieldSurname, Mode: entity.MatchExact, Value: "Doe"}}},
+			ids:   []uint{1, 2},
+			total: 2,
+		},
+		{
+			name:  "should filter by name prefix",
+			query: entity.UserQuery{Filters: []entity.UserFilter{{Field: entity.UserFieldName, Mode: entity.MatchPrefix, Value: "J"}}},
+			ids:   []uint{1, 2},
+			total: 2,
+		},
+		{
+			name: "should filter by every filter",
+			query: entity.UserQuery{Filters: []entity.UserFilter{
+				{Field: entity.UserFieldName, Mode: entity.MatchPrefix, Value: "J"},
+				{Field: entity.UserFieldName, Mode: entity.MatchContains, Value: "an"},
+			}},
+			ids:   []uint{2},
+			total: 1,
+		},
+		{
+			name:  "should match case-sensitively",
+			query: entity.UserQuery{Filters: []entity.UserFilter{{Field: entity.UserFieldName, Mode: entity.MatchContains, Value: "alice"}}},
+			ids:   []uint{},
+			total: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			repo := NewUserInMemory()
+
+			// When
+			page, err := repo.FindAll(context.Background(), tt.query.Normalized())
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.total, page.Total)
+			ids := make([]uint, 0, len(page.Users))
+			for _, u := range page.Users {
+				ids = append(ids, u.ID)
+			}
+			assert.Equal(t, tt.ids, ids)
+		})
+	}
+}
+
+func TestUserInMemory_FindAll_Walk(t *testing.T) {
+	// Given
+	repo := NewUserInMemory()
+	query := entity.UserQuery{Limit: 1, SortBy: entity.UserFieldName}.Normalized()
+
+	// When
+	var names []string
+	for {
+		page, err := repo.FindAll(context.Background(), query)
+		assert.NoError(t, err)
+		for _, u := range page.Users {
+			names = append(names, u.Name)
+		}
+		if page.Next == nil {
+			break
+		}
+		query.After = page.Next
+
+		// the users created meanwhile before the cursor are neither returned nor shift the next pages
+		_, err = repo.Create(context.Background(), entity.User{Name: "Aaron"})
+		assert.NoError(t, err)
+	}
+
+	// Then
+	assert.Equal(t, []string{"Alice", "Jane", "John"}, names)
+}
+
 func TestUserInMemory_FindByID(t *testing.T) {
 	repo := NewUserInMemory()
 	user, err := repo.FindByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.Equal(t, "John", user.Name)
 	assert.Equal(t, "Doe", user.Surname)
+	assert.Equal(t, []string{entity.RoleAdmin}, user.Roles)
 }
 
 func TestUserInMemory_FindByID_NotFound(t *testing.T) {
@@ -52,18 +186,41 @@ func TestUserInMemory_Create(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Alice", user.Name)
 	assert.Equal(t, "Smith", user.Surname)
+	assert.False(t, user.CreatedAt.IsZero())
+	assert.Equal(t, user.CreatedAt, user.UpdatedAt)
 }
 
 func TestUserInMemory_Modify(t *testing.T) {
 	repo := NewUserInMemory()
+	stored, _ := repo.FindByID(context.Background(), 1)
 	user, err := repo.Modify(context.Background(), entity.User{
 		ID:      1,
 		Name:    "Alice",
 		Surname: "Smith",
+		Version: 1,
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "Alice", user.Name)
 	assert.Equal(t, "Smith", user.Surname)
+	assert.Equal(t, uint(2), user.Version)
+	assert.Equal(t, stored.CreatedAt, user.CreatedAt)
+	assert.False(t, user.UpdatedAt.Before(stored.UpdatedAt))
+}
+
+func TestUserInMemory_Modify_ConcurrentModification(t *testing.T) {
+	repo := NewUserInMemory()
+	modified, err := repo.Modify(context.Background(), entity.User{ID: 1, Name: "Alice", Surname: "Smith", Version: 1})
+	assert.NoError(t, err)
+
+	_, err = repo.Modify(context.Background(), entity.User{ID: 1, Name: "Bob", Surname: "Smith", Version: 1})
+	assert.ErrorIs(t, err, errors.ErrConcurrentModification)
+	err = repo.Delete(context.Background(), entity.User{ID: 1, Version: 1})
+	assert.ErrorIs(t, err, errors.ErrConcurrentModification)
+
+	user, err := repo.FindByID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, modified, user)
+	assert.Equal(t, uint(2), user.Version)
 }
 
 func TestUserInMemory_Modify_NotFound(t *testing.T) {
@@ -79,7 +236,78 @@ func TestUserInMemory_Modify_NotFound(t *testing.T) {
 func TestUserInMemory_Delete(t *testing.T) {
 	repo := NewUserInMemory()
 	err := repo.Delete(context.Background(), entity.User{
-		ID: 1,
+		ID:      1,
+		Version: 1,
 	})
 	assert.NoError(t, err)
 }
+
+func TestUserInMemory_Search(t *testing.T) {
+	repo := NewUserInMemory().(*UserInMemory)
+
+	john, _ := repo.FindByID(context.Background(), 1)
+	jane, _ := repo.FindByID(context.Background(), 2)
+	users, err := repo.Search(context.Background(), "DOE", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.User{john, jane}, users)
+
+	// the index is kept up to date on Create, Modify and Delete
+	created, err := repo.Create(context.Background(), entity.User{Name: "Zoë", Surname: "Doe"})
+	assert.NoError(t, err)
+	_, err = repo.Modify(context.Background(), entity.User{ID: 1, Name: "John", Surname: "Smith", Version: 1})
+	assert.NoError(t, err)
+	err = repo.Delete(context.Background(), entity.User{ID: 2, Version: 1})
+	assert.NoError(t, err)
+
+	users, err = repo.Search(context.Background(), "doe", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.User{created}, users)
+
+	users, err = repo.Search(context.Background(), "zoe", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.User{created}, users)
+
+	users, err = repo.Search(context.Background(), "smith", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1, 3}, []uint{users[0].ID, users[1].ID})
+}
+
+func TestUserInMemory_FindCredentialsByUsername(t *testing.T) {
+	repo := NewUserInMemory().(*UserInMemory)
+	credentials, err := repo.FindCredentialsByUsername(context.Background(), "john")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), credentials.UserID)
+	assert.Equal(t, "john", credentials.Username)
+	assert.NotEmpty(t, credentials.PasswordHash)
+}
+
+func TestUserInMemory_FindCredentialsByUsername_NotFound(t *testing.T) {
+	repo := NewUserInMemory().(*UserInMemory)
+	_, err := repo.FindCredentialsByUsername(context.Background(), "unknown")
+	assert.ErrorIs(t, err, errors.ErrUserNotFound)
+}
+
+func TestUserInMemory_CreateWithIdentity(t *testing.T) {
+	repo := NewUserInMemory().(*UserInMemory)
+	identity := entity.ExternalIdentity{Issuer: "https://issuer.example.com", Subject: "external-subject"}
+
+	_, err := repo.FindByIdentity(context.Background(), identity.Issuer, identity.Subject)
+	assert.ErrorIs(t, err, errors.ErrUserNotFound)
+
+	created, err := repo.CreateWithIdentity(context.Background(), entity.User{Name: "John", Surname: "Doe"}, identity)
+	assert.NoError(t, err)
+	assert.NotZero(t, created.ID)
+
+	found, err := repo.FindByIdentity(context.Background(), identity.Issuer, identity.Subject)
+	assert.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	_, err = repo.CreateWithIdentity(context.Background(), entity.User{Name: "John"}, identity)
+	assert.ErrorIs(t, err, errors.ErrUserAlreadyExists)
+}
+
+func TestUserInMemory_Contract(t *testing.T) {
+	suite.Run(t, &repositorytest.UserSuite{NewRepository: func(t *testing.T) repository.User {
+		return NewUserInMemory()
+	}})
+}
@@ -1,13 +1,23 @@
 package repository
 
-import "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+import (
+	"slices"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"gorm.io/gorm"
+)
 
 // toEntityUser converts a UserDBEntity to an entity.User
 func (ub UserDBEntity) toEntityUser() entity.User {
 	return entity.User{
-		ID:      ub.ID,
-		Name:    ub.Name,
-		Surname: ub.Surname,
+		ID:        ub.ID,
+		Name:      ub.Name,
+		Surname:   ub.Surname,
+		Roles:     ub.Roles,
+		Version:   ub.Version,
+		CreatedAt: ub.CreatedAt,
+		UpdatedAt: ub.UpdatedAt,
+		DeletedAt: ub.DeletedAt.Time,
 	}
 }
 
@@ -16,15 +26,25 @@ func (ub UserDBEntity) fromEntityUser(u entity.User) UserDBEntity {
 	ub.ID = u.ID
 	ub.Name = u.Name
 	ub.Surname = u.Surname
+	ub.Roles = u.Roles
+	ub.Version = u.Version
+	ub.CreatedAt = u.CreatedAt
+	ub.UpdatedAt = u.UpdatedAt
+	ub.DeletedAt = gorm.DeletedAt{Time: u.DeletedAt, Valid: u.Deleted()}
 	return ub
 }
 
 // toEntityUser converts a UserInMemoryEntity to an entity.User
 func (um UserInMemoryEntity) toEntityUser() entity.User {
 	return entity.User{
-		ID:      um.ID,
-		Name:    um.Name,
-		Surname: um.Surname,
+		ID:        um.ID,
+		Name:      um.Name,
+		Surname:   um.Surname,
+		Roles:     slices.Clone(um.Roles),
+		Version:   um.Version,
+		CreatedAt: um.CreatedAt,
+		UpdatedAt: um.UpdatedAt,
+		DeletedAt: um.DeletedAt,
 	}
 }
 
@@ -33,5 +53,28 @@ func (um UserInMemoryEntity) fromEntityUser(u entity.User) UserInMemoryEntity {
 	um.ID = u.ID
 	um.Name = u.Name
 	um.Surname = u.Surname
+	um.Roles = slices.Clone(u.Roles)
+	um.Version = u.Version
+	um.CreatedAt = u.CreatedAt
+	um.UpdatedAt = u.UpdatedAt
+	um.DeletedAt = u.DeletedAt
 	return um
 }
+
+// toEntityCredentials converts a UserCredentialsDBEntity to an entity.Credentials
+func (cb UserCredentialsDBEntity) toEntityCredentials() entity.Credentials {
+	return entity.Credentials{
+		UserID:       cb.UserID,
+		Username:     cb.Username,
+		PasswordHash: cb.PasswordHash,
+	}
+}
+
+// toEntityCredentials converts a UserCredentialsInMemoryEntity to an entity.Credentials
+func (cm UserCredentialsInMemoryEntity) toEntityCredentials() entity.Credentials {
+	return entity.Credentials{
+		UserID:       cm.UserID,
+		Username:     cm.Username,
+		PasswordHash: cm.PasswordHash,
+	}
+}
@@ -12,11 +12,13 @@ func TestUserInMemoryEntity_toEntityUser(t *testing.T) {
 		ID:      1,
 		Name:    "John",
 		Surname: "Doe",
+		Roles:   []string{entity.RoleAdmin},
 	}
 	user := userEntity.toEntityUser()
 	assert.Equal(t, userEntity.ID, user.ID)
 	assert.Equal(t, userEntity.Name, user.Name)
 	assert.Equal(t, userEntity.Surname, user.Surname)
+	assert.Equal(t, userEntity.Roles, user.Roles)
 }
 
 func TestUserInMemoryEntity_fromEntityUser(t *testing.T) {
@@ -24,12 +26,14 @@ func TestUserInMemoryEntity_fromEntityUser(t *testing.T) {
 		ID:      1,
 		Name:    "John",
 		Surname: "Doe",
+		Roles:   []string{entity.RoleAdmin},
 	}
 	userEntity := UserInMemoryEntity{}
 	userEntity = userEntity.fromEntityUser(user)
 	assert.Equal(t, user.ID, userEntity.ID)
 	assert.Equal(t, user.Name, userEntity.Name)
 	assert.Equal(t, user.Surname, userEntity.Surname)
+	assert.Equal(t, user.Roles, userEntity.Roles)
 }
 
 func TestUserDBEntity_toEntityUser(t *testing.T) {
@@ -37,11 +41,13 @@ func TestUserDBEntity_toEntityUser(t *testing.T) {
 		ID:      1,
 		Name:    "John",
 		Surname: "Doe",
+		Roles:   []string{entity.RoleAdmin},
 	}
 	user := userDBEntity.toEntityUser()
 	assert.Equal(t, userDBEntity.ID, user.ID)
 	assert.Equal(t, userDBEntity.Name, user.Name)
 	assert.Equal(t, userDBEntity.Surname, user.Surname)
+	assert.Equal(t, userDBEntity.Roles, user.Roles)
 }
 
 func TestUserDBEntity_fromEntityUser(t *testing.T) {
@@ -49,10 +55,36 @@ func TestUserDBEntity_fromEntityUser(t *testing.T) {
 		ID:      1,
 		Name:    "John",
 		Surname: "Doe",
+		Roles:   []string{entity.RoleAdmin},
 	}
 	userDBEntity := UserDBEntity{}
 	userDBEntity = userDBEntity.fromEntityUser(user)
 	assert.Equal(t, user.ID, userDBEntity.ID)
 	assert.Equal(t, user.Name, userDBEntity.Name)
 	assert.Equal(t, user.Surname, userDBEntity.Surname)
+	assert.Equal(t, user.Roles, userDBEntity.Roles)
+}
+
+func TestUserCredentialsInMemoryEntity_toEntityCredentials(t *testing.T) {
+	credentialsEntity := UserCredentialsInMemoryEntity{
+		UserID:       1,
+		Username:     "john",
+		PasswordHash: "hash",
+	}
+	credentials := credentialsEntity.toEntityCredentials()
+	assert.Equal(t, credentialsEntity.UserID, credentials.UserID)
+	assert.Equal(t, credentialsEntity.Username, credentials.Username)
+	assert.Equal(t, credentialsEntity.PasswordHash, credentials.PasswordHash)
+}
+
+func TestUserCredentialsDBEntity_toEntityCredentials(t *testing.T) {
+	credentialsEntity := UserCredentialsDBEntity{
+		UserID:       1,
+		Username:     "john",
+		PasswordHash: "hash",
+	}
+	credentials := credentialsEntity.toEntityCredentials()
+	assert.Equal(t, credentialsEntity.UserID, credentials.UserID)
+	assert.Equal(t, credentialsEntity.Username, credentials.Username)
+	assert.Equal(t, credentialsEntity.PasswordHash, credentials.PasswordHash)
 }
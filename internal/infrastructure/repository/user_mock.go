@@ -17,9 +17,9 @@ func NewMockUser() *MockUser {
 	return &MockUser{}
 }
 
-func (m *MockUser) FindAll(ctx context.Context) ([]entity.User, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]entity.User), args.Error(1)
+func (m *MockUser) FindAll(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(entity.UserPage), args.Error(1)
 }
 
 func (m *MockUser) FindByID(ctx context.Context, id uint) (entity.User, error) {
@@ -27,6 +27,11 @@ func (m *MockUser) FindByID(ctx context.Context, id uint) (entity.User, error) {
 	return args.Get(0).(entity.User), args.Error(1)
 }
 
+func (m *MockUser) FindDeletedByID(ctx context.Context, id uint) (entity.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
 func (m *MockUser) Create(ctx context.Context, user entity.User) (entity.User, error) {
 	return m.save(ctx, user)
 }
@@ -45,6 +50,63 @@ func (m *MockUser) Delete(ctx context.Context, user entity.User) error {
 	return args.Error(0)
 }
 
+func (m *MockUser) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUser) Purge(ctx context.Context, user entity.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+// MockUserCredentials is a mock implementation of repository.UserCredentials by using testify mock.Mock
+type MockUserCredentials struct {
+	mock.Mock
+}
+
+func NewMockUserCredentials() *MockUserCredentials {
+	return &MockUserCredentials{}
+}
+
+func (m *MockUserCredentials) FindCredentialsByUsername(ctx context.Context, username string) (entity.Credentials, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).(entity.Credentials), args.Error(1)
+}
+
+// MockUserIdentity is a mock implementation of repository.UserIdentity by using testify mock.Mock
+type MockUserIdentity struct {
+	mock.Mock
+}
+
+func NewMockUserIdentity() *MockUserIdentity {
+	return &MockUserIdentity{}
+}
+
+func (m *MockUserIdentity) FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUserIdentity) CreateWithIdentity(ctx context.Context, user entity.User, identity entity.ExternalIdentity) (entity.User, error) {
+	args := m.Called(ctx, user, identity)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+// MockUserSearch is a mock implementation of repository.UserSearch by using testify mock.Mock
+type MockUserSearch struct {
+	mock.Mock
+}
+
+func NewMockUserSearch() *MockUserSearch {
+	return &MockUserSearch{}
+}
+
+func (m *MockUserSearch) Search(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	args := m.Called(ctx, query, limit)
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
 // FakeUser is a simple fake implementation of repository.User
 type FakeUser struct {
 	entities []entity.User
@@ -58,8 +120,8 @@ func NewFakeUser(entities []entity.User, err error) repository.User {
 	}
 }
 
-func (m *FakeUser) FindAll(ctx context.Context) ([]entity.User, error) {
-	return m.entities, m.err
+func (m *FakeUser) FindAll(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	return entity.UserPage{Users: m.entities, Total: int64(len(m.entities))}, m.err
 }
 
 func (m *FakeUser) FindByID(ctx context.Context, id uint) (entity.User, error) {
@@ -73,6 +135,17 @@ func (m *FakeUser) FindByID(ctx context.Context, id uint) (entity.User, error) {
 	return u, m.err
 }
 
+func (m *FakeUser) FindDeletedByID(ctx context.Context, id uint) (entity.User, error) {
+	var u entity.User
+	for _, e := range m.entities {
+		if e.ID == id && e.Deleted() {
+			u = e
+			break
+		}
+	}
+	return u, m.err
+}
+
 func (m *FakeUser) Create(ctx context.Context, user entity.User) (entity.User, error) {
 	var u entity.User
 	for _, e := range m.entities {
@@ -98,3 +171,18 @@ func (m *FakeUser) Modify(ctx context.Context, user entity.User) (entity.User, e
 func (m *FakeUser) Delete(ctx context.Context, user entity.User) error {
 	return m.err
 }
+
+func (m *FakeUser) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	var u entity.User
+	for _, e := range m.entities {
+		if e.ID == user.ID {
+			u = e
+			break
+		}
+	}
+	return u, m.err
+}
+
+func (m *FakeUser) Purge(ctx context.Context, user entity.User) error {
+	return m.err
+}
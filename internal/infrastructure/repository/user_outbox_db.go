@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserOutboxDBEntity represents a pending event of a user in the database
+type UserOutboxDBEntity struct {
+	ID         uint                  `gorm:"primarykey"`
+	Type       string                `gorm:"not null"`
+	UserID     uint                  `gorm:"not null"`
+	User       *UserSnapshotDBEntity `gorm:"serializer:json;not null"`
+	OccurredAt time.Time             `gorm:"not null"`
+}
+
+// TableName overrides the table name used by UserOutboxDBEntity to `user_outbox`
+func (UserOutboxDBEntity) TableName() string {
+	return "user_outbox"
+}
+
+type UserOutboxDB struct {
+	DB *gorm.DB
+}
+
+// NewUserOutboxDB creates a new instance of repository.UserOutboxDB
+func NewUserOutboxDB(DB *gorm.DB) repository.UserOutbox {
+	return &UserOutboxDB{DB}
+}
+
+// Append appends an event of a user, in the transaction of the given context if any
+func (r *UserOutboxDB) Append(ctx context.Context, event entity.UserEvent) (entity.UserEvent, error) {
+	event.OccurredAt = time.Now()
+	outboxEntity := UserOutboxDBEntity{}.fromEntityUserEvent(event)
+	if err := dbOf(ctx, r.DB).Create(&outboxEntity).Error; err != nil {
+		return entity.UserEvent{}, err
+	}
+
+	return outboxEntity.toEntityUserEvent(), nil
+}
+
+// FindPending returns the first pending events, locking them without waiting for the ones locked by the other
+// transactions when in the transaction of the given context. SQLite, which has no row locks, serializes the
+// transactions instead, as they take the lock of the database when they begin.
+func (r *UserOutboxDB) FindPending(ctx context.Context, limit int) ([]entity.UserEvent, error) {
+	tx, ok := txOf(ctx)
+	if ok {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	} else {
+		tx = r.DB
+	}
+
+	var outboxEntities []UserOutboxDBEntity
+	if err := tx.Order("id").Limit(limit).Find(&outboxEntities).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]entity.UserEvent, 0, len(outboxEntities))
+	for _, e := range outboxEntities {
+		events = append(events, e.toEntityUserEvent())
+	}
+
+	return events, nil
+}
+
+// MarkPublished removes the published events from the outbox
+func (r *UserOutboxDB) MarkPublished(ctx context.Context, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dbOf(ctx, r.DB).Delete(&UserOutboxDBEntity{}, ids).Error
+}
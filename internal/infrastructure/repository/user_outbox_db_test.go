@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserOutboxDB_Append(t *testing.T) {
+	const query = "INSERT INTO `user_outbox` (`type`,`user_id`,`user`,`occurred_at`) VALUES (?,?,?,?)"
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		then  func(sqlmock.Sqlmock, entity.UserEvent, error)
+	}{
+		{
+			name: "should append the event of a user",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("user.created", 1,
+						`{"id":1,"name":"John","surname":"Doe","roles":null,"version":1,"created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T12:00:00Z"}`,
+						AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			then: func(mock sqlmock.Sqlmock, event entity.UserEvent, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), event.ID)
+				assert.False(t, event.OccurredAt.IsZero())
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "should not append the event of a user",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(errors.New("failed to append"))
+				mock.ExpectRollback()
+			},
+			then: func(mock sqlmock.Sqlmock, event entity.UserEvent, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, event)
+
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+			// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+			db, mock, err := newMockMySqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+			createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+			// When
+			event, err := NewUserOutboxDB(db).Append(context.Background(), entity.NewUserEvent(entity.UserCreated,
+				entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1, CreatedAt: createdAt, UpdatedAt: createdAt}))
+
+			// Then
+			tt.then(mock, event, err)
+		})
+	}
+}
+
+func TestUserOutboxDB_FindPending(t *testing.T) {
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "type", "user_id", "user"}).
+			AddRow(1, "user.created", 1, `{"id":1,"name":"John","surname":"Doe","version":1}`).
+			AddRow(2, "user.purged", 1, `{"id":1,"name":"John","surname":"Doe","version":1}`)
+	}
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		when  func(*UserOutboxDB) ([]entity.UserEvent, error)
+	}{
+		{
+			name: "should find the pending events",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_outbox" ORDER BY id LIMIT $1`)).
+					WithArgs(10).
+					WillReturnRows(rows())
+			},
+			when: func(r *UserOutboxDB) ([]entity.UserEvent, error) {
+				return r.FindPending(context.Background(), 10)
+			},
+		},
+		{
+			name: "should lock the pending events found within a transaction",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user_outbox" ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`)).
+					WithArgs(10).
+					WillReturnRows(rows())
+				mock.ExpectCommit()
+			},
+			when: func(r *UserOutboxDB) ([]entity.UserEvent, error) {
+				var events []entity.UserEvent
+				err := NewTransactorDB(r.DB).WithinTransaction(context.Background(), func(ctx context.Context) error {
+					var err error
+					events, err = r.FindPending(ctx, 10)
+					return err
+				})
+				return events, err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			db, mock, err := newMockPostgresSqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+
+			// When
+			events, err := tt.when(&UserOutboxDB{db})
+
+			// Then
+			assert.NoError(t, err)
+			if assert.Len(t, events, 2) {
+				assert.Equal(t, entity.UserCreated, events[0].Type)
+				assert.Equal(t, entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}, events[0].User)
+				assert.Equal(t, entity.UserPurged, events[1].Type)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserOutboxDB_MarkPublished(t *testing.T) {
+	// Given
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "user_outbox" WHERE "user_outbox"."id" IN ($1,$2)`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// When
+	err = NewUserOutboxDB(db).MarkPublished(context.Background(), 1, 2)
+
+	// Then
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// UserOutboxInMemoryEntity represents a pending event of a user in the in-memory database
+type UserOutboxInMemoryEntity struct {
+	ID         uint
+	Type       entity.UserEventType
+	UserID     uint
+	User       UserInMemoryEntity
+	OccurredAt time.Time
+}
+
+// UserOutboxInMemory represents a user outbox repository in the in-memory database.
+// The events are kept in the order they were appended until they are published.
+type UserOutboxInMemory struct {
+	mu     sync.RWMutex
+	lastID uint
+	events []UserOutboxInMemoryEntity
+}
+
+// NewUserOutboxInMemory creates a new instance of repository.UserOutboxInMemory
+func NewUserOutboxInMemory() repository.UserOutbox {
+	return &UserOutboxInMemory{}
+}
+
+// Append appends an event of a user
+func (r *UserOutboxInMemory) Append(ctx context.Context, event entity.UserEvent) (entity.UserEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	event.ID = r.lastID
+	event.OccurredAt = time.Now()
+	outboxEntity := UserOutboxInMemoryEntity{}.fromEntityUserEvent(event)
+	r.events = append(r.events, outboxEntity)
+
+	return outboxEntity.toEntityUserEvent(), nil
+}
+
+// FindPending returns the first pending events
+func (r *UserOutboxInMemory) FindPending(ctx context.Context, limit int) ([]entity.UserEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]entity.UserEvent, 0, min(len(r.events), limit))
+	for _, e := range r.events[:min(len(r.events), limit)] {
+		events = append(events, e.toEntityUserEvent())
+	}
+
+	return events, nil
+}
+
+// MarkPublished removes the published events from the outbox
+func (r *UserOutboxInMemory) MarkPublished(ctx context.Context, ids ...uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = slices.DeleteFunc(r.events, func(e UserOutboxInMemoryEntity) bool {
+		return slices.Contains(ids, e.ID)
+	})
+
+	return nil
+}
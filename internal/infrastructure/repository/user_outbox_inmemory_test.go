@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOutboxInMemory_AppendFindAndMarkPublished(t *testing.T) {
+	repo := NewUserOutboxInMemory()
+	user := entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}
+	first, err := repo.Append(context.Background(), entity.NewUserEvent(entity.UserCreated, user))
+	require.NoError(t, err)
+	second, err := repo.Append(context.Background(), entity.NewUserEvent(entity.UserDeleted, user))
+	require.NoError(t, err)
+	third, err := repo.Append(context.Background(), entity.NewUserEvent(entity.UserPurged, user))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(1), first.ID)
+	assert.Equal(t, uint(3), third.ID)
+	assert.False(t, first.OccurredAt.IsZero())
+	assert.Equal(t, user, first.User)
+
+	pending, err := repo.FindPending(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.UserEvent{first, second}, pending)
+
+	require.NoError(t, repo.MarkPublished(context.Background(), first.ID, second.ID))
+	pending, err = repo.FindPending(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.UserEvent{third}, pending)
+
+	// the IDs of the published events are not given again
+	fourth, err := repo.Append(context.Background(), entity.NewUserEvent(entity.UserCreated, user))
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), fourth.ID)
+}
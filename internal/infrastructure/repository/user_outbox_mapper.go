@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// toEntityUserEvent converts a UserOutboxDBEntity to an entity.UserEvent
+func (ob UserOutboxDBEntity) toEntityUserEvent() entity.UserEvent {
+	event := entity.UserEvent{
+		ID:         ob.ID,
+		Type:       entity.UserEventType(ob.Type),
+		UserID:     ob.UserID,
+		OccurredAt: ob.OccurredAt,
+	}
+	if user := ob.User.toEntityUser(); user != nil {
+		event.User = *user
+	}
+	return event
+}
+
+// fromEntityUserEvent converts an entity.UserEvent to a UserOutboxDBEntity
+func (ob UserOutboxDBEntity) fromEntityUserEvent(e entity.UserEvent) UserOutboxDBEntity {
+	ob.ID = e.ID
+	ob.Type = string(e.Type)
+	ob.UserID = e.UserID
+	ob.User = fromEntityUserSnapshot(&e.User)
+	ob.OccurredAt = e.OccurredAt
+	return ob
+}
+
+// toEntityUserEvent converts a UserOutboxInMemoryEntity to an entity.UserEvent
+func (om UserOutboxInMemoryEntity) toEntityUserEvent() entity.UserEvent {
+	return entity.UserEvent{
+		ID:         om.ID,
+		Type:       om.Type,
+		UserID:     om.UserID,
+		User:       om.User.toEntityUser(),
+		OccurredAt: om.OccurredAt,
+	}
+}
+
+// fromEntityUserEvent converts an entity.UserEvent to a UserOutboxInMemoryEntity
+func (om UserOutboxInMemoryEntity) fromEntityUserEvent(e entity.UserEvent) UserOutboxInMemoryEntity {
+	om.ID = e.ID
+	om.Type = e.Type
+	om.UserID = e.UserID
+	om.User = UserInMemoryEntity{}.fromEntityUser(e.User)
+	om.OccurredAt = e.OccurredAt
+	return om
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntityUserEvent() entity.UserEvent {
+	createdAt := time.Now().Add(-time.Hour)
+	return entity.UserEvent{
+		ID:     1,
+		Type:   entity.UserDeleted,
+		UserID: 2,
+		User: entity.User{ID: 2, Name: "Jane", Surname: "Doe", Roles: []string{"admin"}, Version: 2,
+			CreatedAt: createdAt, UpdatedAt: time.Now(), DeletedAt: time.Now()},
+		OccurredAt: time.Now(),
+	}
+}
+
+func TestUserOutboxDBEntity_Mapping(t *testing.T) {
+	event := newEntityUserEvent()
+	outboxEntity := UserOutboxDBEntity{}.fromEntityUserEvent(event)
+	assert.Equal(t, event, outboxEntity.toEntityUserEvent())
+}
+
+func TestUserOutboxInMemoryEntity_Mapping(t *testing.T) {
+	event := newEntityUserEvent()
+	outboxEntity := UserOutboxInMemoryEntity{}.fromEntityUserEvent(event)
+	assert.Equal(t, event, outboxEntity.toEntityUserEvent())
+
+	// the user is not shared with the stored entity
+	event.User.Roles[0] = "guest"
+	assert.Equal(t, []string{"admin"}, outboxEntity.User.Roles)
+}
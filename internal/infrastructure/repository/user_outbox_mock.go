@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserOutbox is a mock implementation of repository.UserOutbox by using testify mock.Mock
+type MockUserOutbox struct {
+	mock.Mock
+}
+
+func NewMockUserOutbox() *MockUserOutbox {
+	return &MockUserOutbox{}
+}
+
+func (m *MockUserOutbox) Append(ctx context.Context, event entity.UserEvent) (entity.UserEvent, error) {
+	args := m.Called(ctx, event)
+	return args.Get(0).(entity.UserEvent), args.Error(1)
+}
+
+func (m *MockUserOutbox) FindPending(ctx context.Context, limit int) ([]entity.UserEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]entity.UserEvent), args.Error(1)
+}
+
+func (m *MockUserOutbox) MarkPublished(ctx context.Context, ids ...uint) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
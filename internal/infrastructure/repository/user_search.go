@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// scores of a word of a user matching a term of a search, from the most to the least relevant
+const (
+	searchScoreExact    = 3
+	searchScorePrefix   = 2
+	searchScoreContains = 1
+)
+
+// searchTerms returns the lowercase words of the given text without accents, as matched by the search
+func searchTerms(text string) []string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), text)
+	if err != nil {
+		folded = text
+	}
+	return strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// userSearchIndex is an inverted index of the words of the names and surnames of the in-memory users.
+// Its zero value is an empty index ready to use.
+type userSearchIndex struct {
+	mu sync.RWMutex
+	// ids are the IDs of the users by their words
+	ids map[string]map[uint]struct{}
+	// words are the words of the users by their IDs, to remove them from the index
+	words map[uint][]string
+}
+
+// put indexes the words of the given name and surname of the user, replacing the previous ones
+func (i *userSearchIndex) put(id uint, name, surname string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.removeLocked(id)
+	if i.ids == nil {
+		i.ids = make(map[string]map[uint]struct{})
+		i.words = make(map[uint][]string)
+	}
+
+	words := append(searchTerms(name), searchTerms(surname)...)
+	for _, w := range words {
+		if i.ids[w] == nil {
+			i.ids[w] = make(map[uint]struct{})
+		}
+		i.ids[w][id] = struct{}{}
+	}
+	i.words[id] = words
+}
+
+// remove removes the words of the user from the index
+func (i *userSearchIndex) remove(id uint) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.removeLocked(id)
+}
+
+// removeLocked removes the words of the user from the index, which must be locked
+func (i *userSearchIndex) removeLocked(id uint) {
+	for _, w := range i.words[id] {
+		delete(i.ids[w], id)
+		if len(i.ids[w]) == 0 {
+			delete(i.ids, w)
+		}
+	}
+	delete(i.words, id)
+}
+
+// search returns the IDs of at most limit users having a word matching every term of the given query, sorted by
+// relevance and then by ID. A term matches the words it is equal to, a prefix of, or contained in.
+func (i *userSearchIndex) search(query string, limit int) []uint {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []uint{}
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var scores map[uint]int
+	for _, t := range terms {
+		// the best score of the words of every user matching the term, found by scanning the words of the index
+		termScores := make(map[uint]int)
+		for w, ids := range i.ids {
+			score := wordScore(w, t)
+			if score == 0 {
+				continue
+			}
+			for id := range ids {
+				termScores[id] = max(termScores[id], score)
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if termScores[id] == 0 {
+				delete(scores, id)
+			} else {
+				scores[id] += termScores[id]
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if scores[ids[a]] != scores[ids[b]] {
+			return scores[ids[a]] > scores[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+
+	return ids[:min(limit, len(ids))]
+}
+
+// wordScore returns the score of the word matching the term, or 0 if it does not match
+func wordScore(word, term string) int {
+	switch {
+	case word == term:
+		return searchScoreExact
+	case strings.HasPrefix(word, term):
+		return searchScorePrefix
+	case strings.Contains(word, term):
+		return searchScoreContains
+	default:
+		return 0
+	}
+}
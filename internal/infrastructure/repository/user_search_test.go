@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "should lowercase the words", text: "John DOE", want: []string{"john", "doe"}},
+		{name: "should remove the accents", text: "José Núñez Çelik", want: []string{"jose", "nunez", "celik"}},
+		{name: "should split on punctuation", text: "  O'Neil-Smith, jr. ", want: []string{"o", "neil", "smith", "jr"}},
+		{name: "should drop the search operators", text: "jo:* & !doe | %_", want: []string{"jo", "doe"}},
+		{name: "should keep the digits", text: "user42", want: []string{"user42"}},
+		{name: "should return no terms", text: " - ", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terms := searchTerms(tt.text)
+			if len(tt.want) == 0 {
+				assert.Empty(t, terms)
+				return
+			}
+			assert.Equal(t, tt.want, terms)
+		})
+	}
+}
+
+func TestUserSearchIndex_Search(t *testing.T) {
+	var index userSearchIndex
+	index.put(1, "John", "Doe")
+	index.put(2, "Jane", "Doe")
+	index.put(3, "Alice", "Smith")
+	index.put(4, "Johanna", "Müller")
+	index.put(5, "José", "Johnson")
+	index.put(6, "Iceman", "Aldoe")
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []uint
+	}{
+		{name: "should rank the exact words first", query: "john", limit: 10, want: []uint{1, 5}},
+		{name: "should rank the prefixes before the parts of the words", query: "ice", limit: 10, want: []uint{6, 3}},
+		{name: "should rank the exact words before the parts of the words", query: "doe", limit: 10, want: []uint{1, 2, 6}},
+		{name: "should match the prefixes", query: "jo", limit: 10, want: []uint{1, 4, 5}},
+		{name: "should match every term", query: "j doe", limit: 10, want: []uint{1, 2}},
+		{name: "should ignore the accents and case", query: "MULLER", limit: 10, want: []uint{4}},
+		{name: "should match the accented words", query: "jose", limit: 10, want: []uint{5}},
+		{name: "should limit the users", query: "doe", limit: 1, want: []uint{1}},
+		{name: "should not match", query: "bob", limit: 10, want: []uint{}},
+		{name: "should not match an empty query", query: " ", limit: 10, want: []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, index.search(tt.query, tt.limit))
+		})
+	}
+}
+
+func TestUserSearchIndex_PutAndRemove(t *testing.T) {
+	var index userSearchIndex
+	index.put(1, "John", "Doe")
+	index.put(2, "Jane", "Doe")
+
+	// replaces the previous words of the user
+	index.put(1, "Johnny", "Walker")
+	assert.Equal(t, []uint{2}, index.search("doe", 10))
+	assert.Equal(t, []uint{1}, index.search("walker", 10))
+
+	index.remove(2)
+	assert.Equal(t, []uint{}, index.search("doe", 10))
+	assert.NotContains(t, index.ids, "doe")
+	assert.NotContains(t, index.words, uint(2))
+
+	// removing an unknown user is a no-op
+	index.remove(9)
+	assert.Equal(t, []uint{1}, index.search("john", 10))
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// WebhookDBEntity represents a webhook entity in the database
+type WebhookDBEntity struct {
+	ID         uint     `gorm:"primarykey"`
+	URL        string   `gorm:"not null"`
+	Secret     string   `gorm:"not null"`
+	EventTypes []string `gorm:"serializer:json"`
+	CreatedAt  time.Time
+}
+
+// TableName overrides the table name used by WebhookDBEntity to `webhooks`
+func (WebhookDBEntity) TableName() string {
+	return "webhooks"
+}
+
+type WebhookDB struct {
+	DB *gorm.DB
+}
+
+// NewWebhookDB creates a new instance of repository.WebhookDB
+func NewWebhookDB(DB *gorm.DB) repository.Webhook {
+	return &WebhookDB{DB}
+}
+
+// FindAll returns all webhooks
+func (r *WebhookDB) FindAll(ctx context.Context) ([]entity.Webhook, error) {
+	var webhookEntities []WebhookDBEntity
+	if err := dbOf(ctx, r.DB).Order("id").Find(&webhookEntities).Error; err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]entity.Webhook, 0, len(webhookEntities))
+	for _, e := range webhookEntities {
+		webhooks = append(webhooks, e.toEntityWebhook())
+	}
+
+	return webhooks, nil
+}
+
+// FindByID returns a webhook by ID
+func (r *WebhookDB) FindByID(ctx context.Context, id uint) (entity.Webhook, error) {
+	var webhookEntity WebhookDBEntity
+	err := dbOf(ctx, r.DB).First(&webhookEntity, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Webhook{}, domerrors.ErrWebhookNotFound
+		}
+		return entity.Webhook{}, err
+	}
+
+	return webhookEntity.toEntityWebhook(), nil
+}
+
+// Create creates a webhook
+func (r *WebhookDB) Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error) {
+	webhookEntity := WebhookDBEntity{}.fromEntityWebhook(webhook)
+	if err := dbOf(ctx, r.DB).Create(&webhookEntity).Error; err != nil {
+		return entity.Webhook{}, err
+	}
+
+	return webhookEntity.toEntityWebhook(), nil
+}
+
+// Delete deletes a webhook
+func (r *WebhookDB) Delete(ctx context.Context, id uint) error {
+	result := dbOf(ctx, r.DB).Delete(&WebhookDBEntity{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domerrors.ErrWebhookNotFound
+	}
+
+	return nil
+}
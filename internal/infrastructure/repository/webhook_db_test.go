@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDB_FindAll(t *testing.T) {
+	// Given
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhooks" ORDER BY id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "event_types"}).
+			AddRow(1, "https://example.com/a", "0123456789abcdef", nil).
+			AddRow(2, "https://example.com/b", "0123456789abcdef", `["user.created"]`))
+
+	// When
+	webhooks, err := NewWebhookDB(db).FindAll(context.Background())
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Webhook{
+		{ID: 1, URL: "https://example.com/a", Secret: "0123456789abcdef"},
+		{ID: 2, URL: "https://example.com/b", Secret: "0123456789abcdef",
+			EventTypes: []entity.UserEventType{entity.UserCreated}},
+	}, webhooks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookDB_FindByID(t *testing.T) {
+	const query = `SELECT * FROM "webhooks" WHERE "webhooks"."id" = $1 ORDER BY "webhooks"."id" LIMIT $2`
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		then  func(entity.Webhook, error)
+	}{
+		{
+			name: "should find a webhook",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret"}).
+						AddRow(1, "https://example.com/a", "0123456789abcdef"))
+			},
+			then: func(webhook entity.Webhook, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, entity.Webhook{ID: 1, URL: "https://example.com/a", Secret: "0123456789abcdef"}, webhook)
+			},
+		},
+		{
+			name: "should not find a webhook",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			then: func(webhook entity.Webhook, err error) {
+				assert.ErrorIs(t, err, domerrors.ErrWebhookNotFound)
+				assert.Empty(t, webhook)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			db, mock, err := newMockPostgresSqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+
+			// When
+			webhook, err := NewWebhookDB(db).FindByID(context.Background(), 1)
+
+			// Then
+			tt.then(webhook, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookDB_Create(t *testing.T) {
+	const query = "INSERT INTO `webhooks` (`url`,`secret`,`event_types`,`created_at`) VALUES (?,?,?,?)"
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		then  func(entity.Webhook, error)
+	}{
+		{
+			name: "should create a webhook",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("https://example.com/a", "0123456789abcdef", `["user.created"]`, AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			then: func(webhook entity.Webhook, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), webhook.ID)
+				assert.False(t, webhook.CreatedAt.IsZero())
+			},
+		},
+		{
+			name: "should not create a webhook",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(errors.New("failed to create"))
+				mock.ExpectRollback()
+			},
+			then: func(webhook entity.Webhook, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, webhook)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+			// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+			db, mock, err := newMockMySqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+
+			// When
+			webhook, err := NewWebhookDB(db).Create(context.Background(), entity.Webhook{URL: "https://example.com/a",
+				Secret: "0123456789abcdef", EventTypes: []entity.UserEventType{entity.UserCreated}})
+
+			// Then
+			tt.then(webhook, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookDB_Delete(t *testing.T) {
+	const query = `DELETE FROM "webhooks" WHERE "webhooks"."id" = $1`
+
+	tests := []struct {
+		name     string
+		affected int64
+		then     func(error)
+	}{
+		{
+			name:     "should delete a webhook",
+			affected: 1,
+			then: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:     "should not delete a webhook not found",
+			affected: 0,
+			then: func(err error) {
+				assert.ErrorIs(t, err, domerrors.ErrWebhookNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			db, mock, err := newMockPostgresSqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(1).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			mock.ExpectCommit()
+
+			// When
+			err = NewWebhookDB(db).Delete(context.Background(), 1)
+
+			// Then
+			tt.then(err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
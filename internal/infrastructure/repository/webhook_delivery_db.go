@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookDeliveryDBEntity represents the delivery of an event to a webhook in the database
+type WebhookDeliveryDBEntity struct {
+	ID             uint                  `gorm:"primarykey"`
+	WebhookID      uint                  `gorm:"not null;index"`
+	EventID        uint                  `gorm:"not null"`
+	EventType      string                `gorm:"not null"`
+	UserID         uint                  `gorm:"not null"`
+	User           *UserSnapshotDBEntity `gorm:"serializer:json;not null"`
+	OccurredAt     time.Time             `gorm:"not null"`
+	Status         string                `gorm:"not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int                   `gorm:"not null"`
+	NextAttemptAt  time.Time             `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int                   `gorm:"not null"`
+	LastError      string                `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TableName overrides the table name used by WebhookDeliveryDBEntity to `webhook_deliveries`
+func (WebhookDeliveryDBEntity) TableName() string {
+	return "webhook_deliveries"
+}
+
+type WebhookDeliveryDB struct {
+	DB *gorm.DB
+}
+
+// NewWebhookDeliveryDB creates a new instance of repository.WebhookDeliveryDB
+func NewWebhookDeliveryDB(DB *gorm.DB) repository.WebhookDelivery {
+	return &WebhookDeliveryDB{DB}
+}
+
+// Create creates a delivery, in the transaction of the given context if any
+func (r *WebhookDeliveryDB) Create(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error) {
+	deliveryEntity := WebhookDeliveryDBEntity{}.fromEntityWebhookDelivery(delivery)
+	if err := dbOf(ctx, r.DB).Create(&deliveryEntity).Error; err != nil {
+		return entity.WebhookDelivery{}, err
+	}
+
+	return deliveryEntity.toEntityWebhookDelivery(), nil
+}
+
+// FindDue returns the first due deliveries, locking them without waiting for the ones locked by the other
+// transactions when in the transaction of the given context. SQLite, which has no row locks, serializes the
+// transactions instead, as they take the lock of the database when they begin.
+func (r *WebhookDeliveryDB) FindDue(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	tx, ok := txOf(ctx)
+	if ok {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	} else {
+		tx = r.DB
+	}
+
+	var deliveryEntities []WebhookDeliveryDBEntity
+	err := tx.Where("status = ? AND next_attempt_at <= ?", string(entity.WebhookDeliveryPending), now).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&deliveryEntities).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toEntityWebhookDeliveries(deliveryEntities), nil
+}
+
+// Update updates the status, the attempts and the outcome of the last attempt of a delivery
+func (r *WebhookDeliveryDB) Update(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error) {
+	deliveryEntity := WebhookDeliveryDBEntity{}.fromEntityWebhookDelivery(delivery)
+	err := dbOf(ctx, r.DB).Model(&deliveryEntity).
+		Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "updated_at").
+		Updates(&deliveryEntity).Error
+	if err != nil {
+		return entity.WebhookDelivery{}, err
+	}
+
+	return deliveryEntity.toEntityWebhookDelivery(), nil
+}
+
+// FindByWebhookID returns the latest deliveries of a webhook
+func (r *WebhookDeliveryDB) FindByWebhookID(ctx context.Context, webhookID uint, limit int) ([]entity.WebhookDelivery, error) {
+	var deliveryEntities []WebhookDeliveryDBEntity
+	err := dbOf(ctx, r.DB).Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveryEntities).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toEntityWebhookDeliveries(deliveryEntities), nil
+}
+
+// DeleteByWebhookID deletes the deliveries of a webhook
+func (r *WebhookDeliveryDB) DeleteByWebhookID(ctx context.Context, webhookID uint) error {
+	return dbOf(ctx, r.DB).Where("webhook_id = ?", webhookID).Delete(&WebhookDeliveryDBEntity{}).Error
+}
+
+// toEntityWebhookDeliveries converts the given WebhookDeliveryDBEntity to entity.WebhookDelivery
+func toEntityWebhookDeliveries(deliveryEntities []WebhookDeliveryDBEntity) []entity.WebhookDelivery {
+	deliveries := make([]entity.WebhookDelivery, 0, len(deliveryEntities))
+	for _, e := range deliveryEntities {
+		deliveries = append(deliveries, e.toEntityWebhookDelivery())
+	}
+	return deliveries
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDeliveryDB_Create(t *testing.T) {
+	const query = "INSERT INTO `webhook_deliveries` (`webhook_id`,`event_id`,`event_type`,`user_id`,`user`,`occurred_at`," +
+		"`status`,`attempts`,`next_attempt_at`,`last_status_code`,`last_error`,`created_at`,`updated_at`) " +
+		"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)"
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		then  func(entity.WebhookDelivery, error)
+	}{
+		{
+			name: "should create a delivery",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(2, 1, "user.created", 1,
+						`{"id":1,"name":"John","surname":"Doe","roles":null,"version":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+						AnyTime{}, "pending", 0, AnyTime{}, 0, "", AnyTime{}, AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			then: func(delivery entity.WebhookDelivery, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), delivery.ID)
+				assert.False(t, delivery.CreatedAt.IsZero())
+			},
+		},
+		{
+			name: "should not create a delivery",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(errors.New("failed to create"))
+				mock.ExpectRollback()
+			},
+			then: func(delivery entity.WebhookDelivery, err error) {
+				assert.Error(t, err)
+				assert.Empty(t, delivery)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			// here we create a new mock database for MySQL due to the limitations of go-sqlmock with PostgresSQL
+			// see https://github.com/DATA-DOG/go-sqlmock/issues/118
+			db, mock, err := newMockMySqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+			event := entity.UserEvent{ID: 1, Type: entity.UserCreated, UserID: 1,
+				User: entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}, OccurredAt: time.Now()}
+
+			// When
+			delivery, err := NewWebhookDeliveryDB(db).Create(context.Background(), entity.WebhookDelivery{
+				WebhookID: 2, Event: event, Status: entity.WebhookDeliveryPending, NextAttemptAt: time.Now()})
+
+			// Then
+			tt.then(delivery, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookDeliveryDB_FindDue(t *testing.T) {
+	const query = `SELECT * FROM "webhook_deliveries" WHERE status = $1 AND next_attempt_at <= $2 ` +
+		`ORDER BY next_attempt_at, id LIMIT $3`
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "webhook_id", "event_id", "event_type", "user_id", "user", "status"}).
+			AddRow(1, 2, 1, "user.created", 1, `{"id":1,"name":"John","surname":"Doe","version":1}`, "pending")
+	}
+
+	tests := []struct {
+		name  string
+		given func(sqlmock.Sqlmock)
+		when  func(*WebhookDeliveryDB, time.Time) ([]entity.WebhookDelivery, error)
+	}{
+		{
+			name: "should find the due deliveries",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("pending", AnyTime{}, 10).
+					WillReturnRows(rows())
+			},
+			when: func(r *WebhookDeliveryDB, now time.Time) ([]entity.WebhookDelivery, error) {
+				return r.FindDue(context.Background(), now, 10)
+			},
+		},
+		{
+			name: "should lock the due deliveries found within a transaction",
+			given: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(query+" FOR UPDATE SKIP LOCKED")).
+					WithArgs("pending", AnyTime{}, 10).
+					WillReturnRows(rows())
+				mock.ExpectCommit()
+			},
+			when: func(r *WebhookDeliveryDB, now time.Time) ([]entity.WebhookDelivery, error) {
+				var deliveries []entity.WebhookDelivery
+				err := NewTransactorDB(r.DB).WithinTransaction(context.Background(), func(ctx context.Context) error {
+					var err error
+					deliveries, err = r.FindDue(ctx, now, 10)
+					return err
+				})
+				return deliveries, err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			db, mock, err := newMockPostgresSqlDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.given(mock)
+
+			// When
+			deliveries, err := tt.when(&WebhookDeliveryDB{db}, time.Now())
+
+			// Then
+			assert.NoError(t, err)
+			if assert.Len(t, deliveries, 1) {
+				assert.Equal(t, uint(2), deliveries[0].WebhookID)
+				assert.Equal(t, entity.WebhookDeliveryPending, deliveries[0].Status)
+				assert.Equal(t, entity.UserCreated, deliveries[0].Event.Type)
+				assert.Equal(t, entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1}, deliveries[0].Event.User)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWebhookDeliveryDB_Update(t *testing.T) {
+	// Given
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "status"=$1,"attempts"=$2,"next_attempt_at"=$3,`+
+		`"last_status_code"=$4,"last_error"=$5,"updated_at"=$6 WHERE "id" = $7`)).
+		WithArgs("dead", 8, AnyTime{}, 503, "unexpected status 503", AnyTime{}, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// When
+	delivery, err := NewWebhookDeliveryDB(db).Update(context.Background(), entity.WebhookDelivery{ID: 1,
+		Status: entity.WebhookDeliveryDead, Attempts: 8, NextAttemptAt: time.Now(), LastStatusCode: 503,
+		LastError: "unexpected status 503"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, entity.WebhookDeliveryDead, delivery.Status)
+	assert.False(t, delivery.UpdatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryDB_FindByWebhookID(t *testing.T) {
+	// Given
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`)).
+		WithArgs(2, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "status"}).
+			AddRow(2, 2, "dead").
+			AddRow(1, 2, "delivered"))
+
+	// When
+	deliveries, err := NewWebhookDeliveryDB(db).FindByWebhookID(context.Background(), 2, 100)
+
+	// Then
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, uint(2), deliveries[0].ID)
+		assert.Equal(t, entity.WebhookDeliveryDead, deliveries[0].Status)
+		assert.Equal(t, entity.WebhookDeliveryDelivered, deliveries[1].Status)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryDB_DeleteByWebhookID(t *testing.T) {
+	// Given
+	db, mock, err := newMockPostgresSqlDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "webhook_deliveries" WHERE webhook_id = $1`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	// When
+	err = NewWebhookDeliveryDB(db).DeleteByWebhookID(context.Background(), 2)
+
+	// Then
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// WebhookDeliveryInMemoryEntity represents the delivery of an event to a webhook in the in-memory database
+type WebhookDeliveryInMemoryEntity struct {
+	ID             uint
+	WebhookID      uint
+	Event          UserOutboxInMemoryEntity
+	Status         entity.WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookDeliveryInMemory represents a webhook delivery repository in the in-memory database.
+// The deliveries are kept in the order they were created.
+type WebhookDeliveryInMemory struct {
+	mu         sync.RWMutex
+	lastID     uint
+	deliveries []WebhookDeliveryInMemoryEntity
+}
+
+// NewWebhookDeliveryInMemory creates a new instance of repository.WebhookDeliveryInMemory
+func NewWebhookDeliveryInMemory() repository.WebhookDelivery {
+	return &WebhookDeliveryInMemory{}
+}
+
+// Create creates a delivery
+func (r *WebhookDeliveryInMemory) Create(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	delivery.ID = r.lastID
+	delivery.CreatedAt = time.Now()
+	delivery.UpdatedAt = delivery.CreatedAt
+	deliveryEntity := WebhookDeliveryInMemoryEntity{}.fromEntityWebhookDelivery(delivery)
+	r.deliveries = append(r.deliveries, deliveryEntity)
+
+	return deliveryEntity.toEntityWebhookDelivery(), nil
+}
+
+// FindDue returns the first due deliveries
+func (r *WebhookDeliveryInMemory) FindDue(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := make([]entity.WebhookDelivery, 0)
+	for _, e := range r.deliveries {
+		if e.Status == entity.WebhookDeliveryPending && !e.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, e.toEntityWebhookDelivery())
+		}
+	}
+	slices.SortStableFunc(deliveries, func(a, b entity.WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+
+	return deliveries[:min(len(deliveries), limit)], nil
+}
+
+// Update updates the status, the attempts and the outcome of the last attempt of a delivery
+func (r *WebhookDeliveryInMemory) Update(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.deliveries, func(e WebhookDeliveryInMemoryEntity) bool {
+		return e.ID == delivery.ID
+	})
+	if i < 0 {
+		return delivery, nil
+	}
+
+	e := &r.deliveries[i]
+	e.Status = delivery.Status
+	e.Attempts = delivery.Attempts
+	e.NextAttemptAt = delivery.NextAttemptAt
+	e.LastStatusCode = delivery.LastStatusCode
+	e.LastError = delivery.LastError
+	e.UpdatedAt = time.Now()
+
+	return e.toEntityWebhookDelivery(), nil
+}
+
+// FindByWebhookID returns the latest deliveries of a webhook
+func (r *WebhookDeliveryInMemory) FindByWebhookID(ctx context.Context, webhookID uint, limit int) ([]entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := make([]entity.WebhookDelivery, 0)
+	for _, e := range r.deliveries {
+		if e.WebhookID == webhookID {
+			deliveries = append(deliveries, e.toEntityWebhookDelivery())
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b entity.WebhookDelivery) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return deliveries[:min(len(deliveries), limit)], nil
+}
+
+// DeleteByWebhookID deletes the deliveries of a webhook
+func (r *WebhookDeliveryInMemory) DeleteByWebhookID(ctx context.Context, webhookID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(e WebhookDeliveryInMemoryEntity) bool {
+		return e.WebhookID == webhookID
+	})
+
+	return nil
+}
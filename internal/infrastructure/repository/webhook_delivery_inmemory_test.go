@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDeliveryInMemory_CreateFindUpdateAndDelete(t *testing.T) {
+	repo := NewWebhookDeliveryInMemory()
+	now := time.Now()
+	event := entity.NewUserEvent(entity.UserCreated, entity.User{ID: 1, Name: "John", Surname: "Doe", Version: 1})
+	create := func(webhookID uint, nextAttemptAt time.Time) entity.WebhookDelivery {
+		delivery, err := repo.Create(context.Background(), entity.WebhookDelivery{WebhookID: webhookID, Event: event,
+			Status: entity.WebhookDeliveryPending, NextAttemptAt: nextAttemptAt})
+		require.NoError(t, err)
+		return delivery
+	}
+	first := create(1, now)
+	second := create(2, now.Add(-time.Minute))
+	third := create(1, now.Add(time.Minute))
+
+	assert.Equal(t, uint(1), first.ID)
+	assert.Equal(t, event, first.Event)
+	assert.False(t, first.CreatedAt.IsZero())
+
+	// the due deliveries are found in the order they are due
+	due, err := repo.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.WebhookDelivery{second, first}, due)
+
+	first.Status = entity.WebhookDeliveryDelivered
+	first.Attempts = 1
+	first.LastStatusCode = 204
+	updated, err := repo.Update(context.Background(), first)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WebhookDeliveryDelivered, updated.Status)
+	assert.Equal(t, 1, updated.Attempts)
+
+	due, err = repo.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.WebhookDelivery{second}, due)
+
+	// the deliveries of a webhook are found latest first
+	deliveries, err := repo.FindByWebhookID(context.Background(), 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.WebhookDelivery{third, updated}, deliveries)
+
+	require.NoError(t, repo.DeleteByWebhookID(context.Background(), 1))
+	deliveries, err = repo.FindByWebhookID(context.Background(), 1, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+	deliveries, err = repo.FindByWebhookID(context.Background(), 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.WebhookDelivery{second}, deliveries)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// toEntityWebhookDelivery converts a WebhookDeliveryDBEntity to an entity.WebhookDelivery
+func (d WebhookDeliveryDBEntity) toEntityWebhookDelivery() entity.WebhookDelivery {
+	delivery := entity.WebhookDelivery{
+		ID:        d.ID,
+		WebhookID: d.WebhookID,
+		Event: entity.UserEvent{
+			ID:         d.EventID,
+			Type:       entity.UserEventType(d.EventType),
+			UserID:     d.UserID,
+			OccurredAt: d.OccurredAt,
+		},
+		Status:         entity.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+	if user := d.User.toEntityUser(); user != nil {
+		delivery.Event.User = *user
+	}
+	return delivery
+}
+
+// fromEntityWebhookDelivery converts an entity.WebhookDelivery to a WebhookDeliveryDBEntity
+func (d WebhookDeliveryDBEntity) fromEntityWebhookDelivery(e entity.WebhookDelivery) WebhookDeliveryDBEntity {
+	d.ID = e.ID
+	d.WebhookID = e.WebhookID
+	d.EventID = e.Event.ID
+	d.EventType = string(e.Event.Type)
+	d.UserID = e.Event.UserID
+	d.User = fromEntityUserSnapshot(&e.Event.User)
+	d.OccurredAt = e.Event.OccurredAt
+	d.Status = string(e.Status)
+	d.Attempts = e.Attempts
+	d.NextAttemptAt = e.NextAttemptAt
+	d.LastStatusCode = e.LastStatusCode
+	d.LastError = e.LastError
+	d.CreatedAt = e.CreatedAt
+	d.UpdatedAt = e.UpdatedAt
+	return d
+}
+
+// toEntityWebhookDelivery converts a WebhookDeliveryInMemoryEntity to an entity.WebhookDelivery
+func (d WebhookDeliveryInMemoryEntity) toEntityWebhookDelivery() entity.WebhookDelivery {
+	return entity.WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          d.Event.toEntityUserEvent(),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+// fromEntityWebhookDelivery converts an entity.WebhookDelivery to a WebhookDeliveryInMemoryEntity
+func (d WebhookDeliveryInMemoryEntity) fromEntityWebhookDelivery(e entity.WebhookDelivery) WebhookDeliveryInMemoryEntity {
+	d.ID = e.ID
+	d.WebhookID = e.WebhookID
+	d.Event = UserOutboxInMemoryEntity{}.fromEntityUserEvent(e.Event)
+	d.Status = e.Status
+	d.Attempts = e.Attempts
+	d.NextAttemptAt = e.NextAttemptAt
+	d.LastStatusCode = e.LastStatusCode
+	d.LastError = e.LastError
+	d.CreatedAt = e.CreatedAt
+	d.UpdatedAt = e.UpdatedAt
+	return d
+}
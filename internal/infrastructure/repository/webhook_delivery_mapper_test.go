@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntityWebhookDelivery() entity.WebhookDelivery {
+	return entity.WebhookDelivery{
+		ID:             1,
+		WebhookID:      2,
+		Event:          newEntityUserEvent(),
+		Status:         entity.WebhookDeliveryPending,
+		Attempts:       3,
+		NextAttemptAt:  time.Now().Add(time.Minute),
+		LastStatusCode: 503,
+		LastError:      "unexpected status 503",
+		CreatedAt:      time.Now().Add(-time.Hour),
+		UpdatedAt:      time.Now(),
+	}
+}
+
+func TestWebhookDeliveryDBEntity_Mapping(t *testing.T) {
+	delivery := newEntityWebhookDelivery()
+	deliveryEntity := WebhookDeliveryDBEntity{}.fromEntityWebhookDelivery(delivery)
+	assert.Equal(t, delivery, deliveryEntity.toEntityWebhookDelivery())
+}
+
+func TestWebhookDeliveryInMemoryEntity_Mapping(t *testing.T) {
+	delivery := newEntityWebhookDelivery()
+	deliveryEntity := WebhookDeliveryInMemoryEntity{}.fromEntityWebhookDelivery(delivery)
+	assert.Equal(t, delivery, deliveryEntity.toEntityWebhookDelivery())
+}
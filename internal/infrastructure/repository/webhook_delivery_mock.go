@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookDelivery is a mock implementation of repository.WebhookDelivery by using testify mock.Mock
+type MockWebhookDelivery struct {
+	mock.Mock
+}
+
+func NewMockWebhookDelivery() *MockWebhookDelivery {
+	return &MockWebhookDelivery{}
+}
+
+func (m *MockWebhookDelivery) Create(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error) {
+	args := m.Called(ctx, delivery)
+	return args.Get(0).(entity.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDelivery) FindDue(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]entity.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDelivery) Update(ctx context.Context, delivery entity.WebhookDelivery) (entity.WebhookDelivery, error) {
+	args := m.Called(ctx, delivery)
+	return args.Get(0).(entity.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDelivery) FindByWebhookID(ctx context.Context, webhookID uint, limit int) ([]entity.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, limit)
+	return args.Get(0).([]entity.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDelivery) DeleteByWebhookID(ctx context.Context, webhookID uint) error {
+	args := m.Called(ctx, webhookID)
+	return args.Error(0)
+}
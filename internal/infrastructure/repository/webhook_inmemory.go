@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+)
+
+// WebhookInMemoryEntity represents a webhook entity in the in-memory database
+type WebhookInMemoryEntity struct {
+	ID         uint
+	URL        string
+	Secret     string
+	EventTypes []entity.UserEventType
+	CreatedAt  time.Time
+}
+
+// WebhookInMemory represents a webhook repository in the in-memory database
+type WebhookInMemory struct {
+	mu       sync.RWMutex
+	lastID   uint
+	webhooks map[uint]WebhookInMemoryEntity
+}
+
+// NewWebhookInMemory creates a new instance of repository.WebhookInMemory
+func NewWebhookInMemory() repository.Webhook {
+	return &WebhookInMemory{webhooks: make(map[uint]WebhookInMemoryEntity)}
+}
+
+// FindAll returns all webhooks
+func (r *WebhookInMemory) FindAll(ctx context.Context) ([]entity.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := make([]entity.Webhook, 0, len(r.webhooks))
+	for _, e := range r.webhooks {
+		webhooks = append(webhooks, e.toEntityWebhook())
+	}
+	slices.SortFunc(webhooks, func(a, b entity.Webhook) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return webhooks, nil
+}
+
+// FindByID returns a webhook by ID
+func (r *WebhookInMemory) FindByID(ctx context.Context, id uint) (entity.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhookEntity, ok := r.webhooks[id]
+	if !ok {
+		return entity.Webhook{}, errors.ErrWebhookNotFound
+	}
+
+	return webhookEntity.toEntityWebhook(), nil
+}
+
+// Create creates a webhook
+func (r *WebhookInMemory) Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	webhook.ID = r.lastID
+	webhook.CreatedAt = time.Now()
+	webhookEntity := WebhookInMemoryEntity{}.fromEntityWebhook(webhook)
+	r.webhooks[webhook.ID] = webhookEntity
+
+	return webhookEntity.toEntityWebhook(), nil
+}
+
+// Delete deletes a webhook
+func (r *WebhookInMemory) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return errors.ErrWebhookNotFound
+	}
+	delete(r.webhooks, id)
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookInMemory_CreateFindAndDelete(t *testing.T) {
+	repo := NewWebhookInMemory()
+	first, err := repo.Create(context.Background(), entity.Webhook{URL: "https://example.com/a", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	second, err := repo.Create(context.Background(), entity.Webhook{URL: "https://example.com/b", Secret: "0123456789abcdef",
+		EventTypes: []entity.UserEventType{entity.UserCreated}})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(1), first.ID)
+	assert.Equal(t, uint(2), second.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+
+	webhooks, err := repo.FindAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Webhook{first, second}, webhooks)
+
+	found, err := repo.FindByID(context.Background(), second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, second, found)
+
+	require.NoError(t, repo.Delete(context.Background(), first.ID))
+	_, err = repo.FindByID(context.Background(), first.ID)
+	assert.ErrorIs(t, err, errors.ErrWebhookNotFound)
+	assert.ErrorIs(t, repo.Delete(context.Background(), first.ID), errors.ErrWebhookNotFound)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// toEntityWebhook converts a WebhookDBEntity to an entity.Webhook
+func (w WebhookDBEntity) toEntityWebhook() entity.Webhook {
+	webhook := entity.Webhook{
+		ID:        w.ID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		CreatedAt: w.CreatedAt,
+	}
+	for _, eventType := range w.EventTypes {
+		webhook.EventTypes = append(webhook.EventTypes, entity.UserEventType(eventType))
+	}
+	return webhook
+}
+
+// fromEntityWebhook converts an entity.Webhook to a WebhookDBEntity
+func (w WebhookDBEntity) fromEntityWebhook(e entity.Webhook) WebhookDBEntity {
+	w.ID = e.ID
+	w.URL = e.URL
+	w.Secret = e.Secret
+	w.EventTypes = nil
+	for _, eventType := range e.EventTypes {
+		w.EventTypes = append(w.EventTypes, string(eventType))
+	}
+	w.CreatedAt = e.CreatedAt
+	return w
+}
+
+// toEntityWebhook converts a WebhookInMemoryEntity to an entity.Webhook
+func (w WebhookInMemoryEntity) toEntityWebhook() entity.Webhook {
+	return entity.Webhook{
+		ID:         w.ID,
+		URL:        w.URL,
+		Secret:     w.Secret,
+		EventTypes: slices.Clone(w.EventTypes),
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+// fromEntityWebhook converts an entity.Webhook to a WebhookInMemoryEntity
+func (w WebhookInMemoryEntity) fromEntityWebhook(e entity.Webhook) WebhookInMemoryEntity {
+	w.ID = e.ID
+	w.URL = e.URL
+	w.Secret = e.Secret
+	w.EventTypes = slices.Clone(e.EventTypes)
+	w.CreatedAt = e.CreatedAt
+	return w
+}
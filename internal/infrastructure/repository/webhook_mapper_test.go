@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntityWebhook() entity.Webhook {
+	return entity.Webhook{
+		ID:         1,
+		URL:        "https://example.com/hooks",
+		Secret:     "0123456789abcdef",
+		EventTypes: []entity.UserEventType{entity.UserCreated, entity.UserDeleted},
+		CreatedAt:  time.Now(),
+	}
+}
+
+func TestWebhookDBEntity_Mapping(t *testing.T) {
+	webhook := newEntityWebhook()
+	webhookEntity := WebhookDBEntity{}.fromEntityWebhook(webhook)
+	assert.Equal(t, []string{"user.created", "user.deleted"}, webhookEntity.EventTypes)
+	assert.Equal(t, webhook, webhookEntity.toEntityWebhook())
+}
+
+func TestWebhookInMemoryEntity_Mapping(t *testing.T) {
+	webhook := newEntityWebhook()
+	webhookEntity := WebhookInMemoryEntity{}.fromEntityWebhook(webhook)
+	assert.Equal(t, webhook, webhookEntity.toEntityWebhook())
+
+	// the event types are not shared with the stored entity
+	webhook.EventTypes[0] = entity.UserPurged
+	assert.Equal(t, entity.UserCreated, webhookEntity.EventTypes[0])
+}
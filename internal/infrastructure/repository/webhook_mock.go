@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhook is a mock implementation of repository.Webhook by using testify mock.Mock
+type MockWebhook struct {
+	mock.Mock
+}
+
+func NewMockWebhook() *MockWebhook {
+	return &MockWebhook{}
+}
+
+func (m *MockWebhook) FindAll(ctx context.Context) ([]entity.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.Webhook), args.Error(1)
+}
+
+func (m *MockWebhook) FindByID(ctx context.Context, id uint) (entity.Webhook, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(entity.Webhook), args.Error(1)
+}
+
+func (m *MockWebhook) Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error) {
+	args := m.Called(ctx, webhook)
+	return args.Get(0).(entity.Webhook), args.Error(1)
+}
+
+func (m *MockWebhook) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,79 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// cursorSeparator separates the payload from the signature in the cursor tokens
+const cursorSeparator = "."
+
+// cursorPayload is the signed payload of the cursor tokens
+type cursorPayload struct {
+	SortBy    entity.UserField     `json:"s"`
+	Direction entity.SortDirection `json:"d"`
+	Value     string               `json:"v,omitempty"`
+	ID        uint                 `json:"i"`
+}
+
+// CursorCodec encodes the cursors into tokens made of their base64url JSON payload and its HMAC-SHA256 signature,
+// so that clients cannot forge the positions they resume from
+type CursorCodec struct {
+	secret []byte
+}
+
+// NewCursorCodec creates a new instance of service.UserCursorCodec signing with the given secret
+func NewCursorCodec(secret []byte) (service.UserCursorCodec, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("cursor secret is required")
+	}
+	return &CursorCodec{secret: secret}, nil
+}
+
+// Encode returns the signed token of the given cursor
+func (c *CursorCodec) Encode(cursor entity.UserCursor) (string, error) {
+	payload, err := json.Marshal(cursorPayload(cursor))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot encode cursor")
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + cursorSeparator + base64.RawURLEncoding.EncodeToString(c.sign(encoded)), nil
+}
+
+// Decode returns the cursor of the given token or errors.ErrInvalidCursor if it is malformed or wrongly signed
+func (c *CursorCodec) Decode(token string) (entity.UserCursor, error) {
+	encoded, signature, ok := strings.Cut(token, cursorSeparator)
+	if !ok {
+		return entity.UserCursor{}, domerrors.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(encoded)) {
+		return entity.UserCursor{}, domerrors.ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return entity.UserCursor{}, domerrors.ErrInvalidCursor
+	}
+	var p cursorPayload
+	if err = json.Unmarshal(payload, &p); err != nil {
+		return entity.UserCursor{}, domerrors.ErrInvalidCursor
+	}
+
+	return entity.UserCursor(p), nil
+}
+
+// sign returns the HMAC-SHA256 of the given encoded payload
+func (c *CursorCodec) sign(encoded string) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
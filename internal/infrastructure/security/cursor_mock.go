@@ -0,0 +1,25 @@
+package security
+
+import (
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCursorCodec is a mock implementation of service.UserCursorCodec by using testify mock.Mock
+type MockCursorCodec struct {
+	mock.Mock
+}
+
+func NewMockCursorCodec() *MockCursorCodec {
+	return &MockCursorCodec{}
+}
+
+func (m *MockCursorCodec) Encode(cursor entity.UserCursor) (string, error) {
+	args := m.Called(cursor)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCursorCodec) Decode(token string) (entity.UserCursor, error) {
+	args := m.Called(token)
+	return args.Get(0).(entity.UserCursor), args.Error(1)
+}
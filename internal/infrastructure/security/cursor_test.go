@@ -0,0 +1,71 @@
+package security
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCursorCodec_WithoutSecret(t *testing.T) {
+	codec, err := NewCursorCodec(nil)
+	assert.Error(t, err)
+	assert.Nil(t, codec)
+}
+
+func TestCursorCodec_EncodeAndDecode(t *testing.T) {
+	codec, err := NewCursorCodec([]byte("secret"))
+	require.NoError(t, err)
+	cursor := entity.UserCursor{SortBy: entity.UserFieldName, Direction: entity.SortDesc, Value: "Jane Doe", ID: 2}
+
+	token, err := codec.Encode(cursor)
+	assert.NoError(t, err)
+	assert.NotContains(t, token, "Jane")
+
+	decoded, err := codec.Decode(token)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestCursorCodec_Decode_Invalid(t *testing.T) {
+	codec, err := NewCursorCodec([]byte("secret"))
+	require.NoError(t, err)
+	token, err := codec.Encode(entity.UserCursor{SortBy: entity.UserFieldID, Direction: entity.SortAsc, ID: 2})
+	require.NoError(t, err)
+	payload, signature, _ := strings.Cut(token, cursorSeparator)
+
+	other, err := NewCursorCodec([]byte("other"))
+	require.NoError(t, err)
+	forged, err := other.Encode(entity.UserCursor{SortBy: entity.UserFieldID, Direction: entity.SortAsc, ID: 1})
+	require.NoError(t, err)
+	forgedPayload, _, _ := strings.Cut(forged, cursorSeparator)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "should reject an empty token", token: ""},
+		{name: "should reject a token without signature", token: payload},
+		{name: "should reject a token signed with another secret", token: forged},
+		{name: "should reject a tampered payload", token: forgedPayload + cursorSeparator + signature},
+		{name: "should reject a malformed signature", token: payload + cursorSeparator + "%%%"},
+		{name: "should reject a malformed payload", token: signed(codec.(*CursorCodec), "%%%")},
+		{name: "should reject a payload which is not JSON", token: signed(codec.(*CursorCodec), base64.RawURLEncoding.EncodeToString([]byte("id=1")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := codec.Decode(tt.token)
+			assert.ErrorIs(t, err, domerrors.ErrInvalidCursor)
+			assert.Equal(t, entity.UserCursor{}, cursor)
+		})
+	}
+}
+
+// signed returns the given encoded payload along with its signature by the given codec
+func signed(codec *CursorCodec, encoded string) string {
+	return encoded + cursorSeparator + base64.RawURLEncoding.EncodeToString(codec.sign(encoded))
+}
@@ -0,0 +1,176 @@
+package security
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
+)
+
+// ErrCannotIssue is returned when tokens are issued by a JWT without any active private key.
+var ErrCannotIssue = errors.New("no private key configured to issue tokens")
+
+// Claims are the claims of the access tokens verified by this API.
+// The profile claims are only set by the OpenID Connect providers.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+	// Scope is the space separated list of the granted scopes, as defined by RFC 8693
+	Scope string `json:"scope,omitempty"`
+
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+}
+
+// Principal returns the entity.Principal identified by the claims.
+// The user ID is only set when the subject is the ID of a user of this API.
+func (c *Claims) Principal() entity.Principal {
+	p := entity.Principal{
+		Subject: c.Subject,
+		Roles:   c.Roles,
+		Scopes:  strings.Fields(c.Scope),
+	}
+	if id, err := strconv.ParseUint(c.Subject, 10, 0); err == nil {
+		p.UserID = uint(id)
+	}
+	if c.ExpiresAt != nil {
+		p.ExpiresAt = c.ExpiresAt.Time
+	}
+	return p
+}
+
+// Identity returns the entity.ExternalIdentity identified by the claims
+func (c *Claims) Identity() entity.ExternalIdentity {
+	return entity.ExternalIdentity{
+		Issuer:     c.Issuer,
+		Subject:    c.Subject,
+		Email:      c.Email,
+		Name:       c.Name,
+		GivenName:  c.GivenName,
+		FamilyName: c.FamilyName,
+	}
+}
+
+// JWT issues and validates the access tokens as configured by config.Auth
+type JWT struct {
+	keys   *keyRing
+	parser *jwt.Parser
+
+	issuer   string
+	audience string
+	ttl      time.Duration
+
+	now func() time.Time
+}
+
+// NewJWT creates a new JWT from the given configuration
+func NewJWT(cfg config.Auth) (*JWT, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("auth issuer is required")
+	}
+	if cfg.Audience == "" {
+		return nil, errors.New("auth audience is required")
+	}
+	if cfg.AccessTokenTTL <= 0 {
+		return nil, errors.New("auth access token TTL must be positive")
+	}
+
+	keys, err := newKeyRing(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &JWT{
+		keys:     keys,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.AccessTokenTTL,
+		now:      time.Now,
+	}
+	j.parser = jwt.NewParser(
+		jwt.WithValidMethods(keys.methods()),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		// the claims are validated by the same clock selecting the keys
+		jwt.WithTimeFunc(func() time.Time { return j.now() }),
+	)
+
+	return j, nil
+}
+
+// Issue returns a new access token for the given user, signed by the current key of the key ring
+func (j *JWT) Issue(ctx context.Context, user entity.User) (entity.AccessToken, error) {
+	now := j.now()
+
+	key, ok := j.keys.signing(now)
+	if !ok {
+		return entity.AccessToken{}, ErrCannotIssue
+	}
+
+	expiresAt := now.Add(j.ttl)
+
+	token := jwt.NewWithClaims(key.method, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    j.issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Audience:  jwt.ClaimStrings{j.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Roles: user.Roles,
+	})
+	if key.id != "" {
+		token.Header["kid"] = key.id
+	}
+
+	ss, err := token.SignedString(key.signKey)
+	if err != nil {
+		return entity.AccessToken{}, errors.Wrap(err, "cannot sign token")
+	}
+
+	return entity.AccessToken{Token: ss, ExpiresAt: expiresAt}, nil
+}
+
+// Verify parses the given token and validates its signature, issuer, audience, expiration and not before time.
+// The signature is verified by the key of the key ring given by the `kid` header of the token.
+func (j *JWT) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := j.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := j.keys.verifying(kid, j.now())
+		if !ok {
+			return nil, errors.Errorf("unknown key %q", kid)
+		}
+		// the parser accepts the algorithms of every key, but each key only verifies its own
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, errors.Errorf("unexpected algorithm %q for key %q", t.Method.Alg(), kid)
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// nbf is optional for the JWT spec but every token of this API carries it
+	if claims.NotBefore == nil {
+		return nil, errors.Wrap(jwt.ErrTokenInvalidClaims, "token has no nbf claim")
+	}
+
+	return claims, nil
+}
+
+// PublicKeys returns the public keys of the key ring, to be published so other services can verify the tokens
+func (j *JWT) PublicKeys(ctx context.Context) ([]entity.PublicKey, error) {
+	return j.keys.publicKeys(j.now()), nil
+}
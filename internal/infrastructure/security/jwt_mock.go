@@ -0,0 +1,36 @@
+package security
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTokenIssuer is a mock implementation of service.TokenIssuer by using testify mock.Mock
+type MockTokenIssuer struct {
+	mock.Mock
+}
+
+func NewMockTokenIssuer() *MockTokenIssuer {
+	return &MockTokenIssuer{}
+}
+
+func (m *MockTokenIssuer) Issue(ctx context.Context, user entity.User) (entity.AccessToken, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(entity.AccessToken), args.Error(1)
+}
+
+// MockPublicKeySet is a mock implementation of service.PublicKeySet by using testify mock.Mock
+type MockPublicKeySet struct {
+	mock.Mock
+}
+
+func NewMockPublicKeySet() *MockPublicKeySet {
+	return &MockPublicKeySet{}
+}
+
+func (m *MockPublicKeySet) PublicKeys(ctx context.Context) ([]entity.PublicKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.PublicKey), args.Error(1)
+}
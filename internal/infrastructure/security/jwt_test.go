@@ -0,0 +1,304 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hmacAuthConfig() config.Auth {
+	return config.Auth{
+		Algorithm:      "HS256",
+		Secret:         "test-secret",
+		Issuer:         "hexagonal",
+		Audience:       "hexagonal-api",
+		AccessTokenTTL: 15 * time.Minute,
+		Leeway:         30 * time.Second,
+	}
+}
+
+// writeKeyFiles writes the PEM encoded private and public keys of the given signer to a temporary directory
+func writeKeyFiles(t *testing.T, signer crypto.Signer) (string, string) {
+	dir := t.TempDir()
+
+	private, err := x509.MarshalPKCS8PrivateKey(signer)
+	require.NoError(t, err)
+	privateFile := filepath.Join(dir, "private.pem")
+	require.NoError(t, os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}), 0o600))
+
+	public, err := x509.MarshalPKIXPublicKey(signer.Public())
+	require.NoError(t, err)
+	publicFile := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o600))
+
+	return privateFile, publicFile
+}
+
+func TestNewJWT_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Auth)
+	}{
+		{name: "without issuer", modify: func(cfg *config.Auth) { cfg.Issuer = "" }},
+		{name: "without audience", modify: func(cfg *config.Auth) { cfg.Audience = "" }},
+		{name: "without TTL", modify: func(cfg *config.Auth) { cfg.AccessTokenTTL = 0 }},
+		{name: "with unknown algorithm", modify: func(cfg *config.Auth) { cfg.Algorithm = "XX256" }},
+		{name: "with none algorithm", modify: func(cfg *config.Auth) { cfg.Algorithm = "none" }},
+		{name: "without secret", modify: func(cfg *config.Auth) { cfg.Secret = "" }},
+		{name: "without key files", modify: func(cfg *config.Auth) { cfg.Algorithm = "RS256" }},
+		{name: "with missing key file", modify: func(cfg *config.Auth) {
+			cfg.Algorithm = "RS256"
+			cfg.PrivateKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := hmacAuthConfig()
+			tt.modify(&cfg)
+
+			j, err := NewJWT(cfg)
+			assert.Error(t, err)
+			assert.Nil(t, j)
+		})
+	}
+}
+
+func TestJWT_IssueAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		signer    crypto.Signer
+	}{
+		{name: "HS256", algorithm: "HS256"},
+		{name: "RS256", algorithm: "RS256", signer: rsaKey},
+		{name: "PS256", algorithm: "PS256", signer: rsaKey},
+		{name: "ES256", algorithm: "ES256", signer: ecKey},
+		{name: "EdDSA", algorithm: "EdDSA", signer: edKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := hmacAuthConfig()
+			cfg.Algorithm = tt.algorithm
+			if tt.signer != nil {
+				cfg.Secret = ""
+				cfg.PrivateKeyFile, cfg.PublicKeyFile = writeKeyFiles(t, tt.signer)
+			}
+
+			j, err := NewJWT(cfg)
+			require.NoError(t, err)
+
+			token, err := j.Issue(context.Background(), entity.User{ID: 1, Roles: []string{entity.RoleAdmin}})
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(15*time.Minute), token.ExpiresAt, time.Minute)
+
+			claims, err := j.Verify(token.Token)
+			require.NoError(t, err)
+			assert.Equal(t, "1", claims.Subject)
+			assert.Equal(t, "hexagonal", claims.Issuer)
+			assert.Equal(t, jwt.ClaimStrings{"hexagonal-api"}, claims.Audience)
+			assert.Equal(t, []string{entity.RoleAdmin}, claims.Roles)
+		})
+	}
+}
+
+func TestJWT_Verify_PublicKeyOnly(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateFile, publicFile := writeKeyFiles(t, key)
+
+	cfg := hmacAuthConfig()
+	cfg.Algorithm = "ES256"
+	cfg.Secret = ""
+	cfg.PrivateKeyFile = privateFile
+	issuer, err := NewJWT(cfg)
+	require.NoError(t, err)
+
+	cfg.PrivateKeyFile = ""
+	cfg.PublicKeyFile = publicFile
+	verifier, err := NewJWT(cfg)
+	require.NoError(t, err)
+
+	token, err := issuer.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	claims, err := verifier.Verify(token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+
+	_, err = verifier.Issue(context.Background(), entity.User{ID: 1})
+	assert.ErrorIs(t, err, ErrCannotIssue)
+}
+
+func TestJWT_Verify_Rejected(t *testing.T) {
+	now := time.Now()
+	signed := func(t *testing.T, method jwt.SigningMethod, key any, claims jwt.RegisteredClaims) string {
+		ss, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return ss
+	}
+	valid := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    "hexagonal",
+			Subject:   "1",
+			Audience:  jwt.ClaimStrings{"hexagonal-api"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{
+			name: "minted for another audience",
+			token: func(t *testing.T) string {
+				claims := valid()
+				claims.Audience = jwt.ClaimStrings{"other-api"}
+				return signed(t, jwt.SigningMethodHS256, []byte("test-secret"), claims)
+			},
+		},
+		{
+			name: "minted by another issuer",
+			token: func(t *testing.T) string {
+				claims := valid()
+				claims.Issuer = "other"
+				return signed(t, jwt.SigningMethodHS256, []byte("test-secret"), claims)
+			},
+		},
+		{
+			name: "expired beyond the leeway",
+			token: func(t *testing.T) string {
+				claims := valid()
+				claims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+				return signed(t, jwt.SigningMethodHS256, []byte("test-secret"), claims)
+			},
+		},
+		{
+			name: "without expiration",
+			token: func(t *testing.T) string {
+				claims := valid()
+				claims.ExpiresAt = nil
+				return signed(t, jwt.SigningMethodHS256, []byte("test-secret"), claims)
+			},
+		},
+		{
+			name: "not valid yet",
+			token: func(t *testing.T) string {
+				claims := valid()
+				claims.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+				return signed(t, jwt.SigningMethodHS256, []byte("test-secret"), claims)
+			},
+		},
+		{
+			name: "without not before",
+			token: func(t *testing.T) string {
+				claims := valid()
+				claims.NotBefore = nil
+				return signed(t, jwt.SigningMethodHS256, []byte("test-secret"), claims)
+			},
+		},
+		{
+			name: "signed with another secret",
+			token: func(t *testing.T) string {
+				return signed(t, jwt.SigningMethodHS256, []byte("other-secret"), valid())
+			},
+		},
+		{
+			name: "signed with another algorithm",
+			token: func(t *testing.T) string {
+				return signed(t, jwt.SigningMethodHS512, []byte("test-secret"), valid())
+			},
+		},
+		{
+			name: "not signed",
+			token: func(t *testing.T) string {
+				return signed(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid())
+			},
+		},
+		{
+			name: "malformed",
+			token: func(t *testing.T) string {
+				return "not a token"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := NewJWT(hmacAuthConfig())
+			require.NoError(t, err)
+
+			claims, err := j.Verify(tt.token(t))
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestJWT_Verify_WithinLeeway(t *testing.T) {
+	j, err := NewJWT(hmacAuthConfig())
+	require.NoError(t, err)
+
+	// the token expired 10 seconds ago, which is still accepted by the 30 seconds leeway
+	j.now = func() time.Time { return time.Now().Add(-15*time.Minute - 10*time.Second) }
+	token, err := j.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	j.now = time.Now
+	claims, err := j.Verify(token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+}
+
+func TestClaims_Principal(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims Claims
+		want   entity.Principal
+	}{
+		{
+			name:   "of a user of this API",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}, Roles: []string{entity.RoleAdmin}, Scope: "users:read users:write"},
+			want:   entity.Principal{Subject: "1", UserID: 1, Roles: []string{entity.RoleAdmin}, Scopes: []string{"users:read", "users:write"}},
+		},
+		{
+			name:   "of another subject",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "service-account"}},
+			want:   entity.Principal{Subject: "service-account", Scopes: []string{}},
+		},
+		{
+			name:   "that expires",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))}},
+			want:   entity.Principal{Subject: "1", UserID: 1, Scopes: []string{}, ExpiresAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.claims.Principal())
+		})
+	}
+}
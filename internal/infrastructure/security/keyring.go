@@ -0,0 +1,235 @@
+package security
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
+)
+
+// key is a key of the keyRing, identified by the `kid` header of the tokens it signs
+type key struct {
+	id         string
+	method     jwt.SigningMethod
+	signKey    crypto.PrivateKey
+	verifyKey  crypto.PublicKey
+	activeFrom time.Time
+	retireAt   time.Time
+}
+
+// isRetired reports whether the key no longer verifies tokens at the given time
+func (k key) isRetired(now time.Time) bool {
+	return !k.retireAt.IsZero() && !now.Before(k.retireAt)
+}
+
+// isPublic reports whether the key can be published, that is, whether it is an asymmetric key
+func (k key) isPublic() bool {
+	_, ok := k.method.(*jwt.SigningMethodHMAC)
+	return !ok
+}
+
+// keyRing holds the keys signing and verifying the tokens, which allows rotating them without invalidating the
+// tokens signed by the previous keys
+type keyRing struct {
+	keys []key
+}
+
+// newKeyRing creates a new keyRing from the given configuration.
+// Without config.Auth Keys, the ring holds the single key given by the algorithm, secret and key files.
+func newKeyRing(cfg config.Auth) (*keyRing, error) {
+	if len(cfg.Keys) == 0 {
+		k, err := newKey(cfg.Algorithm, cfg.Secret, cfg.PrivateKeyFile, cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if k.isPublic() {
+			k.id = keyID(k.verifyKey)
+		}
+		return &keyRing{keys: []key{k}}, nil
+	}
+
+	ids := make(map[string]struct{}, len(cfg.Keys))
+	keys := make([]key, 0, len(cfg.Keys))
+	for _, c := range cfg.Keys {
+		if c.ID == "" {
+			return nil, errors.New("auth key id is required")
+		}
+		if _, ok := ids[c.ID]; ok {
+			return nil, errors.Errorf("duplicated auth key id %q", c.ID)
+		}
+		ids[c.ID] = struct{}{}
+
+		if !c.RetireAt.IsZero() && !c.RetireAt.After(c.ActiveFrom) {
+			return nil, errors.Errorf("auth key %q must retire after it is active", c.ID)
+		}
+
+		k, err := newKey(c.Algorithm, "", c.PrivateKeyFile, c.PublicKeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid auth key %q", c.ID)
+		}
+		if !k.isPublic() {
+			return nil, errors.Errorf("auth key %q must use an asymmetric algorithm", c.ID)
+		}
+
+		k.id = c.ID
+		k.activeFrom = c.ActiveFrom
+		k.retireAt = c.RetireAt
+		keys = append(keys, k)
+	}
+
+	return &keyRing{keys: keys}, nil
+}
+
+// signing returns the key signing the new tokens at the given time, that is, the key with a private key which has
+// been active for the shortest time
+func (r *keyRing) signing(now time.Time) (key, bool) {
+	var (
+		signing key
+		found   bool
+	)
+	for _, k := range r.keys {
+		if k.signKey == nil || k.isRetired(now) || now.Before(k.activeFrom) {
+			continue
+		}
+		if !found || k.activeFrom.After(signing.activeFrom) {
+			signing, found = k, true
+		}
+	}
+	return signing, found
+}
+
+// verifying returns the key with the given id accepted to verify tokens at the given time
+func (r *keyRing) verifying(id string, now time.Time) (key, bool) {
+	for _, k := range r.keys {
+		if k.id == id && !k.isRetired(now) {
+			return k, true
+		}
+	}
+	return key{}, false
+}
+
+// methods returns the names of the signing methods of the keys
+func (r *keyRing) methods() []string {
+	methods := make([]string, 0, len(r.keys))
+	for _, k := range r.keys {
+		methods = append(methods, k.method.Alg())
+	}
+	return methods
+}
+
+// publicKeys returns the public keys accepted to verify tokens at the given time.
+// The keys not active yet are included as well, so verifiers know them before they sign any token.
+func (r *keyRing) publicKeys(now time.Time) []entity.PublicKey {
+	keys := make([]entity.PublicKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		if !k.isPublic() || k.isRetired(now) {
+			continue
+		}
+		keys = append(keys, entity.PublicKey{ID: k.id, Algorithm: k.method.Alg(), Key: k.verifyKey})
+	}
+	return keys
+}
+
+// newKey loads the key of the given algorithm, from the secret for the HMAC algorithms or from the key files otherwise
+func newKey(algorithm, secret, privateKeyFile, publicKeyFile string) (key, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil || method == jwt.SigningMethodNone {
+		return key{}, errors.Errorf("unsupported auth algorithm %q", algorithm)
+	}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if secret == "" {
+			return key{}, errors.Errorf("auth secret is required for %s", method.Alg())
+		}
+		return key{method: method, signKey: []byte(secret), verifyKey: []byte(secret)}, nil
+	}
+
+	if privateKeyFile != "" {
+		pem, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return key{}, errors.Wrap(err, "cannot read auth private key file")
+		}
+		private, err := parsePrivateKey(method, pem)
+		if err != nil {
+			return key{}, err
+		}
+		return key{method: method, signKey: private, verifyKey: private.Public()}, nil
+	}
+
+	if publicKeyFile != "" {
+		pem, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return key{}, errors.Wrap(err, "cannot read auth public key file")
+		}
+		public, err := parsePublicKey(method, pem)
+		if err != nil {
+			return key{}, err
+		}
+		return key{method: method, verifyKey: public}, nil
+	}
+
+	return key{}, errors.Errorf("auth private or public key file is required for %s", method.Alg())
+}
+
+// keyID derives a stable key id from the given public key
+func keyID(public crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// parsePrivateKey parses a PEM encoded private key matching the given signing method
+func parsePrivateKey(method jwt.SigningMethod, pem []byte) (crypto.Signer, error) {
+	var (
+		key crypto.PrivateKey
+		err error
+	)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPrivateKeyFromPEM(pem)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(pem)
+	default:
+		return nil, errors.Errorf("unsupported auth algorithm %q", method.Alg())
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s private key", method.Alg())
+	}
+
+	return key.(crypto.Signer), nil
+}
+
+// parsePublicKey parses a PEM encoded public key matching the given signing method
+func parsePublicKey(method jwt.SigningMethod, pem []byte) (crypto.PublicKey, error) {
+	var (
+		key crypto.PublicKey
+		err error
+	)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPublicKeyFromPEM(pem)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPublicKeyFromPEM(pem)
+	default:
+		return nil, errors.Errorf("unsupported auth algorithm %q", method.Alg())
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s public key", method.Alg())
+	}
+
+	return key, nil
+}
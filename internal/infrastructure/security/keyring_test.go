@@ -0,0 +1,186 @@
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rotatingAuthConfig returns a configuration rotating from the RS256 key "old" to the ES256 key "new" at the given time
+func rotatingAuthConfig(t *testing.T, rotation time.Time) config.Auth {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	oldPrivate, _ := writeKeyFiles(t, rsaKey)
+	newPrivate, _ := writeKeyFiles(t, ecKey)
+
+	cfg := hmacAuthConfig()
+	cfg.Keys = []config.AuthKey{
+		{ID: "old", Algorithm: "RS256", PrivateKeyFile: oldPrivate, RetireAt: rotation.Add(time.Hour)},
+		{ID: "new", Algorithm: "ES256", PrivateKeyFile: newPrivate, ActiveFrom: rotation},
+	}
+	return cfg
+}
+
+func TestNewKeyRing_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Auth)
+	}{
+		{name: "without key id", modify: func(cfg *config.Auth) { cfg.Keys[0].ID = "" }},
+		{name: "with duplicated key id", modify: func(cfg *config.Auth) { cfg.Keys[1].ID = "old" }},
+		{name: "with HMAC key", modify: func(cfg *config.Auth) { cfg.Keys[0].Algorithm = "HS256" }},
+		{name: "without key files", modify: func(cfg *config.Auth) { cfg.Keys[0].PrivateKeyFile = "" }},
+		{name: "retiring before active", modify: func(cfg *config.Auth) {
+			cfg.Keys[1].RetireAt = cfg.Keys[1].ActiveFrom.Add(-time.Hour)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := rotatingAuthConfig(t, time.Now())
+			tt.modify(&cfg)
+
+			ring, err := newKeyRing(cfg)
+			assert.Error(t, err)
+			assert.Nil(t, ring)
+		})
+	}
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	rotation := time.Now()
+	ring, err := newKeyRing(rotatingAuthConfig(t, rotation))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		signing   string
+		verifying []string
+	}{
+		{name: "before the rotation", now: rotation.Add(-time.Minute), signing: "old", verifying: []string{"old", "new"}},
+		{name: "after the rotation", now: rotation.Add(time.Minute), signing: "new", verifying: []string{"old", "new"}},
+		{name: "after the old key retires", now: rotation.Add(2 * time.Hour), signing: "new", verifying: []string{"new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signing, ok := ring.signing(tt.now)
+			require.True(t, ok)
+			assert.Equal(t, tt.signing, signing.id)
+
+			var ids []string
+			for _, k := range ring.publicKeys(tt.now) {
+				ids = append(ids, k.ID)
+
+				verifying, ok := ring.verifying(k.ID, tt.now)
+				assert.True(t, ok)
+				assert.Equal(t, k.Key, verifying.verifyKey)
+			}
+			assert.Equal(t, tt.verifying, ids)
+		})
+	}
+}
+
+func TestJWT_Rotation(t *testing.T) {
+	rotation := time.Now()
+	j, err := NewJWT(rotatingAuthConfig(t, rotation))
+	require.NoError(t, err)
+
+	// the old token is signed before the rotation and still verified after it
+	j.now = func() time.Time { return rotation.Add(-time.Minute) }
+	oldToken, err := j.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	j.now = func() time.Time { return rotation.Add(time.Minute) }
+	newToken, err := j.Issue(context.Background(), entity.User{ID: 2})
+	require.NoError(t, err)
+
+	for token, kid := range map[string]string{oldToken.Token: "old", newToken.Token: "new"} {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		require.NoError(t, err)
+		assert.Equal(t, kid, parsed.Header["kid"])
+
+		_, err = j.Verify(token)
+		assert.NoError(t, err)
+	}
+
+	// the old token is rejected once the old key retires
+	j.now = func() time.Time { return rotation.Add(time.Hour) }
+	_, err = j.Verify(oldToken.Token)
+	assert.Error(t, err)
+
+	keys, err := j.PublicKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.PublicKey{{ID: "new", Algorithm: "ES256", Key: keys[0].Key}}, keys)
+}
+
+func TestJWT_Verify_KeyMismatch(t *testing.T) {
+	cfg := rotatingAuthConfig(t, time.Now().Add(-time.Minute))
+	j, err := NewJWT(cfg)
+	require.NoError(t, err)
+
+	token, err := j.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token.Token, &Claims{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		kid  any
+	}{
+		{name: "with unknown kid", kid: "unknown"},
+		{name: "without kid", kid: nil},
+		{name: "with the kid of a key of another algorithm", kid: "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := parsed.Claims.(*Claims)
+			forged := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+			if tt.kid != nil {
+				forged.Header["kid"] = tt.kid
+			}
+			key, _ := j.keys.signing(time.Now())
+			ss, err := forged.SignedString(key.signKey)
+			require.NoError(t, err)
+
+			claims, err = j.Verify(ss)
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestJWT_LegacyKeyID(t *testing.T) {
+	hmac, err := NewJWT(hmacAuthConfig())
+	require.NoError(t, err)
+	keys, err := hmac.PublicKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, keys, "HMAC secrets must never be published")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	cfg := hmacAuthConfig()
+	cfg.Algorithm = "ES256"
+	cfg.Secret = ""
+	cfg.PrivateKeyFile, _ = writeKeyFiles(t, ecKey)
+	es, err := NewJWT(cfg)
+	require.NoError(t, err)
+
+	keys, err = es.PublicKeys(context.Background())
+	assert.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, keyID(&ecKey.PublicKey), keys[0].ID)
+	assert.Equal(t, &ecKey.PublicKey, keys[0].Key)
+}
@@ -0,0 +1,349 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// discoveryPath is the path of the OpenID Connect discovery document, relative to the issuer URL
+	discoveryPath = "/.well-known/openid-configuration"
+	// minJWKSRefresh limits how often the keys are fetched again because of tokens signed by unknown keys
+	minJWKSRefresh = 10 * time.Second
+	// oidcTimeout is the timeout of the requests to the OpenID Connect provider
+	oidcTimeout = 10 * time.Second
+)
+
+// oidcMethods are the signing methods accepted from the OpenID Connect providers, which never share a secret with
+// this API
+var oidcMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// oidcKey is a public key published by the OpenID Connect provider
+type oidcKey struct {
+	// algorithm is the algorithm the key is restricted to, if any
+	algorithm string
+	key       crypto.PublicKey
+}
+
+// accepts reports whether the key verifies the tokens signed by the given method
+func (k oidcKey) accepts(method jwt.SigningMethod) bool {
+	if k.algorithm != "" {
+		return method.Alg() == k.algorithm
+	}
+
+	switch k.key.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
+
+// OIDC validates the tokens issued by the OpenID Connect provider configured by config.OIDC.
+// The provider is discovered on the first validation, so the API starts even when the provider is not reachable.
+type OIDC struct {
+	issuerURL string
+	refresh   time.Duration
+	client    *http.Client
+	parser    *jwt.Parser
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	jwksURI   string
+	keys      map[string]oidcKey
+	fetchedAt time.Time
+}
+
+// NewOIDC creates a new OIDC from the given configuration
+func NewOIDC(cfg config.Auth) (*OIDC, error) {
+	if cfg.OIDC.IssuerURL == "" {
+		return nil, errors.New("auth OIDC issuer URL is required")
+	}
+	if cfg.OIDC.Audience == "" {
+		return nil, errors.New("auth OIDC audience is required")
+	}
+	if cfg.OIDC.JWKSRefresh <= 0 {
+		return nil, errors.New("auth OIDC JWKS refresh must be positive")
+	}
+
+	o := &OIDC{
+		issuerURL: cfg.OIDC.IssuerURL,
+		refresh:   cfg.OIDC.JWKSRefresh,
+		client:    &http.Client{Timeout: oidcTimeout},
+		now:       time.Now,
+	}
+	o.parser = jwt.NewParser(
+		jwt.WithValidMethods(oidcMethods),
+		jwt.WithIssuer(cfg.OIDC.IssuerURL),
+		jwt.WithAudience(cfg.OIDC.Audience),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(func() time.Time { return o.now() }),
+	)
+
+	return o, nil
+}
+
+// Verify parses the given token and validates its signature, issuer, audience and expiration.
+// The signature is verified by the key of the provider given by the `kid` header of the token.
+func (o *OIDC) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := o.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := o.key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if !key.accepts(t.Method) {
+			return nil, errors.Errorf("unexpected algorithm %q for key %q", t.Method.Alg(), kid)
+		}
+		return key.key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.Wrap(jwt.ErrTokenInvalidClaims, "token has no sub claim")
+	}
+
+	return claims, nil
+}
+
+// key returns the key of the provider with the given id.
+// The keys are fetched again when they are stale, or when the key is unknown and they have not been fetched recently.
+func (o *OIDC) key(kid string) (oidcKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	key, ok := o.keys[kid]
+	stale := now.Sub(o.fetchedAt) >= o.refresh
+	if ok && !stale {
+		return key, nil
+	}
+	if !ok && !stale && now.Sub(o.fetchedAt) < minJWKSRefresh {
+		return oidcKey{}, errors.Errorf("unknown key %q", kid)
+	}
+
+	if err := o.fetchKeys(now); err != nil {
+		if ok {
+			// keep verifying with the known keys while the provider is not reachable
+			return key, nil
+		}
+		return oidcKey{}, err
+	}
+
+	key, ok = o.keys[kid]
+	if !ok {
+		return oidcKey{}, errors.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys discovers the provider, if not done yet, and fetches its keys
+func (o *OIDC) fetchKeys(now time.Time) error {
+	if o.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := o.get(strings.TrimSuffix(o.issuerURL, "/")+discoveryPath, &discovery); err != nil {
+			return errors.Wrap(err, "cannot discover the OIDC provider")
+		}
+		if discovery.Issuer != o.issuerURL {
+			return errors.Errorf("OIDC provider issuer %q does not match %q", discovery.Issuer, o.issuerURL)
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("OIDC provider has no jwks_uri")
+		}
+		o.jwksURI = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := o.get(o.jwksURI, &jwks); err != nil {
+		return errors.Wrap(err, "cannot fetch the OIDC provider keys")
+	}
+
+	keys := make(map[string]oidcKey, len(jwks.Keys))
+	for _, raw := range jwks.Keys {
+		kid, key, err := parseJWK(raw)
+		if err != nil {
+			// a key of an unsupported type does not prevent using the others
+			continue
+		}
+		keys[kid] = key
+	}
+
+	o.keys = keys
+	o.fetchedAt = now
+	return nil
+}
+
+// get decodes the JSON document at the given URL into v
+func (o *OIDC) get(url string, v any) error {
+	resp, err := o.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseJWK parses a public JSON Web Key, as defined by RFC 7517 and RFC 7518, returning its id
+func parseJWK(raw json.RawMessage) (string, oidcKey, error) {
+	var jwk struct {
+		KeyType   string `json:"kty"`
+		KeyID     string `json:"kid"`
+		Use       string `json:"use"`
+		Algorithm string `json:"alg"`
+		N         string `json:"n"`
+		E         string `json:"e"`
+		Curve     string `json:"crv"`
+		X         string `json:"x"`
+		Y         string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return "", oidcKey{}, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return "", oidcKey{}, errors.Errorf("key %q is not a signing key", jwk.KeyID)
+	}
+
+	var (
+		key crypto.PublicKey
+		err error
+	)
+	switch jwk.KeyType {
+	case "RSA":
+		key, err = parseRSAJWK(jwk.N, jwk.E)
+	case "EC":
+		key, err = parseECJWK(jwk.Curve, jwk.X, jwk.Y)
+	case "OKP":
+		key, err = parseOKPJWK(jwk.Curve, jwk.X)
+	default:
+		err = errors.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+	if err != nil {
+		return "", oidcKey{}, errors.Wrapf(err, "invalid key %q", jwk.KeyID)
+	}
+
+	return jwk.KeyID, oidcKey{algorithm: jwk.Algorithm, key: key}, nil
+}
+
+// parseRSAJWK parses the modulus and exponent of an RSA JSON Web Key
+func parseRSAJWK(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(modulus) == 0 || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("invalid RSA key")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// parseECJWK parses the curve and coordinates of an EC JSON Web Key
+func parseECJWK(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var (
+		curve elliptic.Curve
+		ec    ecdh.Curve
+	)
+	switch crv {
+	case "P-256":
+		curve, ec = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ec = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ec = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, errors.Errorf("unsupported curve %q", crv)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, err
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xBytes) != size || len(yBytes) != size {
+		return nil, errors.New("invalid EC key coordinates")
+	}
+
+	// the point must be on the curve, which is checked by parsing its uncompressed form
+	point := append(append([]byte{4}, xBytes...), yBytes...)
+	if _, err := ec.NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
+
+// parseOKPJWK parses the curve and public key of an OKP JSON Web Key
+func parseOKPJWK(crv, x string) (ed25519.PublicKey, error) {
+	if crv != "Ed25519" {
+		return nil, errors.Errorf("unsupported curve %q", crv)
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 key")
+	}
+
+	return ed25519.PublicKey(key), nil
+}
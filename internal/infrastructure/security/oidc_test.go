@@ -0,0 +1,281 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func oidcAuthConfig(issuerURL string) config.Auth {
+	return config.Auth{
+		Mode: config.AuthModeOIDC,
+		OIDC: config.OIDC{
+			IssuerURL:   issuerURL,
+			Audience:    "hexagonal-client",
+			JWKSRefresh: time.Hour,
+		},
+		Leeway: 30 * time.Second,
+	}
+}
+
+// oidcClaims returns valid claims of a token of the given provider
+func oidcClaims(p *testutils.OIDCProvider) Claims {
+	now := time.Now()
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.Issuer(),
+			Subject:   "external-subject",
+			Audience:  jwt.ClaimStrings{"hexagonal-client"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		Email:      "john@example.com",
+		GivenName:  "John",
+		FamilyName: "Doe",
+	}
+}
+
+func TestNewOIDC_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Auth)
+	}{
+		{name: "without issuer URL", modify: func(cfg *config.Auth) { cfg.OIDC.IssuerURL = "" }},
+		{name: "without audience", modify: func(cfg *config.Auth) { cfg.OIDC.Audience = "" }},
+		{name: "without JWKS refresh", modify: func(cfg *config.Auth) { cfg.OIDC.JWKSRefresh = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := oidcAuthConfig("https://issuer.example.com")
+			tt.modify(&cfg)
+
+			o, err := NewOIDC(cfg)
+			assert.Error(t, err)
+			assert.Nil(t, o)
+		})
+	}
+}
+
+func TestOIDC_Verify(t *testing.T) {
+	p := testutils.NewOIDCProvider(t)
+	o, err := NewOIDC(oidcAuthConfig(p.Issuer()))
+	require.NoError(t, err)
+
+	claims, err := o.Verify(p.Sign(t, oidcClaims(p)))
+	require.NoError(t, err)
+	assert.Equal(t, entity.ExternalIdentity{
+		Issuer:     p.Issuer(),
+		Subject:    "external-subject",
+		Email:      "john@example.com",
+		GivenName:  "John",
+		FamilyName: "Doe",
+	}, claims.Identity())
+
+	// the keys are cached
+	_, err = o.Verify(p.Sign(t, oidcClaims(p)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.KeyRequests())
+}
+
+func TestOIDC_Verify_Rejected(t *testing.T) {
+	p := testutils.NewOIDCProvider(t)
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{
+			name: "minted for another audience",
+			token: func(t *testing.T) string {
+				claims := oidcClaims(p)
+				claims.Audience = jwt.ClaimStrings{"other-client"}
+				return p.Sign(t, claims)
+			},
+		},
+		{
+			name: "minted by another issuer",
+			token: func(t *testing.T) string {
+				claims := oidcClaims(p)
+				claims.Issuer = "https://other.example.com"
+				return p.Sign(t, claims)
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				claims := oidcClaims(p)
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+				return p.Sign(t, claims)
+			},
+		},
+		{
+			name: "without subject",
+			token: func(t *testing.T) string {
+				claims := oidcClaims(p)
+				claims.Subject = ""
+				return p.Sign(t, claims)
+			},
+		},
+		{
+			name: "signed with a secret",
+			token: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcClaims(p))
+				token.Header["kid"] = "key-1"
+				ss, err := token.SignedString([]byte("secret"))
+				require.NoError(t, err)
+				return ss
+			},
+		},
+		{
+			name: "signed by another provider with the same kid",
+			token: func(t *testing.T) string {
+				claims := oidcClaims(p)
+				return testutils.NewOIDCProvider(t).Sign(t, claims)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := NewOIDC(oidcAuthConfig(p.Issuer()))
+			require.NoError(t, err)
+
+			claims, err := o.Verify(tt.token(t))
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestOIDC_Verify_IssuerMismatch(t *testing.T) {
+	p := testutils.NewOIDCProvider(t)
+
+	// the discovery document of the provider declares its URL without the trailing slash
+	o, err := NewOIDC(oidcAuthConfig(p.Issuer() + "/"))
+	require.NoError(t, err)
+
+	claims := oidcClaims(p)
+	claims.Issuer = p.Issuer() + "/"
+	_, err = o.Verify(p.Sign(t, claims))
+	assert.ErrorContains(t, err, "does not match")
+}
+
+func TestOIDC_Verify_KeyRotation(t *testing.T) {
+	p := testutils.NewOIDCProvider(t)
+	o, err := NewOIDC(oidcAuthConfig(p.Issuer()))
+	require.NoError(t, err)
+	now := time.Now()
+	o.now = func() time.Time { return now }
+
+	_, err = o.Verify(p.Sign(t, oidcClaims(p)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.KeyRequests())
+
+	// a token of an unknown key is rejected without fetching the keys again too soon
+	p.RotateKey(t)
+	rotated := p.Sign(t, oidcClaims(p))
+	_, err = o.Verify(rotated)
+	assert.Error(t, err)
+	assert.Equal(t, 1, p.KeyRequests())
+
+	// and the keys are fetched again later on
+	now = now.Add(minJWKSRefresh)
+	_, err = o.Verify(rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, p.KeyRequests())
+}
+
+func TestOIDC_Verify_ProviderDown(t *testing.T) {
+	p := testutils.NewOIDCProvider(t)
+	o, err := NewOIDC(oidcAuthConfig(p.Issuer()))
+	require.NoError(t, err)
+	now := time.Now()
+	o.now = func() time.Time { return now }
+
+	_, err = o.Verify(p.Sign(t, oidcClaims(p)))
+	require.NoError(t, err)
+
+	// the known keys keep verifying tokens when the stale keys cannot be fetched again
+	p.SetDown(true)
+	now = now.Add(2 * time.Hour)
+	claims := oidcClaims(p)
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Minute))
+	_, err = o.Verify(p.Sign(t, claims))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.KeyRequests())
+
+	// but a provider never reached verifies nothing
+	o, err = NewOIDC(oidcAuthConfig(p.Issuer()))
+	require.NoError(t, err)
+	_, err = o.Verify(p.Sign(t, oidcClaims(p)))
+	assert.Error(t, err)
+}
+
+func TestParseJWK(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPublic, err := ecKey.PublicKey.ECDH()
+	require.NoError(t, err)
+	point := ecPublic.Bytes()[1:]
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	tests := []struct {
+		name    string
+		jwk     string
+		wantErr bool
+		want    oidcKey
+	}{
+		{
+			name: "EC key",
+			jwk:  fmt.Sprintf(`{"kty":"EC","kid":"ec","crv":"P-256","x":"%s","y":"%s"}`, b64(point[:32]), b64(point[32:])),
+			want: oidcKey{key: &ecKey.PublicKey},
+		},
+		{
+			name: "Ed25519 key",
+			jwk:  fmt.Sprintf(`{"kty":"OKP","kid":"ed","alg":"EdDSA","crv":"Ed25519","x":"%s"}`, b64(edPublic)),
+			want: oidcKey{algorithm: "EdDSA", key: edPublic},
+		},
+		{
+			name:    "EC point not on the curve",
+			jwk:     fmt.Sprintf(`{"kty":"EC","kid":"ec","crv":"P-256","x":"%s","y":"%s"}`, b64(point[:32]), b64(point[:32])),
+			wantErr: true,
+		},
+		{
+			name:    "encryption key",
+			jwk:     fmt.Sprintf(`{"kty":"OKP","kid":"ed","use":"enc","crv":"Ed25519","x":"%s"}`, b64(edPublic)),
+			wantErr: true,
+		},
+		{
+			name:    "symmetric key",
+			jwk:     `{"kty":"oct","kid":"hmac","k":"c2VjcmV0"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, key, err := parseJWK(json.RawMessage(tt.jwk))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.algorithm, key.algorithm)
+			assert.Equal(t, tt.want.key, key.key)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// argon2id parameters as recommended by OWASP
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// argon2MaxMemory caps the memory, in KiB, of the argon2id hashes to verify, so a stored hash cannot exhaust the memory
+// of the instance
+const argon2MaxMemory = 256 * 1024
+
+// PasswordHasher hashes passwords with argon2id and verifies both argon2id and bcrypt hashes
+type PasswordHasher struct{}
+
+// NewPasswordHasher creates a new instance of service.PasswordHasher
+func NewPasswordHasher() service.PasswordHasher {
+	return &PasswordHasher{}
+}
+
+// Hash returns the password hashed with argon2id in the PHC string format
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "cannot generate salt")
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether the password matches the given argon2id or bcrypt hash
+func (h *PasswordHasher) Verify(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, password)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	default:
+		return false, errors.New("unsupported password hash format")
+	}
+}
+
+// verifyArgon2id verifies a password against an argon2id hash in the PHC string format
+func verifyArgon2id(hash, password string) (bool, error) {
+	// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, errors.Wrap(err, "malformed argon2id version")
+	}
+	if version != argon2.Version {
+		return false, errors.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, errors.Wrap(err, "malformed argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errors.Wrap(err, "malformed argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, errors.Wrap(err, "malformed argon2id key")
+	}
+	if memory == 0 || memory > argon2MaxMemory || time == 0 || threads == 0 || len(salt) == 0 || len(key) == 0 {
+		return false, errors.New("malformed argon2id hash")
+	}
+
+	other := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
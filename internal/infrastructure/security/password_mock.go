@@ -0,0 +1,22 @@
+package security
+
+import "github.com/stretchr/testify/mock"
+
+// MockPasswordHasher is a mock implementation of service.PasswordHasher by using testify mock.Mock
+type MockPasswordHasher struct {
+	mock.Mock
+}
+
+func NewMockPasswordHasher() *MockPasswordHasher {
+	return &MockPasswordHasher{}
+}
+
+func (m *MockPasswordHasher) Hash(password string) (string, error) {
+	args := m.Called(password)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordHasher) Verify(hash, password string) (bool, error) {
+	args := m.Called(hash, password)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,91 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHasher_HashAndVerify(t *testing.T) {
+	hasher := NewPasswordHasher()
+
+	hash, err := hasher.Hash("lark")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, argon2idPrefix))
+
+	ok, err := hasher.Verify(hash, "lark")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify(hash, "other")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_Hash_Salted(t *testing.T) {
+	hasher := NewPasswordHasher()
+
+	hash1, err := hasher.Hash("lark")
+	assert.NoError(t, err)
+	hash2, err := hasher.Hash("lark")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, hash1, hash2)
+}
+
+func TestPasswordHasher_Verify_Bcrypt(t *testing.T) {
+	hasher := NewPasswordHasher()
+	hash := "$2a$10$jbRdr4jyTjRRHM6iiXlCO.LSrrqujwL3MjupTLU4MA/paEiS/AJiG"
+
+	ok, err := hasher.Verify(hash, "lark")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify(hash, "other")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_Verify_UnsupportedHash(t *testing.T) {
+	hasher := NewPasswordHasher()
+
+	ok, err := hasher.Verify("plain", "plain")
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_Verify_MalformedArgon2id(t *testing.T) {
+	hasher := NewPasswordHasher()
+
+	ok, err := hasher.Verify("$argon2id$v=19$m=19456,t=2,p=1$salt", "lark")
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_Verify_InvalidArgon2idParameters(t *testing.T) {
+	const (
+		salt = "c2FsdHNhbHRzYWx0c2FsdA"
+		key  = "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	)
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "should reject no threads", hash: "$argon2id$v=19$m=19456,t=2,p=0$" + salt + "$" + key},
+		{name: "should reject no iterations", hash: "$argon2id$v=19$m=19456,t=0,p=1$" + salt + "$" + key},
+		{name: "should reject no memory", hash: "$argon2id$v=19$m=0,t=2,p=1$" + salt + "$" + key},
+		{name: "should reject too much memory", hash: "$argon2id$v=19$m=4194304,t=2,p=1$" + salt + "$" + key},
+		{name: "should reject an empty salt", hash: "$argon2id$v=19$m=19456,t=2,p=1$$" + key},
+		{name: "should reject an empty key", hash: "$argon2id$v=19$m=19456,t=2,p=1$" + salt + "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := NewPasswordHasher().Verify(tt.hash, "lark")
+
+			assert.EqualError(t, err, "malformed argon2id hash")
+			assert.False(t, ok)
+		})
+	}
+}
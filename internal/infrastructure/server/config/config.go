@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -15,22 +17,196 @@ const (
 	// ConfigPathEnv is the environment variable that contains the path to the configuration file.
 	ConfigPathEnv         = "CONFIG_PATH"
 	ConfigOverridePathEnv = "CONFIG_OVERRIDE_PATH"
+	// DatabaseURLEnv is the environment variable that contains the URL of the database, replacing the configured one.
+	DatabaseURLEnv = "DATABASE_URL"
 
 	InMemoryDB = "in_memory"
+	PostgresDB = "postgres"
+	MySQLDB    = "mysql"
+	SQLiteDB   = "sqlite"
+
+	// MigrationsAuto applies the pending migrations of the schema of the database when the application starts
+	MigrationsAuto = "auto"
+	// MigrationsCheck refuses to start the application while migrations of the schema of the database are pending
+	MigrationsCheck = "check"
+	// MigrationsOff leaves the schema of the database to the migrate command
+	MigrationsOff = "off"
+
+	DefaultDBConnectTimeout = 10 * time.Second
+	DefaultDBMigrations     = MigrationsAuto
+
+	AuthModeLocal = "local"
+	AuthModeOIDC  = "oidc"
+
+	DefaultAuthMode            = AuthModeLocal
+	DefaultAuthAlgorithm       = "HS256"
+	DefaultAuthAccessTokenTTL  = 15 * time.Minute
+	DefaultAuthRefreshTokenTTL = 30 * 24 * time.Hour
+	DefaultOIDCJWKSRefresh     = time.Hour
+
+	EventPublisherChannel = "channel"
+
+	DefaultEventPublisher      = EventPublisherChannel
+	DefaultEventRelayInterval  = time.Second
+	DefaultEventRelayBatchSize = 100
+	DefaultEventReplaySize     = 1000
+
+	DefaultWebhookTimeout        = 10 * time.Second
+	DefaultWebhookMaxAttempts    = 8
+	DefaultWebhookInitialBackoff = 10 * time.Second
+	DefaultWebhookMaxBackoff     = time.Hour
+	DefaultWebhookBatchSize      = 10
+	DefaultWebhookPollInterval   = time.Second
+
+	// DefaultCacheControl lets the clients cache the responses of the routes not configured, provided they
+	// revalidate them on every use with a conditional request
+	DefaultCacheControl = "private, no-cache"
 )
 
 type Config struct {
-	DB DB `koanf:"db"`
+	DB         DB         `koanf:"db"`
+	Auth       Auth       `koanf:"auth"`
+	Pagination Pagination `koanf:"pagination"`
+	HTTP       HTTP       `koanf:"http"`
+	Events     Events     `koanf:"events"`
+	Webhooks   Webhooks   `koanf:"webhooks"`
 }
 
+// DB holds the configuration of the database, whose Type is one of InMemoryDB, PostgresDB, MySQLDB and SQLiteDB.
+// The Name of a SQLite database is the path of its file, and its timeouts and SSL settings are ignored.
+// When the URL is given, its scheme gives the Type, and it replaces the Host, Port, User, Password and Name, while the
+// other settings apply unless the URL sets them as well.
+// The SSLMode is one of the modes of Postgres: disable, allow, prefer, require, verify-ca and verify-full. It defaults
+// to disable, except for a URL, whose SSL mode is left to the driver unless set.
+// The StatementTimeout aborts the statements running longer, and only applies to the queries in MySQL. It is lifted
+// for the migrations.
+// Migrations is one of MigrationsAuto, MigrationsCheck and MigrationsOff.
 type DB struct {
-	Type     string `koanf:"type"`
-	Host     string `koanf:"host"`
-	Name     string `koanf:"name"`
-	User     string `koanf:"user"`
-	Port     string `koanf:"port"`
-	Password string `koanf:"password"`
-	SSLMode  string `koanf:"ssl-mode"`
+	Type             string        `koanf:"type"`
+	URL              string        `koanf:"url"`
+	Host             string        `koanf:"host"`
+	Name             string        `koanf:"name"`
+	User             string        `koanf:"user"`
+	Port             string        `koanf:"port"`
+	Password         string        `koanf:"password"`
+	SSLMode          string        `koanf:"ssl-mode"`
+	SSLRootCert      string        `koanf:"ssl-root-cert"`
+	SSLCert          string        `koanf:"ssl-cert"`
+	SSLKey           string        `koanf:"ssl-key"`
+	ConnectTimeout   time.Duration `koanf:"connect-timeout"`
+	StatementTimeout time.Duration `koanf:"statement-timeout"`
+	Pool             DBPool        `koanf:"pool"`
+	Migrations       string        `koanf:"migrations"`
+}
+
+// DBPool holds the configuration of the pool of connections to the database. Zero values keep the defaults of
+// database/sql: unlimited open connections, two idle ones, and no limit on their lifetime or idle time.
+type DBPool struct {
+	MaxOpenConns    int           `koanf:"max-open-conns"`
+	MaxIdleConns    int           `koanf:"max-idle-conns"`
+	ConnMaxLifetime time.Duration `koanf:"conn-max-lifetime"`
+	ConnMaxIdleTime time.Duration `koanf:"conn-max-idle-time"`
+}
+
+// dbTypeOf returns the type of the database of the given URL, given by its scheme
+func dbTypeOf(rawURL string) string {
+	scheme, _, _ := strings.Cut(rawURL, ":")
+	switch scheme = strings.ToLower(scheme); scheme {
+	case "postgresql":
+		return PostgresDB
+	case "file":
+		return SQLiteDB
+	}
+	return scheme
+}
+
+// Auth holds the configuration of the JWT access tokens.
+// HMAC algorithms (HS256, HS384, HS512) use the Secret, while RSA (RS*, PS*), ECDSA (ES*) and EdDSA algorithms
+// use the PEM key files. When only the public key file is given, tokens can be validated but not issued.
+// When Keys is given, it replaces Algorithm, Secret, PrivateKeyFile and PublicKeyFile.
+// In the AuthModeOIDC mode, the /api group accepts the tokens of the OIDC provider instead of the local ones.
+type Auth struct {
+	Mode            string        `koanf:"mode"`
+	OIDC            OIDC          `koanf:"oidc"`
+	Keys            []AuthKey     `koanf:"keys"`
+	Algorithm       string        `koanf:"algorithm"`
+	Secret          string        `koanf:"secret"`
+	PrivateKeyFile  string        `koanf:"private-key-file"`
+	PublicKeyFile   string        `koanf:"public-key-file"`
+	Issuer          string        `koanf:"issuer"`
+	Audience        string        `koanf:"audience"`
+	AccessTokenTTL  time.Duration `koanf:"access-token-ttl"`
+	RefreshTokenTTL time.Duration `koanf:"refresh-token-ttl"`
+	Leeway          time.Duration `koanf:"leeway"`
+}
+
+// AuthKey holds an asymmetric key of the key ring signing and verifying the JWT access tokens.
+// A key is published and accepted for verification until RetireAt, and it signs the new tokens from ActiveFrom
+// on, unless a key with a later ActiveFrom takes over. Zero times mean no limit.
+type AuthKey struct {
+	ID             string    `koanf:"id"`
+	Algorithm      string    `koanf:"algorithm"`
+	PrivateKeyFile string    `koanf:"private-key-file"`
+	PublicKeyFile  string    `koanf:"public-key-file"`
+	ActiveFrom     time.Time `koanf:"active-from"`
+	RetireAt       time.Time `koanf:"retire-at"`
+}
+
+// OIDC holds the configuration of the OpenID Connect provider of the AuthModeOIDC mode.
+// The provider is discovered from the IssuerURL, and its keys are fetched again every JWKSRefresh, or sooner when a
+// token is signed by an unknown key.
+type OIDC struct {
+	IssuerURL   string        `koanf:"issuer-url"`
+	Audience    string        `koanf:"audience"`
+	JWKSRefresh time.Duration `koanf:"jwks-refresh"`
+}
+
+// Pagination holds the configuration of the paginated endpoints.
+// The CursorSecret signs the pagination cursors. It falls back to a secret derived from the Auth Secret, never the Auth
+// Secret itself, and to a random secret when there is none, in which case the cursors are only valid for the running
+// instance.
+type Pagination struct {
+	CursorSecret string `koanf:"cursor-secret"`
+}
+
+// HTTP holds the configuration of the HTTP responses.
+// CacheControl maps the cacheable routes, such as "/api/users/:id", to the Cache-Control header of their successful
+// responses.
+type HTTP struct {
+	CacheControl map[string]string `koanf:"cache-control"`
+}
+
+// CacheControlOf returns the Cache-Control header of the given route, or DefaultCacheControl if it is not configured
+func (h HTTP) CacheControlOf(route string) string {
+	if value, ok := h.CacheControl[route]; ok {
+		return value
+	}
+	return DefaultCacheControl
+}
+
+// Events holds the configuration of the relay of the events of the users from their outbox.
+// The Publisher delivers the events to the other services, and the EventPublisherChannel one keeps them in process.
+// The relay publishes at most RelayBatchSize events at once, and polls the outbox every RelayInterval once it is empty.
+// The latest ReplaySize events are kept in process for the streams of events to resume from.
+type Events struct {
+	Publisher      string        `koanf:"publisher"`
+	RelayInterval  time.Duration `koanf:"relay-interval"`
+	RelayBatchSize int           `koanf:"relay-batch-size"`
+	ReplaySize     int           `koanf:"replay-size"`
+}
+
+// Webhooks holds the configuration of the deliveries of the events of the users to the webhooks.
+// A delivery is attempted with a Timeout, and retried after a backoff doubling from InitialBackoff up to MaxBackoff
+// until MaxAttempts attempts failed. The worker attempts at most BatchSize deliveries at once, and polls them every
+// PollInterval once none is due. The deliveries attempted are claimed for BatchSize + 1 times the Timeout, after which
+// the ones whose outcome was not recorded are attempted again.
+type Webhooks struct {
+	Timeout        time.Duration `koanf:"timeout"`
+	MaxAttempts    int           `koanf:"max-attempts"`
+	InitialBackoff time.Duration `koanf:"initial-backoff"`
+	MaxBackoff     time.Duration `koanf:"max-backoff"`
+	BatchSize      int           `koanf:"batch-size"`
+	PollInterval   time.Duration `koanf:"poll-interval"`
 }
 
 func Load() (Config, error) {
@@ -69,9 +245,66 @@ func Load() (Config, error) {
 		return config, err
 	}
 
+	if value, exists := os.LookupEnv(DatabaseURLEnv); exists && value != "" {
+		config.DB.URL = value
+	}
+	if config.DB.URL != "" {
+		config.DB.Type = dbTypeOf(config.DB.URL)
+	}
 	if config.DB.Type == "" {
 		config.DB.Type = InMemoryDB
 	}
+	if config.DB.ConnectTimeout == 0 {
+		config.DB.ConnectTimeout = DefaultDBConnectTimeout
+	}
+	if config.DB.Migrations == "" {
+		config.DB.Migrations = DefaultDBMigrations
+	}
+	if config.Auth.Mode == "" {
+		config.Auth.Mode = DefaultAuthMode
+	}
+	if config.Auth.OIDC.JWKSRefresh == 0 {
+		config.Auth.OIDC.JWKSRefresh = DefaultOIDCJWKSRefresh
+	}
+	if config.Auth.Algorithm == "" {
+		config.Auth.Algorithm = DefaultAuthAlgorithm
+	}
+	if config.Auth.AccessTokenTTL == 0 {
+		config.Auth.AccessTokenTTL = DefaultAuthAccessTokenTTL
+	}
+	if config.Auth.RefreshTokenTTL == 0 {
+		config.Auth.RefreshTokenTTL = DefaultAuthRefreshTokenTTL
+	}
+	if config.Events.Publisher == "" {
+		config.Events.Publisher = DefaultEventPublisher
+	}
+	if config.Events.RelayInterval == 0 {
+		config.Events.RelayInterval = DefaultEventRelayInterval
+	}
+	if config.Events.RelayBatchSize == 0 {
+		config.Events.RelayBatchSize = DefaultEventRelayBatchSize
+	}
+	if config.Events.ReplaySize == 0 {
+		config.Events.ReplaySize = DefaultEventReplaySize
+	}
+	if config.Webhooks.Timeout == 0 {
+		config.Webhooks.Timeout = DefaultWebhookTimeout
+	}
+	if config.Webhooks.MaxAttempts == 0 {
+		config.Webhooks.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if config.Webhooks.InitialBackoff == 0 {
+		config.Webhooks.InitialBackoff = DefaultWebhookInitialBackoff
+	}
+	if config.Webhooks.MaxBackoff == 0 {
+		config.Webhooks.MaxBackoff = DefaultWebhookMaxBackoff
+	}
+	if config.Webhooks.BatchSize == 0 {
+		config.Webhooks.BatchSize = DefaultWebhookBatchSize
+	}
+	if config.Webhooks.PollInterval == 0 {
+		config.Webhooks.PollInterval = DefaultWebhookPollInterval
+	}
 
 	return config, nil
 }
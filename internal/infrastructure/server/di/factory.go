@@ -1,20 +1,300 @@
 package di
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	domusecase "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/db"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/event"
 	infrarepo "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/repository"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/middleware"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/webhook"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/worker"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+	"gorm.io/gorm"
 )
 
-// ResolveUserRepository resolves the user repository based on the configuration
-func ResolveUserRepository(cfg config.DB) (repository.User, error) {
-	if cfg.Type != config.InMemoryDB {
-		DB, err := db.ConnectDatabase(cfg)
+// cursorSecretSize is the number of bytes of the cursor secret generated or derived when none is configured
+const cursorSecretSize = 32
+
+// cursorSecretInfo is the label of the cursor secret derived from the auth secret, so that it is never the secret which
+// signs the JWT
+const cursorSecretInfo = "go-proposal-hexagonal-arch pagination cursor v1"
+
+// ResolveDatabase connects to the database based on the configuration.
+// It returns a nil *gorm.DB when the repositories are kept in memory.
+func ResolveDatabase(cfg config.DB) (*gorm.DB, error) {
+	if cfg.Type == config.InMemoryDB {
+		return nil, nil
+	}
+	return db.ConnectDatabase(cfg)
+}
+
+// ResolveUserRepository resolves the user repository based on the database connection
+func ResolveUserRepository(DB *gorm.DB) repository.User {
+	if DB != nil {
+		return infrarepo.NewUserDB(DB)
+	}
+	return infrarepo.NewUserInMemory()
+}
+
+// ResolveUserCredentialsRepository resolves the user credentials repository, which is kept by the same adapter as the users
+func ResolveUserCredentialsRepository(user repository.User) (repository.UserCredentials, error) {
+	credentials, ok := user.(repository.UserCredentials)
+	if !ok {
+		return nil, errors.Errorf("user repository %T does not store credentials", user)
+	}
+	return credentials, nil
+}
+
+// ResolveUserIdentityRepository resolves the user identity repository, which is kept by the same adapter as the users
+func ResolveUserIdentityRepository(user repository.User) (repository.UserIdentity, error) {
+	identities, ok := user.(repository.UserIdentity)
+	if !ok {
+		return nil, errors.Errorf("user repository %T does not store identities", user)
+	}
+	return identities, nil
+}
+
+// ResolveUserSearchRepository resolves the user search repository, which is kept by the same adapter as the users
+func ResolveUserSearchRepository(user repository.User) (repository.UserSearch, error) {
+	search, ok := user.(repository.UserSearch)
+	if !ok {
+		return nil, errors.Errorf("user repository %T does not search users", user)
+	}
+	return search, nil
+}
+
+// ResolveRefreshTokenRepository resolves the refresh token repository based on the database connection
+func ResolveRefreshTokenRepository(DB *gorm.DB) repository.RefreshToken {
+	if DB != nil {
+		return infrarepo.NewRefreshTokenDB(DB)
+	}
+	return infrarepo.NewRefreshTokenInMemory()
+}
+
+// ResolveAPIKeyRepository resolves the API key repository based on the database connection
+func ResolveAPIKeyRepository(DB *gorm.DB) repository.APIKey {
+	if DB != nil {
+		return infrarepo.NewAPIKeyDB(DB)
+	}
+	return infrarepo.NewAPIKeyInMemory()
+}
+
+// ResolveUserAuditRepository resolves the user audit repository based on the database connection
+func ResolveUserAuditRepository(DB *gorm.DB) repository.UserAudit {
+	if DB != nil {
+		return infrarepo.NewUserAuditDB(DB)
+	}
+	return infrarepo.NewUserAuditInMemory()
+}
+
+// ResolveTransactor resolves the transactor of the repositories based on the database connection
+func ResolveTransactor(DB *gorm.DB) repository.Transactor {
+	if DB != nil {
+		return infrarepo.NewTransactorDB(DB)
+	}
+	return infrarepo.NewTransactorInMemory()
+}
+
+// ResolveUserOutboxRepository resolves the user outbox repository based on the database connection, which also
+// streams the events raised by the use cases to the clients through the given broker once they are committed
+func ResolveUserOutboxRepository(DB *gorm.DB, tx repository.Transactor, broker *event.Broker) repository.UserOutbox {
+	if DB != nil {
+		return event.NewNotifyingUserOutbox(infrarepo.NewUserOutboxDB(DB), tx, broker)
+	}
+	return event.NewNotifyingUserOutbox(infrarepo.NewUserOutboxInMemory(), tx, broker)
+}
+
+// ResolveUserCreator resolves the user creator, whose creations are audited
+func ResolveUserCreator(
+	user repository.User,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+	audit repository.UserAudit,
+) domusecase.UserCreator {
+	return usecase.NewAuditedUserCreator(usecase.NewUserCreator(user, tx, outbox), user, tx, audit)
+}
+
+// ResolveUserModifier resolves the user modifier, whose modifications are audited
+func ResolveUserModifier(
+	user repository.User,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+	audit repository.UserAudit,
+) domusecase.UserModifier {
+	return usecase.NewAuditedUserModifier(usecase.NewUserModifier(user, tx, outbox), user, tx, audit)
+}
+
+// ResolveUserPatcher resolves the user patcher, whose modifications are audited
+func ResolveUserPatcher(
+	user repository.User,
+	patches service.UserPatchApplier,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+	audit repository.UserAudit,
+) domusecase.UserPatcher {
+	return usecase.NewAuditedUserPatcher(usecase.NewUserPatcher(user, patches, tx, outbox), user, tx, audit)
+}
+
+// ResolveUserDeleter resolves the user deleter, whose deletions and purges are audited
+func ResolveUserDeleter(
+	user repository.User,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+	audit repository.UserAudit,
+) domusecase.UserDeleter {
+	return usecase.NewAuditedUserDeleter(usecase.NewUserDeleter(user, tx, outbox), user, tx, audit)
+}
+
+// ResolveUserRestorer resolves the user restorer, whose restorations are audited
+func ResolveUserRestorer(
+	user repository.User,
+	tx repository.Transactor,
+	outbox repository.UserOutbox,
+	audit repository.UserAudit,
+) domusecase.UserRestorer {
+	return usecase.NewAuditedUserRestorer(usecase.NewUserRestorer(user, tx, outbox), user, tx, audit)
+}
+
+// ResolveUserEventBroker resolves the in-process broker of the events of the users, which streams the events raised
+// through this instance to its clients
+func ResolveUserEventBroker(cfg config.Events) *event.Broker {
+	return event.NewBroker(cfg.ReplaySize, event.DefaultSubscriberBufferSize)
+}
+
+// ResolveUserEventPublisher resolves the publisher of the events of the users based on the configuration, which also
+// schedules their deliveries to the webhooks
+func ResolveUserEventPublisher(
+	cfg config.Events,
+	scheduler domusecase.WebhookScheduler,
+) (service.UserEventPublisher, error) {
+	var publisher service.UserEventPublisher
+	switch cfg.Publisher {
+	case config.EventPublisherChannel:
+		publisher = event.NewChannelPublisher()
+	default:
+		return nil, errors.Errorf("unsupported event publisher %q", cfg.Publisher)
+	}
+
+	// the deliveries are scheduled first, in the transaction of the relay
+	return event.NewFanOutPublisher(event.PublisherFunc(scheduler.Schedule), publisher), nil
+}
+
+// ResolveUserEventBatchSize resolves the maximum number of events of the users relayed at once
+func ResolveUserEventBatchSize(cfg config.Events) usecase.UserEventBatchSize {
+	return usecase.UserEventBatchSize(cfg.RelayBatchSize)
+}
+
+// ResolveWebhookRepository resolves the webhook repository based on the database connection
+func ResolveWebhookRepository(DB *gorm.DB) repository.Webhook {
+	if DB != nil {
+		return infrarepo.NewWebhookDB(DB)
+	}
+	return infrarepo.NewWebhookInMemory()
+}
+
+// ResolveWebhookDeliveryRepository resolves the webhook delivery repository based on the database connection
+func ResolveWebhookDeliveryRepository(DB *gorm.DB) repository.WebhookDelivery {
+	if DB != nil {
+		return infrarepo.NewWebhookDeliveryDB(DB)
+	}
+	return infrarepo.NewWebhookDeliveryInMemory()
+}
+
+// ResolveWebhookSender resolves the sender of the events of the users to the webhooks
+func ResolveWebhookSender(cfg config.Webhooks) service.WebhookSender {
+	return webhook.NewHTTPSender(cfg.Timeout)
+}
+
+// ResolveWebhookRetryPolicy resolves the retries of the failed deliveries of the webhooks
+func ResolveWebhookRetryPolicy(cfg config.Webhooks) usecase.WebhookRetryPolicy {
+	return usecase.WebhookRetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}
+}
+
+// ResolveWebhookBatchSize resolves the maximum number of deliveries of the webhooks attempted at once
+func ResolveWebhookBatchSize(cfg config.Webhooks) usecase.WebhookBatchSize {
+	return usecase.WebhookBatchSize(cfg.BatchSize)
+}
+
+// ResolveWebhookLease resolves the time the deliveries of the webhooks claimed for an attempt are left out of the due
+// deliveries, which covers the attempts of a whole batch, each of them lasting the timeout at most, and one more
+func ResolveWebhookLease(cfg config.Webhooks) usecase.WebhookLease {
+	return usecase.WebhookLease(cfg.Timeout * time.Duration(cfg.BatchSize+1))
+}
+
+// ResolveWorkers resolves the background workers relaying the events of the users and delivering them to the
+// webhooks
+func ResolveWorkers(
+	events config.Events,
+	webhooks config.Webhooks,
+	relayer domusecase.UserEventRelayer,
+	deliverer domusecase.WebhookDeliverer,
+) worker.Group {
+	return worker.Group{
+		worker.NewPoller("user events", relayer.Relay, events.RelayInterval),
+		worker.NewPoller("webhook deliveries", deliverer.Deliver, webhooks.PollInterval),
+	}
+}
+
+// ResolveRefreshTokenTTL resolves the lifetime of the refresh tokens
+func ResolveRefreshTokenTTL(cfg config.Auth) usecase.RefreshTokenTTL {
+	return usecase.RefreshTokenTTL(cfg.RefreshTokenTTL)
+}
+
+// ResolveCursorCodec resolves the codec of the pagination cursors, signing with the configured cursor secret, or else
+// with a secret derived from the auth secret, or else with a random secret
+func ResolveCursorCodec(cfg config.Pagination, auth config.Auth) (service.UserCursorCodec, error) {
+	if cfg.CursorSecret != "" {
+		return security.NewCursorCodec([]byte(cfg.CursorSecret))
+	}
+	secret := make([]byte, cursorSecretSize)
+	if auth.Secret != "" {
+		log.Info("No cursor secret configured, the pagination cursors are signed with a secret derived from the auth secret")
+		if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(auth.Secret), nil, []byte(cursorSecretInfo)), secret); err != nil {
+			return nil, errors.Wrap(err, "cannot derive cursor secret")
+		}
+		return security.NewCursorCodec(secret)
+	}
+	log.Warn("No cursor secret configured, the pagination cursors are only valid for this instance")
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Wrap(err, "cannot generate cursor secret")
+	}
+	return security.NewCursorCodec(secret)
+}
+
+// ResolveAuthorizer resolves the authorizer of the /api group based on the auth mode.
+// The API keys are accepted in every mode.
+func ResolveAuthorizer(
+	cfg config.Auth,
+	local *security.JWT,
+	provisioner domusecase.UserProvisioner,
+	apiKeys domusecase.APIKeyAuthenticator,
+) (*middleware.Authorizer, error) {
+	switch cfg.Mode {
+	case config.AuthModeLocal:
+		return middleware.NewAuthorizer(local).WithAPIKeys(apiKeys), nil
+	case config.AuthModeOIDC:
+		oidc, err := security.NewOIDC(cfg)
 		if err != nil {
 			return nil, err
 		}
-		return infrarepo.NewUserDB(DB), nil
+		return middleware.NewProvisioningAuthorizer(oidc, provisioner).WithAPIKeys(apiKeys), nil
+	default:
+		return nil, errors.Errorf("unsupported auth mode %q", cfg.Mode)
 	}
-	return infrarepo.NewUserInMemory(), nil
 }
@@ -7,19 +7,79 @@ import (
 	"github.com/google/wire"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/handler"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/event"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/patch"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/http"
 )
 
-func InitializeAPI(cfg config.DB) (*http.Server, error) {
+func InitializeAPI(cfg config.Config) (*http.Server, error) {
 	wire.Build(
+		wire.FieldsOf(new(config.Config), "DB", "Auth", "Pagination", "HTTP", "Events", "Webhooks"),
+		ResolveDatabase,
 		ResolveUserRepository,
+		ResolveUserCredentialsRepository,
+		ResolveUserIdentityRepository,
+		ResolveUserSearchRepository,
+		ResolveRefreshTokenRepository,
+		ResolveAPIKeyRepository,
+		ResolveUserAuditRepository,
+		ResolveTransactor,
+		ResolveUserOutboxRepository,
+		ResolveUserEventBroker,
+		wire.Bind(new(service.UserEventSubscriber), new(*event.Broker)),
+		ResolveUserEventPublisher,
+		ResolveUserEventBatchSize,
+		ResolveWebhookRepository,
+		ResolveWebhookDeliveryRepository,
+		ResolveWebhookSender,
+		ResolveWebhookRetryPolicy,
+		ResolveWebhookBatchSize,
+		ResolveWebhookLease,
+		ResolveWorkers,
+		ResolveRefreshTokenTTL,
+		ResolveCursorCodec,
+		security.NewPasswordHasher,
+		patch.NewJSONApplier,
+		security.NewJWT,
+		wire.Bind(new(service.TokenIssuer), new(*security.JWT)),
+		wire.Bind(new(service.PublicKeySet), new(*security.JWT)),
+		ResolveAuthorizer,
 		usecase.NewUserFinderAll,
 		usecase.NewUserFinderByID,
-		usecase.NewUserCreator,
-		usecase.NewUserModifier,
-		usecase.NewUserDeleter,
+		usecase.NewUserSearcher,
+		ResolveUserCreator,
+		ResolveUserModifier,
+		ResolveUserPatcher,
+		ResolveUserDeleter,
+		ResolveUserRestorer,
+		usecase.NewUserHistoryFinder,
+		usecase.NewUserEventRelayer,
+		usecase.NewUserAuthenticator,
+		usecase.NewUserProvisioner,
+		usecase.NewTokenGranter,
+		usecase.NewTokenRefresher,
+		usecase.NewTokenRevoker,
+		usecase.NewAPIKeyFinderAll,
+		usecase.NewAPIKeyCreator,
+		usecase.NewAPIKeyRevoker,
+		usecase.NewAPIKeyAuthenticator,
+		usecase.NewWebhookFinderAll,
+		usecase.NewWebhookCreator,
+		usecase.NewWebhookDeleter,
+		usecase.NewWebhookDeliveryFinder,
+		usecase.NewWebhookScheduler,
+		usecase.NewWebhookDeliverer,
 		handler.NewUserAPI,
+		handler.NewUserHistoryAPI,
+		handler.NewUserEventAPI,
+		handler.NewUserSocketAPI,
+		handler.NewAPIKeyAPI,
+		handler.NewWebhookAPI,
+		handler.NewLoginAPI,
+		handler.NewJWKSAPI,
 		http.NewServer,
 	)
 
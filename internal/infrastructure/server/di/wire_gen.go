@@ -9,23 +9,104 @@ package di
 import (
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/handler"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/patch"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/http"
 )
 
 // Injectors from wire.go:
 
-func InitializeAPI(cfg config.DB) (*http.Server, error) {
-	user, err := ResolveUserRepository(cfg)
+func InitializeAPI(cfg config.Config) (*http.Server, error) {
+	db := cfg.DB
+	gormDB, err := ResolveDatabase(db)
 	if err != nil {
 		return nil, err
 	}
-	userFinderAll := usecase.NewUserFinderAll(user)
+	user := ResolveUserRepository(gormDB)
+	pagination := cfg.Pagination
+	auth := cfg.Auth
+	userCursorCodec, err := ResolveCursorCodec(pagination, auth)
+	if err != nil {
+		return nil, err
+	}
+	userFinderAll := usecase.NewUserFinderAll(user, userCursorCodec)
+	userSearch, err := ResolveUserSearchRepository(user)
+	if err != nil {
+		return nil, err
+	}
+	userSearcher := usecase.NewUserSearcher(userSearch)
 	userFinderByID := usecase.NewUserFinderByID(user)
-	userCreator := usecase.NewUserCreator(user)
-	userModifier := usecase.NewUserModifier(user)
-	userDeleter := usecase.NewUserDeleter(user)
-	userAPI := handler.NewUserAPI(userFinderAll, userFinderByID, userCreator, userModifier, userDeleter)
-	server := http.NewServer(userAPI)
+	transactor := ResolveTransactor(gormDB)
+	events := cfg.Events
+	broker := ResolveUserEventBroker(events)
+	userOutbox := ResolveUserOutboxRepository(gormDB, transactor, broker)
+	userAudit := ResolveUserAuditRepository(gormDB)
+	userCreator := ResolveUserCreator(user, transactor, userOutbox, userAudit)
+	userModifier := ResolveUserModifier(user, transactor, userOutbox, userAudit)
+	userPatchApplier := patch.NewJSONApplier()
+	userPatcher := ResolveUserPatcher(user, userPatchApplier, transactor, userOutbox, userAudit)
+	userDeleter := ResolveUserDeleter(user, transactor, userOutbox, userAudit)
+	userRestorer := ResolveUserRestorer(user, transactor, userOutbox, userAudit)
+	userAPI := handler.NewUserAPI(userFinderAll, userSearcher, userFinderByID, userCreator, userModifier, userPatcher, userDeleter, userRestorer)
+	userHistoryFinder := usecase.NewUserHistoryFinder(user, userAudit)
+	userHistoryAPI := handler.NewUserHistoryAPI(userHistoryFinder)
+	userEventAPI := handler.NewUserEventAPI(broker)
+	userSocketAPI := handler.NewUserSocketAPI(broker)
+	apiKey := ResolveAPIKeyRepository(gormDB)
+	apiKeyFinderAll := usecase.NewAPIKeyFinderAll(apiKey)
+	apiKeyCreator := usecase.NewAPIKeyCreator(apiKey, user)
+	apiKeyRevoker := usecase.NewAPIKeyRevoker(apiKey)
+	apiKeyAPI := handler.NewAPIKeyAPI(apiKeyFinderAll, apiKeyCreator, apiKeyRevoker)
+	webhook := ResolveWebhookRepository(gormDB)
+	webhookFinderAll := usecase.NewWebhookFinderAll(webhook)
+	webhookCreator := usecase.NewWebhookCreator(webhook)
+	webhookDelivery := ResolveWebhookDeliveryRepository(gormDB)
+	webhookDeleter := usecase.NewWebhookDeleter(transactor, webhook, webhookDelivery)
+	webhookDeliveryFinder := usecase.NewWebhookDeliveryFinder(webhook, webhookDelivery)
+	webhookAPI := handler.NewWebhookAPI(webhookFinderAll, webhookCreator, webhookDeleter, webhookDeliveryFinder)
+	userCredentials, err := ResolveUserCredentialsRepository(user)
+	if err != nil {
+		return nil, err
+	}
+	passwordHasher := security.NewPasswordHasher()
+	userAuthenticator := usecase.NewUserAuthenticator(user, userCredentials, passwordHasher)
+	refreshToken := ResolveRefreshTokenRepository(gormDB)
+	jwt, err := security.NewJWT(auth)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenTTL := ResolveRefreshTokenTTL(auth)
+	tokenGranter := usecase.NewTokenGranter(refreshToken, jwt, refreshTokenTTL)
+	tokenRefresher := usecase.NewTokenRefresher(user, refreshToken, jwt, refreshTokenTTL)
+	tokenRevoker := usecase.NewTokenRevoker(refreshToken)
+	loginAPI := handler.NewLoginAPI(userAuthenticator, tokenGranter, tokenRefresher, tokenRevoker)
+	jwksapi := handler.NewJWKSAPI(jwt)
+	userIdentity, err := ResolveUserIdentityRepository(user)
+	if err != nil {
+		return nil, err
+	}
+	userProvisioner := usecase.NewUserProvisioner(userIdentity, transactor, userOutbox)
+	apiKeyAuthenticator := usecase.NewAPIKeyAuthenticator(apiKey, user)
+	authorizer, err := ResolveAuthorizer(auth, jwt, userProvisioner, apiKeyAuthenticator)
+	if err != nil {
+		return nil, err
+	}
+	configHTTP := cfg.HTTP
+	webhooks := cfg.Webhooks
+	webhookScheduler := usecase.NewWebhookScheduler(webhook, webhookDelivery)
+	userEventPublisher, err := ResolveUserEventPublisher(events, webhookScheduler)
+	if err != nil {
+		return nil, err
+	}
+	userEventBatchSize := ResolveUserEventBatchSize(events)
+	userEventRelayer := usecase.NewUserEventRelayer(transactor, userOutbox, userEventPublisher, userEventBatchSize)
+	webhookSender := ResolveWebhookSender(webhooks)
+	webhookRetryPolicy := ResolveWebhookRetryPolicy(webhooks)
+	webhookBatchSize := ResolveWebhookBatchSize(webhooks)
+	webhookLease := ResolveWebhookLease(webhooks)
+	webhookDeliverer := usecase.NewWebhookDeliverer(transactor, webhook, webhookDelivery, webhookSender, webhookRetryPolicy, webhookBatchSize, webhookLease)
+	group := ResolveWorkers(events, webhooks, userEventRelayer, webhookDeliverer)
+	server := http.NewServer(userAPI, userHistoryAPI, userEventAPI, userSocketAPI, apiKeyAPI, webhookAPI, loginAPI, jwksapi, authorizer, configHTTP, group, broker)
 	return server, nil
 }
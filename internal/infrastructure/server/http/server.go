@@ -4,52 +4,132 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/swagger"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/handler"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/problem"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/event"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
 	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/middleware"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/worker"
 
 	_ "github.com/josepdcs/go-proposal-hexagonal-arch/cmd/api/docs"
 )
 
 const (
-	usersPath   = "users"
-	usersPathID = usersPath + "/:id"
+	apiPath          = "/api"
+	usersPath        = "users"
+	usersPathID      = usersPath + "/:id"
+	usersPathSearch  = usersPath + "/search"
+	usersPathRestore = usersPathID + "/restore"
+	usersPathHistory = usersPathID + "/history"
+	usersPathEvents  = usersPath + "/events"
+	usersPathSocket  = usersPath + "/ws"
+	apiKeysPath      = "api-keys"
+	apiKeysPathID    = apiKeysPath + "/:id"
+	webhooksPath     = "webhooks"
+	webhooksPathID   = webhooksPath + "/:id"
+	webhooksPathLog  = webhooksPathID + "/deliveries"
 )
 
 type Server struct {
-	app *fiber.App
+	app     *fiber.App
+	workers worker.Group
+	broker  *event.Broker
 }
 
-func NewServer(user *handler.UserAPI) *Server {
-	app := fiber.New()
+func NewServer(
+	user *handler.UserAPI,
+	userHistory *handler.UserHistoryAPI,
+	userEvent *handler.UserEventAPI,
+	userSocket *handler.UserSocketAPI,
+	apiKey *handler.APIKeyAPI,
+	webhook *handler.WebhookAPI,
+	login *handler.LoginAPI,
+	jwks *handler.JWKSAPI,
+	auth *middleware.Authorizer,
+	httpConfig config.HTTP,
+	workers worker.Group,
+	broker *event.Broker,
+) *Server {
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+
+	// Correlation ID of the requests, echoed in the problems answered on error
+	app.Use(requestid.New(), middleware.RequestID())
 
 	// Swagger docs
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
 	// Request JWT
-	app.Post("/login", handler.Login)
+	app.Post("/login", login.Login)
+	app.Post("/auth/refresh", login.Refresh)
+	app.Post("/auth/logout", login.Logout)
+
+	// Public keys verifying the JWT
+	app.Get("/.well-known/jwks.json", jwks.JWKS)
+
+	// Access token of the browsers, which cannot set the headers of a WebSocket
+	app.Use(apiPath+"/"+usersPathSocket, middleware.QueryToken("access_token"))
 
 	// Auth middleware
-	api := app.Group("/api", middleware.Authorization)
+	api := app.Group(apiPath, auth.Authorization)
+
+	// Cache-Control of the conditional reads, configured by their full route
+	cacheControl := func(path string) fiber.Handler {
+		return middleware.CacheControl(httpConfig.CacheControlOf(apiPath + "/" + path))
+	}
+
+	// Scopes of the API keys, whose principals are limited to them on top of the roles of their owner
+	read := middleware.LimitScope(entity.ScopeUsersRead)
+	write := middleware.LimitScope(entity.ScopeUsersWrite)
+	admin := middleware.LimitScope(entity.ScopeAdmin)
+	requireAdmin := middleware.RequireRole(entity.RoleAdmin)
+
+	api.Get(usersPath, read, cacheControl(usersPath), user.FindAll)
+	// registered before usersPathID, which would match them too
+	api.Get(usersPathSearch, read, user.Search)
+	api.Get(usersPathEvents, read, userEvent.Stream)
+	api.Get(usersPathSocket, read, userSocket.Subscribe)
+	api.Get(usersPathID, read, cacheControl(usersPathID), user.FindByID)
+	api.Post(usersPath, write, user.Create)
+	api.Put(usersPathID, write, user.Modify)
+	api.Patch(usersPathID, write, user.Patch)
+	api.Delete(usersPathID, write, user.Delete)
+	api.Post(usersPathRestore, write, user.Restore)
+	api.Get(usersPathHistory, read, admin, requireAdmin, userHistory.FindByUserID)
+
+	api.Get(apiKeysPath, admin, requireAdmin, apiKey.FindAll)
+	api.Post(apiKeysPath, admin, requireAdmin, apiKey.Create)
+	api.Delete(apiKeysPathID, admin, requireAdmin, apiKey.Revoke)
 
-	api.Get(usersPath, user.FindAll)
-	api.Get(usersPathID, user.FindByID)
-	api.Post(usersPath, user.Create)
-	api.Put(usersPathID, user.Modify)
-	api.Delete(usersPathID, user.Delete)
+	api.Get(webhooksPath, admin, requireAdmin, webhook.FindAll)
+	api.Post(webhooksPath, admin, requireAdmin, webhook.Create)
+	api.Delete(webhooksPathID, admin, requireAdmin, webhook.Delete)
+	api.Get(webhooksPathLog, admin, requireAdmin, webhook.FindDeliveries)
 
-	return &Server{app: app}
+	return &Server{app: app, workers: workers, broker: broker}
 }
 
+// Start starts the background workers and then serves the HTTP requests
 func (sh *Server) Start() error {
+	sh.workers.Start()
 	return sh.app.Listen(":8080")
 }
 
+// Shutdown ends the streams of events, stops serving the HTTP requests and then the background workers
 func (sh *Server) Shutdown() error {
+	defer sh.workers.Stop()
+	// the streams would keep their connections open, so the server would never be idle
+	sh.broker.Close()
 	return sh.app.Shutdown()
 }
 
+// ShutdownWithTimeout ends the streams of events, stops serving the HTTP requests within the given timeout and then
+// the background workers
 func (sh *Server) ShutdownWithTimeout(timeout time.Duration) error {
+	defer sh.workers.Stop()
+	sh.broker.Close()
 	return sh.app.ShutdownWithTimeout(timeout)
 }
 
@@ -0,0 +1,153 @@
+package http_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/di"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApp returns the Fiber app of the API with an in-memory database
+func newApp(t *testing.T) *fiber.App {
+	server, err := di.InitializeAPI(config.Config{
+		DB: config.DB{Type: config.InMemoryDB},
+		Auth: config.Auth{
+			Mode:            config.AuthModeLocal,
+			Algorithm:       config.DefaultAuthAlgorithm,
+			Secret:          "server-test-secret",
+			Issuer:          "go-proposal-hexagonal-arch",
+			Audience:        "go-proposal-hexagonal-arch",
+			AccessTokenTTL:  config.DefaultAuthAccessTokenTTL,
+			RefreshTokenTTL: config.DefaultAuthRefreshTokenTTL,
+		},
+		Events: config.Events{
+			Publisher:      config.DefaultEventPublisher,
+			RelayInterval:  config.DefaultEventRelayInterval,
+			RelayBatchSize: config.DefaultEventRelayBatchSize,
+			ReplaySize:     config.DefaultEventReplaySize,
+		},
+		Webhooks: config.Webhooks{
+			Timeout:        config.DefaultWebhookTimeout,
+			MaxAttempts:    config.DefaultWebhookMaxAttempts,
+			InitialBackoff: config.DefaultWebhookInitialBackoff,
+			MaxBackoff:     config.DefaultWebhookMaxBackoff,
+			BatchSize:      config.DefaultWebhookBatchSize,
+			PollInterval:   config.DefaultWebhookPollInterval,
+		},
+	})
+	require.NoError(t, err)
+	return server.Fiber()
+}
+
+// call sends a request with the given authorization and body to the app, and returns its status and body
+func call(t *testing.T, app *fiber.App, method, path, authorization, body string) (int, []byte) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if authorization != "" {
+		req.Header.Set(fiber.HeaderAuthorization, authorization)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, respBody
+}
+
+// adminToken returns the authorization of the admin seeded in memory
+func adminToken(t *testing.T, app *fiber.App) string {
+	status, body := call(t, app, http.MethodPost, "/login", "", `{"username": "john", "password": "lark"}`)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(body, &resp))
+	return "Bearer " + resp.Token
+}
+
+// apiKey creates an API key of the admin with the given scopes, and returns its authorization
+func apiKey(t *testing.T, app *fiber.App, admin string, scopes ...string) string {
+	req, err := json.Marshal(map[string]any{"name": "test", "scopes": scopes, "expires_at": time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	status, body := call(t, app, http.MethodPost, "/api/api-keys", admin, string(req))
+	require.Equal(t, http.StatusCreated, status, string(body))
+	var resp struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(body, &resp))
+	return "ApiKey " + resp.Key
+}
+
+func TestServer_APIKeyScopes(t *testing.T) {
+	const newUser = `{"name": "Ada", "surname": "Lovelace"}`
+
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "should let a read-only key read the users", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodGet, path: "/api/users", status: http.StatusOK},
+		{name: "should forbid a read-only key to create a user", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodPost, path: "/api/users", body: newUser, status: http.StatusForbidden},
+		{name: "should forbid a read-only key to modify a user", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodPut, path: "/api/users/1", body: `{"name": "John", "surname": "Doe"}`, status: http.StatusForbidden},
+		{name: "should forbid a read-only key to patch a user", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodPatch, path: "/api/users/1", body: `{"name": "Johnny"}`, status: http.StatusForbidden},
+		{name: "should forbid a read-only key of an admin to delete a user", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodDelete, path: "/api/users/1", status: http.StatusForbidden},
+		{name: "should forbid a read-only key of an admin to create an API key", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodPost, path: "/api/api-keys", body: `{"name": "escalated"}`, status: http.StatusForbidden},
+		{name: "should forbid a read-only key of an admin to create a webhook", scopes: []string{entity.ScopeUsersRead},
+			method: http.MethodPost, path: "/api/webhooks", body: `{"url": "https://example.com/hook"}`, status: http.StatusForbidden},
+		{name: "should forbid a write-only key to read the users", scopes: []string{entity.ScopeUsersWrite},
+			method: http.MethodGet, path: "/api/users/1", status: http.StatusForbidden},
+		{name: "should let a write key create a user", scopes: []string{entity.ScopeUsersWrite},
+			method: http.MethodPost, path: "/api/users", body: newUser, status: http.StatusCreated},
+		{name: "should let a write key delete a user", scopes: []string{entity.ScopeUsersWrite},
+			method: http.MethodDelete, path: "/api/users/1", status: http.StatusNoContent},
+		{name: "should forbid a write key without the admin scope to purge a user", scopes: []string{entity.ScopeUsersWrite},
+			method: http.MethodDelete, path: "/api/users/1?purge=true", status: http.StatusForbidden},
+		{name: "should let a write key restore a user, which is not deleted", scopes: []string{entity.ScopeUsersWrite},
+			method: http.MethodPost, path: "/api/users/1/restore", status: http.StatusConflict},
+		{name: "should let an admin key of an admin list the API keys", scopes: []string{entity.ScopeAdmin},
+			method: http.MethodGet, path: "/api/api-keys", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			app := newApp(t)
+			key := apiKey(t, app, adminToken(t, app), tt.scopes...)
+
+			// When
+			status, body := call(t, app, tt.method, tt.path, key, tt.body)
+
+			// Then
+			assert.Equal(t, tt.status, status, string(body))
+		})
+	}
+}
+
+func TestServer_UserToken(t *testing.T) {
+	// Given
+	app := newApp(t)
+
+	// When
+	status, body := call(t, app, http.MethodGet, "/api/api-keys", adminToken(t, app), "")
+
+	// Then
+	assert.Equal(t, http.StatusOK, status, string(body), "the tokens of the users are left to their roles")
+}
@@ -1,36 +1,183 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
 	"github.com/pkg/errors"
 )
 
-func Authorization(c *fiber.Ctx) error {
+const (
+	// ClaimsKey is the key of the claims of the validated token in the fiber.Ctx locals
+	ClaimsKey = "claims"
+	// APIKeyHeader is the header carrying the API key of the machine clients
+	APIKeyHeader = "X-API-Key"
+	// apiKeyScheme is the authorization scheme of the API keys, as an alternative to APIKeyHeader
+	apiKeyScheme = "ApiKey "
+)
+
+// TokenVerifier validates the access tokens of the incoming requests
+type TokenVerifier interface {
+	// Verify returns the claims of the given token or an error if it is not valid
+	Verify(token string) (*security.Claims, error)
+}
+
+// Authorizer checks the bearer token of the incoming requests
+type Authorizer struct {
+	verifier    TokenVerifier
+	provisioner usecase.UserProvisioner
+	apiKeys     usecase.APIKeyAuthenticator
+}
+
+// NewAuthorizer creates a new Authorizer of the tokens issued by this API
+func NewAuthorizer(verifier TokenVerifier) *Authorizer {
+	return &Authorizer{
+		verifier: verifier,
+	}
+}
+
+// NewProvisioningAuthorizer creates a new Authorizer of the tokens issued by an external identity provider.
+// The subject of the tokens is mapped to a local user, which is provisioned the first time the subject is seen.
+func NewProvisioningAuthorizer(verifier TokenVerifier, provisioner usecase.UserProvisioner) *Authorizer {
+	return &Authorizer{
+		verifier:    verifier,
+		provisioner: provisioner,
+	}
+}
+
+// WithAPIKeys makes the Authorizer accept the API keys validated by the given authenticator besides the bearer tokens
+func (a *Authorizer) WithAPIKeys(apiKeys usecase.APIKeyAuthenticator) *Authorizer {
+	a.apiKeys = apiKeys
+	return a
+}
+
+// Authorization rejects the requests without a valid bearer token, or a valid API key if they are accepted.
+// The claims of the token are stored in the locals of the fiber.Ctx, and the entity.Principal they identify is
+// propagated into its user context. The principal of an external token gets the ID and roles of its local user.
+func (a *Authorizer) Authorization(c *fiber.Ctx) error {
+	if key, ok := apiKey(c); ok && a.apiKeys != nil {
+		return a.apiKeyAuthorization(c, key)
+	}
+
 	s := c.Get("Authorization")
 
 	token := strings.TrimPrefix(s, "Bearer ")
 
-	if err := validateToken(token); err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "invalid token",
-		})
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return errors.Wrap(domerrors.ErrUnauthorized, "invalid token")
+	}
+
+	principal := claims.Principal()
+	if a.provisioner != nil {
+		user, err := a.provisioner.Provision(c.UserContext(), claims.Identity())
+		if err != nil {
+			return errors.WithMessage(err, "cannot provision user")
+		}
+		principal.UserID = user.ID
+		principal.Roles = user.Roles
+	}
+
+	c.Locals(ClaimsKey, claims)
+	c.SetUserContext(entity.ContextWithPrincipal(c.UserContext(), principal))
+
+	return c.Next()
+}
+
+// apiKeyAuthorization rejects the requests without a valid API key, and propagates the entity.Principal of the key
+// into the user context of the valid ones
+func (a *Authorizer) apiKeyAuthorization(c *fiber.Ctx, key string) error {
+	principal, err := a.apiKeys.Authenticate(c.UserContext(), key)
+	if err != nil {
+		return errors.WithMessage(err, "cannot authenticate api key")
 	}
 
+	c.SetUserContext(entity.ContextWithPrincipal(c.UserContext(), principal))
+
 	return c.Next()
 }
 
-func validateToken(token string) error {
-	_, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.Errorf("unexpected signing method: %v", t.Header["alg"])
+// apiKey returns the API key of the request, given by the APIKeyHeader or the ApiKey authorization scheme
+func apiKey(c *fiber.Ctx) (string, bool) {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return key, true
+	}
+	return strings.CutPrefix(c.Get("Authorization"), apiKeyScheme)
+}
+
+// QueryToken takes the bearer token of the requests without Authorization header from the given query parameter, for
+// the clients which cannot set headers, such as the browsers opening a WebSocket. It must run before
+// Authorizer.Authorization, and only on the routes of those clients, since the URLs end up in the logs.
+func QueryToken(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token := c.Query(param); token != "" && c.Get(fiber.HeaderAuthorization) == "" {
+			c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		return c.Next()
+	}
+}
+
+// Claims returns the claims stored by Authorizer.Authorization, if any
+func Claims(c *fiber.Ctx) (*security.Claims, bool) {
+	claims, ok := c.Locals(ClaimsKey).(*security.Claims)
+	return claims, ok
+}
+
+// RequireRole rejects the requests whose principal has none of the given roles.
+// It must run after Authorizer.Authorization.
+func RequireRole(roles ...string) fiber.Handler {
+	return requirePrincipal(func(p entity.Principal) bool {
+		for _, role := range roles {
+			if p.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequireScope rejects the requests whose principal has not been granted all the given scopes.
+// It must run after Authorizer.Authorization.
+func RequireScope(scopes ...string) fiber.Handler {
+	return requirePrincipal(func(p entity.Principal) bool {
+		for _, scope := range scopes {
+			if !p.HasScope(scope) {
+				return false
+			}
 		}
+		return true
+	})
+}
 
-		return []byte("secret"), nil
+// LimitScope rejects the requests whose principal is scoped, as the API keys are, and has not been granted all the
+// given scopes. The other principals are left to their roles. It must run after Authorizer.Authorization.
+func LimitScope(scopes ...string) fiber.Handler {
+	return requirePrincipal(func(p entity.Principal) bool {
+		for _, scope := range scopes {
+			if !p.Allows(scope) {
+				return false
+			}
+		}
+		return true
 	})
+}
+
+// requirePrincipal rejects the requests without principal, or whose principal is not allowed by the given function
+func requirePrincipal(allowed func(p entity.Principal) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		p, ok := entity.PrincipalFromContext(c.UserContext())
+		if !ok {
+			return domerrors.ErrUnauthorized
+		}
 
-	return err
+		if !allowed(p) {
+			return domerrors.ErrForbidden
+		}
+
+		return c.Next()
+	}
 }
@@ -0,0 +1,473 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/security"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/server/config"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const protectedEndpoint = "/api/protected"
+
+func newTestJWT(t *testing.T, audience string) *security.JWT {
+	j, err := security.NewJWT(config.Auth{
+		Algorithm:      "HS256",
+		Secret:         "test-secret",
+		Issuer:         "hexagonal",
+		Audience:       audience,
+		AccessTokenTTL: time.Minute,
+	})
+	require.NoError(t, err)
+	return j
+}
+
+func TestAuthorizer_Authorization(t *testing.T) {
+	j := newTestJWT(t, "hexagonal-api")
+
+	validToken, err := j.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+	otherAudienceToken, err := newTestJWT(t, "other-api").Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{name: "should accept a valid token", authorization: "Bearer " + validToken.Token, status: http.StatusOK},
+		{name: "should reject a missing token", authorization: "", status: http.StatusUnauthorized},
+		{name: "should reject a malformed token", authorization: "Bearer malformed", status: http.StatusUnauthorized},
+		{name: "should reject a token for another audience", authorization: "Bearer " + otherAudienceToken.Token, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Get(protectedEndpoint, NewAuthorizer(j).Authorization, func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+			req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+// writePrivateKeyFile writes a new PEM encoded ECDSA private key to a temporary directory
+func writePrivateKeyFile(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "private.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return file
+}
+
+func TestAuthorizer_Authorization_KeyRotation(t *testing.T) {
+	oldKeyFile := writePrivateKeyFile(t)
+	newKeyFile := writePrivateKeyFile(t)
+	newJWT := func(keys ...config.AuthKey) *security.JWT {
+		j, err := security.NewJWT(config.Auth{
+			Keys:           keys,
+			Issuer:         "hexagonal",
+			Audience:       "hexagonal-api",
+			AccessTokenTTL: time.Minute,
+		})
+		require.NoError(t, err)
+		return j
+	}
+
+	// the API signs with the old key, then rotates to the new key while still verifying the old one
+	before := newJWT(config.AuthKey{ID: "old", Algorithm: "ES256", PrivateKeyFile: oldKeyFile})
+	after := newJWT(
+		config.AuthKey{ID: "old", Algorithm: "ES256", PrivateKeyFile: oldKeyFile},
+		config.AuthKey{ID: "new", Algorithm: "ES256", PrivateKeyFile: newKeyFile, ActiveFrom: time.Now()},
+	)
+	// a key signing with the id of the new key but another private key
+	forged := newJWT(config.AuthKey{ID: "new", Algorithm: "ES256", PrivateKeyFile: oldKeyFile})
+
+	oldToken, err := before.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+	newToken, err := after.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+	forgedToken, err := forged.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "should accept a token signed by the old key", token: oldToken.Token, status: http.StatusOK},
+		{name: "should accept a token signed by the new key", token: newToken.Token, status: http.StatusOK},
+		{name: "should reject a token not signed by the key of its kid", token: forgedToken.Token, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Get(protectedEndpoint, NewAuthorizer(after).Authorization, func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAuthorizer_Authorization_Claims(t *testing.T) {
+	// Given
+	j := newTestJWT(t, "hexagonal-api")
+	token, err := j.Issue(context.Background(), entity.User{ID: 1, Roles: []string{entity.RoleAdmin}})
+	require.NoError(t, err)
+
+	var (
+		claims    *security.Claims
+		principal entity.Principal
+	)
+	a := testutils.App()
+	a.Get(protectedEndpoint, NewAuthorizer(j).Authorization, func(c *fiber.Ctx) error {
+		claims, _ = Claims(c)
+		principal, _ = entity.PrincipalFromContext(c.UserContext())
+		return c.SendStatus(http.StatusOK)
+	})
+
+	// When
+	req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token.Token)
+	resp, err := a.Test(req, -1)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotNil(t, claims)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Equal(t, []string{entity.RoleAdmin}, claims.Roles)
+	assert.Equal(t, entity.Principal{Subject: "1", UserID: 1, Roles: []string{entity.RoleAdmin}, Scopes: []string{}, ExpiresAt: claims.ExpiresAt.Time}, principal)
+}
+
+func TestRequireRoleAndScope(t *testing.T) {
+	principal := entity.Principal{Subject: "1", UserID: 1, Roles: []string{"editor"}, Scopes: []string{"users:read", "users:write"}}
+	scoped := entity.Principal{Subject: "apikey:0123456789ab", UserID: 1, Roles: []string{"editor"}, Scopes: []string{"users:read"}, Scoped: true}
+
+	tests := []struct {
+		name      string
+		principal *entity.Principal
+		handler   fiber.Handler
+		status    int
+	}{
+		{name: "should accept a principal with the role", principal: &principal, handler: RequireRole("editor"), status: http.StatusOK},
+		{name: "should accept a principal with any of the roles", principal: &principal, handler: RequireRole(entity.RoleAdmin, "editor"), status: http.StatusOK},
+		{name: "should reject a principal without the role", principal: &principal, handler: RequireRole(entity.RoleAdmin), status: http.StatusForbidden},
+		{name: "should accept a principal with all the scopes", principal: &principal, handler: RequireScope("users:read", "users:write"), status: http.StatusOK},
+		{name: "should reject a principal without any of the scopes", principal: &principal, handler: RequireScope("users:read", "users:delete"), status: http.StatusForbidden},
+		{name: "should reject a request without principal", handler: RequireRole("editor"), status: http.StatusUnauthorized},
+		{name: "should accept a scoped principal with all the scopes", principal: &scoped, handler: LimitScope("users:read"), status: http.StatusOK},
+		{name: "should reject a scoped principal without any of the scopes", principal: &scoped, handler: LimitScope("users:read", "users:write"), status: http.StatusForbidden},
+		{name: "should leave a principal not scoped to its roles", principal: &principal, handler: LimitScope(entity.ScopeAdmin), status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Get(protectedEndpoint, func(c *fiber.Ctx) error {
+				if tt.principal != nil {
+					c.SetUserContext(entity.ContextWithPrincipal(c.UserContext(), *tt.principal))
+				}
+				return c.Next()
+			}, tt.handler, func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAuthorizer_Authorization_OIDC(t *testing.T) {
+	provider := testutils.NewOIDCProvider(t)
+	o, err := security.NewOIDC(config.Auth{
+		Mode: config.AuthModeOIDC,
+		OIDC: config.OIDC{IssuerURL: provider.Issuer(), Audience: "hexagonal-client", JWKSRefresh: time.Hour},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	expiresAt := jwt.NewNumericDate(now.Add(time.Minute))
+	identity := entity.ExternalIdentity{Issuer: provider.Issuer(), Subject: "external-subject", Email: "john@example.com"}
+	externalToken := provider.Sign(t, security.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    provider.Issuer(),
+			Subject:   "external-subject",
+			Audience:  jwt.ClaimStrings{"hexagonal-client"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: expiresAt,
+		},
+		Scope: "users:read",
+		Email: "john@example.com",
+	})
+	localToken, err := newTestJWT(t, "hexagonal-client").Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		given         func() *usecase.MockUserProvisioner
+		status        int
+		principal     entity.Principal
+	}{
+		{
+			name:          "should accept a token of the provider and map it to its local user",
+			authorization: "Bearer " + externalToken,
+			given: func() *usecase.MockUserProvisioner {
+				p := usecase.NewMockUserProvisioner()
+				p.On("Provision", mock.Anything, identity).Return(entity.User{ID: 7, Roles: []string{entity.RoleAdmin}}, nil)
+				return p
+			},
+			status: http.StatusOK,
+			principal: entity.Principal{
+				Subject:   "external-subject",
+				UserID:    7,
+				Roles:     []string{entity.RoleAdmin},
+				Scopes:    []string{"users:read"},
+				ExpiresAt: expiresAt.Time,
+			},
+		},
+		{
+			name:          "should fail when the local user cannot be provisioned",
+			authorization: "Bearer " + externalToken,
+			given: func() *usecase.MockUserProvisioner {
+				p := usecase.NewMockUserProvisioner()
+				p.On("Provision", mock.Anything, identity).Return(entity.User{}, errors.New("error"))
+				return p
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:          "should reject a token issued by this API",
+			authorization: "Bearer " + localToken.Token,
+			given:         usecase.NewMockUserProvisioner,
+			status:        http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			provisioner := tt.given()
+			var principal entity.Principal
+			a := testutils.App()
+			a.Get(protectedEndpoint, NewProvisioningAuthorizer(o, provisioner).Authorization, func(c *fiber.Ctx) error {
+				principal, _ = entity.PrincipalFromContext(c.UserContext())
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+			req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.principal, principal)
+			provisioner.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthorizer_Authorization_APIKey(t *testing.T) {
+	j := newTestJWT(t, "hexagonal-api")
+	token, err := j.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+	keyPrincipal := entity.Principal{Subject: "apikey:0123456789ab", UserID: 2, Scopes: []string{"users:read"}}
+
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		given     func() *usecase.MockAPIKeyAuthenticator
+		status    int
+		principal entity.Principal
+	}{
+		{
+			name:   "should accept a valid API key header",
+			header: APIKeyHeader,
+			value:  "0123456789ab.secret",
+			given: func() *usecase.MockAPIKeyAuthenticator {
+				k := usecase.NewMockAPIKeyAuthenticator()
+				k.On("Authenticate", mock.Anything, "0123456789ab.secret").Return(keyPrincipal, nil)
+				return k
+			},
+			status:    http.StatusOK,
+			principal: keyPrincipal,
+		},
+		{
+			name:   "should accept a valid API key authorization",
+			header: fiber.HeaderAuthorization,
+			value:  "ApiKey 0123456789ab.secret",
+			given: func() *usecase.MockAPIKeyAuthenticator {
+				k := usecase.NewMockAPIKeyAuthenticator()
+				k.On("Authenticate", mock.Anything, "0123456789ab.secret").Return(keyPrincipal, nil)
+				return k
+			},
+			status:    http.StatusOK,
+			principal: keyPrincipal,
+		},
+		{
+			name:   "should reject an invalid API key",
+			header: APIKeyHeader,
+			value:  "0123456789ab.wrong",
+			given: func() *usecase.MockAPIKeyAuthenticator {
+				k := usecase.NewMockAPIKeyAuthenticator()
+				k.On("Authenticate", mock.Anything, "0123456789ab.wrong").Return(entity.Principal{}, domerrors.ErrInvalidAPIKey)
+				return k
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "should fail when the API key cannot be authenticated",
+			header: APIKeyHeader,
+			value:  "0123456789ab.secret",
+			given: func() *usecase.MockAPIKeyAuthenticator {
+				k := usecase.NewMockAPIKeyAuthenticator()
+				k.On("Authenticate", mock.Anything, "0123456789ab.secret").Return(entity.Principal{}, errors.New("error"))
+				return k
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:      "should still accept a bearer token",
+			header:    fiber.HeaderAuthorization,
+			value:     "Bearer " + token.Token,
+			given:     usecase.NewMockAPIKeyAuthenticator,
+			status:    http.StatusOK,
+			principal: entity.Principal{Subject: "1", UserID: 1, Scopes: []string{}, ExpiresAt: jwt.NewNumericDate(token.ExpiresAt).Time},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			apiKeys := tt.given()
+			var principal entity.Principal
+			a := testutils.App()
+			a.Get(protectedEndpoint, NewAuthorizer(j).WithAPIKeys(apiKeys).Authorization, func(c *fiber.Ctx) error {
+				principal, _ = entity.PrincipalFromContext(c.UserContext())
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+			req.Header.Set(tt.header, tt.value)
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.principal, principal)
+			apiKeys.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthorizer_Authorization_APIKeyNotAccepted(t *testing.T) {
+	// Given
+	a := testutils.App()
+	a.Get(protectedEndpoint, NewAuthorizer(newTestJWT(t, "hexagonal-api")).Authorization, func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	// When
+	req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+	req.Header.Set(APIKeyHeader, "0123456789ab.secret")
+	resp, err := a.Test(req, -1)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestQueryToken(t *testing.T) {
+	j := newTestJWT(t, "hexagonal-api")
+
+	token, err := j.Issue(context.Background(), entity.User{ID: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		query         string
+		authorization string
+		status        int
+	}{
+		{name: "should accept a valid token of the query", query: "?access_token=" + token.Token, status: http.StatusOK},
+		{name: "should reject an invalid token of the query", query: "?access_token=malformed", status: http.StatusUnauthorized},
+		{name: "should reject a request without token", status: http.StatusUnauthorized},
+		{
+			name:          "should prefer the token of the authorization header",
+			query:         "?access_token=" + token.Token,
+			authorization: "Bearer malformed",
+			status:        http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Get(protectedEndpoint, QueryToken("access_token"), NewAuthorizer(j).Authorization, func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint+tt.query, nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheControl sets the given Cache-Control header on the successful and the not modified responses of a route,
+// leaving its errors uncached
+func CacheControl(value string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if status := c.Response().StatusCode(); status == fiber.StatusOK || status == fiber.StatusNotModified {
+			c.Set(fiber.HeaderCacheControl, value)
+		}
+		return nil
+	}
+}
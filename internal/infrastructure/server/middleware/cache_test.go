@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	domerrors "github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/errors"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      fiber.Handler
+		status       int
+		cacheControl string
+	}{
+		{
+			name:         "should set the Cache-Control of a successful response",
+			handler:      func(c *fiber.Ctx) error { return c.SendString("ok") },
+			status:       http.StatusOK,
+			cacheControl: "private, max-age=60",
+		},
+		{
+			name:         "should set the Cache-Control of a not modified response",
+			handler:      func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNotModified) },
+			status:       http.StatusNotModified,
+			cacheControl: "private, max-age=60",
+		},
+		{
+			name:    "should not set the Cache-Control of an error",
+			handler: func(c *fiber.Ctx) error { return domerrors.ErrUserNotFound },
+			status:  http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Get(protectedEndpoint, CacheControl("private, max-age=60"), tt.handler)
+
+			// When
+			resp, err := a.Test(httptest.NewRequest(http.MethodGet, protectedEndpoint, nil), -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.cacheControl, resp.Header.Get(fiber.HeaderCacheControl))
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+)
+
+// RequestID carries the ID of the request, as returned in its X-Request-ID header by the requestid middleware, in
+// its user context, so that the use cases can correlate their records with the request
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if id := c.GetRespHeader(fiber.HeaderXRequestID); id != "" {
+			// copied, since fiber reuses the memory of the headers once the request is answered
+			c.SetUserContext(entity.ContextWithRequestID(c.UserContext(), strings.Clone(id)))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	testutils "github.com/josepdcs/go-proposal-hexagonal-arch/internal/infrastructure/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{
+			name:      "should carry the given ID of the request in its context",
+			requestID: "3f5c1a7e-request",
+		},
+		{
+			name: "should carry the generated ID of the request in its context",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			a := testutils.App()
+			a.Use(requestid.New())
+			var carried string
+			a.Get(protectedEndpoint, RequestID(), func(c *fiber.Ctx) error {
+				carried = entity.RequestIDFromContext(c.UserContext())
+				return c.SendStatus(fiber.StatusNoContent)
+			})
+
+			// When
+			req := httptest.NewRequest(http.MethodGet, protectedEndpoint, nil)
+			if tt.requestID != "" {
+				req.Header.Set(fiber.HeaderXRequestID, tt.requestID)
+			}
+			resp, err := a.Test(req, -1)
+
+			// Then
+			assert.NoError(t, err)
+			assert.NotEmpty(t, carried)
+			assert.Equal(t, resp.Header.Get(fiber.HeaderXRequestID), carried)
+			if tt.requestID != "" {
+				assert.Equal(t, tt.requestID, carried)
+			}
+		})
+	}
+}
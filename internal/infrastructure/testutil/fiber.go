@@ -2,11 +2,12 @@ package testutils
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/api/problem"
 	"github.com/valyala/fasthttp"
 )
 
 func App() *fiber.App {
-	return fiber.New(fiber.Config{UnescapePath: true})
+	return fiber.New(fiber.Config{UnescapePath: true, ErrorHandler: problem.ErrorHandler})
 }
 
 func AcquireFiberCtx(app *fiber.App) *fiber.Ctx {
@@ -0,0 +1,132 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCProvider is an in-process fake OpenID Connect provider publishing its discovery document and RS256 keys
+type OIDCProvider struct {
+	server      *httptest.Server
+	keyRequests atomic.Int32
+
+	mu   sync.Mutex
+	keys []oidcProviderKey
+	down bool
+}
+
+type oidcProviderKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+// NewOIDCProvider starts a new OIDCProvider with a single key, which is stopped at the end of the test
+func NewOIDCProvider(t *testing.T) *OIDCProvider {
+	p := &OIDCProvider{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = p.write(w, map[string]string{"issuer": p.Issuer(), "jwks_uri": p.Issuer() + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		if p.write(w, p.jwks()) {
+			p.keyRequests.Add(1)
+		}
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	p.RotateKey(t)
+	return p
+}
+
+// Issuer returns the issuer URL of the provider
+func (p *OIDCProvider) Issuer() string {
+	return p.server.URL
+}
+
+// KeyRequests returns the number of times the keys of the provider have been fetched successfully
+func (p *OIDCProvider) KeyRequests() int {
+	return int(p.keyRequests.Load())
+}
+
+// SetDown makes the provider answer every request with an error
+func (p *OIDCProvider) SetDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+}
+
+// RotateKey publishes a new key, which signs the next tokens, and returns its id
+func (p *OIDCProvider) RotateKey(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := fmt.Sprintf("key-%d", len(p.keys)+1)
+	p.keys = append(p.keys, oidcProviderKey{id: id, key: key})
+	return id
+}
+
+// Sign returns a token with the given claims signed by the current key of the provider
+func (p *OIDCProvider) Sign(t *testing.T, claims jwt.Claims) string {
+	p.mu.Lock()
+	current := p.keys[len(p.keys)-1]
+	p.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = current.id
+	ss, err := token.SignedString(current.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ss
+}
+
+// jwks returns the JSON Web Key Set of the provider
+func (p *OIDCProvider) jwks() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]map[string]string, 0, len(p.keys))
+	for _, k := range p.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"kid": k.id,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(k.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.key.E)).Bytes()),
+		})
+	}
+	return map[string]any{"keys": keys}
+}
+
+// write writes the given document as JSON, or an error when the provider is down, reporting whether it was written
+func (p *OIDCProvider) write(w http.ResponseWriter, v any) bool {
+	p.mu.Lock()
+	down := p.down
+	p.mu.Unlock()
+
+	if down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(v) == nil
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// headers of the requests sent to the webhooks
+const (
+	// EventHeader carries the type of the event
+	EventHeader = "X-Webhook-Event"
+	// IDHeader carries the ID of the event, which is the same on every delivery of the event to every webhook so the
+	// receivers can discard the duplicates
+	IDHeader = "X-Webhook-ID"
+	// DeliveryHeader carries the ID of the delivery, which is the same on every attempt of the delivery
+	DeliveryHeader = "X-Webhook-Delivery"
+	// TimestampHeader carries the Unix time the request was signed at, so the receivers can reject the replays
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader carries the signature of the request, as "sha256=" followed by the hex encoded
+	// HMAC-SHA256, keyed by the secret of the webhook, of the timestamp, a dot and the body of the request
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// maxResponseBody is the number of bytes of the response of a webhook read before closing it
+const maxResponseBody = 4 << 10
+
+// payload is the JSON body of the requests sent to the webhooks
+type payload struct {
+	ID         uint        `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       entity.User `json:"data"`
+}
+
+// HTTPSender sends the events of the users to the webhooks by POST requests signed with HMAC-SHA256.
+// The webhooks accept an event by answering with a 2xx status; redirections are not followed.
+type HTTPSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewHTTPSender creates a new HTTPSender whose requests time out after the given duration
+func NewHTTPSender(timeout time.Duration) service.WebhookSender {
+	return &HTTPSender{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send sends the event of the given delivery to the given webhook and returns the HTTP status answered, if any, along
+// with an error unless it is a 2xx one
+func (s *HTTPSender) Send(ctx context.Context, webhook entity.Webhook, delivery entity.WebhookDelivery) (int, error) {
+	user := delivery.Event.User
+	if user.Roles == nil {
+		user.Roles = []string{}
+	}
+	body, err := json.Marshal(payload{
+		ID:         delivery.Event.ID,
+		Type:       string(delivery.Event.Type),
+		OccurredAt: delivery.Event.OccurredAt,
+		Data:       user,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot encode the event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot create the request")
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event.Type))
+	req.Header.Set(IDHeader, strconv.FormatUint(uint64(delivery.Event.ID), 10))
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// the body is drained so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseBody))
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res.StatusCode, errors.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign returns the value of the SignatureHeader of a request with the given timestamp and body, signed with the given
+// secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
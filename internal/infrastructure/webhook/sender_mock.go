@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookSender is a mock implementation of service.WebhookSender by using testify mock.Mock
+type MockWebhookSender struct {
+	mock.Mock
+}
+
+func NewMockWebhookSender() *MockWebhookSender {
+	return &MockWebhookSender{}
+}
+
+func (m *MockWebhookSender) Send(ctx context.Context, webhook entity.Webhook, delivery entity.WebhookDelivery) (int, error) {
+	args := m.Called(ctx, webhook, delivery)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	const secret = "0123456789abcdef"
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	delivery := entity.WebhookDelivery{
+		ID:        7,
+		WebhookID: 1,
+		Event: entity.UserEvent{ID: 3, Type: entity.UserCreated, UserID: 1,
+			User: entity.User{ID: 1, Name: "John", Surname: "Doe", Roles: []string{"admin"}, Version: 1}, OccurredAt: now},
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		then    func(*testing.T, int, error)
+	}{
+		{
+			name: "should send the signed event",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, "user.created", r.Header.Get(EventHeader))
+				assert.Equal(t, "3", r.Header.Get(IDHeader))
+				assert.Equal(t, "7", r.Header.Get(DeliveryHeader))
+				assert.Equal(t, "1709294400", r.Header.Get(TimestampHeader))
+				assert.Equal(t, Sign(secret, "1709294400", body), r.Header.Get(SignatureHeader))
+				assert.JSONEq(t, `{"id":3,"type":"user.created","occurred_at":"2024-03-01T12:00:00Z",
+					"data":{"id":1,"name":"John","surname":"Doe","roles":["admin"]}}`, string(body))
+				w.WriteHeader(http.StatusNoContent)
+			},
+			then: func(t *testing.T, status int, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusNoContent, status)
+			},
+		},
+		{
+			name: "should fail when the webhook does not answer with a 2xx status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			then: func(t *testing.T, status int, err error) {
+				assert.EqualError(t, err, "unexpected status 503")
+				assert.Equal(t, http.StatusServiceUnavailable, status)
+			},
+		},
+		{
+			name: "should not follow the redirections",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			},
+			then: func(t *testing.T, status int, err error) {
+				assert.EqualError(t, err, "unexpected status 302")
+				assert.Equal(t, http.StatusFound, status)
+			},
+		},
+		{
+			name: "should fail when the webhook does not answer in time",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+			},
+			then: func(t *testing.T, status int, err error) {
+				assert.Error(t, err)
+				assert.Zero(t, status)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			sender := NewHTTPSender(100 * time.Millisecond).(*HTTPSender)
+			sender.now = func() time.Time { return now }
+
+			// When
+			status, err := sender.Send(context.Background(), entity.Webhook{ID: 1, URL: server.URL, Secret: secret},
+				delivery)
+
+			// Then
+			tt.then(t, status, err)
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// the signature of the example of the README
+	assert.Equal(t, "sha256=7c37f60af0e35bb12d5707cbbb81558ab38ad947cbf07657f19f6da9dfe9a533",
+		Sign("0123456789abcdef", "1709294400", []byte(`{"id":1}`)))
+}
+
+func TestHTTPSender_Send_WithoutRoles(t *testing.T) {
+	// Given
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	// When
+	_, err := NewHTTPSender(time.Second).Send(context.Background(), entity.Webhook{URL: server.URL},
+		entity.WebhookDelivery{Event: entity.UserEvent{ID: 1, Type: entity.UserCreated, User: entity.User{ID: 1}}})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"roles":[]`)
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// PollFunc processes a batch of pending work and returns the number of items processed
+type PollFunc func(ctx context.Context) (int, error)
+
+// Poller runs a PollFunc in the background.
+// It polls batch after batch while there is work left, and waits for the given interval once there is none or polling
+// fails.
+type Poller struct {
+	name     string
+	poll     PollFunc
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewPoller creates a new Poller of the given name, running the given function every given interval
+func NewPoller(name string, poll PollFunc, interval time.Duration) *Poller {
+	return &Poller{
+		name:     name,
+		poll:     poll,
+		interval: interval,
+	}
+}
+
+// Start starts polling in the background until Stop is called
+func (p *Poller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		p.run(ctx)
+	}()
+}
+
+// Stop stops polling and waits for the poll in progress, if any, to end
+func (p *Poller) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+// run polls until the given context is done
+func (p *Poller) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := p.poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Cannot poll %s: %v", p.name, err)
+		}
+
+		if n > 0 && err == nil {
+			// more work may be pending
+			timer.Reset(0)
+		} else {
+			timer.Reset(p.interval)
+		}
+	}
+}
+
+// Group is a group of pollers started and stopped together
+type Group []*Poller
+
+// Start starts every poller of the group
+func (g Group) Start() {
+	for _, p := range g {
+		p.Start()
+	}
+}
+
+// Stop stops every poller of the group and waits for them
+func (g Group) Stop() {
+	for _, p := range g {
+		p.Stop()
+	}
+}
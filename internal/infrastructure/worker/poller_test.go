@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josepdcs/go-proposal-hexagonal-arch/internal/application/usecase"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPoller(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func() *usecase.MockUserEventRelayer
+	}{
+		{
+			name: "should poll batch after batch and then wait",
+			given: func() *usecase.MockUserEventRelayer {
+				m := usecase.NewMockUserEventRelayer()
+				m.On("Relay", mock.Anything).Return(100, nil).Twice()
+				m.On("Relay", mock.Anything).Return(0, nil).Once()
+				return m
+			},
+		},
+		{
+			name: "should wait after failing to poll",
+			given: func() *usecase.MockUserEventRelayer {
+				m := usecase.NewMockUserEventRelayer()
+				m.On("Relay", mock.Anything).Return(1, errors.New("failed to publish")).Once()
+				return m
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockRelayer := tt.given()
+			poller := NewPoller("user events", mockRelayer.Relay, time.Hour)
+
+			// When
+			poller.Start()
+
+			// Then
+			assert.Eventually(t, func() bool {
+				return mockRelayer.AssertExpectations(new(testing.T))
+			}, time.Second, time.Millisecond)
+			poller.Stop()
+			// no more poll is made until the interval elapses
+			mockRelayer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	// Given
+	var first, second atomic.Int32
+	group := Group{
+		NewPoller("first", func(ctx context.Context) (int, error) { first.Add(1); return 0, nil }, time.Hour),
+		NewPoller("second", func(ctx context.Context) (int, error) { second.Add(1); return 0, nil }, time.Hour),
+	}
+
+	// When
+	group.Start()
+
+	// Then
+	assert.Eventually(t, func() bool {
+		return first.Load() == 1 && second.Load() == 1
+	}, time.Second, time.Millisecond)
+	group.Stop()
+}
+
+func TestPoller_Stop_NotStarted(t *testing.T) {
+	NewPoller("user events", usecase.NewMockUserEventRelayer().Relay, time.Second).Stop()
+}